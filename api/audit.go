@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/banzaicloud/pipeline/audit"
+	"github.com/banzaicloud/pipeline/auth"
+	"github.com/banzaicloud/pipeline/config"
+	pkgCommon "github.com/banzaicloud/pipeline/pkg/common"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAuditEvents lists the audit events of an organization, latest first, filtered by the query parameters.
+func GetAuditEvents(c *gin.Context) {
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+
+	var query audit.EventQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Failed to parse query",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	page, err := audit.FindEvents(config.DB(), organizationID, query)
+	if err != nil {
+		log.Errorf("error listing audit events: %s", err.Error())
+
+		c.JSON(http.StatusInternalServerError, pkgCommon.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error listing audit events",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
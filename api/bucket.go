@@ -172,6 +172,13 @@ func CreateBucket(c *gin.Context) {
 		err := objectStore.CreateBucket(createBucketRequest.Name)
 		if err != nil {
 			logger.Error(err.Error())
+
+			return
+		}
+
+		err = secret.RecordUsage(organization.ID, createBucketRequest.SecretId, secret.UsageKindBucket, createBucketRequest.Name)
+		if err != nil {
+			logger.Errorf("recording secret usage failed: %s", err.Error())
 		}
 	}()
 
@@ -323,9 +330,18 @@ func DeleteBucket(c *gin.Context) {
 		return
 	}
 
+	if err = releaseBucketSecretUsage(organization.ID, bucketName); err != nil {
+		logger.Errorf("releasing secret usage failed: %s", err.Error())
+	}
+
 	logger.Infof("object store bucket deleted")
 }
 
+// releaseBucketSecretUsage removes the record of the secret used for creating a bucket
+func releaseBucketSecretUsage(organizationID uint, bucketName string) error {
+	return secret.ReleaseUsage(organizationID, secret.UsageKindBucket, bucketName)
+}
+
 func getBucketContext(c *gin.Context, logger logrus.FieldLogger) (*auth.Organization, *secret.SecretItemResponse, string, bool) {
 	organization := auth.GetCurrentOrganization(c.Request)
 
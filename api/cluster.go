@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"path"
 	"strconv"
 	"strings"
 	"sync"
@@ -59,7 +60,7 @@ func getClusterFromRequest(c *gin.Context) (cluster.CommonCluster, bool) {
 	// TODO: move these to a struct and create them only once upon application init
 	clusters := intCluster.NewClusters(config.DB())
 	secretValidator := providers.NewSecretValidator(secret.Store)
-	clusterManager := cluster.NewManager(clusters, secretValidator, log, errorHandler)
+	clusterManager := cluster.NewManager(clusters, secretValidator, clusterOperations, log, errorHandler)
 
 	ctx := ginutils.Context(context.Background(), c)
 
@@ -110,35 +111,10 @@ func getClusterFromRequest(c *gin.Context) (cluster.CommonCluster, bool) {
 		return nil, false
 	}
 
-	return cl, true
-}
-
-func getPostHookFunctions(postHooks pkgCluster.PostHooks) (ph []cluster.PostFunctioner) {
-
-	log.Info("Get posthook function(s)")
-
-	for postHookName, param := range postHooks {
+	// status changes made while serving the request are recorded with its user and correlation ID
+	cluster.SetStatusContext(ctx, cl, auth.GetCurrentUser(c.Request).ID)
 
-		function := cluster.HookMap[postHookName]
-		if function != nil {
-
-			if f, isOk := function.(*cluster.PostFunctionWithParam); isOk {
-				fa := *f
-				fa.SetParams(param)
-				function = &fa
-			}
-
-			log.Infof("posthook function: %s", function)
-			log.Infof("posthook params: %#v", param)
-			ph = append(ph, function)
-		} else {
-			log.Warnf("there's no function with this name [%s]", postHookName)
-		}
-	}
-
-	log.Infof("Found posthooks: %v", ph)
-
-	return
+	return cl, true
 }
 
 // GetClusterStatus retrieves the cluster status
@@ -233,7 +209,7 @@ func GetClusters(c *gin.Context) {
 
 	// TODO: move these to a struct and create them only once upon application init
 	secretValidator := providers.NewSecretValidator(secret.Store)
-	clusterManager := cluster.NewManager(intCluster.NewClusters(config.DB()), secretValidator, log, errorHandler)
+	clusterManager := cluster.NewManager(intCluster.NewClusters(config.DB()), secretValidator, clusterOperations, log, errorHandler)
 
 	logger.Info("fetching clusters")
 
@@ -276,22 +252,46 @@ func ReRunPostHooks(c *gin.Context) {
 		return
 	}
 
-	var ph pkgCluster.PostHooks
-	if err := c.BindJSON(&ph); err != nil {
-		log.Errorf("error during binding request: %s", err.Error())
-		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
-			Code:    http.StatusBadRequest,
-			Message: "error during binding request",
-			Error:   err.Error(),
-		})
-		return
-	}
-
 	var posthooks []cluster.PostFunctioner
-	if len(ph) == 0 {
-		posthooks = cluster.BasePostHookFunctions
+
+	if retry := c.Query("retry"); retry != "" {
+		var err error
+		posthooks, err = cluster.GetPostHookFunctionsToRetry(commonCluster.GetID(), retry)
+		if err != nil {
+			log.Errorf("error during getting posthooks to retry: %s", err.Error())
+			c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "error during getting posthooks to retry",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if len(posthooks) == 0 {
+			c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "there are no posthooks to retry",
+				Error:   "there are no posthooks to retry",
+			})
+			return
+		}
 	} else {
-		posthooks = getPostHookFunctions(ph)
+		var ph pkgCluster.PostHooks
+		if err := c.BindJSON(&ph); err != nil {
+			log.Errorf("error during binding request: %s", err.Error())
+			c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "error during binding request",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if len(ph) == 0 {
+			posthooks = cluster.BasePostHookFunctions
+		} else {
+			posthooks = cluster.GetPostHookFunctions(ph)
+		}
 	}
 
 	log.Infof("Cluster id: %d", commonCluster.GetID())
@@ -926,6 +926,10 @@ func InstallSecretsToCluster(c *gin.Context) {
 		return
 	}
 
+	if !checkSecretsAccess(c, commonCluster.GetOrganizationId(), request.Query) {
+		return
+	}
+
 	secretSources, err := cluster.InstallSecrets(commonCluster, &request.Query, request.Namespace)
 
 	if err != nil {
@@ -941,6 +945,48 @@ func InstallSecretsToCluster(c *gin.Context) {
 	c.JSON(http.StatusOK, secretSources)
 }
 
+// InstallImagePullSecret installs a docker registry secret into namespaces of a cluster
+// and makes the default service account of the namespaces use it for pulling images
+func InstallImagePullSecret(c *gin.Context) {
+	commonCluster, ok := getClusterFromRequest(c)
+	if !ok {
+		return
+	}
+
+	var request pkgSecret.InstallImagePullSecretRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Errorf("Error parsing request: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !checkSecretAccess(c, commonCluster.GetOrganizationId(), secret.GenerateSecretIDFromName(request.SecretName)) {
+		return
+	}
+
+	if err := cluster.InstallImagePullSecret(commonCluster, request.SecretName, request.Namespaces); err != nil {
+		log.Errorf("Error installing image pull secret [%s] into cluster [%d]: %s", request.SecretName, commonCluster.GetID(), err.Error())
+
+		code := http.StatusInternalServerError
+		if isInvalid(err) {
+			code = http.StatusBadRequest
+		}
+
+		c.AbortWithStatusJSON(code, pkgCommon.ErrorResponse{
+			Code:    code,
+			Message: "Error installing image pull secret into cluster",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
 var kubeProxyCache sync.Map
 
 // GetGlobalClusterID generates an universally unique ID for a cluster within the Pipeline
@@ -948,6 +994,18 @@ func GetGlobalClusterID(cluster cluster.CommonCluster) string {
 	return fmt.Sprint(cluster.GetOrganizationId(), "-", cluster.GetID())
 }
 
+// isKubernetesSecretPath tells whether a path of the Kubernetes API may refer to secrets,
+// any path with a secrets segment is considered one regardless of the API group and the namespace
+func isKubernetesSecretPath(apiPath string) bool {
+	for _, segment := range strings.Split(path.Clean("/"+apiPath), "/") {
+		if strings.EqualFold(segment, "secrets") {
+			return true
+		}
+	}
+
+	return false
+}
+
 // ProxyToCluster sets up a proxy and forwards all requests to the cluster's API server.
 func ProxyToCluster(c *gin.Context) {
 
@@ -956,6 +1014,16 @@ func ProxyToCluster(c *gin.Context) {
 		return
 	}
 
+	// values of Kubernetes secrets are revealed to admins only, like use-only secrets are
+	if isKubernetesSecretPath(c.Param("path")) && !secretAccessor(c, commonCluster.GetOrganizationId()).Admin {
+		c.AbortWithStatusJSON(http.StatusForbidden, pkgCommon.ErrorResponse{
+			Code:    http.StatusForbidden,
+			Message: "Reading secrets through the cluster proxy is allowed for admins only",
+			Error:   "forbidden",
+		})
+		return
+	}
+
 	clusterKey := GetGlobalClusterID(commonCluster)
 
 	kubeProxy, found := kubeProxyCache.Load(clusterKey)
@@ -41,7 +41,7 @@ func CreateClusterRequest(c *gin.Context) {
 	orgID := auth.GetCurrentOrganization(c.Request).ID
 	userID := auth.GetCurrentUser(c.Request).ID
 
-	ph := getPostHookFunctions(createClusterRequest.PostHooks)
+	ph := cluster.GetPostHookFunctions(createClusterRequest.PostHooks)
 	ctx := ginutils.Context(context.Background(), c)
 	commonCluster, err := CreateCluster(ctx, &createClusterRequest, orgID, userID, ph)
 	if err != nil {
@@ -75,7 +75,7 @@ func CreateCluster(
 
 		logger.Info("fill data from profile")
 
-		profile, err := defaults.GetProfile(createClusterRequest.Cloud, createClusterRequest.ProfileName)
+		profileResponse, err := defaults.GetOrganizationProfile(organizationID, createClusterRequest.GetDistribution(), createClusterRequest.ProfileName)
 		if err != nil {
 			return nil, &pkgCommon.ErrorResponse{
 				Code:    http.StatusNotFound,
@@ -84,9 +84,6 @@ func CreateCluster(
 			}
 		}
 
-		logger.Info("create profile response")
-		profileResponse := profile.GetProfile()
-
 		logger.Info("create cluster request from profile")
 		newRequest, err := profileResponse.CreateClusterRequest(createClusterRequest)
 		if err != nil {
@@ -121,7 +118,7 @@ func CreateCluster(
 	// TODO: move these to a struct and create them only once upon application init
 	clusters := intCluster.NewClusters(config.DB())
 	secretValidator := providers.NewSecretValidator(secret.Store)
-	clusterManager := cluster.NewManager(clusters, secretValidator, log, errorHandler)
+	clusterManager := cluster.NewManager(clusters, secretValidator, clusterOperations, log, errorHandler)
 
 	creationCtx := cluster.CreationContext{
 		OrganizationID: organizationID,
@@ -130,8 +127,11 @@ func CreateCluster(
 		SecretID:       createClusterRequest.SecretId,
 		Provider:       createClusterRequest.Cloud,
 		PostHooks:      postHooks,
+		Labels:         createClusterRequest.Labels,
 	}
 
+	cluster.SetStatusContext(ctx, commonCluster, userID)
+
 	creator := cluster.NewCommonClusterCreator(createClusterRequest, commonCluster)
 
 	commonCluster, err = clusterManager.CreateCluster(ctx, creationCtx, creator)
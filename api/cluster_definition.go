@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/banzaicloud/pipeline/auth"
+	"github.com/banzaicloud/pipeline/cluster"
+	"github.com/banzaicloud/pipeline/internal/platform/gin/utils"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	pkgCommon "github.com/banzaicloud/pipeline/pkg/common"
+	"github.com/ghodss/yaml"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/sirupsen/logrus"
+)
+
+// GetClusterDefinition exports the definition of a cluster as a create cluster request in JSON or YAML format.
+func GetClusterDefinition(c *gin.Context) {
+	commonCluster, ok := getClusterFromRequest(c)
+	if ok != true {
+		return
+	}
+
+	logger := log.WithFields(logrus.Fields{
+		"organization": commonCluster.GetOrganizationId(),
+		"cluster":      commonCluster.GetID(),
+	})
+
+	definition, ok := getClusterDefinition(c, commonCluster, logger)
+	if !ok {
+		return
+	}
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, definition)
+	case "yaml":
+		out, err := yaml.Marshal(definition)
+		if err != nil {
+			logger.Errorf("error marshaling cluster definition: %s", err.Error())
+
+			c.JSON(http.StatusInternalServerError, pkgCommon.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Error marshaling cluster definition",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.Data(http.StatusOK, "application/x-yaml; charset=utf-8", out)
+	default:
+		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Unsupported format",
+			Error:   "format must be either json or yaml",
+		})
+	}
+}
+
+// CloneCluster creates a new cluster with the definition of an existing one.
+func CloneCluster(c *gin.Context) {
+	commonCluster, ok := getClusterFromRequest(c)
+	if ok != true {
+		return
+	}
+
+	logger := log.WithFields(logrus.Fields{
+		"organization": commonCluster.GetOrganizationId(),
+		"cluster":      commonCluster.GetID(),
+	})
+
+	var cloneRequest pkgCluster.CloneClusterRequest
+	if err := c.BindJSON(&cloneRequest); err != nil {
+		logger.Errorf("error parsing request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	createClusterRequest, ok := getClusterDefinition(c, commonCluster, logger)
+	if !ok {
+		return
+	}
+
+	createClusterRequest.Name = cloneRequest.Name
+
+	cluster.RelocateClusterRequest(createClusterRequest, cloneRequest.Location)
+
+	if cloneRequest.SecretId != "" {
+		createClusterRequest.SecretId = cloneRequest.SecretId
+	}
+
+	logger.WithField("clone", cloneRequest.Name).Info("cloning cluster")
+
+	createClusterFromDefinition(c, createClusterRequest)
+}
+
+// CreateClusterFromDefinition creates a cluster from a definition exported in JSON or YAML format.
+func CreateClusterFromDefinition(c *gin.Context) {
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		log.Errorf("error reading request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error reading request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// JSON is valid YAML, so both formats are handled here
+	var createClusterRequest pkgCluster.CreateClusterRequest
+	if err := yaml.Unmarshal(body, &createClusterRequest); err != nil {
+		log.Errorf("error parsing request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := binding.Validator.ValidateStruct(&createClusterRequest); err != nil {
+		log.Errorf("invalid cluster definition: %s", err.Error())
+		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid cluster definition",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	createClusterFromDefinition(c, &createClusterRequest)
+}
+
+func getClusterDefinition(c *gin.Context, commonCluster cluster.CommonCluster, logger logrus.FieldLogger) (*pkgCluster.CreateClusterRequest, bool) {
+	definition, err := cluster.GetClusterDefinition(commonCluster)
+	if isInvalid(err) {
+		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+			Error:   err.Error(),
+		})
+		return nil, false
+	} else if err != nil {
+		logger.Errorf("error getting cluster definition: %s", err.Error())
+
+		c.JSON(http.StatusInternalServerError, pkgCommon.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error getting cluster definition",
+			Error:   err.Error(),
+		})
+		return nil, false
+	}
+
+	return definition, true
+}
+
+func createClusterFromDefinition(c *gin.Context, createClusterRequest *pkgCluster.CreateClusterRequest) {
+	orgID := auth.GetCurrentOrganization(c.Request).ID
+	userID := auth.GetCurrentUser(c.Request).ID
+
+	ph := cluster.GetPostHookFunctions(createClusterRequest.PostHooks)
+	ctx := ginutils.Context(context.Background(), c)
+
+	commonCluster, err := CreateCluster(ctx, createClusterRequest, orgID, userID, ph)
+	if err != nil {
+		c.JSON(err.Code, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, pkgCluster.CreateClusterResponse{
+		Name:       commonCluster.GetName(),
+		ResourceID: commonCluster.GetID(),
+	})
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"io"
 	"net/http"
 	"strconv"
 
@@ -8,6 +9,8 @@ import (
 	"github.com/banzaicloud/pipeline/config"
 	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
 	"github.com/banzaicloud/pipeline/internal/platform/gin/utils"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	pkgCommon "github.com/banzaicloud/pipeline/pkg/common"
 	"github.com/banzaicloud/pipeline/pkg/providers"
 	"github.com/banzaicloud/pipeline/secret"
 	"github.com/gin-gonic/gin"
@@ -30,17 +33,41 @@ func DeleteCluster(c *gin.Context) {
 
 	force, _ := strconv.ParseBool(c.DefaultQuery("force", "false"))
 
+	// the request body is optional
+	var deleteRequest pkgCluster.DeleteClusterRequest
+	if err := c.ShouldBindJSON(&deleteRequest); err != nil && err != io.EOF {
+		log.Errorf("error during binding request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "error during binding request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	preDeleteHooks := cluster.GetPreDeleteHookFunctions(deleteRequest.PreDeleteHooks)
+
 	// DeleteCluster deletes the underlying model, so we get this data here
 	clusterID, clusterName := commonCluster.GetID(), commonCluster.GetName()
 
 	// TODO: move these to a struct and create them only once upon application init
 	clusters := intCluster.NewClusters(config.DB())
 	secretValidator := providers.NewSecretValidator(secret.Store)
-	clusterManager := cluster.NewManager(clusters, secretValidator, log, errorHandler)
+	clusterManager := cluster.NewManager(clusters, secretValidator, clusterOperations, log, errorHandler)
 
 	ctx := ginutils.Context(c.Request.Context(), c)
 
-	clusterManager.DeleteCluster(ctx, commonCluster, force, &kubeProxyCache)
+	err := clusterManager.DeleteCluster(ctx, commonCluster, force, preDeleteHooks, &kubeProxyCache)
+	if err != nil {
+		log.Errorf("error during cluster deletion: %s", err.Error())
+
+		c.JSON(http.StatusInternalServerError, pkgCommon.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error deleting cluster",
+			Error:   err.Error(),
+		})
+		return
+	}
 
 	c.JSON(http.StatusAccepted, DeleteClusterResponse{
 		Status:     http.StatusAccepted,
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/banzaicloud/pipeline/cluster"
+	"github.com/banzaicloud/pipeline/config"
+	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	pkgCommon "github.com/banzaicloud/pipeline/pkg/common"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// clusterDriftEventsLimit is the number of recorded drift events returned along the current differences
+const clusterDriftEventsLimit = 50
+
+// GetClusterDrift compares the stored node pools of a cluster with its state in the cloud.
+func GetClusterDrift(c *gin.Context) {
+	commonCluster, ok := getClusterFromRequest(c)
+	if ok != true {
+		return
+	}
+
+	logger := log.WithFields(logrus.Fields{
+		"organization": commonCluster.GetOrganizationId(),
+		"cluster":      commonCluster.GetID(),
+	})
+
+	drifts, err := cluster.DetectDrift(commonCluster)
+	if err != nil {
+		logger.Errorf("error detecting cluster drift: %s", err.Error())
+
+		c.JSON(http.StatusInternalServerError, pkgCommon.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error detecting cluster drift",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	events, err := intCluster.NewDriftEvents(config.DB()).FindByCluster(commonCluster.GetID(), clusterDriftEventsLimit)
+	if err != nil {
+		logger.Errorf("error listing cluster drift events: %s", err.Error())
+
+		c.JSON(http.StatusInternalServerError, pkgCommon.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error listing cluster drift events",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response := pkgCluster.GetClusterDriftResponse{
+		InSync:      len(drifts) == 0,
+		Differences: drifts,
+		Events:      make([]pkgCluster.ClusterDriftEventResponse, 0, len(events)),
+		CheckedAt:   time.Now(),
+	}
+
+	if response.Differences == nil {
+		response.Differences = []pkgCluster.NodePoolDrift{}
+	}
+
+	for _, event := range events {
+		response.Events = append(response.Events, pkgCluster.ClusterDriftEventResponse{
+			NodePoolDrift: pkgCluster.NodePoolDrift{
+				NodePool: event.NodePool,
+				Type:     event.Type,
+				Desired:  event.Desired,
+				Actual:   event.Actual,
+			},
+			Reapplied:  event.Reapplied,
+			DetectedAt: event.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/banzaicloud/pipeline/auth"
+	"github.com/banzaicloud/pipeline/cluster"
+	"github.com/banzaicloud/pipeline/config"
+	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
+	"github.com/banzaicloud/pipeline/internal/platform/gin/utils"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	pkgCommon "github.com/banzaicloud/pipeline/pkg/common"
+	"github.com/banzaicloud/pipeline/pkg/providers"
+	"github.com/banzaicloud/pipeline/secret"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// ImportCluster adopts an existing EKS, GKE or AKS cluster as a managed cluster
+func ImportCluster(c *gin.Context) {
+	var importClusterRequest pkgCluster.ImportClusterRequest
+	if err := c.BindJSON(&importClusterRequest); err != nil {
+		log.Error(errors.Wrap(err, "Error parsing request"))
+		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	orgID := auth.GetCurrentOrganization(c.Request).ID
+	userID := auth.GetCurrentUser(c.Request).ID
+
+	logger := log.WithFields(logrus.Fields{
+		"organization": orgID,
+		"user":         userID,
+		"cluster":      importClusterRequest.Name,
+	})
+
+	postHooks, err := cluster.GetImportPostHookFunctions(importClusterRequest.PostHooks)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	commonCluster, err := cluster.CreateCommonClusterFromImportRequest(&importClusterRequest, orgID, userID)
+	if err != nil {
+		logger.Errorf("error during create common cluster from import request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// TODO: move these to a struct and create them only once upon application init
+	clusters := intCluster.NewClusters(config.DB())
+	secretValidator := providers.NewSecretValidator(secret.Store)
+	clusterManager := cluster.NewManager(clusters, secretValidator, clusterOperations, log, errorHandler)
+
+	importCtx := cluster.ImportContext{
+		OrganizationID: orgID,
+		UserID:         userID,
+		Name:           importClusterRequest.Name,
+		SecretID:       importClusterRequest.SecretId,
+		Provider:       importClusterRequest.Cloud,
+		PostHooks:      postHooks,
+	}
+
+	ctx := ginutils.Context(c.Request.Context(), c)
+
+	cluster.SetStatusContext(ctx, commonCluster, userID)
+
+	commonCluster, err = clusterManager.ImportCluster(ctx, importCtx, commonCluster)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case err == cluster.ErrAlreadyExists || isInvalid(err):
+			statusCode = http.StatusBadRequest
+		case isForbidden(err):
+			statusCode = http.StatusForbidden
+		case isNotFound(err):
+			statusCode = http.StatusNotFound
+		case isPreconditionFailed(err):
+			statusCode = http.StatusPreconditionFailed
+		}
+
+		if statusCode == http.StatusInternalServerError {
+			logger.Errorf("error during cluster import: %s", err.Error())
+		} else {
+			logger.Debugf("cluster cannot be imported: %s", err.Error())
+		}
+
+		c.JSON(statusCode, pkgCommon.ErrorResponse{
+			Code:    statusCode,
+			Message: err.Error(),
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, pkgCluster.CreateClusterResponse{
+		Name:       commonCluster.GetName(),
+		ResourceID: commonCluster.GetID(),
+	})
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/banzaicloud/pipeline/cluster"
+	"github.com/banzaicloud/pipeline/config"
+	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
+	"github.com/banzaicloud/pipeline/internal/platform/gin/utils"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	pkgCommon "github.com/banzaicloud/pipeline/pkg/common"
+	"github.com/banzaicloud/pipeline/pkg/providers"
+	"github.com/banzaicloud/pipeline/secret"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GetClusterLabels returns the labels of a cluster.
+func GetClusterLabels(c *gin.Context) {
+	commonCluster, ok := getClusterFromRequest(c)
+	if ok != true {
+		return
+	}
+
+	secretValidator := providers.NewSecretValidator(secret.Store)
+	clusterManager := cluster.NewManager(intCluster.NewClusters(config.DB()), secretValidator, clusterOperations, log, errorHandler)
+
+	ctx := ginutils.Context(context.Background(), c)
+
+	labels, err := clusterManager.GetClusterLabels(ctx, commonCluster)
+	if err != nil {
+		log.WithField("cluster", commonCluster.GetID()).Errorf("error getting cluster labels: %s", err.Error())
+
+		c.JSON(http.StatusInternalServerError, pkgCommon.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error getting cluster labels",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, pkgCluster.ClusterLabels{Labels: labels})
+}
+
+// UpdateClusterLabels replaces the labels of a cluster, the deployment sets selecting the cluster by its labels are rolled out.
+func UpdateClusterLabels(c *gin.Context) {
+	commonCluster, ok := getClusterFromRequest(c)
+	if ok != true {
+		return
+	}
+
+	logger := log.WithFields(logrus.Fields{
+		"organization": commonCluster.GetOrganizationId(),
+		"cluster":      commonCluster.GetID(),
+	})
+
+	var request pkgCluster.ClusterLabels
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Errorf("error parsing request: %s", err.Error())
+
+		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	secretValidator := providers.NewSecretValidator(secret.Store)
+	clusterManager := cluster.NewManager(intCluster.NewClusters(config.DB()), secretValidator, clusterOperations, log, errorHandler)
+
+	ctx := ginutils.Context(context.Background(), c)
+
+	if err := clusterManager.SetClusterLabels(ctx, commonCluster, request.Labels); isInvalid(err) {
+		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid cluster labels",
+			Error:   err.Error(),
+		})
+		return
+	} else if err != nil {
+		logger.Errorf("error updating cluster labels: %s", err.Error())
+
+		c.JSON(http.StatusInternalServerError, pkgCommon.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error updating cluster labels",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
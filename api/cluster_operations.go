@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/banzaicloud/pipeline/cluster"
+	"github.com/banzaicloud/pipeline/config"
+	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	pkgCommon "github.com/banzaicloud/pipeline/pkg/common"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// clusterOperations is the queue cluster operations started through the API are executed on.
+var clusterOperations *cluster.OperationQueue
+
+// SetClusterOperationQueue sets the queue cluster operations started through the API are executed on.
+func SetClusterOperationQueue(queue *cluster.OperationQueue) {
+	clusterOperations = queue
+}
+
+// GetClusterOperations lists the create/delete operations of a cluster, the most recent one first.
+func GetClusterOperations(c *gin.Context) {
+	commonCluster, ok := getClusterFromRequest(c)
+	if ok != true {
+		return
+	}
+
+	logger := log.WithFields(logrus.Fields{
+		"organization": commonCluster.GetOrganizationId(),
+		"cluster":      commonCluster.GetID(),
+	})
+
+	operations, err := intCluster.NewOperations(config.DB()).FindByCluster(commonCluster.GetOrganizationId(), commonCluster.GetID())
+	if err != nil {
+		logger.Errorf("error listing cluster operations: %s", err.Error())
+
+		c.JSON(http.StatusInternalServerError, pkgCommon.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error listing cluster operations",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response := make([]pkgCluster.OperationResponse, 0, len(operations))
+	for _, operation := range operations {
+		response = append(response, pkgCluster.OperationResponse{
+			ID:         operation.ID,
+			Type:       operation.Type,
+			Status:     operation.Status,
+			Step:       operation.Step,
+			Attempts:   operation.Attempts,
+			LastError:  operation.LastError,
+			CreatedAt:  operation.CreatedAt,
+			UpdatedAt:  operation.UpdatedAt,
+			FinishedAt: operation.FinishedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
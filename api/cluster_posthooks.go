@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/banzaicloud/pipeline/config"
+	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	pkgCommon "github.com/banzaicloud/pipeline/pkg/common"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GetPostHookExecutions lists the posthook executions of a cluster, the most recent one first.
+func GetPostHookExecutions(c *gin.Context) {
+	getHookExecutions(c, pkgCluster.HookPhasePostHook)
+}
+
+// GetPreDeleteHookExecutions lists the pre-delete hook executions of a cluster, the most recent one first.
+func GetPreDeleteHookExecutions(c *gin.Context) {
+	getHookExecutions(c, pkgCluster.HookPhasePreDelete)
+}
+
+func getHookExecutions(c *gin.Context, phase string) {
+	commonCluster, ok := getClusterFromRequest(c)
+	if ok != true {
+		return
+	}
+
+	logger := log.WithFields(logrus.Fields{
+		"organization": commonCluster.GetOrganizationId(),
+		"cluster":      commonCluster.GetID(),
+		"phase":        phase,
+	})
+
+	executions, err := intCluster.NewHookExecutions(config.DB()).FindByCluster(commonCluster.GetID(), phase)
+	if err != nil {
+		logger.Errorf("error listing hook executions: %s", err.Error())
+
+		c.JSON(http.StatusInternalServerError, pkgCommon.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error listing hook executions",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response := make([]pkgCluster.HookExecutionResponse, 0, len(executions))
+	for _, execution := range executions {
+		var params interface{}
+		if execution.Params != "" {
+			if err := json.Unmarshal([]byte(execution.Params), &params); err != nil {
+				logger.Warnf("could not decode params of hook execution %d: %s", execution.ID, err.Error())
+			}
+		}
+
+		response = append(response, pkgCluster.HookExecutionResponse{
+			ID:         execution.ID,
+			RunID:      execution.RunID,
+			Name:       execution.Name,
+			Params:     params,
+			Status:     execution.Status,
+			Error:      execution.Error,
+			StartedAt:  execution.StartedAt,
+			FinishedAt: execution.FinishedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/banzaicloud/pipeline/config"
+	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	pkgCommon "github.com/banzaicloud/pipeline/pkg/common"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GetClusterStatusHistory lists the status transitions of a cluster in chronological order.
+func GetClusterStatusHistory(c *gin.Context) {
+	commonCluster, ok := getClusterFromRequest(c)
+	if ok != true {
+		return
+	}
+
+	logger := log.WithFields(logrus.Fields{
+		"organization": commonCluster.GetOrganizationId(),
+		"cluster":      commonCluster.GetID(),
+	})
+
+	history, err := intCluster.NewStatusHistory(config.DB()).FindByCluster(commonCluster.GetID())
+	if err != nil {
+		logger.Errorf("error listing cluster status history: %s", err.Error())
+
+		c.JSON(http.StatusInternalServerError, pkgCommon.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error listing cluster status history",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response := make([]pkgCluster.StatusHistoryResponse, 0, len(history))
+	for _, transition := range history {
+		response = append(response, pkgCluster.StatusHistoryResponse{
+			FromStatus:    transition.FromStatus,
+			ToStatus:      transition.ToStatus,
+			StatusMessage: transition.StatusMessage,
+			UserID:        transition.UserID,
+			CorrelationID: transition.CorrelationID,
+			CreatedAt:     transition.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestIsKubernetesSecretPath(t *testing.T) {
+	cases := []struct {
+		path   string
+		secret bool
+	}{
+		{path: "/api/v1/namespaces/default/secrets/registry", secret: true},
+		{path: "/api/v1/secrets", secret: true},
+		{path: "/api/v1/watch/namespaces/default/secrets", secret: true},
+		{path: "/api/v1/namespaces/default/configmaps/../secrets/registry", secret: true},
+		{path: "/api/v1/namespaces/default/pods", secret: false},
+		{path: "/apis/apps/v1/namespaces/default/deployments/secret-manager", secret: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			if secret := isKubernetesSecretPath(tc.path); secret != tc.secret {
+				t.Errorf("Expected secret path %t, got: %t", tc.secret, secret)
+			}
+		})
+	}
+}
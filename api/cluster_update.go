@@ -44,7 +44,7 @@ func UpdateCluster(c *gin.Context) {
 	// TODO: move these to a struct and create them only once upon application init
 	clusters := intCluster.NewClusters(config.DB())
 	secretValidator := providers.NewSecretValidator(secret.Store)
-	clusterManager := cluster.NewManager(clusters, secretValidator, log, errorHandler)
+	clusterManager := cluster.NewManager(clusters, secretValidator, clusterOperations, log, errorHandler)
 
 	updateCtx := cluster.UpdateContext{
 		OrganizationID: auth.GetCurrentOrganization(c.Request).ID,
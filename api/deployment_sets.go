@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/banzaicloud/pipeline/auth"
+	"github.com/banzaicloud/pipeline/cluster"
+	"github.com/banzaicloud/pipeline/config"
+	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
+	"github.com/banzaicloud/pipeline/internal/platform/gin/utils"
+	pkgCommon "github.com/banzaicloud/pipeline/pkg/common"
+	pkgHelm "github.com/banzaicloud/pipeline/pkg/helm"
+	"github.com/banzaicloud/pipeline/pkg/providers"
+	"github.com/banzaicloud/pipeline/secret"
+	"github.com/gin-gonic/gin"
+)
+
+// ListDeploymentSets lists the deployment sets of the organization.
+func ListDeploymentSets(c *gin.Context) {
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+	ctx := ginutils.Context(context.Background(), c)
+
+	secretValidator := providers.NewSecretValidator(secret.Store)
+	clusterManager := cluster.NewManager(intCluster.NewClusters(config.DB()), secretValidator, clusterOperations, log, errorHandler)
+
+	sets, err := clusterManager.ListDeploymentSets(ctx, organizationID)
+	if err != nil {
+		log.Errorf("Error listing deployment sets: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, pkgCommon.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error listing deployment sets",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, sets)
+}
+
+// GetDeploymentSet returns a deployment set of the organization with the status of its releases per cluster.
+func GetDeploymentSet(c *gin.Context) {
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+	ctx := ginutils.Context(context.Background(), c)
+
+	secretValidator := providers.NewSecretValidator(secret.Store)
+	clusterManager := cluster.NewManager(intCluster.NewClusters(config.DB()), secretValidator, clusterOperations, log, errorHandler)
+
+	set, err := clusterManager.GetDeploymentSet(ctx, organizationID, c.Param("name"))
+	if err != nil {
+		replyWithDeploymentSetError(c, "Error getting deployment set", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, set)
+}
+
+// CreateDeploymentSet creates a deployment set, its chart is released into the selected clusters in the background.
+func CreateDeploymentSet(c *gin.Context) {
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+	userID := auth.GetCurrentUser(c.Request).ID
+	ctx := ginutils.Context(context.Background(), c)
+
+	secretValidator := providers.NewSecretValidator(secret.Store)
+	clusterManager := cluster.NewManager(intCluster.NewClusters(config.DB()), secretValidator, clusterOperations, log, errorHandler)
+
+	var request pkgHelm.DeploymentSetRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Errorf("Error parsing request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	set, err := clusterManager.CreateDeploymentSet(ctx, organizationID, userID, &request)
+	if err == cluster.ErrDeploymentSetAlreadyExists {
+		c.JSON(http.StatusConflict, pkgCommon.ErrorResponse{
+			Code:    http.StatusConflict,
+			Message: "Error creating deployment set",
+			Error:   err.Error(),
+		})
+		return
+	} else if err != nil {
+		replyWithDeploymentSetError(c, "Error creating deployment set", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, set)
+}
+
+// UpdateDeploymentSet replaces a deployment set, its releases are upgraded in the background.
+func UpdateDeploymentSet(c *gin.Context) {
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+	ctx := ginutils.Context(context.Background(), c)
+
+	secretValidator := providers.NewSecretValidator(secret.Store)
+	clusterManager := cluster.NewManager(intCluster.NewClusters(config.DB()), secretValidator, clusterOperations, log, errorHandler)
+
+	var request pkgHelm.DeploymentSetRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Errorf("Error parsing request: %s", err.Error())
+		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	request.Name = c.Param("name")
+
+	set, err := clusterManager.UpdateDeploymentSet(ctx, organizationID, &request)
+	if err != nil {
+		replyWithDeploymentSetError(c, "Error updating deployment set", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, set)
+}
+
+// DeleteDeploymentSet deletes a deployment set, its releases are deleted from the clusters in the background.
+func DeleteDeploymentSet(c *gin.Context) {
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+	ctx := ginutils.Context(context.Background(), c)
+
+	secretValidator := providers.NewSecretValidator(secret.Store)
+	clusterManager := cluster.NewManager(intCluster.NewClusters(config.DB()), secretValidator, clusterOperations, log, errorHandler)
+
+	err := clusterManager.DeleteDeploymentSet(ctx, organizationID, c.Param("name"))
+	if err != nil {
+		replyWithDeploymentSetError(c, "Error deleting deployment set", err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+func replyWithDeploymentSetError(c *gin.Context, message string, err error) {
+	code := http.StatusInternalServerError
+	if isNotFound(err) {
+		code = http.StatusNotFound
+	} else if isInvalid(err) {
+		code = http.StatusBadRequest
+	} else {
+		log.Errorf("%s: %s", message, err.Error())
+	}
+
+	c.JSON(code, pkgCommon.ErrorResponse{
+		Code:    code,
+		Message: message,
+		Error:   err.Error(),
+	})
+}
@@ -56,3 +56,17 @@ func isPreconditionFailed(err error) bool {
 
 	return false
 }
+
+// isForbidden checks whether an error is about an action not being allowed.
+func isForbidden(err error) bool {
+	// Check the root cause error.
+	err = errors.Cause(err)
+
+	if e, ok := err.(interface {
+		Forbidden() bool
+	}); ok {
+		return e.Forbidden()
+	}
+
+	return false
+}
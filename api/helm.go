@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/banzaicloud/pipeline/auth"
+	"github.com/banzaicloud/pipeline/cluster"
 	"github.com/banzaicloud/pipeline/helm"
 	pkgCommmon "github.com/banzaicloud/pipeline/pkg/common"
 	pkgHelm "github.com/banzaicloud/pipeline/pkg/helm"
@@ -57,14 +58,24 @@ func CreateDeployment(c *gin.Context) {
 		})
 		return
 	}
+	namespace := parsedRequest.namespace
+	if namespace == "" {
+		namespace = helm.DefaultNamespace
+	}
+
+	if !parsedRequest.dryRun && !installDeploymentImagePullSecrets(c, parsedRequest, namespace) {
+		return
+	}
+
 	release, err := helm.CreateDeployment(parsedRequest.deploymentName,
 		parsedRequest.deploymentVersion,
 		parsedRequest.deploymentPackage,
 		parsedRequest.namespace,
 		parsedRequest.deploymentReleaseName,
+		parsedRequest.dryRun,
 		parsedRequest.values,
 		parsedRequest.kubeConfig,
-		helm.GenerateHelmRepoEnv(parsedRequest.organizationName))
+		helm.GenerateHelmRepoEnv(parsedRequest.organizationName, parsedRequest.organizationID))
 	if err != nil {
 		//TODO distinguish error codes
 		log.Errorf("Error during create deployment. %s", err.Error())
@@ -86,6 +97,11 @@ func CreateDeployment(c *gin.Context) {
 		ReleaseName: releaseName,
 		Notes:       releaseNotes,
 	}
+	if parsedRequest.dryRun {
+		response.Manifests = pkgHelm.SplitManifest(release.GetRelease().GetManifest())
+		c.JSON(http.StatusOK, response)
+		return
+	}
 	c.JSON(http.StatusCreated, response)
 	return
 }
@@ -263,6 +279,83 @@ func GetDeploymentResources(c *gin.Context) {
 
 }
 
+// GetDeploymentHistory returns the revisions of a helm deployment
+func GetDeploymentHistory(c *gin.Context) {
+	name := c.Param("name")
+	log.Infof("getting history of deployment: [%s]", name)
+
+	kubeConfig, ok := GetK8sConfig(c)
+	if !ok {
+		log.Errorf("could not get the k8s config for querying the history of deployment: [%s]", name)
+		return
+	}
+
+	history, err := helm.GetDeploymentHistory(name, kubeConfig)
+	if err != nil {
+		log.Errorf("Error during getting deployment history: %s", err.Error())
+
+		httpStatusCode := http.StatusInternalServerError
+		if _, ok := err.(*helm.DeploymentNotFoundError); ok {
+			httpStatusCode = http.StatusNotFound
+		}
+
+		c.JSON(httpStatusCode, pkgCommmon.ErrorResponse{
+			Code:    httpStatusCode,
+			Message: "Error getting deployment history",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// RollbackDeployment rolls back a helm deployment to a previous revision
+func RollbackDeployment(c *gin.Context) {
+	name := c.Param("name")
+	log.Infof("rolling back deployment: [%s]", name)
+
+	kubeConfig, ok := GetK8sConfig(c)
+	if !ok {
+		log.Errorf("could not get the k8s config for rolling back deployment: [%s]", name)
+		return
+	}
+
+	var request pkgHelm.RollbackDeploymentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Errorf("Error during binding RollbackDeploymentRequest: %s", err.Error())
+		c.JSON(http.StatusBadRequest, pkgCommmon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error parsing request",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response, err := helm.RollbackDeployment(name, request.Revision, request.Wait, request.Timeout, kubeConfig)
+	if err != nil {
+		log.Errorf("Error during rolling back deployment: %s", err.Error())
+
+		httpStatusCode := http.StatusBadRequest
+		if _, ok := err.(*helm.DeploymentNotFoundError); ok {
+			httpStatusCode = http.StatusNotFound
+		}
+
+		c.JSON(httpStatusCode, pkgCommmon.ErrorResponse{
+			Code:    httpStatusCode,
+			Message: "Error rolling back deployment",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, pkgHelm.RollbackDeploymentResponse{
+		ReleaseName: name,
+		Revision:    response.GetRelease().GetVersion(),
+		Status:      response.GetRelease().GetInfo().GetStatus().GetCode().String(),
+	})
+}
+
 // InitHelmOnCluster installs Helm on AKS cluster and configure the Helm client
 func InitHelmOnCluster(c *gin.Context) {
 	log.Info("Start helm install")
@@ -355,9 +448,26 @@ func UpgradeDeployment(c *gin.Context) {
 		return
 	}
 
+	if len(parsedRequest.imagePullSecrets) > 0 && !parsedRequest.dryRun {
+		deployment, err := helm.GetDeployment(name, parsedRequest.kubeConfig)
+		if err != nil {
+			log.Errorf("Error during getting deployment. %s", err.Error())
+			c.JSON(http.StatusInternalServerError, pkgCommmon.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Error getting deployment",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		if !installDeploymentImagePullSecrets(c, parsedRequest, deployment.Namespace) {
+			return
+		}
+	}
+
 	release, err := helm.UpgradeDeployment(name, parsedRequest.deploymentName,
 		parsedRequest.deploymentVersion, parsedRequest.deploymentPackage, parsedRequest.values,
-		parsedRequest.reuseValues, parsedRequest.kubeConfig, helm.GenerateHelmRepoEnv(parsedRequest.organizationName))
+		parsedRequest.reuseValues, parsedRequest.dryRun, parsedRequest.kubeConfig, helm.GenerateHelmRepoEnv(parsedRequest.organizationName, parsedRequest.organizationID))
 	if err != nil {
 		log.Errorf("Error during upgrading deployment. %s", err.Error())
 		c.JSON(http.StatusInternalServerError, pkgCommmon.ErrorResponse{
@@ -376,10 +486,55 @@ func UpgradeDeployment(c *gin.Context) {
 		ReleaseName: name,
 		Notes:       releaseNotes,
 	}
+	if parsedRequest.dryRun {
+		response.Manifests = pkgHelm.SplitManifest(release.GetRelease().GetManifest())
+		c.JSON(http.StatusOK, response)
+		return
+	}
 	c.JSON(http.StatusCreated, response)
 	return
 }
 
+// DiffDeployment returns the changes an upgrade of a helm deployment would make per K8s object
+func DiffDeployment(c *gin.Context) {
+	name := c.Param("name")
+	log.Infof("Diffing deployment: %s", name)
+	parsedRequest, err := parseCreateUpdateDeploymentRequest(c)
+	if err != nil {
+		log.Error(err.Error())
+		c.JSON(http.StatusBadRequest, pkgCommmon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error during parsing request!",
+			Error:   errors.Cause(err).Error(),
+		})
+		return
+	}
+
+	diffs, err := helm.DiffDeployment(name, parsedRequest.deploymentName,
+		parsedRequest.deploymentVersion, parsedRequest.deploymentPackage, parsedRequest.values,
+		parsedRequest.reuseValues, parsedRequest.kubeConfig, helm.GenerateHelmRepoEnv(parsedRequest.organizationName, parsedRequest.organizationID))
+	if err != nil {
+		log.Errorf("Error during diffing deployment. %s", err.Error())
+
+		httpStatusCode := http.StatusInternalServerError
+		if _, ok := err.(*helm.DeploymentNotFoundError); ok {
+			httpStatusCode = http.StatusNotFound
+		}
+
+		c.JSON(httpStatusCode, pkgCommmon.ErrorResponse{
+			Code:    httpStatusCode,
+			Message: "Error diffing deployment",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, pkgHelm.DeploymentDiffResponse{
+		ReleaseName: name,
+		Objects:     diffs,
+	})
+}
+
 //DeleteDeployment deletes a Helm deployment
 func DeleteDeployment(c *gin.Context) {
 	name := c.Param("name")
@@ -412,10 +567,37 @@ type parsedDeploymentRequest struct {
 	deploymentPackage     []byte
 	deploymentReleaseName string
 	reuseValues           bool
+	dryRun                bool
 	namespace             string
 	values                []byte
 	kubeConfig            []byte
 	organizationName      string
+	organizationID        uint
+	imagePullSecrets      []string
+	commonCluster         cluster.CommonCluster
+}
+
+// installDeploymentImagePullSecrets installs the image pull secrets of a deployment into its namespace
+func installDeploymentImagePullSecrets(c *gin.Context, parsedRequest *parsedDeploymentRequest, namespace string) bool {
+	for _, secretName := range parsedRequest.imagePullSecrets {
+		if err := cluster.InstallImagePullSecret(parsedRequest.commonCluster, secretName, []string{namespace}); err != nil {
+			log.Errorf("Error during installing image pull secret %s: %s", secretName, err.Error())
+
+			code := http.StatusInternalServerError
+			if isInvalid(err) {
+				code = http.StatusBadRequest
+			}
+
+			c.JSON(code, pkgCommmon.ErrorResponse{
+				Code:    code,
+				Message: "Error installing image pull secret",
+				Error:   err.Error(),
+			})
+			return false
+		}
+	}
+
+	return true
 }
 
 func parseCreateUpdateDeploymentRequest(c *gin.Context) (*parsedDeploymentRequest, error) {
@@ -432,6 +614,7 @@ func parseCreateUpdateDeploymentRequest(c *gin.Context) (*parsedDeploymentReques
 	}
 
 	pdr.organizationName = organization.Name
+	pdr.organizationID = organization.ID
 
 	var deployment *pkgHelm.CreateUpdateDeploymentRequest
 	err = c.BindJSON(&deployment)
@@ -447,7 +630,10 @@ func parseCreateUpdateDeploymentRequest(c *gin.Context) (*parsedDeploymentReques
 	pdr.deploymentPackage = deployment.Package
 	pdr.deploymentReleaseName = deployment.ReleaseName
 	pdr.reuseValues = deployment.ReUseValues
+	pdr.dryRun = deployment.DryRun
 	pdr.namespace = deployment.Namespace
+	pdr.imagePullSecrets = deployment.ImagePullSecrets
+	pdr.commonCluster = commonCluster
 
 	if deployment.Values != nil {
 		pdr.values, err = yaml.Marshal(deployment.Values)
@@ -468,7 +654,8 @@ func HelmReposGet(c *gin.Context) {
 
 	log.Info("Get helm repository")
 
-	response, err := helm.ReposGet(helm.GenerateHelmRepoEnv(auth.GetCurrentOrganization(c.Request).Name))
+	organization := auth.GetCurrentOrganization(c.Request)
+	response, err := helm.ReposGet(helm.GenerateHelmRepoEnv(organization.Name, organization.ID))
 	if err != nil {
 		log.Errorf("Error during get helm repo list: %s", err.Error())
 		c.JSON(http.StatusInternalServerError, pkgCommmon.ErrorResponse{
@@ -486,8 +673,8 @@ func HelmReposGet(c *gin.Context) {
 func HelmReposAdd(c *gin.Context) {
 	log.Info("Add helm repository")
 
-	var repo *repo.Entry
-	err := c.BindJSON(&repo)
+	var request *pkgHelm.RepoRequest
+	err := c.BindJSON(&request)
 	if err != nil {
 		log.Errorf("Error parsing request: %s", err.Error())
 		c.JSON(http.StatusBadRequest, pkgCommmon.ErrorResponse{
@@ -498,8 +685,25 @@ func HelmReposAdd(c *gin.Context) {
 		return
 	}
 
-	helmEnv := helm.GenerateHelmRepoEnv(auth.GetCurrentOrganization(c.Request).Name)
-	_, err = helm.ReposAdd(helmEnv, repo)
+	organization := auth.GetCurrentOrganization(c.Request)
+	secrets, err := helm.NewRepoSecrets(organization.ID, request)
+	if err != nil {
+		log.Errorf("Error validating helm repo secrets: %s", err.Error())
+		c.JSON(http.StatusBadRequest, pkgCommmon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error validating helm repo secrets",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	repo := &repo.Entry{
+		Name: request.Name,
+		URL:  request.URL,
+	}
+
+	helmEnv := helm.GenerateHelmRepoEnv(organization.Name, organization.ID)
+	_, err = helm.ReposAdd(helmEnv, repo, secrets)
 	if err != nil {
 		log.Errorf("Error adding helm repo: %s", err.Error())
 		c.JSON(http.StatusBadRequest, pkgCommmon.ErrorResponse{
@@ -542,7 +746,8 @@ func HelmReposDelete(c *gin.Context) {
 
 	repoName := c.Param("name")
 	log.Debugf("repoName: %s", repoName)
-	helmEnv := helm.GenerateHelmRepoEnv(auth.GetCurrentOrganization(c.Request).Name)
+	organization := auth.GetCurrentOrganization(c.Request)
+	helmEnv := helm.GenerateHelmRepoEnv(organization.Name, organization.ID)
 	err := helm.ReposDelete(helmEnv, repoName)
 	if err != nil {
 		log.Error("Error during get helm repo delete.", err.Error())
@@ -576,8 +781,8 @@ func HelmReposModify(c *gin.Context) {
 	repoName := c.Param("name")
 	log.Debugf("repoName: %s", repoName)
 
-	var newRepo *repo.Entry
-	err := c.BindJSON(&newRepo)
+	var request *pkgHelm.RepoRequest
+	err := c.BindJSON(&request)
 	if err != nil {
 		log.Errorf("Error parsing request: %s", err.Error())
 		c.JSON(http.StatusBadRequest, pkgCommmon.ErrorResponse{
@@ -587,8 +792,46 @@ func HelmReposModify(c *gin.Context) {
 		})
 		return
 	}
-	helmEnv := helm.GenerateHelmRepoEnv(auth.GetCurrentOrganization(c.Request).Name)
-	errModify := helm.ReposModify(helmEnv, repoName, newRepo)
+
+	organization := auth.GetCurrentOrganization(c.Request)
+	helmEnv := helm.GenerateHelmRepoEnv(organization.Name, organization.ID)
+
+	// the secrets are validated against the scheme of the current url if it is not modified
+	if request.URL == "" {
+		entries, err := helm.ReposGet(helmEnv)
+		if err != nil {
+			log.Errorf("Error during getting helm repo: %s", err.Error())
+			c.JSON(http.StatusBadRequest, pkgCommmon.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Error during getting helm repo",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		for _, entry := range entries {
+			if entry.Name == repoName {
+				request.URL = entry.URL
+			}
+		}
+	}
+
+	secrets, err := helm.NewRepoSecrets(organization.ID, request)
+	if err != nil {
+		log.Errorf("Error validating helm repo secrets: %s", err.Error())
+		c.JSON(http.StatusBadRequest, pkgCommmon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error validating helm repo secrets",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	newRepo := &repo.Entry{
+		Name: request.Name,
+		URL:  request.URL,
+	}
+	errModify := helm.ReposModify(helmEnv, repoName, newRepo, secrets)
 	if errModify != nil {
 		if errModify == helm.ErrRepoNotFound {
 			c.JSON(http.StatusNotFound, pkgCommmon.ErrorResponse{
@@ -621,7 +864,8 @@ func HelmReposUpdate(c *gin.Context) {
 
 	repoName := c.Param("name")
 	log.Debugf("repoName: %s", repoName)
-	helmEnv := helm.GenerateHelmRepoEnv(auth.GetCurrentOrganization(c.Request).Name)
+	organization := auth.GetCurrentOrganization(c.Request)
+	helmEnv := helm.GenerateHelmRepoEnv(organization.Name, organization.ID)
 	errUpdate := helm.ReposUpdate(helmEnv, repoName)
 	if errUpdate != nil {
 		log.Errorf("Error during helm repo update. %s", errUpdate.Error())
@@ -657,7 +901,8 @@ func HelmCharts(c *gin.Context) {
 	}
 
 	log.Info(query)
-	helmEnv := helm.GenerateHelmRepoEnv(auth.GetCurrentOrganization(c.Request).Name)
+	organization := auth.GetCurrentOrganization(c.Request)
+	helmEnv := helm.GenerateHelmRepoEnv(organization.Name, organization.ID)
 	response, err := helm.ChartsGet(helmEnv, query.Name, query.Repo, query.Version, query.Keyword)
 	if err != nil {
 		log.Error("Error during get helm repo chart list.", err.Error())
@@ -686,7 +931,8 @@ func HelmChart(c *gin.Context) {
 	chartVersion := c.DefaultQuery("version", "")
 	log.Debugln("version:", chartVersion)
 
-	helmEnv := helm.GenerateHelmRepoEnv(auth.GetCurrentOrganization(c.Request).Name)
+	organization := auth.GetCurrentOrganization(c.Request)
+	helmEnv := helm.GenerateHelmRepoEnv(organization.Name, organization.ID)
 	response, err := helm.ChartGet(helmEnv, chartRepo, chartName, chartVersion)
 	if err != nil {
 		log.Error("Error during get helm chart information.", err.Error())
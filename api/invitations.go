@@ -0,0 +1,186 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/banzaicloud/pipeline/auth"
+	"github.com/banzaicloud/pipeline/config"
+	"github.com/banzaicloud/pipeline/pkg/common"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// CreateInvitationRequest describes an invitation to an organization
+type CreateInvitationRequest struct {
+	Provider string `json:"provider,omitempty"`
+	Login    string `json:"login,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Role     string `json:"role,omitempty"`
+}
+
+// CreateInvitationResponse describes a created invitation along with the token accepting it
+type CreateInvitationResponse struct {
+	*auth.Invitation
+	Token string `json:"token"`
+}
+
+// AcceptInvitationRequest describes the acceptance of an invitation
+type AcceptInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// GetInvitations lists the pending invitations of an organization
+func GetInvitations(c *gin.Context) {
+	organization := auth.GetCurrentOrganization(c.Request)
+
+	invitations, err := auth.GetPendingInvitations(organization.ID)
+	if err != nil {
+		log.Errorf("error listing invitations: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error listing invitations",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+// CreateInvitation invites a user to an organization by login (of GitHub unless the provider is set) or email,
+// role=admin|member|viewer or a custom role of the organization can be in the body, otherwise member is the default role.
+func CreateInvitation(c *gin.Context) {
+	organization := auth.GetCurrentOrganization(c.Request)
+	user := auth.GetCurrentUser(c.Request)
+
+	var request CreateInvitationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		message := fmt.Sprintf("error parsing invitation from request: %s", err)
+		log.Info(message)
+		c.JSON(http.StatusBadRequest, common.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: message,
+			Error:   message,
+		})
+		return
+	}
+
+	invitation, token, err := auth.CreateInvitation(
+		organization.ID,
+		user.ID,
+		request.Provider,
+		request.Login,
+		request.Email,
+		request.Role,
+		viper.GetDuration(config.AuthInvitationTTL),
+	)
+	if err != nil {
+		log.Info(err.Error())
+		c.JSON(http.StatusBadRequest, common.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error creating invitation",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateInvitationResponse{Invitation: invitation, Token: token})
+}
+
+// RevokeInvitation deletes a pending invitation of an organization
+func RevokeInvitation(c *gin.Context) {
+	organization := auth.GetCurrentOrganization(c.Request)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		message := fmt.Sprintf("error parsing invitation id: %s", err)
+		log.Info(message)
+		c.JSON(http.StatusBadRequest, common.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: message,
+			Error:   message,
+		})
+		return
+	}
+
+	err = auth.RevokeInvitation(organization.ID, uint(id))
+	if err == auth.ErrInvitationNotFound {
+		c.JSON(http.StatusNotFound, common.ErrorResponse{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+			Error:   err.Error(),
+		})
+		return
+	} else if err != nil {
+		log.Errorf("error revoking invitation: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error revoking invitation",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AcceptInvitation adds the current user to the organization of the invitation the token in the body belongs to
+func AcceptInvitation(c *gin.Context) {
+	var request AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		message := fmt.Sprintf("error parsing token from request: %s", err)
+		log.Info(message)
+		c.JSON(http.StatusBadRequest, common.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: message,
+			Error:   message,
+		})
+		return
+	}
+
+	// virtual users act on behalf of a user in a single organization
+	user := auth.GetCurrentUser(c.Request)
+	if user == nil || user.Virtual {
+		c.JSON(http.StatusForbidden, common.ErrorResponse{
+			Code:    http.StatusForbidden,
+			Message: "Only users can accept invitations",
+			Error:   "Only users can accept invitations",
+		})
+		return
+	}
+
+	invitation, err := auth.AcceptInvitation(user, request.Token)
+	if err == auth.ErrInvitationNotFound {
+		c.JSON(http.StatusNotFound, common.ErrorResponse{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+			Error:   err.Error(),
+		})
+		return
+	} else if err != nil {
+		log.Errorf("error accepting invitation: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error accepting invitation",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	organization, err := auth.GetOrganizationById(invitation.OrganizationID)
+	if err != nil {
+		log.Errorf("error getting organization: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error getting organization",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	log.Infof("user %d accepted the invitation to organization %d as %s", user.ID, organization.ID, invitation.Role)
+
+	c.JSON(http.StatusOK, organization)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/banzaicloud/pipeline/cluster"
 	"github.com/banzaicloud/pipeline/config"
 	"github.com/banzaicloud/pipeline/helm"
+	intHelm "github.com/banzaicloud/pipeline/internal/helm"
 	"github.com/banzaicloud/pipeline/pkg/common"
 	"github.com/gin-gonic/gin"
 )
@@ -146,9 +147,9 @@ func CreateOrganization(c *gin.Context) {
 	}
 
 	auth.AddOrgRoles(organization.ID)
-	auth.AddOrgRoleForUser(user.ID, organization.ID)
+	auth.AddOrgRoleForUser(user.ID, organization.ID, auth.RoleAdmin)
 
-	helm.InstallLocalHelm(helm.GenerateHelmRepoEnv(organization.Name))
+	helm.InstallLocalHelm(helm.GenerateHelmRepoEnv(organization.Name, organization.ID))
 
 	c.JSON(http.StatusOK, organization)
 }
@@ -186,6 +187,8 @@ func DeleteOrganization(c *gin.Context) {
 		})
 	} else {
 
+		auth.DeleteOrgRoles(uint(id))
+
 		log.Infof("Clean org's statestore folder %s", deleteName)
 		if err := cluster.CleanHelmFolder(deleteName); err != nil {
 			log.Errorf("Statestore cleaning failed: %s", err.Error())
@@ -214,5 +217,10 @@ func deleteOrgFromDB(organization *auth.Organization, user *auth.User) error {
 		tx.Rollback()
 		return err
 	}
+	err = intHelm.NewRepositories(tx).DeleteByOrganization(organization.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
 	return tx.Commit().Error
 }
@@ -1,15 +1,16 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
 
+	"github.com/banzaicloud/pipeline/auth"
 	"github.com/banzaicloud/pipeline/internal/platform/database"
 	"github.com/banzaicloud/pipeline/model/defaults"
 	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
 	pkgCommon "github.com/banzaicloud/pipeline/pkg/common"
-	pkgErrors "github.com/banzaicloud/pipeline/pkg/errors"
-	oracle "github.com/banzaicloud/pipeline/pkg/providers/oracle/model"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/pkg/errors"
 )
 
@@ -19,13 +20,14 @@ const (
 )
 
 // GetClusterProfiles handles /profiles/cluster/:type GET api endpoint.
-// Sends back the saved cluster profiles
+// Sends back the cluster profiles of the organization along with the global ones they do not override
 func GetClusterProfiles(c *gin.Context) {
 
 	distributionType := c.Param(distributionTypeKey)
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
 	log.Infof("Start getting saved cluster profiles [%s]", distributionType)
 
-	resp, err := getProfiles(distributionType)
+	resp, err := defaults.GetAllOrganizationProfiles(organizationID, distributionType)
 	if err != nil {
 		log.Errorf("Error during getting defaults to %s: %s", distributionType, err.Error())
 		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
@@ -40,53 +42,22 @@ func GetClusterProfiles(c *gin.Context) {
 }
 
 // AddClusterProfile handles /profiles/cluster/:type POST api endpoint.
-// Saves ClusterProfileRequest data into the database.
+// Saves ClusterProfileRequest data as a profile of the organization.
+// The profile inherits the fields missing from the request from its base profile.
 // Saving failed if profile with the given name is already exists
 func AddClusterProfile(c *gin.Context) {
 
 	log.Info("Start getting save cluster profile")
 
-	log.Debug("Bind json into ClusterProfileRequest struct")
-	// bind request body to struct
-	var profileRequest pkgCluster.ClusterProfileRequest
-	if err := c.BindJSON(&profileRequest); err != nil {
-		log.Error(errors.Wrap(err, "Error parsing request"))
-		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
-			Code:    http.StatusBadRequest,
-			Message: "Error parsing request",
-			Error:   err.Error(),
-		})
+	profileRequest, overrides, ok := bindClusterProfileRequest(c)
+	if !ok {
 		return
 	}
-	log.Info("Parsing request succeeded")
-	log.Infof("Convert ClusterProfileRequest into ClusterProfile model with name: %s", profileRequest.Name)
 
-	// convert request into ClusterProfile model
-	if prof, err := convertRequestToProfile(&profileRequest); err != nil {
-		log.Error("Error during convert profile: &s", err.Error())
-		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
-			Code:    http.StatusBadRequest,
-			Message: "Error during convert profile",
-			Error:   err.Error(),
-		})
-	} else if !prof.IsDefinedBefore() {
-		// name is free
-		log.Info("Convert succeeded")
-		log.Info("Save cluster profile into database")
-		if err := prof.SaveInstance(); err != nil {
-			// save failed
-			log.Errorf("Error during persist cluster profile: %s", err.Error())
-			c.JSON(http.StatusInternalServerError, pkgCommon.ErrorResponse{
-				Code:    http.StatusInternalServerError,
-				Message: "Error during persist cluster profile",
-				Error:   err.Error(),
-			})
-		} else {
-			// save succeeded
-			log.Info("Save cluster profile succeeded")
-			c.Status(http.StatusCreated)
-		}
-	} else {
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+	distribution := profileRequest.GetDistribution()
+
+	if _, err := defaults.FindOrganizationProfile(organizationID, distribution, profileRequest.Name); err == nil {
 		// profile with given name is already exists
 		log.Error("Cluster profile with the given name is already exists")
 		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
@@ -94,156 +65,208 @@ func AddClusterProfile(c *gin.Context) {
 			Message: "Cluster profile with the given name is already exists, please update not create profile",
 			Error:   "Cluster profile with the given name is already exists, please update not create profile",
 		})
+		return
+	} else if !database.IsRecordNotFoundError(err) {
+		log.Errorf("Error during getting profile: %s", err.Error())
+		sendBackGetProfileErrorResponse(c, err)
+		return
+	}
+
+	base := profileRequest.Base
+	if len(base) == 0 {
+		// a profile with the name of a global one overrides it, other profiles extend the default one
+		base = defaults.GetDefaultProfileName()
+		if _, err := defaults.GetProfile(distribution, profileRequest.Name); err == nil {
+			base = profileRequest.Name
+		}
+	}
+
+	profile := &defaults.OrganizationProfile{
+		OrganizationID: organizationID,
+		Distribution:   distribution,
+		Name:           profileRequest.Name,
 	}
 
+	saveOrganizationProfile(c, profile, base, overrides)
 }
 
-// getProfiles loads cluster profiles from database by distribution
-func getProfiles(distribution string) ([]pkgCluster.ClusterProfileResponse, error) {
+// UpdateClusterProfile handles /cluster/profiles/:type PUT api endpoint.
+// Updates the fields of an organization profile given in the request.
+// Updating a global profile creates an organization profile overriding it.
+func UpdateClusterProfile(c *gin.Context) {
 
-	var response []pkgCluster.ClusterProfileResponse
-	profiles, err := defaults.GetAllProfiles(distribution)
-	if err != nil {
-		// error during getting profiles
-		return nil, err
+	profileRequest, overrides, ok := bindClusterProfileRequest(c)
+	if !ok {
+		return
 	}
-	for _, p := range profiles {
-		r := p.GetProfile()
-		response = append(response, *r)
+
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+	distribution := profileRequest.GetDistribution()
+
+	log.Infof("Load cluster profile from database: %s[%s]", profileRequest.Name, distribution)
+
+	profile, err := defaults.FindOrganizationProfile(organizationID, distribution, profileRequest.Name)
+	if database.IsRecordNotFoundError(err) {
+		// the global profile is overridden for the organization only
+		if _, err := defaults.GetProfile(distribution, profileRequest.Name); err != nil {
+			log.Error(errors.Wrap(err, "Error during getting profile"))
+			sendBackGetProfileErrorResponse(c, err)
+			return
+		}
+
+		profile = &defaults.OrganizationProfile{
+			OrganizationID: organizationID,
+			Distribution:   distribution,
+			Name:           profileRequest.Name,
+			Base:           profileRequest.Name,
+		}
+	} else if err != nil {
+		log.Error(errors.Wrap(err, "Error during getting profile"))
+		sendBackGetProfileErrorResponse(c, err)
+		return
 	}
-	return response, nil
 
+	base := profileRequest.Base
+	if len(base) == 0 {
+		base = profile.Base
+	}
+
+	saveOrganizationProfile(c, profile, base, overrides)
 }
 
-// convertRequestToProfile converts a ClusterProfileRequest into ClusterProfile
-func convertRequestToProfile(request *pkgCluster.ClusterProfileRequest) (defaults.ClusterProfile, error) {
+// DeleteClusterProfile handles /cluster/profiles/:type/:name DELETE api endpoint.
+// Deletes a profile of the organization, the global profile with the same name becomes visible again.
+// Deleting failed if the name belongs to a global profile only.
+func DeleteClusterProfile(c *gin.Context) {
+
+	distribution := c.Param(distributionTypeKey)
+	name := c.Param(nameKey)
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+	log.Infof("Start deleting cluster profile: %s[%s]", name, distribution)
 
-	switch request.Cloud {
-	case pkgCluster.Amazon:
-		if request.Properties.EC2 != nil {
-			var ec2Profile defaults.EC2Profile
-			ec2Profile.UpdateProfile(request, false)
-			return &ec2Profile, nil
+	log.Infof("Load cluster profile from database: %s[%s]", name, distribution)
+
+	// load cluster profile from database
+	profile, err := defaults.FindOrganizationProfile(organizationID, distribution, name)
+	if database.IsRecordNotFoundError(err) {
+		if _, err := defaults.GetProfile(distribution, name); err == nil {
+			// global profiles are shared by every organization
+			log.Error("Global profiles cannot be deleted")
+			c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Global profiles cannot be deleted",
+				Error:   "Global profiles cannot be deleted",
+			})
+			return
 		}
-		var eksProfile defaults.EKSProfile
-		eksProfile.UpdateProfile(request, false)
-		return &eksProfile, nil
-	case pkgCluster.Azure:
-		var aksProfile defaults.AKSProfile
-		aksProfile.UpdateProfile(request, false)
-		return &aksProfile, nil
-	case pkgCluster.Google:
-		var gkeProfile defaults.GKEProfile
-		gkeProfile.UpdateProfile(request, false)
-		return &gkeProfile, nil
-	case pkgCluster.Oracle:
-		var okeProfile oracle.Profile
-		okeProfile.UpdateProfile(request, false)
-		return &okeProfile, nil
-	default:
-		return nil, pkgErrors.ErrorNotSupportedCloudType
+	}
+	if err != nil {
+		// load from database failed
+		log.Error(errors.Wrap(err, "Error during getting profile"))
+		sendBackGetProfileErrorResponse(c, err)
+		return
 	}
 
-}
+	log.Info("Getting profile succeeded")
+	log.Info("Delete from database")
+	if err := profile.DeleteProfile(); err != nil {
+		// delete from db failed
+		log.Error(errors.Wrap(err, "Error during profile delete"))
+		c.JSON(http.StatusInternalServerError, pkgCommon.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error during profile delete",
+			Error:   err.Error(),
+		})
+	} else {
+		// delete succeeded
+		log.Info("Delete from database succeeded")
+		c.Status(http.StatusOK)
+	}
 
-// UpdateClusterProfile handles /cluster/profiles/:type PUT api endpoint.
-// Updates existing cluster profiles.
-// Updating failed if the name is the default name.
-func UpdateClusterProfile(c *gin.Context) {
+}
 
+// bindClusterProfileRequest parses the request body into a ClusterProfileRequest and
+// the raw fields of the request, which are the overrides of an organization profile
+func bindClusterProfileRequest(c *gin.Context) (*pkgCluster.ClusterProfileRequest, map[string]interface{}, bool) {
 	log.Debug("Bind json into ClusterProfileRequest struct")
-	// bind request body to struct
+
 	var profileRequest pkgCluster.ClusterProfileRequest
-	if err := c.BindJSON(&profileRequest); err != nil {
+	var overrides map[string]interface{}
+
+	body, err := c.GetRawData()
+	if err == nil {
+		err = json.Unmarshal(body, &profileRequest)
+	}
+	if err == nil {
+		err = binding.Validator.ValidateStruct(&profileRequest)
+	}
+	if err == nil {
+		err = json.Unmarshal(body, &overrides)
+	}
+	if err != nil {
 		log.Error(errors.Wrap(err, "Error parsing request"))
 		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
 			Code:    http.StatusBadRequest,
 			Message: "Error parsing request",
 			Error:   err.Error(),
 		})
-		return
+		return nil, nil, false
 	}
+
 	log.Debug("Parsing request succeeded")
 
-	if defaults.GetDefaultProfileName() == profileRequest.Name {
-		// default profiles cannot updated
-		log.Error("The default profile cannot be updated") // todo move to constants
+	return &profileRequest, overrides, true
+}
+
+// saveOrganizationProfile sets the base and merges the overrides of an organization profile and saves it
+func saveOrganizationProfile(c *gin.Context, profile *defaults.OrganizationProfile, base string, overrides map[string]interface{}) {
+	if _, err := defaults.GetProfile(profile.Distribution, base); err != nil {
+		log.Errorf("Error during getting base profile: %s", err.Error())
 		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "The default profile cannot be updated",
-			Error:   "The default profile cannot be updated",
+			Message: "Base profile not found",
+			Error:   err.Error(),
 		})
 		return
 	}
 
-	log.Infof("Load cluster from database: %s[%s]", profileRequest.Name, profileRequest.Cloud)
+	profile.Base = base
 
-	// load cluster profile from database
-	if profile, err := defaults.GetProfile(profileRequest.Cloud, profileRequest.Name); err != nil {
-		// load from db failed
-		log.Error(errors.Wrap(err, "Error during getting profile"))
-		sendBackGetProfileErrorResponse(c, err)
-	} else if err := profile.UpdateProfile(&profileRequest, true); err != nil {
-		// updating failed
-		log.Error(errors.Wrap(err, "Error during update profile"))
-		c.JSON(http.StatusInternalServerError, pkgCommon.ErrorResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "Error during update profile",
+	if err := profile.SetOverrides(overrides); err != nil {
+		log.Errorf("Error during convert profile: %s", err.Error())
+		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error during convert profile",
 			Error:   err.Error(),
 		})
-	} else {
-		// update success
-		log.Infof("Update succeeded")
-		c.Status(http.StatusCreated)
+		return
 	}
 
-}
-
-// DeleteClusterProfile handles /cluster/profiles/:type/:name DELETE api endpoint.
-// Deletes saved cluster profile.
-// Deleting failed if the name is the default name.
-func DeleteClusterProfile(c *gin.Context) {
-
-	distribution := c.Param(distributionTypeKey)
-	name := c.Param(nameKey)
-	log.Infof("Start deleting cluster profile: %s[%s]", name, distribution)
-
-	if defaults.GetDefaultProfileName() == name {
-		// default profile cannot deleted
-		log.Error("The default profile cannot be deleted")
+	// the overrides must still produce a valid profile
+	if _, err := profile.GetProfile(); err != nil {
+		log.Errorf("Error during convert profile: %s", err.Error())
 		c.JSON(http.StatusBadRequest, pkgCommon.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "The default profile cannot be deleted",
-			Error:   "The default profile cannot be deleted",
+			Message: "Error during convert profile",
+			Error:   err.Error(),
 		})
 		return
 	}
 
-	log.Infof("Load cluster profile from database: %s[%s]", name, distribution)
-
-	// load cluster profile from database
-	if profile, err := defaults.GetProfile(distribution, name); err != nil {
-		// load from database failed
-		log.Error(errors.Wrap(err, "Error during getting profile"))
-		sendBackGetProfileErrorResponse(c, err)
-	} else {
-		log.Info("Getting profile succeeded")
-		log.Info("Delete from database")
-		if err := profile.DeleteProfile(); err != nil {
-			// delete from db failed
-			log.Error(errors.Wrap(err, "Error during profile delete"))
-			c.JSON(http.StatusInternalServerError, pkgCommon.ErrorResponse{
-				Code:    http.StatusInternalServerError,
-				Message: "Error during profile delete",
-				Error:   err.Error(),
-			})
-		} else {
-			// delete succeeded
-			log.Info("Delete from database succeeded")
-			c.Status(http.StatusOK)
-		}
+	log.Info("Save cluster profile into database")
+	if err := profile.SaveInstance(); err != nil {
+		// save failed
+		log.Errorf("Error during persist cluster profile: %s", err.Error())
+		c.JSON(http.StatusInternalServerError, pkgCommon.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error during persist cluster profile",
+			Error:   err.Error(),
+		})
+		return
 	}
 
+	log.Info("Save cluster profile succeeded")
+	c.Status(http.StatusCreated)
 }
 
 func sendBackGetProfileErrorResponse(c *gin.Context, err error) {
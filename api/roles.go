@@ -0,0 +1,66 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/banzaicloud/pipeline/auth"
+	"github.com/banzaicloud/pipeline/pkg/common"
+	"github.com/gin-gonic/gin"
+)
+
+// GetOrgRoles lists the built-in and the custom roles of an organization
+func GetOrgRoles(c *gin.Context) {
+	organization := auth.GetCurrentOrganization(c.Request)
+
+	c.JSON(http.StatusOK, auth.GetOrgRoles(organization.ID))
+}
+
+// SaveOrgRole creates or replaces a custom role of an organization
+func SaveOrgRole(c *gin.Context) {
+	organization := auth.GetCurrentOrganization(c.Request)
+
+	var role auth.OrgRole
+	if err := c.ShouldBindJSON(&role); err != nil {
+		message := fmt.Sprintf("error parsing role from request: %s", err)
+		log.Info(message)
+		c.JSON(http.StatusBadRequest, common.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: message,
+			Error:   message,
+		})
+		return
+	}
+
+	role.Name = c.Param("name")
+	role.BuiltIn = false
+
+	if err := auth.SaveOrgRole(organization.ID, role); err != nil {
+		log.Info(err.Error())
+		c.JSON(http.StatusBadRequest, common.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error saving role",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteOrgRole deletes a custom role of an organization
+func DeleteOrgRole(c *gin.Context) {
+	organization := auth.GetCurrentOrganization(c.Request)
+
+	if err := auth.DeleteOrgRole(organization.ID, c.Param("name")); err != nil {
+		log.Info(err.Error())
+		c.JSON(http.StatusBadRequest, common.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error deleting role",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
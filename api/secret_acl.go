@@ -0,0 +1,221 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/banzaicloud/pipeline/auth"
+	"github.com/banzaicloud/pipeline/pkg/common"
+	secretTypes "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/banzaicloud/pipeline/secret"
+	"github.com/gin-gonic/gin"
+)
+
+// GetSecretACL returns the ACL of a secret
+func GetSecretACL(c *gin.Context) {
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+	secretID := c.Param("id")
+
+	if _, err := secret.RestrictedStore.Get(organizationID, secretID); err != nil {
+		replyGetSecretError(c, err)
+		return
+	}
+
+	acl, err := secret.GetACL(organizationID, secretID)
+	if err != nil {
+		log.Errorf("Error during getting secret ACL: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error during getting secret ACL",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, acl)
+}
+
+// UpdateSecretACL replaces the ACL of a secret, only the owner of the secret and admins can change it
+func UpdateSecretACL(c *gin.Context) {
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+	secretID := c.Param("id")
+
+	var request secret.SecretACLRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Errorf("Error during binding SecretACLRequest: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusBadRequest, common.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error during binding",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	for _, role := range request.Roles {
+		if !auth.IsOrgRole(organizationID, role) {
+			message := fmt.Sprintf("role %q does not exist in the organization", role)
+			c.AbortWithStatusJSON(http.StatusBadRequest, common.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: message,
+				Error:   message,
+			})
+			return
+		}
+	}
+
+	if _, err := secret.RestrictedStore.Get(organizationID, secretID); err != nil {
+		replyGetSecretError(c, err)
+		return
+	}
+
+	acl, ok := getSecretACLFor(c, organizationID, secretID)
+	if !ok {
+		return
+	}
+
+	if !acl.CanManage(secretAccessor(c, organizationID)) {
+		replySecretAccessDenied(c, secretID)
+		return
+	}
+
+	if request.OwnerID != 0 {
+		acl.OwnerID = request.OwnerID
+	}
+	acl.Users = request.Users
+	acl.Roles = request.Roles
+	acl.UseOnly = request.UseOnly
+
+	if err := secret.SaveACL(acl); err != nil {
+		log.Errorf("Error during saving secret ACL: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error during saving secret ACL",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, acl)
+}
+
+// secretAccessor returns the current user along with the role in the organization
+func secretAccessor(c *gin.Context, organizationID uint) secret.Accessor {
+	user := auth.GetCurrentUser(c.Request)
+
+	role, err := auth.GetUserOrganizationRole(user.ID, organizationID)
+	if err != nil {
+		log.Warnf("could not get the role of user %d in organization %d: %s", user.ID, organizationID, err.Error())
+	}
+
+	return secret.Accessor{
+		UserID: user.ID,
+		Role:   role,
+		Admin:  role == auth.RoleAdmin,
+	}
+}
+
+// saveSecretOwner records the current user as the owner of a new secret, the ACL left by a deleted secret is reset
+func saveSecretOwner(c *gin.Context, organizationID uint, secretID string) {
+	acl, err := secret.GetACL(organizationID, secretID)
+	if err == nil {
+		acl.OwnerID = auth.GetCurrentUser(c.Request).ID
+		acl.Users = nil
+		acl.Roles = nil
+		acl.UseOnly = false
+
+		err = secret.SaveACL(acl)
+	}
+	if err != nil {
+		log.Errorf("Error during saving the owner of the secret: %s", err.Error())
+	}
+}
+
+// getSecretACLFor returns the ACL of a secret, replies with an error if it can't be read
+func getSecretACLFor(c *gin.Context, organizationID uint, secretID string) (*secret.SecretACL, bool) {
+	acl, err := secret.GetACL(organizationID, secretID)
+	if err != nil {
+		log.Errorf("Error during getting secret ACL: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error during getting secret ACL",
+			Error:   err.Error(),
+		})
+		return nil, false
+	}
+
+	return acl, true
+}
+
+// checkSecretAccess replies with 403 if the current user can't change the secret according to its ACL
+func checkSecretAccess(c *gin.Context, organizationID uint, secretID string) bool {
+	acl, ok := getSecretACLFor(c, organizationID, secretID)
+	if !ok {
+		return false
+	}
+
+	if !acl.CanAccess(secretAccessor(c, organizationID)) {
+		replySecretAccessDenied(c, secretID)
+		return false
+	}
+
+	return true
+}
+
+// checkSecretsAccess replies with 403 if the current user can't access every secret matching the query according to their ACLs.
+// Use-only secrets can be installed into clusters, their values can't be read back through the cluster proxy.
+func checkSecretsAccess(c *gin.Context, organizationID uint, query secretTypes.ListSecretsQuery) bool {
+	fail := func(err error) bool {
+		log.Errorf("Error during checking secret ACLs: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error during checking secret ACLs",
+			Error:   err.Error(),
+		})
+		return false
+	}
+
+	query.Values = false
+
+	items, err := secret.Store.List(organizationID, &query)
+	if err != nil {
+		return fail(err)
+	}
+
+	acls, err := secret.GetACLs(organizationID)
+	if err != nil {
+		return fail(err)
+	}
+
+	accessor := secretAccessor(c, organizationID)
+
+	for _, item := range items {
+		if acl, ok := acls[item.ID]; ok && !acl.CanAccess(accessor) {
+			replySecretAccessDenied(c, item.ID)
+			return false
+		}
+	}
+
+	return true
+}
+
+func replySecretAccessDenied(c *gin.Context, secretID string) {
+	err := secret.AccessDeniedError{SecretID: secretID}
+	c.AbortWithStatusJSON(http.StatusForbidden, common.ErrorResponse{
+		Code:    http.StatusForbidden,
+		Message: err.Error(),
+		Error:   err.Error(),
+	})
+}
+
+func replyGetSecretError(c *gin.Context, err error) {
+	statusCode := http.StatusBadRequest
+	if err == secret.ErrSecretNotExists {
+		statusCode = http.StatusNotFound
+	}
+	log.Errorf("Error during getting secret: %s", err.Error())
+	c.AbortWithStatusJSON(statusCode, common.ErrorResponse{
+		Code:    statusCode,
+		Message: "Error during getting secret",
+		Error:   err.Error(),
+	})
+}
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/banzaicloud/pipeline/auth"
+	"github.com/banzaicloud/pipeline/pkg/common"
+	"github.com/banzaicloud/pipeline/secret"
+	"github.com/gin-gonic/gin"
+)
+
+// maxRenameAttempts limits the suffixes tried when renaming an imported secret
+const maxRenameAttempts = 100
+
+// ExportSecrets returns the secrets of the organization selected by the query in a bundle encrypted with the passphrase,
+// secrets the user can't reveal are left out
+func ExportSecrets(c *gin.Context) {
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+
+	var request secret.ExportSecretsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Errorf("Error during binding ExportSecretsRequest: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusBadRequest, common.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error during binding",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := isValidOrganizationSecretType(organizationID, request.Query.Type); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, common.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Not supported secret type",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	request.Query.Values = true
+
+	items, err := secret.RestrictedStore.List(organizationID, &request.Query)
+	if err != nil {
+		log.Errorf("Error during listing secrets: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error during listing secrets",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	acls, err := secret.GetACLs(organizationID)
+	if err != nil {
+		log.Errorf("Error during checking secret ACLs: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error during listing secrets",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	accessor := secretAccessor(c, organizationID)
+
+	var exported []*secret.SecretItemResponse
+	for _, item := range items {
+		if acl, ok := acls[item.ID]; ok && !acl.CanReveal(accessor) {
+			log.Debugf("Secret[%s] is left out of the export by its ACL", item.ID)
+			continue
+		}
+
+		exported = append(exported, item)
+	}
+
+	bundle, err := secret.SealBundle(exported, request.Passphrase)
+	if err != nil {
+		log.Errorf("Error during sealing secret bundle: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error during exporting secrets",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	log.Infof("Exported %d secrets of organization %d", len(exported), organizationID)
+
+	c.JSON(http.StatusOK, bundle)
+}
+
+// ImportSecrets restores the secrets of a bundle, secrets with existing names are skipped, overwritten or renamed
+// according to the conflict mode
+func ImportSecrets(c *gin.Context) {
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+
+	var request secret.ImportSecretsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Errorf("Error during binding ImportSecretsRequest: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusBadRequest, common.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error during binding",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if request.ConflictMode == "" {
+		request.ConflictMode = secret.ImportConflictSkip
+	}
+
+	if !secret.IsValidConflictMode(request.ConflictMode) {
+		message := fmt.Sprintf("unknown conflict mode: %s", request.ConflictMode)
+		c.AbortWithStatusJSON(http.StatusBadRequest, common.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: message,
+			Error:   message,
+		})
+		return
+	}
+
+	items, err := secret.OpenBundle(&request.Bundle, request.Passphrase)
+	if err != nil {
+		log.Errorf("Error during opening secret bundle: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusBadRequest, common.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error during opening secret bundle",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	response := secret.ImportSecretsResponse{Secrets: make([]secret.ImportSecretResult, 0, len(items))}
+	for _, item := range items {
+		result := importSecret(c, organizationID, item, request.ConflictMode)
+		if result.Error != "" {
+			log.Warnf("Error during importing secret[%s]: %s", item.Name, result.Error)
+		}
+
+		response.Secrets = append(response.Secrets, result)
+	}
+
+	log.Infof("Imported %d secrets into organization %d", len(items), organizationID)
+
+	c.JSON(http.StatusOK, response)
+}
+
+func importSecret(c *gin.Context, organizationID uint, item secret.BundleItem, conflictMode string) secret.ImportSecretResult {
+	result := secret.ImportSecretResult{Name: item.Name}
+
+	fail := func(err error) secret.ImportSecretResult {
+		result.Status = secret.ImportStatusFailed
+		result.Error = err.Error()
+
+		return result
+	}
+
+	if err := item.Validate(organizationID); err != nil {
+		return fail(err)
+	}
+
+	request := secret.CreateSecretRequest{
+		Name:      item.Name,
+		Type:      item.Type,
+		Values:    item.Values,
+		Tags:      item.Tags,
+		UpdatedBy: auth.GetCurrentUser(c.Request).Login,
+	}
+
+	existing, err := secret.Backend.Get(organizationID, secret.GenerateSecretIDFromName(item.Name))
+	if err != nil && err != secret.ErrSecretNotExists {
+		return fail(err)
+	}
+
+	if existing != nil {
+		switch conflictMode {
+		case secret.ImportConflictSkip:
+			result.ID = existing.ID
+			result.Status = secret.ImportStatusSkipped
+
+			return result
+
+		case secret.ImportConflictOverwrite:
+			if !checkImportAccess(c, organizationID, existing.ID) {
+				return fail(secret.AccessDeniedError{SecretID: existing.ID})
+			}
+
+			version := int(existing.Version)
+			request.Version = &version
+
+			if err := secret.RestrictedStore.Update(organizationID, existing.ID, &request); err != nil {
+				return fail(err)
+			}
+
+			result.ID = existing.ID
+			result.Status = secret.ImportStatusOverwritten
+
+			return result
+
+		case secret.ImportConflictRename:
+			name, err := freeSecretName(organizationID, item.Name)
+			if err != nil {
+				return fail(err)
+			}
+
+			request.Name = name
+			result.Name = name
+		}
+	}
+
+	secretID, err := secret.RestrictedStore.Store(organizationID, &request)
+	if err != nil {
+		return fail(err)
+	}
+
+	saveSecretOwner(c, organizationID, secretID)
+
+	result.ID = secretID
+	result.Status = secret.ImportStatusCreated
+	if existing != nil {
+		result.Status = secret.ImportStatusRenamed
+	}
+
+	return result
+}
+
+// checkImportAccess checks whether the current user can overwrite a secret according to its ACL
+func checkImportAccess(c *gin.Context, organizationID uint, secretID string) bool {
+	acl, err := secret.GetACL(organizationID, secretID)
+	if err != nil {
+		log.Errorf("Error during getting secret ACL: %s", err.Error())
+		return false
+	}
+
+	return acl.CanAccess(secretAccessor(c, organizationID))
+}
+
+// freeSecretName returns the name suffixed with the first number not used by a secret yet
+func freeSecretName(organizationID uint, name string) (string, error) {
+	for i := 1; i <= maxRenameAttempts; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+
+		_, err := secret.Backend.Get(organizationID, secret.GenerateSecretIDFromName(candidate))
+		if err == secret.ErrSecretNotExists {
+			return candidate, nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+
+	return "", fmt.Errorf("could not find a free name for secret %s", name)
+}
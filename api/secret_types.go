@@ -0,0 +1,134 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/banzaicloud/pipeline/auth"
+	"github.com/banzaicloud/pipeline/pkg/common"
+	secretTypes "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/banzaicloud/pipeline/secret"
+	"github.com/gin-gonic/gin"
+)
+
+// ListSecretTypes returns the custom secret types of the organization
+func ListSecretTypes(c *gin.Context) {
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+
+	types, err := secret.ListSecretTypes(organizationID)
+	if err != nil {
+		log.Errorf("Error during listing secret types: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error during listing secret types",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, types)
+}
+
+// GetSecretType returns a custom secret type of the organization
+func GetSecretType(c *gin.Context) {
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+
+	secretType, err := secret.GetSecretType(organizationID, c.Param("name"))
+	if err == secret.ErrSecretTypeNotExists {
+		c.AbortWithStatusJSON(http.StatusNotFound, common.ErrorResponse{
+			Code:    http.StatusNotFound,
+			Message: "Secret type not found",
+			Error:   err.Error(),
+		})
+		return
+	} else if err != nil {
+		log.Errorf("Error during getting secret type: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error during getting secret type",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, secretType)
+}
+
+// SaveSecretType creates or replaces a custom secret type of the organization,
+// existing secrets of the type are validated against the new rules when they are updated
+func SaveSecretType(c *gin.Context) {
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+	name := c.Param("name")
+
+	var request secret.SecretTypeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Errorf("Error during binding SecretTypeRequest: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusBadRequest, common.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Error during binding",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := request.Validate(name); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, common.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid secret type",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	secretType, err := secret.SaveSecretType(organizationID, name, &request)
+	if err != nil {
+		log.Errorf("Error during saving secret type: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error during saving secret type",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, secretType)
+}
+
+// DeleteSecretType deletes a custom secret type of the organization which has no secrets
+func DeleteSecretType(c *gin.Context) {
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+	name := c.Param("name")
+
+	secrets, err := secret.Backend.List(organizationID, &secretTypes.ListSecretsQuery{Type: name})
+	if err != nil {
+		log.Errorf("Error during listing secrets: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error during listing secrets",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if len(secrets) > 0 {
+		message := fmt.Sprintf("there are %d secrets of type %s", len(secrets), name)
+		c.AbortWithStatusJSON(http.StatusConflict, common.ErrorResponse{
+			Code:    http.StatusConflict,
+			Message: message,
+			Error:   message,
+		})
+		return
+	}
+
+	if err := secret.DeleteSecretType(organizationID, name); err != nil {
+		log.Errorf("Error during deleting secret type: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error during deleting secret type",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/banzaicloud/pipeline/auth"
+	"github.com/banzaicloud/pipeline/config"
+	"github.com/banzaicloud/pipeline/dns/route53"
+	route53model "github.com/banzaicloud/pipeline/dns/route53/model"
+	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
+	"github.com/banzaicloud/pipeline/pkg/common"
+	secretTypes "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/banzaicloud/pipeline/secret"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	clusterUIDTagPrefix = "clusterUID:"
+	spotguideTagPrefix  = "repo:"
+)
+
+// GetSecretUsage lists the clusters, buckets, DNS setups and spotguides referencing a secret
+func GetSecretUsage(c *gin.Context) {
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+	secretID := c.Param("id")
+
+	secretItem, err := secret.RestrictedStore.Get(organizationID, secretID)
+	if err != nil {
+		replyGetSecretError(c, err)
+		return
+	}
+
+	usages, err := getSecretUsages(organizationID, secretItem)
+	if err != nil {
+		log.Errorf("Error during getting secret usage: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error during getting secret usage",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, secret.UsageResponse{
+		SecretID: secretID,
+		InUse:    len(usages) > 0,
+		Usages:   usages,
+	})
+}
+
+// getSecretUsages collects the resources referencing a secret
+func getSecretUsages(organizationID uint, secretItem *secret.SecretItemResponse) ([]secret.Usage, error) {
+	usages := []secret.Usage{}
+
+	clusters := intCluster.NewClusters(config.DB())
+
+	// clusters created with the secret
+	clusterModels, err := clusters.FindBySecret(organizationID, secretItem.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, clusterModel := range clusterModels {
+		usages = append(usages, secret.Usage{
+			Kind:   secret.UsageKindCluster,
+			ID:     strconv.FormatUint(uint64(clusterModel.ID), 10),
+			Name:   clusterModel.Name,
+			Reason: "cluster credentials",
+		})
+	}
+
+	// secrets installed into the namespaces of clusters
+	installations, err := intCluster.NewSecretInstallations(config.DB()).FindBySecret(organizationID, secretItem.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, installation := range installations {
+		clusterModel, err := clusters.FindOneByID(organizationID, installation.ClusterID)
+		if isNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		usages = append(usages, secret.Usage{
+			Kind:   secret.UsageKindCluster,
+			ID:     strconv.FormatUint(uint64(clusterModel.ID), 10),
+			Name:   clusterModel.Name,
+			Reason: fmt.Sprintf("installed into namespace %s", installation.Namespace),
+		})
+	}
+
+	for _, tag := range secretItem.Tags {
+		switch {
+		// secrets generated for clusters, like the SSH keys
+		case strings.HasPrefix(tag, clusterUIDTagPrefix):
+			clusterModel, err := clusters.FindOneByUID(organizationID, strings.TrimPrefix(tag, clusterUIDTagPrefix))
+			if isNotFound(err) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			usages = append(usages, secret.Usage{
+				Kind:   secret.UsageKindCluster,
+				ID:     strconv.FormatUint(uint64(clusterModel.ID), 10),
+				Name:   clusterModel.Name,
+				Reason: "generated for the cluster",
+			})
+
+		// secrets created when launching spotguides
+		case strings.HasPrefix(tag, spotguideTagPrefix):
+			usages = append(usages, secret.Usage{
+				Kind:   secret.UsageKindSpotguide,
+				Name:   strings.TrimPrefix(tag, spotguideTagPrefix),
+				Reason: "spotguide secret",
+			})
+		}
+	}
+
+	// buckets created with the secret
+	bucketUsages, err := secret.FindUsages(organizationID, secretItem.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, bucketUsage := range bucketUsages {
+		usages = append(usages, secret.Usage{
+			Kind:   bucketUsage.Kind,
+			Name:   bucketUsage.Name,
+			Reason: "bucket credentials",
+		})
+	}
+
+	// the Route53 secret of the organization's domain
+	if secretItem.Name == route53.IAMUserAccessKeySecretName && hasSecretTag(secretItem.Tags, secretTypes.TagBanzaiHidden) {
+		var domains []*route53model.Route53Domain
+
+		err := config.DB().Where(&route53model.Route53Domain{OrganizationId: organizationID}).Find(&domains).Error
+		if err != nil {
+			return nil, err
+		}
+
+		for _, domain := range domains {
+			usages = append(usages, secret.Usage{
+				Kind:   secret.UsageKindDNS,
+				ID:     strconv.FormatUint(uint64(domain.ID), 10),
+				Name:   domain.Domain,
+				Reason: "Route53 access key",
+			})
+		}
+	}
+
+	return usages, nil
+}
+
+// describeSecretUsages returns a short, human readable list of the resources referencing a secret
+func describeSecretUsages(usages []secret.Usage) string {
+	descriptions := make([]string, 0, len(usages))
+	for _, usage := range usages {
+		descriptions = append(descriptions, fmt.Sprintf("%s %s (%s)", usage.Kind, usage.Name, usage.Reason))
+	}
+
+	return strings.Join(descriptions, ", ")
+}
+
+func hasSecretTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/banzaicloud/pipeline/cluster"
 	"github.com/banzaicloud/pipeline/config"
 	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
+	"github.com/banzaicloud/pipeline/internal/platform/gin/utils"
 	"github.com/banzaicloud/pipeline/pkg/common"
 	"github.com/banzaicloud/pipeline/pkg/providers"
 	secretTypes "github.com/banzaicloud/pipeline/pkg/secret"
@@ -65,8 +66,9 @@ func validateSecret(c *gin.Context, createSecretRequest *secret.CreateSecretRequ
 
 	ok = true
 	log.Info("Start validation")
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
 	verifier := verify.NewVerifier(createSecretRequest.Type, createSecretRequest.Values)
-	if validationError = createSecretRequest.Validate(verifier); validationError != nil && validate {
+	if validationError = createSecretRequest.Validate(organizationID, verifier); validationError != nil && validate {
 		ok = false
 		log.Errorf("Validation error: %s", validationError.Error())
 		c.AbortWithStatusJSON(http.StatusBadRequest, common.ErrorResponse{
@@ -144,6 +146,8 @@ func AddSecrets(c *gin.Context) {
 
 	log.Infof("Secret stored at: %d/%s", organizationID, secretID)
 
+	saveSecretOwner(c, organizationID, secretID)
+
 	var errorMsg string
 	if validationError != nil {
 		errorMsg = validationError.Error()
@@ -224,6 +228,10 @@ func UpdateSecrets(c *gin.Context) {
 		return
 	}
 
+	if !checkSecretAccess(c, organizationID, secretID) {
+		return
+	}
+
 	if err := secret.RestrictedStore.Update(organizationID, secretID, &createSecretRequest); err != nil {
 		statusCode := http.StatusInternalServerError
 		if secret.IsCASError(err) {
@@ -267,6 +275,85 @@ func UpdateSecrets(c *gin.Context) {
 	})
 }
 
+// RotateSecret creates a new version of a secret and installs it into the clusters it was installed into
+func RotateSecret(c *gin.Context) {
+
+	organizationID := auth.GetCurrentOrganization(c.Request).ID
+	log.Debugf("Organization id: %d", organizationID)
+
+	secretID := c.Param("id")
+
+	// the request body is optional, generated values are regenerated without it
+	var rotateSecretRequest secret.RotateSecretRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&rotateSecretRequest); err != nil {
+			log.Errorf("Error during binding RotateSecretRequest: %s", err.Error())
+			c.AbortWithStatusJSON(http.StatusBadRequest, common.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "Error during binding",
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+
+	//Check if the received value is base64 encoded if not encode it.
+	if rotateSecretRequest.Values[secretTypes.K8SConfig] != "" {
+		rotateSecretRequest.Values[secretTypes.K8SConfig] = utils.EncodeStringToBase64(rotateSecretRequest.Values[secretTypes.K8SConfig])
+	}
+
+	if !checkSecretAccess(c, organizationID, secretID) {
+		return
+	}
+
+	updatedBy := auth.GetCurrentUser(c.Request).Login
+
+	s, err := secret.RestrictedStore.Rotate(organizationID, secretID, rotateSecretRequest.Values, updatedBy)
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		if err == secret.ErrSecretNotExists {
+			statusCode = http.StatusNotFound
+		} else if secret.IsCASError(err) {
+			statusCode = http.StatusConflict
+		}
+		log.Errorf("Error during rotation: %s", err.Error())
+		c.AbortWithStatusJSON(statusCode, common.ErrorResponse{
+			Code:    statusCode,
+			Message: "Error during rotation",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	log.Infof("Secret rotated at: %d/%s, version: %d", organizationID, secretID, s.Version)
+
+	secretValidator := providers.NewSecretValidator(secret.Store)
+	clusterManager := cluster.NewManager(intCluster.NewClusters(config.DB()), secretValidator, clusterOperations, log, errorHandler)
+
+	ctx := ginutils.Context(context.Background(), c)
+
+	results, err := clusterManager.PropagateSecret(ctx, organizationID, s)
+	if err != nil {
+		log.Errorf("Error during propagating rotated secret: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Secret rotated but propagating it to clusters failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, secret.RotateSecretResponse{
+		ID:        secretID,
+		Name:      s.Name,
+		Type:      s.Type,
+		Version:   s.Version,
+		UpdatedAt: s.UpdatedAt,
+		UpdatedBy: s.UpdatedBy,
+		Clusters:  results,
+	})
+}
+
 // ListSecrets returns the user all secrets, if the secret type or tag is filled
 // then a filtered response is returned
 func ListSecrets(c *gin.Context) {
@@ -286,7 +373,7 @@ func ListSecrets(c *gin.Context) {
 
 	log.Debugln("Organization:", organizationID, "type:", query.Type, "tag:", query.Tag, "values:", query.Values)
 
-	if err := IsValidSecretType(query.Type); err != nil {
+	if err := isValidOrganizationSecretType(organizationID, query.Type); err != nil {
 		log.Errorf("Error validation secret type[%s]: %s", query.Tag, err.Error())
 		c.JSON(http.StatusBadRequest, common.ErrorResponse{
 			Code:    http.StatusBadRequest,
@@ -301,6 +388,13 @@ func ListSecrets(c *gin.Context) {
 				Message: "Error during listing secrets",
 				Error:   err.Error(),
 			})
+		} else if err := hideRestrictedSecretValues(c, organizationID, secrets); err != nil {
+			log.Errorf("Error during checking secret ACLs: %s", err.Error())
+			c.AbortWithStatusJSON(http.StatusInternalServerError, common.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "Error during listing secrets",
+				Error:   err.Error(),
+			})
 		} else {
 			c.JSON(http.StatusOK, secrets)
 		}
@@ -314,18 +408,30 @@ func GetSecret(c *gin.Context) {
 
 	secretID := c.Param("id")
 
-	if secret, err := secret.RestrictedStore.Get(organizationID, secretID); err != nil {
+	if s, err := secret.RestrictedStore.Get(organizationID, secretID); err != nil {
 		log.Errorf("Error during getting secret: %s", err.Error())
 		c.AbortWithStatusJSON(http.StatusBadRequest, common.ErrorResponse{
 			Code:    http.StatusBadRequest,
 			Message: "Error during listing secret",
 			Error:   err.Error(),
 		})
+	} else if err := hideRestrictedSecretValues(c, organizationID, []*secret.SecretItemResponse{s}); err != nil {
+		log.Errorf("Error during checking secret ACL: %s", err.Error())
+		c.AbortWithStatusJSON(http.StatusInternalServerError, common.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "Error during listing secret",
+			Error:   err.Error(),
+		})
 	} else {
-		c.JSON(http.StatusOK, secret)
+		c.JSON(http.StatusOK, s)
 	}
 }
 
+// hideRestrictedSecretValues hides the values of the secrets the current user can't reveal according to their ACLs
+func hideRestrictedSecretValues(c *gin.Context, organizationID uint, secrets []*secret.SecretItemResponse) error {
+	return secret.HideRestrictedValues(organizationID, secrets, secretAccessor(c, organizationID))
+}
+
 // DeleteSecrets delete a secret with the given secret id
 func DeleteSecrets(c *gin.Context) {
 	log.Info("Start deleting secrets")
@@ -336,6 +442,12 @@ func DeleteSecrets(c *gin.Context) {
 
 	secretID := c.Param("id")
 
+	if !checkSecretAccess(c, organizationID, secretID) {
+		return
+	}
+
+	force, _ := strconv.ParseBool(c.DefaultQuery("force", "false"))
+
 	log.Infof("Check clusters before delete secret[%s]", secretID)
 	if err := checkClustersBeforeDelete(organizationID, secretID); err != nil {
 		log.Errorf("Cluster found with this secret[%s]: %s", secretID, err.Error())
@@ -344,6 +456,13 @@ func DeleteSecrets(c *gin.Context) {
 			Message: fmt.Sprintf("Cluster found with this secret[%s]", secretID),
 			Error:   err.Error(),
 		})
+	} else if err := checkUsagesBeforeDelete(organizationID, secretID, force); err != nil {
+		log.Errorf("Secret[%s] is still in use: %s", secretID, err.Error())
+		c.AbortWithStatusJSON(http.StatusConflict, common.ErrorResponse{
+			Code:    http.StatusConflict,
+			Message: fmt.Sprintf("Secret[%s] is still in use, delete it with force=true to ignore", secretID),
+			Error:   err.Error(),
+		})
 	} else if err := secret.RestrictedStore.Delete(organizationID, secretID); err != nil {
 		log.Errorf("Error during deleting secrets: %s", err.Error())
 		code := http.StatusInternalServerError
@@ -359,7 +478,8 @@ func DeleteSecrets(c *gin.Context) {
 	}
 }
 
-// ListAllowedSecretTypes returns the allowed secret types and the required keys
+// ListAllowedSecretTypes returns the allowed secret types and the required keys,
+// the custom secret types are listed as well when an organization is selected
 func ListAllowedSecretTypes(c *gin.Context) {
 
 	log.Info("Start listing allowed types and required keys")
@@ -367,7 +487,12 @@ func ListAllowedSecretTypes(c *gin.Context) {
 	secretType := c.Param("type")
 	log.Infof("Secret type: %s", secretType)
 
-	if response, err := GetAllowedTypes(secretType); err != nil {
+	var organizationID uint
+	if organization := auth.GetCurrentOrganization(c.Request); organization != nil {
+		organizationID = organization.ID
+	}
+
+	if response, err := GetAllowedTypes(organizationID, secretType); err != nil {
 		log.Errorf("Error during listing allowed types: %s", err.Error())
 		c.JSON(http.StatusBadRequest, common.ErrorResponse{
 			Code:    http.StatusBadRequest,
@@ -379,16 +504,18 @@ func ListAllowedSecretTypes(c *gin.Context) {
 	}
 }
 
-// GetAllowedTypes filters the allowed secret types if necessary
-func GetAllowedTypes(secretType string) (interface{}, error) {
+// GetAllowedTypes filters the allowed secret types of the organization if necessary
+func GetAllowedTypes(organizationID uint, secretType string) (interface{}, error) {
 	if len(secretType) == 0 {
 		log.Info("List all types and keys")
-		return secretTypes.DefaultRules, nil
-	} else if err := IsValidSecretType(secretType); err != nil {
+		return secret.GetAllowedSecretTypes(organizationID)
+	} else if meta, err := secret.GetSecretTypeMeta(organizationID, secretType); err == secret.ErrSecretTypeNotExists {
+		return nil, ErrNotSupportedSecretType
+	} else if err != nil {
 		return nil, err
 	} else {
 		log.Info("Valid secret type. List filtered secret types")
-		return secretTypes.DefaultRules[secretType], nil
+		return meta, nil
 	}
 }
 
@@ -402,11 +529,26 @@ func IsValidSecretType(secretType string) error {
 	return nil
 }
 
+// isValidOrganizationSecretType checks the given secret type is built-in or a custom secret type of the organization
+func isValidOrganizationSecretType(organizationID uint, secretType string) error {
+	if len(secretType) == 0 {
+		return nil
+	}
+
+	if _, err := secret.GetSecretTypeMeta(organizationID, secretType); err == secret.ErrSecretTypeNotExists {
+		return ErrNotSupportedSecretType
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // checkClustersBeforeDelete returns error if there's a running cluster that created with the given secret
 func checkClustersBeforeDelete(orgId uint, secretId string) error {
 	// TODO: move these to a struct and create them only once upon application init
 	secretValidator := providers.NewSecretValidator(secret.Store)
-	clusterManager := cluster.NewManager(intCluster.NewClusters(config.DB()), secretValidator, log, errorHandler)
+	clusterManager := cluster.NewManager(intCluster.NewClusters(config.DB()), secretValidator, clusterOperations, log, errorHandler)
 
 	clusters, err := clusterManager.GetClustersBySecretID(context.Background(), orgId, secretId)
 	if err != nil {
@@ -426,3 +568,30 @@ func checkClustersBeforeDelete(orgId uint, secretId string) error {
 
 	return nil
 }
+
+// checkUsagesBeforeDelete returns error if the secret is still referenced by clusters, buckets, DNS setups or spotguides,
+// forced deletions only log a warning
+func checkUsagesBeforeDelete(orgId uint, secretId string, force bool) error {
+	secretItem, err := secret.Backend.Get(orgId, secretId)
+	if err != nil {
+		// deleting reports the missing secret
+		return nil
+	}
+
+	usages, err := getSecretUsages(orgId, secretItem)
+	if err != nil {
+		log.Warnf("could not get secret usages: %s", err.Error())
+		return nil
+	}
+
+	if len(usages) == 0 {
+		return nil
+	}
+
+	if force {
+		log.Warnf("deleting secret[%s] which is still used by: %s", secretId, describeSecretUsages(usages))
+		return nil
+	}
+
+	return fmt.Errorf("secret is used by: %s", describeSecretUsages(usages))
+}
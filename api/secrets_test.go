@@ -58,7 +58,7 @@ func TestListAllowedSecretTypes(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			if response, err := api.GetAllowedTypes(tc.secretType); err != nil {
+			if response, err := api.GetAllowedTypes(0, tc.secretType); err != nil {
 				if !reflect.DeepEqual(tc.error, err) {
 					t.Errorf("Error during listing allowed types: %s", err.Error())
 				}
@@ -93,7 +93,7 @@ func TestAddSecret(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			if err := tc.request.Validate(tc.verifier); err != nil {
+			if err := tc.request.Validate(orgId, tc.verifier); err != nil {
 				if !tc.isError {
 					t.Errorf("Error during validate request: %s", err.Error())
 				}
@@ -65,7 +65,7 @@ func GetUsers(c *gin.Context) {
 	}
 }
 
-// AddUser adds a user to an organization, role=admin|member has to be in the body, otherwise member is the default role.
+// AddUser adds a user to an organization, role=admin|member|viewer or a custom role of the organization can be in the body, otherwise member is the default role.
 func AddUser(c *gin.Context) {
 
 	log.Info("Adding user to organization")
@@ -84,8 +84,8 @@ func AddUser(c *gin.Context) {
 	}
 
 	role := struct {
-		Role string `json:"role" binding:"required,eq=member|eq=admin"`
-	}{Role: "member"}
+		Role string `json:"role" binding:"required"`
+	}{Role: auth.RoleMember}
 
 	if c.Request.ContentLength != 0 {
 		err = c.ShouldBindJSON(&role)
@@ -104,6 +104,17 @@ func AddUser(c *gin.Context) {
 	organization := auth.GetCurrentOrganization(c.Request)
 	user := &auth.User{ID: uint(id)}
 
+	if !auth.IsOrgRole(organization.ID, role.Role) {
+		message := fmt.Sprintf("role %q does not exist in the organization", role.Role)
+		log.Info(message)
+		c.JSON(http.StatusBadRequest, common.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: message,
+			Error:   message,
+		})
+		return
+	}
+
 	err = addUserToOrgInDb(organization, user, role.Role)
 
 	if err != nil {
@@ -118,7 +129,7 @@ func AddUser(c *gin.Context) {
 		return
 	}
 
-	auth.AddOrgRoleForUser(user.ID, organization.ID)
+	auth.AddOrgRoleForUser(user.ID, organization.ID, role.Role)
 
 	c.Status(http.StatusNoContent)
 }
@@ -178,20 +189,7 @@ func RemoveUser(c *gin.Context) {
 		return
 	}
 
-	user, err := auth.GetCurrentUserFromDB(c.Request)
-	if err != nil {
-		message := "failed to query user for deletion: " + err.Error()
-		log.Info(message)
-		statusCode := auth.GormErrorToStatusCode(err)
-		c.AbortWithStatusJSON(statusCode, common.ErrorResponse{
-			Code:    statusCode,
-			Message: message,
-			Error:   message,
-		})
-		return
-	}
-
-	auth.DeleteOrgRoleForUser(user.ID, organization.ID)
+	auth.DeleteOrgRoleForUser(uint(id), organization.ID)
 
 	c.Status(http.StatusNoContent)
 }
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/textproto"
+	"strconv"
 	"strings"
 	"time"
 
@@ -31,20 +32,52 @@ func (*closeableBuffer) Close() error {
 
 // AuditEvent holds all information related to a user interaction
 type AuditEvent struct {
-	ID         uint      `gorm:"primary_key"`
-	Time       time.Time `gorm:"index"`
-	ClientIP   string    `gorm:"size:45"`
-	UserAgent  string
-	Path       string `gorm:"size:8000"`
-	Method     string `gorm:"size:7"`
-	UserID     uint
-	StatusCode int
-	Body       *string `gorm:"type:json"`
-	Headers    string  `gorm:"type:json"`
+	ID             uint          `gorm:"primary_key" json:"id"`
+	Time           time.Time     `gorm:"index" json:"time"`
+	ClientIP       string        `gorm:"size:45" json:"clientIp"`
+	UserAgent      string        `json:"userAgent"`
+	Path           string        `gorm:"size:8000" json:"path"`
+	Method         string        `gorm:"size:7" json:"method"`
+	UserID         uint          `json:"userId"`
+	OrganizationID uint          `gorm:"index" json:"organizationId,omitempty"`
+	StatusCode     int           `json:"statusCode"`
+	Latency        time.Duration `json:"latency"`
+	Body           *string       `gorm:"type:json" json:"body,omitempty"`
+	Headers        string        `gorm:"type:json" json:"headers"`
 }
 
-// LogWriter instance is a Gin Middleware which logs all request data into MySQL audit_events table.
-func LogWriter(notloggedPaths []string, whitelistedHeaders []string) gin.HandlerFunc {
+// redactedFields are the fields of the secret request bodies blanked entirely, like the passphrase and the bundle of secret exports and imports
+var redactedFields = []string{"passphrase", "bundle"}
+
+// redactBody blanks the secret values of a request body, the keys are kept to show which values were sent
+func redactBody(rawBody []byte) ([]byte, error) {
+	data := map[string]interface{}{}
+	if err := json.Unmarshal(rawBody, &data); err != nil {
+		return nil, err
+	}
+
+	if _, ok := data["values"]; ok {
+		// cast returns a copy, so the redacted values have to be written back
+		values := cast.ToStringMapString(data["values"])
+		redacted := make(map[string]string, len(values))
+		for k := range values {
+			redacted[k] = ""
+		}
+		data["values"] = redacted
+	}
+
+	for _, field := range redactedFields {
+		if _, ok := data[field]; ok {
+			data[field] = ""
+		}
+	}
+
+	return json.Marshal(data)
+}
+
+// LogWriter instance is a Gin Middleware which logs all request data into MySQL audit_events table
+// and streams them to the sink if there is one.
+func LogWriter(notloggedPaths []string, whitelistedHeaders []string, sink Sink) gin.HandlerFunc {
 	skip := map[string]struct{}{}
 
 	for _, path := range notloggedPaths {
@@ -83,19 +116,8 @@ func LogWriter(notloggedPaths []string, whitelistedHeaders []string) gin.Handler
 
 			// Filter out sensitive data from body
 			var body *string
-			if strings.Contains(path, "/secrets") && len(rawBody) > 0 {
-				data := map[string]interface{}{}
-				err := json.Unmarshal(rawBody, &data)
-				if err != nil {
-					c.AbortWithError(http.StatusInternalServerError, err)
-					log.Errorln(err)
-					return
-				}
-				values := cast.ToStringMapString(data["values"])
-				for k := range values {
-					values[k] = ""
-				}
-				newBody, err := json.Marshal(data)
+			if strings.Contains(path, "/secret") && len(rawBody) > 0 {
+				newBody, err := redactBody(rawBody)
 				if err != nil {
 					c.AbortWithError(http.StatusInternalServerError, err)
 					log.Errorln(err)
@@ -111,18 +133,11 @@ func LogWriter(notloggedPaths []string, whitelistedHeaders []string) gin.Handler
 			clientIP := c.ClientIP()
 			method := c.Request.Method
 			userAgent := c.Request.UserAgent()
-			statusCode := c.Writer.Status()
 
 			if raw != "" {
 				path = path + "?" + raw
 			}
 
-			user := auth.GetCurrentUser(c.Request)
-			var userID uint
-			if user != nil {
-				userID = user.ID
-			}
-
 			filteredHeaders := http.Header{}
 			for _, header := range whitelistedHeaders {
 				if values := c.Request.Header[textproto.CanonicalMIMEHeaderKey(header)]; len(values) != 0 {
@@ -136,24 +151,44 @@ func LogWriter(notloggedPaths []string, whitelistedHeaders []string) gin.Handler
 				return
 			}
 
+			var organizationID uint
+			if orgID, err := strconv.ParseUint(c.Param("orgid"), 10, 32); err == nil {
+				organizationID = uint(orgID)
+			}
+
+			// The status code, the latency and the authenticated user are known only after the handlers ran
+			c.Next()
+
+			user := auth.GetCurrentUser(c.Request)
+			var userID uint
+			if user != nil {
+				userID = user.ID
+			}
+
 			event := AuditEvent{
-				Time:       start,
-				ClientIP:   clientIP,
-				UserAgent:  userAgent,
-				UserID:     userID,
-				StatusCode: statusCode,
-				Method:     method,
-				Path:       path,
-				Body:       body,
-				Headers:    string(headers),
+				Time:           start,
+				ClientIP:       clientIP,
+				UserAgent:      userAgent,
+				UserID:         userID,
+				OrganizationID: organizationID,
+				StatusCode:     c.Writer.Status(),
+				Latency:        time.Since(start),
+				Method:         method,
+				Path:           path,
+				Body:           body,
+				Headers:        string(headers),
 			}
 
+			// The response has been written already, so errors can only be logged from here
 			err = db.Save(&event).Error
 			if err != nil {
-				c.AbortWithError(http.StatusInternalServerError, err)
 				log.Errorln(err)
 				return
 			}
+
+			if sink != nil {
+				sink.Write(&event)
+			}
 		}
 	}
 }
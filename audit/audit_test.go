@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRedactBody(t *testing.T) {
+	body, err := redactBody([]byte(`{"name":"my-secret","type":"password","values":{"username":"admin","password":"s3cr3t"}}`))
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	if strings.Contains(string(body), "s3cr3t") || strings.Contains(string(body), "admin") {
+		t.Errorf("Expected the secret values to be redacted, got: %s", body)
+	}
+
+	var data struct {
+		Name   string            `json:"name"`
+		Values map[string]string `json:"values"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	if data.Name != "my-secret" {
+		t.Errorf("Expected name: my-secret, got: %s", data.Name)
+	}
+
+	for _, key := range []string{"username", "password"} {
+		if value, ok := data.Values[key]; !ok || value != "" {
+			t.Errorf("Expected the %s key with an empty value, got: %q", key, value)
+		}
+	}
+}
+
+func TestRedactBodyWithoutValues(t *testing.T) {
+	body, err := redactBody([]byte(`{"name":"my-secret"}`))
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	if string(body) != `{"name":"my-secret"}` {
+		t.Errorf("Expected the body to be unchanged, got: %s", body)
+	}
+}
+
+func TestRedactBodyOfSecretBundle(t *testing.T) {
+	body, err := redactBody([]byte(`{"passphrase":"correct horse","bundle":{"version":1,"salt":"c2FsdA==","data":"ZGF0YQ=="},"conflictMode":"skip"}`))
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	if string(body) != `{"bundle":"","conflictMode":"skip","passphrase":""}` {
+		t.Errorf("Expected the passphrase and the bundle to be redacted, got: %s", body)
+	}
+}
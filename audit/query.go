@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// Default and maximum page sizes of audit event queries
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 1000
+)
+
+// EventQuery filters the audit events of an organization
+type EventQuery struct {
+	UserID     uint      `form:"userId"`
+	PathPrefix string    `form:"path"`
+	Method     string    `form:"method"`
+	StatusCode int       `form:"status"`
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page       int       `form:"page"`
+	PageSize   int       `form:"pageSize"`
+}
+
+// EventPage is a page of the audit events matching a query, latest first
+type EventPage struct {
+	Events   []*AuditEvent `json:"events"`
+	Total    int           `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"pageSize"`
+}
+
+// FindEvents returns a page of the audit events of an organization matching the query
+func FindEvents(db *gorm.DB, organizationID uint, query EventQuery) (*EventPage, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+
+	if query.PageSize < 1 {
+		query.PageSize = DefaultPageSize
+	} else if query.PageSize > MaxPageSize {
+		query.PageSize = MaxPageSize
+	}
+
+	// zero fields of the filter are ignored, so the organization is filtered explicitly
+	scope := db.Model(&AuditEvent{}).Where("organization_id = ?", organizationID).Where(&AuditEvent{
+		UserID:     query.UserID,
+		Method:     strings.ToUpper(query.Method),
+		StatusCode: query.StatusCode,
+	})
+
+	if query.PathPrefix != "" {
+		escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+		scope = scope.Where("path LIKE ?", escaper.Replace(query.PathPrefix)+"%")
+	}
+
+	if !query.From.IsZero() {
+		scope = scope.Where("time >= ?", query.From)
+	}
+
+	if !query.To.IsZero() {
+		scope = scope.Where("time < ?", query.To)
+	}
+
+	page := &EventPage{
+		Events:   []*AuditEvent{},
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	}
+
+	if err := scope.Count(&page.Total).Error; err != nil {
+		return nil, errors.Wrap(err, "could not count audit events")
+	}
+
+	err := scope.Order("time desc, id desc").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&page.Events).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list audit events")
+	}
+
+	return page, nil
+}
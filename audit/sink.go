@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// sinkBufferSize is the number of events waiting to be written to a sink, events are dropped when the buffer is full
+const sinkBufferSize = 1000
+
+// Sink streams audit events to an external system, like a SIEM
+type Sink interface {
+	// Write queues the event for writing without blocking the request
+	Write(event *AuditEvent)
+}
+
+// eventWriter writes an audit event to its destination
+type eventWriter interface {
+	write(event *AuditEvent) error
+}
+
+// asyncSink writes the events in the background, so slow destinations don't delay the requests
+type asyncSink struct {
+	events chan *AuditEvent
+	writer eventWriter
+}
+
+func newAsyncSink(writer eventWriter) *asyncSink {
+	sink := &asyncSink{
+		events: make(chan *AuditEvent, sinkBufferSize),
+		writer: writer,
+	}
+
+	go func() {
+		for event := range sink.events {
+			if err := sink.writer.write(event); err != nil {
+				log.Errorf("failed to write audit event %d to sink: %s", event.ID, err.Error())
+			}
+		}
+	}()
+
+	return sink
+}
+
+// Write queues the event for writing, the event is dropped if the sink can't keep up with the requests
+func (s *asyncSink) Write(event *AuditEvent) {
+	select {
+	case s.events <- event:
+	default:
+		log.Warnf("audit sink buffer is full, dropping event %d", event.ID)
+	}
+}
+
+// fileWriter appends the events to a file as JSON lines
+type fileWriter struct {
+	file io.Writer
+}
+
+func (w *fileWriter) write(event *AuditEvent) error {
+	return json.NewEncoder(w.file).Encode(event)
+}
+
+// webhookWriter posts the events to a webhook one by one as JSON lines
+type webhookWriter struct {
+	url    string
+	client *http.Client
+}
+
+func (w *webhookWriter) write(event *AuditEvent) error {
+	body := &bytes.Buffer{}
+	if err := json.NewEncoder(body).Encode(event); err != nil {
+		return err
+	}
+
+	resp, err := w.client.Post(w.url, "application/x-ndjson", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return errors.Errorf("webhook responded with %s", resp.Status)
+	}
+
+	return nil
+}
+
+// NewFileSink returns a sink appending the events to a file as JSON lines
+func NewFileSink(path string) (Sink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open audit sink file")
+	}
+
+	return newAsyncSink(&fileWriter{file: file}), nil
+}
+
+// NewWebhookSink returns a sink posting the events to a webhook as JSON lines
+func NewWebhookSink(url string) Sink {
+	return newAsyncSink(&webhookWriter{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	})
+}
+
+// NewSink returns the sink of the given type ("file" or "webhook") writing to the target (a path or a URL)
+func NewSink(sinkType string, target string) (Sink, error) {
+	switch sinkType {
+	case "":
+		return nil, nil
+	case "file":
+		return NewFileSink(target)
+	case "webhook":
+		return NewWebhookSink(target), nil
+	default:
+		return nil, errors.Errorf("unknown audit sink type: %s", sinkType)
+	}
+}
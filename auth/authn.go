@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	bauth "github.com/banzaicloud/bank-vaults/auth"
 	"github.com/banzaicloud/pipeline/config"
@@ -154,29 +155,64 @@ func Init(db *gorm.DB) {
 		DeregisterHandler: BanzaiDeregisterHandler,
 	})
 
-	githubProvider := github.New(&github.Config{
-		// ClientID and ClientSecret is validated inside github.New()
-		ClientID:     viper.GetString("auth.clientid"),
-		ClientSecret: viper.GetString("auth.clientsecret"),
+	// GitHub stays the default provider unless only other providers are configured
+	oidcEnabled := viper.GetBool("auth.oidc.enabled")
+	gitlabEnabled := viper.GetBool("auth.gitlab.enabled")
+
+	if viper.GetString("auth.clientid") != "" || !(oidcEnabled || gitlabEnabled) {
+		githubProvider := github.New(&github.Config{
+			// ClientID and ClientSecret is validated inside github.New()
+			ClientID:     viper.GetString("auth.clientid"),
+			ClientSecret: viper.GetString("auth.clientsecret"),
+
+			// The same as Drone's scopes
+			Scopes: []string{
+				"repo",
+				"user:email",
+				"read:org",
+			},
+		})
+		githubProvider.AuthorizeHandler = NewGithubAuthorizeHandler(githubProvider)
+		Auth.RegisterProvider(githubProvider)
+	}
 
-		// The same as Drone's scopes
-		Scopes: []string{
-			"repo",
-			"user:email",
-			"read:org",
-		},
-	})
-	githubProvider.AuthorizeHandler = NewGithubAuthorizeHandler(githubProvider)
-	Auth.RegisterProvider(githubProvider)
+	if oidcEnabled {
+		oidcProvider, err := NewOIDCProvider(OIDCConfig{
+			Issuer:       viper.GetString("auth.oidc.issuer"),
+			ClientID:     viper.GetString("auth.oidc.clientid"),
+			ClientSecret: viper.GetString("auth.oidc.clientsecret"),
+			Scopes:       viper.GetStringSlice("auth.oidc.scopes"),
+			GroupsClaim:  viper.GetString("auth.oidc.groupsClaim"),
+			AdminGroups:  viper.GetStringSlice("auth.oidc.adminGroups"),
+		})
+		if err != nil {
+			panic(fmt.Sprintf("Failed to configure OpenID Connect provider: %s", err.Error()))
+		}
+		Auth.RegisterProvider(oidcProvider)
+	}
+
+	if gitlabEnabled {
+		gitlabProvider, err := NewGitlabProvider(GitlabConfig{
+			URL:          viper.GetString("auth.gitlab.url"),
+			ClientID:     viper.GetString("auth.gitlab.clientid"),
+			ClientSecret: viper.GetString("auth.gitlab.clientsecret"),
+		})
+		if err != nil {
+			panic(fmt.Sprintf("Failed to configure GitLab provider: %s", err.Error()))
+		}
+		Auth.RegisterProvider(gitlabProvider)
+	}
 
 	TokenStore = bauth.NewVaultTokenStore("pipeline")
 
 	jwtAuth := bauth.JWTAuth(TokenStore, signingKey, func(claims *bauth.ScopedClaims) interface{} {
 		userID, _ := strconv.ParseUint(claims.Subject, 10, 32)
 		return &User{
-			ID:      uint(userID),
-			Login:   claims.Text, // This is needed for Drone virtual user tokens
-			Virtual: claims.Type == DroneHookTokenType,
+			ID:         uint(userID),
+			Login:      claims.Text, // This is needed for Drone virtual user tokens
+			Virtual:    claims.Type == DroneHookTokenType,
+			TokenID:    claims.Id,
+			TokenScope: claims.Scope,
 		}
 	})
 
@@ -186,6 +222,11 @@ func Init(db *gorm.DB) {
 			return
 		}
 		jwtAuth(c)
+
+		// expired tokens are rejected by the JWT validation already
+		if user := GetCurrentUser(c.Request); !c.IsAborted() && user != nil && user.TokenID != "" {
+			touchAPIToken(user.TokenID)
+		}
 	}
 }
 
@@ -207,6 +248,14 @@ func Install(engine *gin.Engine) {
 		authGroup.GET("/github/logout", authHandler)
 		authGroup.GET("/github/register", authHandler)
 		authGroup.GET("/github/callback", authHandler)
+		authGroup.GET("/oidc/login", authHandler)
+		authGroup.GET("/oidc/logout", authHandler)
+		authGroup.GET("/oidc/register", authHandler)
+		authGroup.GET("/oidc/callback", authHandler)
+		authGroup.GET("/gitlab/login", authHandler)
+		authGroup.GET("/gitlab/logout", authHandler)
+		authGroup.GET("/gitlab/register", authHandler)
+		authGroup.GET("/gitlab/callback", authHandler)
 		authGroup.POST("/tokens", GenerateToken)
 		authGroup.GET("/tokens", GetTokens)
 		authGroup.GET("/tokens/:id", GetTokens)
@@ -249,10 +298,16 @@ func GenerateToken(c *gin.Context) {
 		}
 	}
 
-	tokenRequest := struct {
-		Name        string `json:"name,omitempty"`
-		VirtualUser string `json:"virtualUser,omitempty"`
-	}{Name: "generated"}
+	// a restricted token could be used to create tokens with more privileges
+	if currentUser.TokenScope != "" {
+		if scope, err := parseTokenScope(currentUser.TokenScope); err != nil || scope.Restricted() {
+			err := c.AbortWithError(http.StatusForbidden, fmt.Errorf("Restricted tokens cannot create tokens"))
+			log.Info(c.ClientIP(), " ", err.Error())
+			return
+		}
+	}
+
+	tokenRequest := TokenRequest{Name: "generated"}
 
 	if c.Request.Method == http.MethodPost && c.Request.ContentLength > 0 {
 		if err := c.ShouldBindJSON(&tokenRequest); err != nil {
@@ -262,18 +317,39 @@ func GenerateToken(c *gin.Context) {
 		}
 	}
 
+	if tokenRequest.ExpiresAt != nil && !tokenRequest.ExpiresAt.After(time.Now()) {
+		err := c.AbortWithError(http.StatusBadRequest, fmt.Errorf("Token expiry must be in the future"))
+		log.Info(c.ClientIP(), " ", err.Error())
+		return
+	}
+
+	scope, err := newTokenScope(tokenRequest.Organizations, tokenRequest.Scopes)
+	if err != nil {
+		err := c.AbortWithError(http.StatusBadRequest, err)
+		log.Info(c.ClientIP(), " ", err.Error())
+		return
+	}
+
+	if err := validateTokenOrganizations(currentUser.ID, tokenRequest.Organizations); err != nil {
+		err := c.AbortWithError(http.StatusBadRequest, err)
+		log.Info(c.ClientIP(), " ", err.Error())
+		return
+	}
+
 	isForVirtualUser := tokenRequest.VirtualUser != ""
 
 	userID := currentUser.IDString()
 	userLogin := currentUser.Login
 	tokenType := DroneUserTokenType
+	var ownerID uint
 	if isForVirtualUser {
 		userID = tokenRequest.VirtualUser
 		userLogin = tokenRequest.VirtualUser
 		tokenType = DroneHookTokenType
+		ownerID = currentUser.ID
 	}
 
-	tokenID, signedToken, err := createAndStoreAPIToken(userID, userLogin, tokenType, tokenRequest.Name)
+	tokenID, signedToken, err := createAndStoreAPIToken(userID, userLogin, ownerID, tokenType, tokenRequest.Name, scope.String(), tokenRequest.ExpiresAt)
 
 	if err != nil {
 		err = c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("%s", err))
@@ -295,29 +371,34 @@ func GenerateToken(c *gin.Context) {
 			return
 		}
 
+		// virtual users act on behalf of the user with the role the user currently has in the organization
 		AddDefaultRoleForVirtualUser(userID)
-		AddOrgRoleForUser(userID, organization.ID)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"id": tokenID, "token": signedToken})
 }
 
-func createAPIToken(userID string, userLogin string, tokenType bauth.TokenType) (string, string, error) {
+func createAPIToken(userID string, userLogin string, tokenType bauth.TokenType, scope string, expiresAt *time.Time) (string, string, error) {
 	tokenID := uuid.NewV4().String()
 
+	var expiresAtUnix int64
+	if expiresAt != nil {
+		expiresAtUnix = expiresAt.Unix()
+	}
+
 	// Create the Claims
 	claims := &bauth.ScopedClaims{
 		StandardClaims: jwt.StandardClaims{
 			Issuer:    JwtIssuer,
 			Audience:  JwtAudience,
 			IssuedAt:  jwt.TimeFunc().Unix(),
-			ExpiresAt: 0,
+			ExpiresAt: expiresAtUnix,
 			Subject:   userID,
 			Id:        tokenID,
 		},
-		Scope: "api:invoke", // "scope" for Pipeline
-		Type:  tokenType,    // "type" for Drone
-		Text:  userLogin,    // "text" for Drone
+		Scope: scope,     // "scope" for Pipeline
+		Type:  tokenType, // "type" for Drone
+		Text:  userLogin, // "text" for Drone
 	}
 
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -329,8 +410,8 @@ func createAPIToken(userID string, userLogin string, tokenType bauth.TokenType)
 	return tokenID, signedToken, nil
 }
 
-func createAndStoreAPIToken(userID string, userLogin string, tokenType bauth.TokenType, tokenName string) (string, string, error) {
-	tokenID, signedToken, err := createAPIToken(userID, userLogin, tokenType)
+func createAndStoreAPIToken(userID string, userLogin string, ownerID uint, tokenType bauth.TokenType, tokenName string, scope string, expiresAt *time.Time) (string, string, error) {
+	tokenID, signedToken, err := createAPIToken(userID, userLogin, tokenType, scope, expiresAt)
 	if err != nil {
 		return "", "", err
 	}
@@ -341,6 +422,11 @@ func createAndStoreAPIToken(userID string, userLogin string, tokenType bauth.Tok
 		return "", "", errors.Wrap(err, "Failed to store user token")
 	}
 
+	err = config.DB().Create(&APITokenModel{ID: tokenID, UserID: userID, OwnerID: ownerID, ExpiresAt: expiresAt, Scope: scope}).Error
+	if err != nil {
+		return "", "", errors.Wrap(err, "Failed to store user token metadata")
+	}
+
 	return tokenID, signedToken, nil
 }
 
@@ -356,17 +442,27 @@ func GetTokens(c *gin.Context) {
 
 	if tokenID == "" {
 		tokens, err := TokenStore.List(currentUser.IDString())
+		if err == nil {
+			var responses []*TokenResponse
+			responses, err = getTokenResponses(currentUser.IDString(), tokens...)
+			if err == nil {
+				c.JSON(http.StatusOK, responses)
+			}
+		}
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusInternalServerError, err)
-		} else {
-			c.JSON(http.StatusOK, tokens)
 		}
 	} else {
 		token, err := TokenStore.Lookup(currentUser.IDString(), tokenID)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusInternalServerError, err)
 		} else if token != nil {
-			c.JSON(http.StatusOK, token)
+			responses, err := getTokenResponses(currentUser.IDString(), token)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, err)
+			} else {
+				c.JSON(http.StatusOK, responses[0])
+			}
 		} else {
 			c.AbortWithStatusJSON(http.StatusNotFound, pkgCommon.ErrorResponse{
 				Code:    http.StatusNotFound,
@@ -391,6 +487,9 @@ func DeleteToken(c *gin.Context) {
 		c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Errorf("Missing token id"))
 	} else {
 		err := TokenStore.Revoke(currentUser.IDString(), tokenID)
+		if err == nil {
+			err = config.DB().Where(&APITokenModel{ID: tokenID, UserID: currentUser.IDString()}).Delete(&APITokenModel{}).Error
+		}
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusInternalServerError, err)
 		} else {
@@ -419,9 +518,10 @@ func (sessionStorer *BanzaiSessionStorer) Update(w http.ResponseWriter, req *htt
 		return fmt.Errorf("Can't get current user")
 	}
 
-	// Drone tokens have to stored in Vault, because they act as Pipeline API tokens as well
+	// Drone tokens have to stored in Vault, because they act as Pipeline API tokens as well.
+	// Drone keeps using the token for the builds of the user after the session ends, so it must not expire.
 	// TODO We need GC them somehow
-	_, droneToken, err := createAndStoreAPIToken(claims.UserID, currentUser.Login, DroneUserTokenType, "Drone session token")
+	_, droneToken, err := createAndStoreAPIToken(claims.UserID, currentUser.Login, 0, DroneUserTokenType, "Drone session token", APITokenScopeInvoke, nil)
 	if err != nil {
 		log.Info(req.RemoteAddr, err.Error())
 		return err
@@ -521,6 +621,12 @@ func BanzaiDeregisterHandler(context *auth.Context) {
 		}
 	}
 
+	if err := config.DB().Where(&APITokenModel{UserID: user.IDString()}).Delete(&APITokenModel{}).Error; err != nil {
+		log.Errorln("Failed remove user's token metadata during user deletetion:", err)
+		http.Error(context.Writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	// Delete Casbin roles
 	DeleteRolesForUser(user.ID)
 
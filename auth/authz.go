@@ -3,8 +3,10 @@ package auth
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/banzaicloud/pipeline/config"
 	"github.com/casbin/casbin"
 	"github.com/casbin/gorm-adapter"
 	"github.com/gin-gonic/gin"
@@ -25,11 +27,14 @@ g = _, _
 e = some(where (p.eft == allow))
 
 [matchers]
-m = g(r.sub, p.sub) && keyMatch(r.obj, p.obj) && (r.act == p.act || p.act == "*")
+m = g(r.sub, p.sub) && pathMatch(r.obj, p.obj) && (r.act == p.act || p.act == "*")
 `
 
 const logging = false
 
+// defaultVirtualRole allows virtual users to list the organization they belong to
+const defaultVirtualRole = "defaultVirtual"
+
 var enforcer *casbin.SyncedEnforcer
 
 // NewAuthorizer returns the MySQL based default authorizer
@@ -37,8 +42,10 @@ func NewAuthorizer(dsn string) gin.HandlerFunc {
 	adapter := gormadapter.NewAdapter("mysql", dsn, true)
 	model := casbin.NewModel(modelDefinition)
 	enforcer = casbin.NewSyncedEnforcer(model, adapter, logging)
+	enforcer.AddFunction("pathMatch", pathMatchFunc)
 	enforcer.StartAutoLoadPolicy(10 * time.Second)
 	addDefaultPolicies()
+	syncOrgRoles()
 	return newAuthorizer(enforcer)
 }
 
@@ -74,9 +81,48 @@ func (a *BearerAuthorizer) CheckPermission(r *http.Request) bool {
 	userID := a.GetUserID(r)
 	method := r.Method
 	path := r.URL.Path
+	user := GetCurrentUser(r)
+	if !checkTokenScope(user, method, path) {
+		return false
+	}
+	if user != nil && user.Virtual {
+		return a.checkVirtualUserPermission(user, path, method)
+	}
 	return a.enforcer.Enforce(userID, path, method)
 }
 
+// checkVirtualUserPermission checks the permission of a virtual user, which acts on behalf of the user created its token
+// with the role the user currently has in the organization of the virtual user
+func (a *BearerAuthorizer) checkVirtualUserPermission(user *User, path string, method string) bool {
+	if a.enforcer.Enforce(defaultVirtualRole, path, method) {
+		return true
+	}
+
+	var token APITokenModel
+	if err := config.DB().Where(&APITokenModel{ID: user.TokenID}).First(&token).Error; err != nil {
+		log.Infof("failed to load token of virtual user %s: %s", user.Login, err.Error())
+		return false
+	}
+
+	// tokens created before the owner was recorded are not authorized to act on behalf of anyone
+	if token.OwnerID == 0 {
+		return false
+	}
+
+	organization := Organization{Name: GetOrgNameFromVirtualUser(user.Login)}
+	if err := config.DB().Where(&organization).First(&organization).Error; err != nil {
+		log.Infof("failed to load organization of virtual user %s: %s", user.Login, err.Error())
+		return false
+	}
+
+	role, err := GetUserOrganizationRole(token.OwnerID, organization.ID)
+	if err != nil {
+		return false
+	}
+
+	return a.enforcer.Enforce(orgRoleName(organization.ID, role), path, method)
+}
+
 // RequirePermission returns the 403 Forbidden to the client
 func (a *BearerAuthorizer) RequirePermission(c *gin.Context) {
 	c.AbortWithStatus(http.StatusForbidden)
@@ -89,7 +135,8 @@ func addDefaultPolicies() {
 	enforcer.AddPolicy("default", basePath+"/api/v1/orgs", "*")
 	enforcer.AddPolicy("default", basePath+"/api/v1/token", "*")
 	enforcer.AddPolicy("default", basePath+"/api/v1/tokens", "*")
-	enforcer.AddPolicy("defaultVirtual", basePath+"/api/v1/orgs", "GET")
+	enforcer.AddPolicy("default", basePath+"/api/v1/invitations/accept", "POST")
+	enforcer.AddPolicy(defaultVirtualRole, basePath+"/api/v1/orgs", "GET")
 }
 
 // AddDefaultRoleForUser adds all the default non-org-specific role to a user.
@@ -99,28 +146,51 @@ func AddDefaultRoleForUser(userID interface{}) {
 
 // AddDefaultRoleForVirtualUser adds org list role to a virtual user.
 func AddDefaultRoleForVirtualUser(userID interface{}) {
-	enforcer.AddRoleForUser(fmt.Sprint(userID), "defaultVirtual")
+	enforcer.AddRoleForUser(fmt.Sprint(userID), defaultVirtualRole)
 }
 
-// AddOrgRoles creates an organization role, by adding the default (*) org policies for the given organization.
+// AddOrgRoles creates the built-in roles of the given organizations.
 func AddOrgRoles(orgids ...uint) {
-	basePath := viper.GetString("pipeline.basepath")
 	for _, orgid := range orgids {
-		enforcer.AddPolicy(orgRoleName(orgid), fmt.Sprintf("%s/api/v1/orgs/%d", basePath, orgid), "*")
-		enforcer.AddPolicy(orgRoleName(orgid), fmt.Sprintf("%s/api/v1/orgs/%d/*", basePath, orgid), "*")
+		syncRoleRules(orgid, orgRoleName(orgid, RoleAdmin), adminRules)
+		syncRoleRules(orgid, orgRoleName(orgid, RoleViewer), viewerRules)
+		syncRoleRules(orgid, orgRoleName(orgid, RoleMember), memberRules)
+
+		// members can do everything viewers can
+		enforcer.AddGroupingPolicy(orgRoleName(orgid, RoleMember), orgRoleName(orgid, RoleViewer))
 	}
 }
 
-// AddOrgRoleForUser adds a user to an organization by adding the associated organization role.
-func AddOrgRoleForUser(userID interface{}, orgids ...uint) {
-	for _, orgid := range orgids {
-		enforcer.AddRoleForUser(fmt.Sprint(userID), orgRoleName(orgid))
+// DeleteOrgRoles removes every role of the given organization along with the users' assignments.
+func DeleteOrgRoles(orgid uint) {
+	prefix := orgRolePrefix(orgid)
+
+	for _, subject := range enforcer.GetAllSubjects() {
+		if strings.HasPrefix(subject, prefix) {
+			enforcer.DeleteRole(subject)
+		}
 	}
+
+	enforcer.DeleteRole(orgRoleName(orgid, RoleAdmin))
 }
 
-// DeleteOrgRoleForUser removes a user from an organization by removing the associated organization role.
-func DeleteOrgRoleForUser(userID uint, orgid uint) {
-	enforcer.DeleteRoleForUser(fmt.Sprint(userID), orgRoleName(orgid))
+// AddOrgRoleForUser adds a user to an organization with the given role, replacing the role the user had before.
+func AddOrgRoleForUser(userID interface{}, orgid uint, role string) {
+	DeleteOrgRoleForUser(userID, orgid)
+	enforcer.AddRoleForUser(fmt.Sprint(userID), orgRoleName(orgid, role))
+}
+
+// DeleteOrgRoleForUser removes a user from an organization by removing the associated organization roles.
+func DeleteOrgRoleForUser(userID interface{}, orgid uint) {
+	user := fmt.Sprint(userID)
+	adminRole := orgRoleName(orgid, RoleAdmin)
+	prefix := orgRolePrefix(orgid)
+
+	for _, role := range enforcer.GetRolesForUser(user) {
+		if role == adminRole || strings.HasPrefix(role, prefix) {
+			enforcer.DeleteRoleForUser(user, role)
+		}
+	}
 }
 
 // DeleteRolesForUser removes all roles for a given user.
@@ -128,6 +198,66 @@ func DeleteRolesForUser(userID uint) {
 	enforcer.DeleteUser(fmt.Sprint(userID))
 }
 
-func orgRoleName(orgid uint) string {
-	return fmt.Sprint("org-", orgid)
+// syncOrgRoles assigns the organization roles to the users according to the roles stored in the database.
+func syncOrgRoles() {
+	var userOrganizations []UserOrganization
+
+	if err := config.DB().Find(&userOrganizations).Error; err != nil {
+		log.Errorf("failed to load organization roles of users: %s", err.Error())
+		return
+	}
+
+	orgs := make(map[uint]bool)
+	for _, userOrganization := range userOrganizations {
+		if !orgs[userOrganization.OrganizationID] {
+			AddOrgRoles(userOrganization.OrganizationID)
+			orgs[userOrganization.OrganizationID] = true
+		}
+
+		AddOrgRoleForUser(userOrganization.UserID, userOrganization.OrganizationID, userOrganization.Role)
+	}
+}
+
+func orgRoleName(orgid uint, role string) string {
+	// admins keep the role all organization members had before roles were enforced
+	if role == RoleAdmin {
+		return fmt.Sprint("org-", orgid)
+	}
+
+	return orgRolePrefix(orgid) + role
+}
+
+func orgRolePrefix(orgid uint) string {
+	return fmt.Sprintf("org-%d-", orgid)
+}
+
+// pathMatch checks whether the path matches the pattern of a policy,
+// :name matches a single path segment, a trailing * matches the rest of the path
+func pathMatch(path string, pattern string) bool {
+	pathSegments := strings.Split(path, "/")
+	patternSegments := strings.Split(pattern, "/")
+
+	for i, segment := range patternSegments {
+		if segment == "*" && i == len(patternSegments)-1 {
+			return len(pathSegments) > i
+		}
+
+		if i >= len(pathSegments) {
+			return false
+		}
+
+		if strings.HasPrefix(segment, ":") {
+			if pathSegments[i] == "" {
+				return false
+			}
+		} else if segment != pathSegments[i] {
+			return false
+		}
+	}
+
+	return len(pathSegments) == len(patternSegments)
+}
+
+func pathMatchFunc(args ...interface{}) (interface{}, error) {
+	return pathMatch(args[0].(string), args[1].(string)), nil
 }
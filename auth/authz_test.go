@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"testing"
+)
+
+func TestPathMatch(t *testing.T) {
+	cases := []struct {
+		path    string
+		pattern string
+		matches bool
+	}{
+		{path: "/api/v1/orgs", pattern: "/api/v1/orgs", matches: true},
+		{path: "/api/v1/orgs/1", pattern: "/api/v1/orgs", matches: false},
+		{path: "/api/v1/orgs/1", pattern: "/api/v1/orgs/1", matches: true},
+		{path: "/api/v1/orgs/12", pattern: "/api/v1/orgs/1", matches: false},
+		{path: "/api/v1/orgs/1/clusters", pattern: "/api/v1/orgs/1/*", matches: true},
+		{path: "/api/v1/orgs/1/clusters/2/deployments", pattern: "/api/v1/orgs/1/*", matches: true},
+		{path: "/api/v1/orgs/1", pattern: "/api/v1/orgs/1/*", matches: false},
+		{path: "/api/v1/orgs/12/clusters", pattern: "/api/v1/orgs/1/*", matches: false},
+		{path: "/api/v1/orgs/1/clusters/2", pattern: "/api/v1/orgs/1/clusters/:id", matches: true},
+		{path: "/api/v1/orgs/1/clusters/2/config", pattern: "/api/v1/orgs/1/clusters/:id", matches: false},
+		{path: "/api/v1/orgs/1/clusters/", pattern: "/api/v1/orgs/1/clusters/:id", matches: false},
+		{path: "/api/v1/orgs/1/clusters/2/deployments/app", pattern: "/api/v1/orgs/1/clusters/:id/*", matches: true},
+		{path: "/api/v1/orgs/1/clusters/2", pattern: "/api/v1/orgs/1/clusters/:id/*", matches: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.path+" "+tc.pattern, func(t *testing.T) {
+			if matches := pathMatch(tc.path, tc.pattern); matches != tc.matches {
+				t.Errorf("expected path %q matching pattern %q to be %t", tc.path, tc.pattern, tc.matches)
+			}
+		})
+	}
+}
+
+func TestMemberRules(t *testing.T) {
+	cases := []struct {
+		path    string
+		verb    string
+		allowed bool
+	}{
+		{path: "/clusters/2", verb: "PUT", allowed: true},
+		{path: "/clusters/2", verb: "DELETE", allowed: false},
+		{path: "/clusters/2/deployments/app", verb: "DELETE", allowed: true},
+		{path: "/clusters/2/config", verb: "GET", allowed: false},
+		{path: "/clusters/2/proxy/api/v1/pods", verb: "GET", allowed: true},
+		{path: "/clusters/2/proxy/api/v1/namespaces/default/pods/app", verb: "DELETE", allowed: false},
+		{path: "/clusters/2/proxy/api/v1/namespaces", verb: "POST", allowed: false},
+	}
+
+	rules := append(append([]RoleRule{}, viewerRules...), memberRules...)
+
+	for _, tc := range cases {
+		t.Run(tc.verb+" "+tc.path, func(t *testing.T) {
+			var allowed bool
+			for _, rule := range rules {
+				for _, verb := range rule.Verbs {
+					if pathMatch(tc.path, rule.Path) && (verb == tc.verb || verb == AllVerbs) {
+						allowed = true
+					}
+				}
+			}
+
+			if allowed != tc.allowed {
+				t.Errorf("expected %s %s to be allowed for members: %t", tc.verb, tc.path, tc.allowed)
+			}
+		})
+	}
+}
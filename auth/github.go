@@ -13,12 +13,35 @@ import (
 	"golang.org/x/oauth2"
 )
 
+// GithubProviderName is the name of the GitHub login provider
+const GithubProviderName = "github"
+
 //GithubExtraInfo struct for github credentials
 type GithubExtraInfo struct {
 	Login string
 	Token string
 }
 
+// GetLogin returns the GitHub login of the user
+func (info *GithubExtraInfo) GetLogin() string {
+	return info.Login
+}
+
+// GetOrganizations returns the GitHub organizations of the user
+func (info *GithubExtraInfo) GetOrganizations() ([]*Organization, error) {
+	return getGithubOrganizations(info.Token)
+}
+
+// SupportsDrone returns true, Drone users are GitHub users
+func (info *GithubExtraInfo) SupportsDrone() bool {
+	return true
+}
+
+// EmailVerified returns true, GitHub only returns the public email of the user, which has to be verified
+func (info *GithubExtraInfo) EmailVerified() bool {
+	return true
+}
+
 //NewGithubAuthorizeHandler handler for Github auth
 func NewGithubAuthorizeHandler(provider *githubauth.GithubProvider) func(context *auth.Context) (*claims.Claims, error) {
 	return func(context *auth.Context) (*claims.Claims, error) {
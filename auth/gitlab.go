@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/qor/auth"
+	"golang.org/x/oauth2"
+)
+
+// GitlabProviderName is the name of the GitLab login provider
+const GitlabProviderName = "gitlab"
+
+// gitlabOwnerAccessLevel is the access level of the owners of a GitLab group
+const gitlabOwnerAccessLevel = 50
+
+// GitlabConfig describes a GitLab instance used as a login provider
+type GitlabConfig struct {
+	URL          string
+	ClientID     string
+	ClientSecret string
+}
+
+// GitlabExtraInfo holds the login name and the groups of a user logged in through GitLab
+type GitlabExtraInfo struct {
+	Login          string
+	Groups         []*Organization
+	EmailConfirmed bool
+}
+
+// GetLogin returns the login name of the user
+func (info *GitlabExtraInfo) GetLogin() string {
+	return info.Login
+}
+
+// GetOrganizations returns an organization for every GitLab group of the user
+func (info *GitlabExtraInfo) GetOrganizations() ([]*Organization, error) {
+	return info.Groups, nil
+}
+
+// SupportsDrone returns false, Drone works with GitHub users only
+func (info *GitlabExtraInfo) SupportsDrone() bool {
+	return false
+}
+
+// EmailVerified returns true if the user confirmed the email in GitLab
+func (info *GitlabExtraInfo) EmailVerified() bool {
+	return info.EmailConfirmed
+}
+
+type gitlabUser struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+
+	// ConfirmedAt is empty until the user confirms the email
+	ConfirmedAt string `json:"confirmed_at"`
+}
+
+type gitlabGroup struct {
+	ID       int64  `json:"id"`
+	FullPath string `json:"full_path"`
+}
+
+type gitlabMember struct {
+	AccessLevel int `json:"access_level"`
+}
+
+// NewGitlabProvider returns a GitLab login provider, GitLab groups are imported as organizations
+func NewGitlabProvider(config GitlabConfig) (auth.Provider, error) {
+	if config.ClientID == "" || config.ClientSecret == "" {
+		return nil, errors.New("client id and client secret are required for GitLab")
+	}
+
+	baseURL := strings.TrimSuffix(config.URL, "/")
+	if baseURL == "" {
+		baseURL = "https://gitlab.com"
+	}
+
+	return &oauthProvider{
+		name:         GitlabProviderName,
+		clientID:     config.ClientID,
+		clientSecret: config.ClientSecret,
+		endpoint: oauth2.Endpoint{
+			AuthURL:  baseURL + "/oauth/authorize",
+			TokenURL: baseURL + "/oauth/token",
+		},
+		scopes: []string{"read_user", "api"},
+		fetchUser: func(client *http.Client, token *oauth2.Token) (*auth.Schema, error) {
+			return fetchGitlabUser(client, baseURL+"/api/v4")
+		},
+	}, nil
+}
+
+func fetchGitlabUser(client *http.Client, apiURL string) (*auth.Schema, error) {
+	var user gitlabUser
+	if _, err := getGitlabResource(client, apiURL+"/user", &user); err != nil {
+		return nil, errors.Wrap(err, "could not fetch GitLab user")
+	}
+
+	var groups []gitlabGroup
+	for page := "1"; page != ""; {
+		var pageGroups []gitlabGroup
+
+		nextPage, err := getGitlabResource(client, apiURL+"/groups?min_access_level=10&per_page=100&page="+page, &pageGroups)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not fetch GitLab groups")
+		}
+
+		groups = append(groups, pageGroups...)
+		page = nextPage
+	}
+
+	orgs := []*Organization{}
+	for _, group := range groups {
+		var member gitlabMember
+		_, err := getGitlabResource(client, fmt.Sprintf("%s/groups/%d/members/all/%d", apiURL, group.ID, user.ID), &member)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not fetch GitLab membership in group %s", group.FullPath)
+		}
+
+		// GitLab group owners are admins, everyone else are members
+		role := RoleMember
+		if member.AccessLevel >= gitlabOwnerAccessLevel {
+			role = RoleAdmin
+		}
+		externalID := fmt.Sprint(group.ID)
+		orgs = append(orgs, &Organization{
+			Name:       organizationName(group.FullPath),
+			Provider:   GitlabProviderName,
+			ExternalID: &externalID,
+			Role:       role,
+		})
+	}
+
+	return &auth.Schema{
+		UID:   fmt.Sprint(user.ID),
+		Name:  user.Name,
+		Email: user.Email,
+		Image: user.AvatarURL,
+		RawInfo: &GitlabExtraInfo{
+			Login:          user.Username,
+			Groups:         orgs,
+			EmailConfirmed: user.ConfirmedAt != "",
+		},
+	}, nil
+}
+
+// getGitlabResource decodes a GitLab API resource, the next page of paginated resources is returned as well
+func getGitlabResource(client *http.Client, url string, resource interface{}) (string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("unexpected response: %s", resp.Status)
+	}
+
+	return resp.Header.Get("X-Next-Page"), json.NewDecoder(resp.Body).Decode(resource)
+}
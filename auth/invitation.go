@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/banzaicloud/pipeline/config"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// ErrInvitationNotFound is returned when an invitation token doesn't belong to a pending invitation
+var ErrInvitationNotFound = errors.New("invitation not found or expired")
+
+// Invitation invites a user, identified by the login of an identity provider or by email, to an organization with a role
+type Invitation struct {
+	ID             uint       `gorm:"primary_key" json:"id"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+	OrganizationID uint       `gorm:"index" json:"organizationId"`
+	Provider       string     `json:"provider,omitempty"`
+	Login          string     `gorm:"index" json:"login,omitempty"`
+	Email          string     `gorm:"index" json:"email,omitempty"`
+	Role           string     `json:"role"`
+	TokenHash      string     `gorm:"unique_index;size:64" json:"-"`
+	InvitedBy      uint       `json:"invitedBy"`
+	ExpiresAt      time.Time  `json:"expiresAt"`
+	AcceptedAt     *time.Time `json:"acceptedAt,omitempty"`
+	AcceptedBy     *uint      `json:"acceptedBy,omitempty"`
+}
+
+// TableName overrides Invitation's table name
+func (Invitation) TableName() string {
+	return "organization_invitations"
+}
+
+// CreateInvitation invites a user to an organization, the returned token accepts the invitation.
+// Logins are unique within an identity provider only, GitHub is the default provider of logins.
+func CreateInvitation(organizationID uint, invitedBy uint, provider string, login string, email string, role string, ttl time.Duration) (*Invitation, string, error) {
+	if (login == "") == (email == "") {
+		return nil, "", errors.New("either a login or an email is required")
+	}
+
+	if login == "" {
+		provider = ""
+	} else if provider == "" {
+		provider = GithubProviderName
+	} else if provider != GithubProviderName && provider != GitlabProviderName && provider != OIDCProviderName {
+		return nil, "", errors.Errorf("unknown identity provider %q", provider)
+	}
+
+	if role == "" {
+		role = RoleMember
+	}
+
+	if !IsOrgRole(organizationID, role) {
+		return nil, "", errors.Errorf("role %q does not exist in the organization", role)
+	}
+
+	token, err := generateInvitationToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	invitation := &Invitation{
+		OrganizationID: organizationID,
+		Provider:       provider,
+		Login:          login,
+		Email:          strings.ToLower(email),
+		Role:           role,
+		TokenHash:      hashInvitationToken(token),
+		InvitedBy:      invitedBy,
+		ExpiresAt:      time.Now().Add(ttl),
+	}
+
+	if err := config.DB().Create(invitation).Error; err != nil {
+		return nil, "", errors.Wrap(err, "could not save invitation")
+	}
+
+	return invitation, token, nil
+}
+
+// GetPendingInvitations returns the invitations of an organization which are neither accepted nor expired
+func GetPendingInvitations(organizationID uint) ([]*Invitation, error) {
+	invitations := []*Invitation{}
+
+	err := pendingInvitations(config.DB()).
+		Where("organization_id = ?", organizationID).
+		Order("created_at").
+		Find(&invitations).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list invitations")
+	}
+
+	return invitations, nil
+}
+
+// RevokeInvitation deletes a pending invitation of an organization
+func RevokeInvitation(organizationID uint, invitationID uint) error {
+	result := config.DB().
+		Where("id = ? AND organization_id = ? AND accepted_at IS NULL", invitationID, organizationID).
+		Delete(&Invitation{})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "could not revoke invitation")
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrInvitationNotFound
+	}
+
+	return nil
+}
+
+// AcceptInvitation makes the user a member of the organization the invitation token belongs to
+func AcceptInvitation(user *User, token string) (*Invitation, error) {
+	var invitation Invitation
+
+	err := pendingInvitations(config.DB()).Where("token_hash = ?", hashInvitationToken(token)).First(&invitation).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, ErrInvitationNotFound
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not find invitation")
+	}
+
+	if err := applyInvitation(config.DB(), user, &invitation); err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// acceptPendingInvitations applies the invitations sent to the login of a new user at the identity provider the user
+// logged in with or to the email of the user, if the identity provider verified it
+func acceptPendingInvitations(db *gorm.DB, user *User, provider string, emailVerified bool) error {
+	var invitations []*Invitation
+
+	scope := pendingInvitations(db)
+	if user.Email != "" && emailVerified {
+		scope = scope.Where("(provider = ? AND login = ?) OR email = ?", provider, user.Login, strings.ToLower(user.Email))
+	} else {
+		scope = scope.Where("provider = ? AND login = ?", provider, user.Login)
+	}
+
+	if err := scope.Order("created_at").Find(&invitations).Error; err != nil {
+		return errors.Wrap(err, "could not list invitations of the user")
+	}
+
+	for _, invitation := range invitations {
+		if err := applyInvitation(db, user, invitation); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applyInvitation(db *gorm.DB, user *User, invitation *Invitation) error {
+	now := time.Now()
+
+	tx := db.Begin()
+	if err := tx.Error; err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+
+	// the invitation can be accepted only once
+	result := tx.Model(invitation).
+		Where("accepted_at IS NULL").
+		Updates(map[string]interface{}{"accepted_at": now, "accepted_by": user.ID})
+	if result.Error != nil {
+		tx.Rollback()
+		return errors.Wrap(result.Error, "could not accept invitation")
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return ErrInvitationNotFound
+	}
+
+	// UserOrganization has no primary key, so the membership is updated explicitly
+	userOrganization := UserOrganization{UserID: user.ID, OrganizationID: invitation.OrganizationID}
+
+	var count int
+	err := tx.Model(&UserOrganization{}).Where(userOrganization).Count(&count).Error
+	if err == nil && count > 0 {
+		err = tx.Model(&UserOrganization{}).Where(userOrganization).Update("role", invitation.Role).Error
+	} else if err == nil {
+		userOrganization.Role = invitation.Role
+		err = tx.Create(&userOrganization).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "could not add user to organization")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "could not accept invitation")
+	}
+
+	invitation.AcceptedAt = &now
+	invitation.AcceptedBy = &user.ID
+
+	AddOrgRoles(invitation.OrganizationID)
+	AddOrgRoleForUser(user.ID, invitation.OrganizationID, invitation.Role)
+
+	return nil
+}
+
+func pendingInvitations(db *gorm.DB) *gorm.DB {
+	return db.Where("accepted_at IS NULL AND expires_at > ?", time.Now())
+}
+
+func generateInvitationToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", errors.Wrap(err, "could not generate invitation token")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// only the hash of the tokens is stored, so a database leak doesn't expose pending invitations
+func hashInvitationToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
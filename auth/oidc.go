@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/qor/auth"
+	"golang.org/x/oauth2"
+)
+
+// OIDCProviderName is the name of the generic OpenID Connect login provider
+const OIDCProviderName = "oidc"
+
+// OIDCConfig describes an OpenID Connect identity provider, like Keycloak or Dex
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+
+	// GroupsClaim is the claim of the user info listing the groups of the user
+	GroupsClaim string
+
+	// AdminGroups are the groups whose members are the admins of the corresponding organizations
+	AdminGroups []string
+}
+
+// OIDCExtraInfo holds the login name and the groups of a user logged in through OpenID Connect
+type OIDCExtraInfo struct {
+	Login          string
+	Groups         []string
+	AdminGroups    []string
+	EmailConfirmed bool
+}
+
+// GetLogin returns the login name of the user
+func (info *OIDCExtraInfo) GetLogin() string {
+	return info.Login
+}
+
+// GetOrganizations returns an organization for every group of the user
+func (info *OIDCExtraInfo) GetOrganizations() ([]*Organization, error) {
+	admin := make(map[string]bool, len(info.AdminGroups))
+	for _, group := range info.AdminGroups {
+		admin[group] = true
+	}
+
+	orgs := []*Organization{}
+	for _, group := range info.Groups {
+		name := organizationName(group)
+		if name == "" {
+			continue
+		}
+
+		role := RoleMember
+		if admin[group] {
+			role = RoleAdmin
+		}
+		// the groups claim has no IDs, the group itself identifies the organization
+		externalID := group
+		orgs = append(orgs, &Organization{
+			Name:       name,
+			Provider:   OIDCProviderName,
+			ExternalID: &externalID,
+			Role:       role,
+		})
+	}
+	return orgs, nil
+}
+
+// SupportsDrone returns false, Drone works with GitHub users only
+func (info *OIDCExtraInfo) SupportsDrone() bool {
+	return false
+}
+
+// EmailVerified returns the email_verified claim of the user
+func (info *OIDCExtraInfo) EmailVerified() bool {
+	return info.EmailConfirmed
+}
+
+type oidcDiscovery struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// NewOIDCProvider returns an OpenID Connect login provider configured from the discovery document of the issuer
+func NewOIDCProvider(config OIDCConfig) (auth.Provider, error) {
+	if config.Issuer == "" || config.ClientID == "" || config.ClientSecret == "" {
+		return nil, errors.New("issuer, client id and client secret are required for OpenID Connect")
+	}
+
+	discoveryURL := strings.TrimSuffix(config.Issuer, "/") + "/.well-known/openid-configuration"
+	resp, err := http.Get(discoveryURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch OpenID Connect discovery document")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("could not fetch OpenID Connect discovery document: %s", resp.Status)
+	}
+
+	var discovery oidcDiscovery
+	if err := json.NewDecoder(resp.Body).Decode(&discovery); err != nil {
+		return nil, errors.Wrap(err, "could not decode OpenID Connect discovery document")
+	}
+
+	scopes := config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email", "groups"}
+	}
+
+	groupsClaim := config.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = "groups"
+	}
+
+	return &oauthProvider{
+		name:         OIDCProviderName,
+		clientID:     config.ClientID,
+		clientSecret: config.ClientSecret,
+		endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.AuthorizationEndpoint,
+			TokenURL: discovery.TokenEndpoint,
+		},
+		scopes: scopes,
+		fetchUser: func(client *http.Client, token *oauth2.Token) (*auth.Schema, error) {
+			return fetchOIDCUser(client, discovery.UserinfoEndpoint, groupsClaim, config.AdminGroups)
+		},
+	}, nil
+}
+
+func fetchOIDCUser(client *http.Client, userinfoEndpoint string, groupsClaim string, adminGroups []string) (*auth.Schema, error) {
+	resp, err := client.Get(userinfoEndpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch OpenID Connect user info")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("could not fetch OpenID Connect user info: %s", resp.Status)
+	}
+
+	var userinfo map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&userinfo); err != nil {
+		return nil, errors.Wrap(err, "could not decode OpenID Connect user info")
+	}
+
+	claim := func(name string) string {
+		if value, ok := userinfo[name].(string); ok {
+			return value
+		}
+		return ""
+	}
+
+	subject := claim("sub")
+	if subject == "" {
+		return nil, errors.New("OpenID Connect user info has no subject")
+	}
+
+	login := claim("preferred_username")
+	if login == "" {
+		login = strings.Split(claim("email"), "@")[0]
+	}
+	if login == "" {
+		login = subject
+	}
+
+	// some identity providers send the claim as a string
+	emailVerified := userinfo["email_verified"] == true || userinfo["email_verified"] == "true"
+
+	var groups []string
+	if values, ok := userinfo[groupsClaim].([]interface{}); ok {
+		for _, value := range values {
+			groups = append(groups, fmt.Sprint(value))
+		}
+	}
+
+	return &auth.Schema{
+		UID:   subject,
+		Name:  claim("name"),
+		Email: claim("email"),
+		Image: claim("picture"),
+		RawInfo: &OIDCExtraInfo{
+			Login:          login,
+			Groups:         groups,
+			AdminGroups:    adminGroups,
+			EmailConfirmed: emailVerified,
+		},
+	}, nil
+}
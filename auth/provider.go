@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/qor/auth"
+	"github.com/qor/auth/auth_identity"
+	"github.com/qor/auth/claims"
+	"github.com/qor/qor/utils"
+	"golang.org/x/oauth2"
+)
+
+// UserExtraInfo is the identity provider specific information of a registering user
+type UserExtraInfo interface {
+	// GetLogin returns the login name of the user
+	GetLogin() string
+
+	// GetOrganizations returns the organizations the user should be a member of
+	GetOrganizations() ([]*Organization, error)
+
+	// SupportsDrone tells whether the user can be bootstrapped in Drone, which works with GitHub users only
+	SupportsDrone() bool
+
+	// EmailVerified tells whether the identity provider verified the email of the user,
+	// unverified emails must not grant access to anything
+	EmailVerified() bool
+}
+
+// oauthUserFetcher returns the registration schema of the user the token belongs to
+type oauthUserFetcher func(client *http.Client, token *oauth2.Token) (*auth.Schema, error)
+
+// oauthProvider is a login provider using the OAuth2 authorization code flow
+type oauthProvider struct {
+	name         string
+	clientID     string
+	clientSecret string
+	endpoint     oauth2.Endpoint
+	scopes       []string
+	fetchUser    oauthUserFetcher
+}
+
+// GetName returns the name of the provider
+func (provider *oauthProvider) GetName() string {
+	return provider.name
+}
+
+// ConfigAuth implements auth.Provider
+func (provider *oauthProvider) ConfigAuth(*auth.Auth) {}
+
+// OAuthConfig returns the OAuth2 configuration of the provider for the request
+func (provider *oauthProvider) OAuthConfig(context *auth.Context) *oauth2.Config {
+	req := context.Request
+	scheme := req.URL.Scheme
+	if scheme == "" {
+		scheme = "http://"
+	}
+
+	return &oauth2.Config{
+		ClientID:     provider.clientID,
+		ClientSecret: provider.clientSecret,
+		Endpoint:     provider.endpoint,
+		RedirectURL:  scheme + req.Host + context.Auth.AuthURL(provider.name+"/callback"),
+		Scopes:       provider.scopes,
+	}
+}
+
+// Login redirects the user to the identity provider
+func (provider *oauthProvider) Login(context *auth.Context) {
+	state := claims.Claims{}
+	state.Subject = "state"
+	signedState := context.Auth.SessionStorer.SignedToken(&state)
+
+	url := provider.OAuthConfig(context).AuthCodeURL(signedState)
+	http.Redirect(context.Writer, context.Request, url, http.StatusFound)
+}
+
+// Logout implements auth.Provider
+func (provider *oauthProvider) Logout(context *auth.Context) {}
+
+// Register registers the user through the login flow
+func (provider *oauthProvider) Register(context *auth.Context) {
+	provider.Login(context)
+}
+
+// Callback handles the redirection of the identity provider
+func (provider *oauthProvider) Callback(context *auth.Context) {
+	context.Auth.LoginHandler(context, provider.authorize)
+}
+
+// ServeHTTP implements auth.Provider
+func (provider *oauthProvider) ServeHTTP(*auth.Context) {}
+
+func (provider *oauthProvider) authorize(context *auth.Context) (*claims.Claims, error) {
+	var (
+		authInfo     auth_identity.Basic
+		authIdentity = reflect.New(utils.ModelType(context.Auth.Config.AuthIdentityModel)).Interface()
+		req          = context.Request
+		tx           = context.Auth.GetDB(req)
+		oauthCfg     = provider.OAuthConfig(context)
+	)
+
+	state, err := context.Auth.SessionStorer.ValidateClaims(req.URL.Query().Get("state"))
+	if err != nil {
+		log.Info(req.RemoteAddr, err.Error())
+		return nil, err
+	}
+
+	if state.Valid() != nil || state.Subject != "state" {
+		log.Info(req.RemoteAddr, auth.ErrUnauthorized.Error())
+		return nil, auth.ErrUnauthorized
+	}
+
+	token, err := oauthCfg.Exchange(oauth2.NoContext, req.URL.Query().Get("code"))
+	if err != nil {
+		log.Info(req.RemoteAddr, err.Error())
+		return nil, err
+	}
+
+	schema, err := provider.fetchUser(oauthCfg.Client(oauth2.NoContext, token), token)
+	if err != nil {
+		log.Info(req.RemoteAddr, err.Error())
+		return nil, err
+	}
+
+	schema.Provider = provider.name
+
+	authInfo.Provider = provider.name
+	authInfo.UID = schema.UID
+
+	if !tx.Model(authIdentity).Where(authInfo).Scan(&authInfo).RecordNotFound() {
+		return authInfo.ToClaims(), nil
+	}
+
+	_, userID, err := context.Auth.UserStorer.Save(schema, context)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID != "" {
+		authInfo.UserID = userID
+	}
+
+	if err = tx.Where(authInfo).FirstOrCreate(authIdentity).Error; err != nil {
+		log.Info(req.RemoteAddr, err.Error())
+		return nil, err
+	}
+
+	return authInfo.ToClaims(), nil
+}
+
+// organizationName returns the Pipeline organization name of a group of an identity provider,
+// nested groups are flattened, because organization names can't contain slashes
+func organizationName(group string) string {
+	return strings.Replace(strings.Trim(group, "/"), "/", "-", -1)
+}
@@ -0,0 +1,261 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/banzaicloud/pipeline/config"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// Built-in organization roles
+const (
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+	RoleViewer = "viewer"
+)
+
+// AllVerbs allows every HTTP method in a role rule
+const AllVerbs = "*"
+
+var readVerbs = []string{http.MethodGet, http.MethodHead}
+
+var roleNameRegexp = regexp.MustCompile("^[a-z0-9]([-a-z0-9]*[a-z0-9])?$")
+
+// RoleRule allows the verbs (HTTP methods) on a path of the organization API.
+// The path is relative to /api/v1/orgs/:orgid, :name matches a single path segment, a trailing * matches the rest of the path.
+type RoleRule struct {
+	Path  string   `json:"path"`
+	Verbs []string `json:"verbs" binding:"required,min=1"`
+}
+
+// OrgRole describes a role users can have in an organization
+type OrgRole struct {
+	Name    string     `json:"name"`
+	BuiltIn bool       `json:"builtIn"`
+	Rules   []RoleRule `json:"rules" binding:"required,min=1,dive"`
+}
+
+// viewerRules allow reading everything in an organization except secrets and cluster credentials
+var viewerRules = []RoleRule{
+	{Path: "", Verbs: readVerbs},
+	{Path: "/spotguides", Verbs: readVerbs},
+	{Path: "/spotguides/*", Verbs: readVerbs},
+	{Path: "/clusters", Verbs: readVerbs},
+	{Path: "/clusters/:id", Verbs: readVerbs},
+	{Path: "/clusters/:id/details", Verbs: readVerbs},
+	{Path: "/clusters/:id/pods", Verbs: readVerbs},
+	{Path: "/clusters/:id/posthooks", Verbs: readVerbs},
+	{Path: "/clusters/:id/predeletehooks", Verbs: readVerbs},
+	{Path: "/clusters/:id/operations", Verbs: readVerbs},
+	{Path: "/clusters/:id/drift", Verbs: readVerbs},
+	{Path: "/clusters/:id/statushistory", Verbs: readVerbs},
+	{Path: "/clusters/:id/definition", Verbs: readVerbs},
+	{Path: "/clusters/:id/apiendpoint", Verbs: readVerbs},
+	{Path: "/clusters/:id/nodes", Verbs: readVerbs},
+	{Path: "/clusters/:id/endpoints", Verbs: readVerbs},
+	{Path: "/clusters/:id/hpa", Verbs: readVerbs},
+	{Path: "/clusters/:id/deployments", Verbs: readVerbs},
+	{Path: "/clusters/:id/deployments/*", Verbs: readVerbs},
+	{Path: "/clusters/:id/labels", Verbs: readVerbs},
+	{Path: "/deploymentsets", Verbs: readVerbs},
+	{Path: "/deploymentsets/:name", Verbs: readVerbs},
+	{Path: "/helm/*", Verbs: readVerbs},
+	{Path: "/profiles/*", Verbs: readVerbs},
+	{Path: "/users", Verbs: readVerbs},
+	{Path: "/users/:id", Verbs: readVerbs},
+	{Path: "/roles", Verbs: readVerbs},
+	{Path: "/secrettypes", Verbs: readVerbs},
+	{Path: "/secrettypes/:name", Verbs: readVerbs},
+	{Path: "/allowed/secrets", Verbs: readVerbs},
+	{Path: "/allowed/secrets/:type", Verbs: readVerbs},
+	{Path: "/buckets", Verbs: readVerbs},
+	{Path: "/buckets/*", Verbs: readVerbs},
+	{Path: "/cloudinfo", Verbs: readVerbs},
+	{Path: "/cloudinfo/*", Verbs: readVerbs},
+	{Path: "/azure/*", Verbs: readVerbs},
+}
+
+// memberRules extend the viewer rules with everything except deleting clusters, getting cluster credentials,
+// changing resources through the cluster proxy, changing secrets and managing the users and the organization
+var memberRules = []RoleRule{
+	{Path: "/spotguides", Verbs: []string{http.MethodPost, http.MethodPut}},
+	{Path: "/clusters", Verbs: []string{http.MethodPost}},
+	{Path: "/clusterimports", Verbs: []string{http.MethodPost}},
+	{Path: "/clusterdefinitions", Verbs: []string{http.MethodPost}},
+	{Path: "/clusters/:id", Verbs: []string{http.MethodPut}},
+	{Path: "/clusters/:id/posthooks", Verbs: []string{http.MethodPut}},
+	{Path: "/clusters/:id/clone", Verbs: []string{http.MethodPost}},
+	{Path: "/clusters/:id/secrets", Verbs: []string{http.MethodPost}},
+	{Path: "/clusters/:id/imagepullsecrets", Verbs: []string{http.MethodPost}},
+	{Path: "/clusters/:id/labels", Verbs: []string{http.MethodPut}},
+	{Path: "/clusters/:id/hpa", Verbs: []string{http.MethodPut, http.MethodDelete}},
+	{Path: "/clusters/:id/helminit", Verbs: []string{http.MethodPost}},
+	{Path: "/clusters/:id/deployments", Verbs: []string{http.MethodPost}},
+	{Path: "/clusters/:id/deployments/*", Verbs: []string{http.MethodPost, http.MethodPut, http.MethodDelete}},
+	{Path: "/clusters/:id/proxy/*", Verbs: readVerbs},
+	{Path: "/secrets", Verbs: []string{http.MethodGet, http.MethodPost}},
+	{Path: "/secrets/:id", Verbs: readVerbs},
+	{Path: "/secrets/:id/validate", Verbs: readVerbs},
+	{Path: "/secretexports", Verbs: []string{http.MethodPost}},
+	{Path: "/secrets/:id/usage", Verbs: readVerbs},
+	{Path: "/secrets/:id/acl", Verbs: []string{http.MethodGet, http.MethodPut}},
+	{Path: "/deploymentsets", Verbs: []string{http.MethodPost}},
+	{Path: "/deploymentsets/:name", Verbs: []string{http.MethodPut, http.MethodDelete}},
+	{Path: "/helm/*", Verbs: []string{http.MethodPost, http.MethodPut, http.MethodDelete}},
+	{Path: "/profiles/*", Verbs: []string{http.MethodPost, http.MethodPut, http.MethodDelete}},
+	{Path: "/buckets", Verbs: []string{http.MethodPost}},
+	{Path: "/buckets/*", Verbs: []string{http.MethodDelete}},
+	{Path: "/azure/*", Verbs: []string{http.MethodPost, http.MethodDelete}},
+}
+
+// adminRules allow everything in an organization
+var adminRules = []RoleRule{
+	{Path: "", Verbs: []string{AllVerbs}},
+	{Path: "/*", Verbs: []string{AllVerbs}},
+}
+
+// IsBuiltInRole checks whether the role is one of the roles every organization has
+func IsBuiltInRole(role string) bool {
+	return role == RoleAdmin || role == RoleMember || role == RoleViewer
+}
+
+// IsOrgRole checks whether the role exists in the organization
+func IsOrgRole(orgid uint, role string) bool {
+	if IsBuiltInRole(role) {
+		return true
+	}
+
+	return len(enforcer.GetFilteredPolicy(0, orgRoleName(orgid, role))) > 0
+}
+
+// GetOrgRoles returns the built-in and the custom roles of an organization
+func GetOrgRoles(orgid uint) []OrgRole {
+	roles := []OrgRole{
+		{Name: RoleAdmin, BuiltIn: true, Rules: adminRules},
+		{Name: RoleMember, BuiltIn: true, Rules: append(append([]RoleRule{}, viewerRules...), memberRules...)},
+		{Name: RoleViewer, BuiltIn: true, Rules: viewerRules},
+	}
+
+	prefix := orgRolePrefix(orgid)
+	var names []string
+	for _, subject := range enforcer.GetAllSubjects() {
+		name := strings.TrimPrefix(subject, prefix)
+		if name != subject && !IsBuiltInRole(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		roles = append(roles, OrgRole{Name: name, Rules: getOrgRoleRules(orgid, name)})
+	}
+
+	return roles
+}
+
+// SaveOrgRole creates or replaces a custom role of an organization, the users having the role get the new rules at once
+func SaveOrgRole(orgid uint, role OrgRole) error {
+	if !roleNameRegexp.MatchString(role.Name) {
+		return errors.Errorf("invalid role name %q: it must consist of lower case alphanumeric characters or '-'", role.Name)
+	}
+
+	if IsBuiltInRole(role.Name) {
+		return errors.Errorf("built-in role %q cannot be changed", role.Name)
+	}
+
+	for _, rule := range role.Rules {
+		if rule.Path != "" && !strings.HasPrefix(rule.Path, "/") {
+			return errors.Errorf("invalid rule path %q: it must start with '/'", rule.Path)
+		}
+	}
+
+	roleName := orgRoleName(orgid, role.Name)
+
+	enforcer.RemoveFilteredPolicy(0, roleName)
+	addRoleRules(orgid, roleName, role.Rules)
+
+	return nil
+}
+
+// DeleteOrgRole deletes a custom role of an organization unless a user has it
+func DeleteOrgRole(orgid uint, role string) error {
+	if IsBuiltInRole(role) {
+		return errors.Errorf("built-in role %q cannot be deleted", role)
+	}
+
+	var count int
+	err := config.DB().Model(&UserOrganization{}).Where(&UserOrganization{OrganizationID: orgid, Role: role}).Count(&count).Error
+	if err != nil {
+		return errors.Wrap(err, "could not count users with the role")
+	}
+
+	if count > 0 {
+		return errors.Errorf("role %q is assigned to %d user(s)", role, count)
+	}
+
+	enforcer.DeleteRole(orgRoleName(orgid, role))
+
+	return nil
+}
+
+func getOrgRoleRules(orgid uint, role string) []RoleRule {
+	orgPath := orgBasePath(orgid)
+
+	var rules []RoleRule
+	paths := make(map[string]int)
+
+	for _, policy := range enforcer.GetFilteredPolicy(0, orgRoleName(orgid, role)) {
+		path := strings.TrimPrefix(policy[1], orgPath)
+
+		i, ok := paths[path]
+		if !ok {
+			i = len(rules)
+			paths[path] = i
+			rules = append(rules, RoleRule{Path: path})
+		}
+
+		rules[i].Verbs = append(rules[i].Verbs, policy[2])
+	}
+
+	return rules
+}
+
+// syncRoleRules replaces the rules of a role stored earlier with the given ones, so that the built-in roles
+// lose the rules removed from them in a later version
+func syncRoleRules(orgid uint, roleName string, rules []RoleRule) {
+	orgPath := orgBasePath(orgid)
+
+	allowed := make(map[string]bool)
+	for _, rule := range rules {
+		for _, verb := range rule.Verbs {
+			allowed[orgPath+rule.Path+" "+strings.ToUpper(verb)] = true
+		}
+	}
+
+	for _, policy := range enforcer.GetFilteredPolicy(0, roleName) {
+		if len(policy) == 3 && !allowed[policy[1]+" "+policy[2]] {
+			enforcer.RemovePolicy(policy[0], policy[1], policy[2])
+		}
+	}
+
+	addRoleRules(orgid, roleName, rules)
+}
+
+func addRoleRules(orgid uint, roleName string, rules []RoleRule) {
+	orgPath := orgBasePath(orgid)
+
+	for _, rule := range rules {
+		for _, verb := range rule.Verbs {
+			enforcer.AddPolicy(roleName, orgPath+rule.Path, strings.ToUpper(verb))
+		}
+	}
+}
+
+func orgBasePath(orgid uint) string {
+	return fmt.Sprintf("%s/api/v1/orgs/%d", viper.GetString("pipeline.basepath"), orgid)
+}
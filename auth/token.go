@@ -0,0 +1,372 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	bauth "github.com/banzaicloud/bank-vaults/auth"
+	"github.com/banzaicloud/pipeline/config"
+	"github.com/pkg/errors"
+)
+
+// APITokenScopeInvoke is the scope of the tokens which can access everything their user can
+const APITokenScopeInvoke = "api:invoke"
+
+// Access levels of the resource scopes of API tokens
+const (
+	TokenAccessNone  = "none"
+	TokenAccessRead  = "read"
+	TokenAccessWrite = "write"
+)
+
+// tokenScopeOrgPrefix marks the organizations an API token is restricted to in the scope claim
+const tokenScopeOrgPrefix = "org:"
+
+// lastUsedPrecision limits how often the last usage of a token is written to the database
+const lastUsedPrecision = time.Minute
+
+var resourceScopeRegexp = regexp.MustCompile("^[a-z]+:(none|read|write)$")
+
+var accessLevels = map[string]int{
+	TokenAccessNone:  0,
+	TokenAccessRead:  1,
+	TokenAccessWrite: 2,
+}
+
+// TokenRequest describes an API token to be created
+type TokenRequest struct {
+	Name          string     `json:"name,omitempty"`
+	VirtualUser   string     `json:"virtualUser,omitempty"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+	Organizations []uint     `json:"organizations,omitempty"`
+	Scopes        []string   `json:"scopes,omitempty"`
+}
+
+// TokenResponse describes an API token of a user with its restrictions and usage
+type TokenResponse struct {
+	*bauth.Token
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt    *time.Time `json:"lastUsedAt,omitempty"`
+	Organizations []uint     `json:"organizations,omitempty"`
+	Scopes        []string   `json:"scopes,omitempty"`
+}
+
+// APITokenModel stores the expiry, the scope and the last usage of an API token kept in the TokenStore
+type APITokenModel struct {
+	ID         string `gorm:"primary_key;size:36"`
+	UserID     string `gorm:"index"`
+	OwnerID    uint   `gorm:"index"` // the user the token of a virtual user acts on behalf of
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time `gorm:"index"`
+	LastUsedAt *time.Time
+	Scope      string `sql:"type:text"`
+}
+
+// TableName overrides APITokenModel's table name
+func (APITokenModel) TableName() string {
+	return "api_tokens"
+}
+
+// tokenScope is the parsed scope claim of an API token
+type tokenScope struct {
+	// organizations the token can access, nil means every organization of the user
+	organizations map[uint]bool
+
+	// access levels of the resources, nil means full access
+	resources map[string]string
+}
+
+// newTokenScope creates a token scope from the organizations and resource scopes of a token request
+func newTokenScope(organizations []uint, scopes []string) (*tokenScope, error) {
+	s := &tokenScope{}
+
+	if len(organizations) > 0 {
+		s.organizations = make(map[uint]bool, len(organizations))
+		for _, orgID := range organizations {
+			s.organizations[orgID] = true
+		}
+	}
+
+	if len(scopes) > 0 {
+		s.resources = make(map[string]string, len(scopes))
+		for _, scope := range scopes {
+			if !resourceScopeRegexp.MatchString(scope) {
+				return nil, errors.Errorf("invalid scope %q: it must be <resource>:none|read|write", scope)
+			}
+
+			parts := strings.SplitN(scope, ":", 2)
+			s.resources[parts[0]] = parts[1]
+		}
+	}
+
+	return s, nil
+}
+
+// parseTokenScope parses the space separated scope claim of an API token
+func parseTokenScope(scope string) (*tokenScope, error) {
+	var organizations []uint
+	var scopes []string
+
+	for _, item := range strings.Fields(scope) {
+		switch {
+		case item == APITokenScopeInvoke:
+		case strings.HasPrefix(item, tokenScopeOrgPrefix):
+			orgID, err := strconv.ParseUint(strings.TrimPrefix(item, tokenScopeOrgPrefix), 10, 32)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid organization scope %q", item)
+			}
+			organizations = append(organizations, uint(orgID))
+		default:
+			scopes = append(scopes, item)
+		}
+	}
+
+	return newTokenScope(organizations, scopes)
+}
+
+// String returns the scope claim of the token
+func (s *tokenScope) String() string {
+	items := []string{APITokenScopeInvoke}
+
+	for _, orgID := range s.Organizations() {
+		items = append(items, fmt.Sprint(tokenScopeOrgPrefix, orgID))
+	}
+
+	items = append(items, s.Scopes()...)
+
+	return strings.Join(items, " ")
+}
+
+// Organizations returns the organizations the token is restricted to in ascending order
+func (s *tokenScope) Organizations() []uint {
+	var organizations []uint
+	for orgID := range s.organizations {
+		organizations = append(organizations, orgID)
+	}
+
+	sort.Slice(organizations, func(i, j int) bool { return organizations[i] < organizations[j] })
+
+	return organizations
+}
+
+// Scopes returns the resource scopes of the token in alphabetical order
+func (s *tokenScope) Scopes() []string {
+	var scopes []string
+	for resource, access := range s.resources {
+		scopes = append(scopes, resource+":"+access)
+	}
+
+	sort.Strings(scopes)
+
+	return scopes
+}
+
+// Restricted checks whether the token can access less than its user
+func (s *tokenScope) Restricted() bool {
+	return s.organizations != nil || s.resources != nil
+}
+
+// Allows checks whether the token can be used for the request.
+// Only the organization API is restricted, the rest is left to the Casbin policies.
+func (s *tokenScope) Allows(method string, path string) bool {
+	const orgsPath = "/api/v1/orgs/"
+
+	i := strings.Index(path, orgsPath)
+	if i < 0 {
+		return true
+	}
+
+	segments := strings.Split(strings.TrimSuffix(path[i+len(orgsPath):], "/"), "/")
+
+	if s.organizations != nil {
+		orgID, err := strconv.ParseUint(segments[0], 10, 32)
+		if err != nil || !s.organizations[uint(orgID)] {
+			return false
+		}
+	}
+
+	if s.resources == nil {
+		return true
+	}
+
+	required := TokenAccessWrite
+	if method == http.MethodGet || method == http.MethodHead {
+		required = TokenAccessRead
+	}
+
+	granted, ok := s.resources[tokenResource(segments[1:])]
+	if !ok {
+		granted = TokenAccessNone
+	}
+
+	return accessLevels[granted] >= accessLevels[required]
+}
+
+// tokenResource returns the resource scope governing a path of the organization API
+func tokenResource(segments []string) string {
+	if len(segments) == 0 || segments[0] == "" {
+		return "organization"
+	}
+
+	switch {
+	case segments[0] == "clusters" && len(segments) > 2 && segments[2] == "deployments":
+		return "deployments"
+	case segments[0] == "clusters" && len(segments) > 2 && segments[2] == "secrets":
+		return "secrets"
+	case segments[0] == "helm" && len(segments) > 1 && segments[1] == "deployments":
+		return "deployments"
+	}
+
+	return segments[0]
+}
+
+// checkTokenScope checks the scope of the API token the user authenticated with, session users are not restricted
+func checkTokenScope(user *User, method string, path string) bool {
+	if user == nil || user.TokenScope == "" {
+		return true
+	}
+
+	scope, err := parseTokenScope(user.TokenScope)
+	if err != nil {
+		log.Infof("invalid token scope: %s", err.Error())
+		return false
+	}
+
+	return scope.Allows(method, path)
+}
+
+// validateTokenOrganizations checks whether the user is a member of every organization a token is restricted to
+func validateTokenOrganizations(userID uint, organizations []uint) error {
+	for _, orgID := range organizations {
+		var count int
+		err := config.DB().Model(&UserOrganization{}).Where(&UserOrganization{UserID: userID, OrganizationID: orgID}).Count(&count).Error
+		if err != nil {
+			return errors.Wrap(err, "could not check organization membership")
+		}
+
+		if count == 0 {
+			return errors.Errorf("user is not a member of organization %d", orgID)
+		}
+	}
+
+	return nil
+}
+
+// touchedTokens holds the time the usage of the API tokens was recorded last by token ID
+var touchedTokens = struct {
+	sync.Mutex
+	times map[string]time.Time
+}{times: make(map[string]time.Time)}
+
+// shouldTouchAPIToken returns true if the usage of an API token was not recorded within the precision
+func shouldTouchAPIToken(tokenID string, now time.Time) bool {
+	touchedTokens.Lock()
+	defer touchedTokens.Unlock()
+
+	if touchedAt, ok := touchedTokens.times[tokenID]; ok && now.Sub(touchedAt) < lastUsedPrecision {
+		return false
+	}
+
+	// forget the tokens not used recently, so that the map doesn't grow with every token ever used
+	for id, touchedAt := range touchedTokens.times {
+		if now.Sub(touchedAt) >= lastUsedPrecision {
+			delete(touchedTokens.times, id)
+		}
+	}
+
+	touchedTokens.times[tokenID] = now
+
+	return true
+}
+
+// touchAPIToken records the usage of an API token, at most once within the precision by Pipeline instance
+func touchAPIToken(tokenID string) {
+	now := time.Now()
+
+	if !shouldTouchAPIToken(tokenID, now) {
+		return
+	}
+
+	err := config.DB().Model(&APITokenModel{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", tokenID, now.Add(-lastUsedPrecision)).
+		Update("last_used_at", now).Error
+	if err != nil {
+		log.Warnf("failed to record the usage of token %s: %s", tokenID, err.Error())
+	}
+}
+
+// getTokenResponses returns the tokens of a user along with their stored metadata
+func getTokenResponses(userID string, tokens ...*bauth.Token) ([]*TokenResponse, error) {
+	var models []APITokenModel
+	err := config.DB().Where(&APITokenModel{UserID: userID}).Find(&models).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load token metadata")
+	}
+
+	metadata := make(map[string]APITokenModel, len(models))
+	for _, m := range models {
+		metadata[m.ID] = m
+	}
+
+	responses := make([]*TokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		response := &TokenResponse{Token: token}
+
+		if m, ok := metadata[token.ID]; ok {
+			response.ExpiresAt = m.ExpiresAt
+			response.LastUsedAt = m.LastUsedAt
+
+			if scope, err := parseTokenScope(m.Scope); err == nil {
+				response.Organizations = scope.Organizations()
+				response.Scopes = scope.Scopes()
+			}
+		}
+
+		responses = append(responses, response)
+	}
+
+	return responses, nil
+}
+
+// StartTokenPurger periodically revokes the expired API tokens in the background.
+func StartTokenPurger(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			PurgeExpiredTokens()
+		}
+	}()
+}
+
+// PurgeExpiredTokens removes the expired API tokens from the TokenStore.
+func PurgeExpiredTokens() {
+	var expired []APITokenModel
+	err := config.DB().Where("expires_at < ?", time.Now()).Find(&expired).Error
+	if err != nil {
+		log.Errorf("failed to list expired tokens: %s", err.Error())
+		return
+	}
+
+	for _, token := range expired {
+		if err := TokenStore.Revoke(token.UserID, token.ID); err != nil {
+			log.Errorf("failed to revoke expired token %s: %s", token.ID, err.Error())
+			continue
+		}
+
+		if err := config.DB().Delete(&token).Error; err != nil {
+			log.Errorf("failed to delete expired token %s: %s", token.ID, err.Error())
+		}
+	}
+
+	if len(expired) > 0 {
+		log.Infof("purged %d expired tokens", len(expired))
+	}
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTokenScope(t *testing.T) {
+	scope, err := newTokenScope([]uint{2, 1}, []string{"secrets:none", "clusters:read", "deployments:write"})
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	claim := scope.String()
+	if expected := "api:invoke org:1 org:2 clusters:read deployments:write secrets:none"; claim != expected {
+		t.Errorf("Expected scope claim: %q, got: %q", expected, claim)
+	}
+
+	parsed, err := parseTokenScope(claim)
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	if !reflect.DeepEqual(scope, parsed) {
+		t.Errorf("Expected parsed scope: %#v, got: %#v", scope, parsed)
+	}
+
+	if _, err := newTokenScope(nil, []string{"clusters:admin"}); err == nil {
+		t.Error("Expected error for invalid access level, got: <nil>")
+	}
+}
+
+func TestTokenScopeAllows(t *testing.T) {
+	scope, err := parseTokenScope("api:invoke org:1 clusters:read deployments:write secrets:none")
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	legacy, err := parseTokenScope(APITokenScopeInvoke)
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	tests := []struct {
+		scope    *tokenScope
+		method   string
+		path     string
+		expected bool
+	}{
+		{scope, http.MethodGet, "/pipeline/api/v1/orgs", true},
+		{scope, http.MethodGet, "/pipeline/api/v1/orgs/1/clusters", true},
+		{scope, http.MethodGet, "/pipeline/api/v1/orgs/2/clusters", false},
+		{scope, http.MethodPost, "/pipeline/api/v1/orgs/1/clusters", false},
+		{scope, http.MethodPost, "/pipeline/api/v1/orgs/1/clusters/3/deployments", true},
+		{scope, http.MethodDelete, "/pipeline/api/v1/orgs/1/helm/deployments/my-release", true},
+		{scope, http.MethodGet, "/pipeline/api/v1/orgs/1/clusters/3/secrets", false},
+		{scope, http.MethodGet, "/pipeline/api/v1/orgs/1/secrets", false},
+		{scope, http.MethodGet, "/pipeline/api/v1/orgs/1/buckets", false},
+		{scope, http.MethodGet, "/pipeline/api/v1/orgs/1", false},
+		{legacy, http.MethodDelete, "/pipeline/api/v1/orgs/2/secrets/abc", true},
+	}
+
+	for _, test := range tests {
+		if allowed := test.scope.Allows(test.method, test.path); allowed != test.expected {
+			t.Errorf("%s %s: expected %t, got %t", test.method, test.path, test.expected, allowed)
+		}
+	}
+}
+
+func TestShouldTouchAPIToken(t *testing.T) {
+	now := time.Now()
+
+	if !shouldTouchAPIToken("token", now) {
+		t.Fatal("Expected the first usage to be recorded")
+	}
+
+	if shouldTouchAPIToken("token", now.Add(time.Second)) {
+		t.Error("Expected the usage within the precision not to be recorded")
+	}
+
+	if !shouldTouchAPIToken("other", now.Add(time.Second)) {
+		t.Error("Expected the usage of another token to be recorded")
+	}
+
+	if !shouldTouchAPIToken("token", now.Add(lastUsedPrecision)) {
+		t.Error("Expected the usage after the precision to be recorded")
+	}
+}
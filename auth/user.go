@@ -52,6 +52,8 @@ type User struct {
 	Image         string         `form:"image" json:"image,omitempty"`
 	Organizations []Organization `gorm:"many2many:user_organizations" json:"organizations,omitempty"`
 	Virtual       bool           `json:"-" gorm:"-"` // Used only internally
+	TokenID       string         `json:"-" gorm:"-"` // Used only internally
+	TokenScope    string         `json:"-" gorm:"-"` // Used only internally
 }
 
 //DroneUser struct
@@ -78,13 +80,15 @@ type UserOrganization struct {
 
 //Organization struct
 type Organization struct {
-	ID        uint      `gorm:"primary_key" json:"id"`
-	GithubID  *int64    `gorm:"unique" json:"githubId,omitempty"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
-	Name      string    `gorm:"unique;not null" json:"name"`
-	Users     []User    `gorm:"many2many:user_organizations" json:"users,omitempty"`
-	Role      string    `json:"-" gorm:"-"` // Used only internally
+	ID         uint      `gorm:"primary_key" json:"id"`
+	GithubID   *int64    `gorm:"unique" json:"githubId,omitempty"`
+	Provider   string    `gorm:"unique_index:idx_organization_external_id" json:"provider,omitempty"`
+	ExternalID *string   `gorm:"unique_index:idx_organization_external_id" json:"-"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+	Name       string    `gorm:"unique;not null" json:"name"`
+	Users      []User    `gorm:"many2many:user_organizations" json:"users,omitempty"`
+	Role       string    `json:"-" gorm:"-"` // Used only internally
 }
 
 //IDString returns the ID as string
@@ -176,8 +180,8 @@ type BanzaiUserStorer struct {
 	droneDB          *gorm.DB
 }
 
-// Save differs from the default UserStorer.Save() in that it
-// extracts Token and Login and saves to Drone DB as well
+// Save differs from the default UserStorer.Save() in that it extracts Login from the identity provider,
+// saves the user to Drone DB if the provider supports it and imports the user's organizations
 func (bus BanzaiUserStorer) Save(schema *auth.Schema, context *auth.Context) (user interface{}, userID string, err error) {
 
 	currentUser := &User{}
@@ -186,16 +190,24 @@ func (bus BanzaiUserStorer) Save(schema *auth.Schema, context *auth.Context) (us
 		return nil, "", err
 	}
 
-	// This assumes GitHub auth only right now
-	githubExtraInfo := schema.RawInfo.(*GithubExtraInfo)
-	currentUser.Login = githubExtraInfo.Login
-	err = bus.createUserInDroneDB(currentUser, githubExtraInfo.Token)
-	if err != nil {
-		log.Info(context.Request.RemoteAddr, err.Error())
-		return nil, "", err
+	extraInfo, ok := schema.RawInfo.(UserExtraInfo)
+	if !ok {
+		return nil, "", fmt.Errorf("unsupported identity provider: %s", schema.Provider)
 	}
+	currentUser.Login = extraInfo.GetLogin()
+
+	githubExtraInfo, isGithubUser := extraInfo.(*GithubExtraInfo)
+
+	// Drone users can only be bootstrapped from identity providers Drone supports
+	if extraInfo.SupportsDrone() && isGithubUser {
+		err = bus.createUserInDroneDB(currentUser, githubExtraInfo.Token)
+		if err != nil {
+			log.Info(context.Request.RemoteAddr, err.Error())
+			return nil, "", err
+		}
 
-	synchronizeDroneRepos(currentUser.Login)
+		synchronizeDroneRepos(currentUser.Login)
+	}
 
 	// When a user registers a default organization is created in which he/she is admin
 	userOrg := Organization{
@@ -209,7 +221,7 @@ func (bus BanzaiUserStorer) Save(schema *auth.Schema, context *auth.Context) (us
 		return nil, "", fmt.Errorf("failed to create user organization: %s", err.Error())
 	}
 
-	err = helm.InstallLocalHelm(helm.GenerateHelmRepoEnv(currentUser.Organizations[0].Name))
+	err = helm.InstallLocalHelm(helm.GenerateHelmRepoEnv(currentUser.Organizations[0].Name, currentUser.Organizations[0].ID))
 	if err != nil {
 		log.Errorf("Error during local helm install: %s", err.Error())
 	}
@@ -217,20 +229,29 @@ func (bus BanzaiUserStorer) Save(schema *auth.Schema, context *auth.Context) (us
 	AddDefaultRoleForUser(currentUser.ID)
 
 	// Save the Github token to Vault
-	token := bauth.NewToken(GithubTokenID, "Github access token")
-	token.Value = githubExtraInfo.Token
-	err = TokenStore.Store(fmt.Sprint(currentUser.ID), token)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to store Github access token: %s", err.Error())
+	if isGithubUser {
+		token := bauth.NewToken(GithubTokenID, "Github access token")
+		token.Value = githubExtraInfo.Token
+		err = TokenStore.Store(fmt.Sprint(currentUser.ID), token)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to store Github access token: %s", err.Error())
+		}
 	}
 
-	githubOrgIDs, err := importGithubOrganizations(currentUser, context, githubExtraInfo.Token)
+	importedOrgRoles, err := importOrganizations(currentUser, context, extraInfo)
 
 	if err == nil {
-		orgids := []uint{currentUser.Organizations[0].ID}
-		orgids = append(orgids, githubOrgIDs...)
-		AddOrgRoles(orgids...)
-		AddOrgRoleForUser(currentUser.ID, orgids...)
+		orgRoles := map[uint]string{currentUser.Organizations[0].ID: RoleAdmin}
+		for orgid, role := range importedOrgRoles {
+			orgRoles[orgid] = role
+		}
+		for orgid, role := range orgRoles {
+			AddOrgRoles(orgid)
+			AddOrgRoleForUser(currentUser.ID, orgid, role)
+		}
+
+		// Invitations sent to the user before the first login, unverified emails can't claim invitations
+		err = acceptPendingInvitations(db, currentUser, schema.Provider, extraInfo.EmailVerified())
 	}
 
 	return currentUser, fmt.Sprint(db.NewScope(currentUser).PrimaryKeyValue()), err
@@ -277,42 +298,69 @@ func getGithubOrganizations(token string) ([]*Organization, error) {
 	orgs := []*Organization{}
 	for _, membership := range memberships {
 		githubOrg := membership.GetOrganization()
-		org := Organization{Name: githubOrg.GetLogin(), GithubID: githubOrg.ID, Role: membership.GetRole()}
+		// GitHub organization owners are admins, everyone else (members, billing managers) are members
+		role := RoleMember
+		if membership.GetRole() == RoleAdmin {
+			role = RoleAdmin
+		}
+		org := Organization{Name: githubOrg.GetLogin(), GithubID: githubOrg.ID, Provider: GithubProviderName, Role: role}
 		orgs = append(orgs, &org)
 	}
 	return orgs, nil
 }
 
-func importGithubOrganizations(currentUser *User, context *auth.Context, githubToken string) ([]uint, error) {
+// importOrganizations makes the user a member of the organizations (GitHub organizations, GitLab or OpenID Connect groups)
+// the identity provider returns for the user
+func importOrganizations(currentUser *User, context *auth.Context, extraInfo UserExtraInfo) (map[uint]string, error) {
 
-	githubOrgs, err := getGithubOrganizations(githubToken)
+	orgs, err := extraInfo.GetOrganizations()
 	if err != nil {
 		log.Info("Failed to list organizations", err)
-		githubOrgs = []*Organization{}
+		orgs = []*Organization{}
 	}
 
-	orgids := []uint{}
+	orgRoles := map[uint]string{}
 
 	tx := context.Auth.GetDB(context.Request).Begin()
 	{
-		for _, githubOrg := range githubOrgs {
-			err = tx.Where(&githubOrg).FirstOrCreate(githubOrg).Error
+		for _, org := range orgs {
+			found, err := findImportedOrganization(tx, org)
 			if err != nil {
 				tx.Rollback()
 				return nil, err
 			}
-			err = tx.Model(currentUser).Association("Organizations").Append(githubOrg).Error
+			if !found {
+				// an organization with the same name created otherwise (a personal organization
+				// or a group of another identity provider) must never be taken over by a group
+				var count int
+				err = tx.Model(&Organization{}).Where("name = ?", org.Name).Count(&count).Error
+				if err != nil {
+					tx.Rollback()
+					return nil, err
+				}
+				if count > 0 {
+					log.Warnf("Skipping %s group %s of user %s, organization %s already exists", org.Provider, org.Name, currentUser.Login, org.Name)
+					continue
+				}
+
+				err = tx.Create(org).Error
+				if err != nil {
+					tx.Rollback()
+					return nil, err
+				}
+			}
+			err = tx.Model(currentUser).Association("Organizations").Append(org).Error
 			if err != nil {
 				tx.Rollback()
 				return nil, err
 			}
-			userRoleInOrg := UserOrganization{UserID: currentUser.ID, OrganizationID: githubOrg.ID}
-			err = tx.Model(&UserOrganization{}).Where(userRoleInOrg).Update("role", githubOrg.Role).Error
+			userRoleInOrg := UserOrganization{UserID: currentUser.ID, OrganizationID: org.ID}
+			err = tx.Model(&UserOrganization{}).Where(userRoleInOrg).Update("role", org.Role).Error
 			if err != nil {
 				tx.Rollback()
 				return nil, err
 			}
-			orgids = append(orgids, githubOrg.ID)
+			orgRoles[org.ID] = org.Role
 		}
 	}
 
@@ -321,7 +369,31 @@ func importGithubOrganizations(currentUser *User, context *auth.Context, githubT
 		return nil, err
 	}
 
-	return orgids, nil
+	return orgRoles, nil
+}
+
+// findImportedOrganization looks up the organization of a group of an identity provider by the ID of the group,
+// GitHub organizations are identified by their GitHub ID. The found organization is loaded into org keeping its role.
+func findImportedOrganization(db *gorm.DB, org *Organization) (bool, error) {
+	query := db.Where("provider = ? AND external_id = ?", org.Provider, org.ExternalID)
+	if org.GithubID != nil {
+		query = db.Where("github_id = ?", *org.GithubID)
+	} else if org.Provider == "" || org.ExternalID == nil {
+		return false, fmt.Errorf("organization %s has no external id", org.Name)
+	}
+
+	var existing Organization
+	err := query.First(&existing).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	existing.Role = org.Role
+	*org = existing
+
+	return true, nil
 }
 
 // GetOrganizationById returns an organization from database by ID
@@ -332,6 +404,13 @@ func GetOrganizationById(orgID uint) (*Organization, error) {
 	return &org, err
 }
 
+// GetUserOrganizationRole returns the role of a user in an organization
+func GetUserOrganizationRole(userID uint, orgID uint) (string, error) {
+	var userOrganization UserOrganization
+	err := config.DB().Where(&UserOrganization{UserID: userID, OrganizationID: orgID}).First(&userOrganization).Error
+	return userOrganization.Role, err
+}
+
 // GetUserById returns user
 func GetUserById(userId uint) (*User, error) {
 	db := config.DB()
@@ -65,6 +65,27 @@ func CreateAKSClusterFromRequest(request *pkgCluster.CreateClusterRequest, orgId
 	return &cluster, nil
 }
 
+//CreateAKSClusterFromImportRequest creates ClusterModel struct from the import request
+func CreateAKSClusterFromImportRequest(request *pkgCluster.ImportClusterRequest, orgId, userId uint) (*AKSCluster, error) {
+	log.Debug("Create ClusterModel struct from the import request")
+	var cluster AKSCluster
+
+	cluster.modelCluster = &model.ClusterModel{
+		Name:           request.Name,
+		Location:       request.Location,
+		Cloud:          request.Cloud,
+		OrganizationId: orgId,
+		CreatedBy:      userId,
+		SecretId:       request.SecretId,
+		Distribution:   pkgCluster.AKS,
+		Imported:       true,
+		AKS: model.AKSClusterModel{
+			ResourceGroup: request.Properties.ImportClusterAKS.ResourceGroup,
+		},
+	}
+	return &cluster, nil
+}
+
 //AKSCluster struct for AKS cluster
 type AKSCluster struct {
 	azureCluster *azureType.Value //Don't use this directly
@@ -184,6 +205,51 @@ func (c *AKSCluster) CreateCluster() error {
 	return nil
 }
 
+// ImportCluster reads the definition of an existing AKS cluster into the cluster model
+func (c *AKSCluster) ImportCluster() error {
+	azureCluster, err := c.GetAzureCluster()
+	if err != nil {
+		return err
+	}
+
+	if stage := azureCluster.Properties.ProvisioningState; stage != statusSucceeded {
+		return &preconditionFailedError{errors.Errorf("AKS cluster is not ready, its provisioning state is %s", stage)}
+	}
+
+	c.modelCluster.AKS.KubernetesVersion = azureCluster.Properties.KubernetesVersion
+
+	var nodePools []*model.AKSNodePoolModel
+	for _, profile := range azureCluster.Properties.AgentPoolProfiles {
+		var name string
+		if profile.Name != nil {
+			name = *profile.Name
+		}
+
+		var count int
+		if profile.Count != nil {
+			count = int(*profile.Count)
+		}
+
+		nodePools = append(nodePools, &model.AKSNodePoolModel{
+			CreatedBy:        c.modelCluster.CreatedBy,
+			Name:             name,
+			NodeMinCount:     count,
+			NodeMaxCount:     count,
+			Count:            count,
+			NodeInstanceType: string(profile.VMSize),
+		})
+	}
+
+	if len(nodePools) == 0 {
+		return &preconditionFailedError{pkgErrors.ErrorNodePoolNotProvided}
+	}
+
+	c.modelCluster.AKS.NodePools = nodePools
+	log.Info("AKS cluster definition read successfully")
+
+	return nil
+}
+
 //Persist save the cluster model
 func (c *AKSCluster) Persist(status, statusMessage string) error {
 	return c.modelCluster.UpdateStatus(status, statusMessage)
@@ -714,7 +780,8 @@ func (c *AKSCluster) RequiresSshPublicKey() bool {
 	return true
 }
 
-// ListNodeNames returns node names to label them
+// ListNodeNames returns node names to label them,
+// the nodes of the node pools missing from the cluster spec are listed as well
 func (c *AKSCluster) ListNodeNames() (labels pkgCommon.NodeNames, err error) {
 
 	var client azureClient.ClusterManager
@@ -729,17 +796,17 @@ func (c *AKSCluster) ListNodeNames() (labels pkgCommon.NodeNames, err error) {
 
 	var vms []compute.VirtualMachine
 	vms, err = azureClient.ListVirtualMachines(client, c.modelCluster.AKS.ResourceGroup, c.modelCluster.Name, c.modelCluster.Location)
-	for _, np := range c.modelCluster.AKS.NodePools {
-		if np != nil {
-			for _, vm := range vms {
-				if vm.OsProfile != nil && vm.OsProfile.ComputerName != nil {
-					for key, tag := range vm.Tags {
-						if poolNameKey == key && tag != nil && *tag == np.Name {
-							labels[np.Name] = append(labels[np.Name], *vm.OsProfile.ComputerName)
-						}
-					}
-				}
-			}
+	if err != nil {
+		return
+	}
+
+	for _, vm := range vms {
+		if vm.OsProfile == nil || vm.OsProfile.ComputerName == nil {
+			continue
+		}
+
+		if tag, ok := vm.Tags[poolNameKey]; ok && tag != nil {
+			labels[*tag] = append(labels[*tag], *vm.OsProfile.ComputerName)
 		}
 	}
 
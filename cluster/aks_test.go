@@ -0,0 +1,54 @@
+package cluster
+
+import (
+	"testing"
+
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	"github.com/banzaicloud/pipeline/pkg/cluster/aks"
+)
+
+func TestCreateAKSClusterFromRequestIsNotImported(t *testing.T) {
+	request := &pkgCluster.CreateClusterRequest{
+		Name:     "akscluster",
+		Location: "westeurope",
+		Cloud:    pkgCluster.Azure,
+		Properties: &pkgCluster.CreateClusterProperties{
+			CreateClusterAKS: &aks.CreateClusterAKS{
+				ResourceGroup:     "resource-group",
+				KubernetesVersion: "1.11.3",
+				NodePools: map[string]*aks.NodePoolCreate{
+					"pool1": {Count: 1, NodeInstanceType: "Standard_D2_v2"},
+				},
+			},
+		},
+	}
+
+	cluster, err := CreateAKSClusterFromRequest(request, 1, userId)
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	if isImportedCluster(cluster) {
+		t.Error("Expected a created cluster not to be imported, deleting it would only detach it")
+	}
+}
+
+func TestCreateAKSClusterFromImportRequestIsImported(t *testing.T) {
+	request := &pkgCluster.ImportClusterRequest{
+		Name:     "akscluster",
+		Location: "westeurope",
+		Cloud:    pkgCluster.Azure,
+		Properties: &pkgCluster.ImportClusterProperties{
+			ImportClusterAKS: &aks.ImportClusterAKS{ResourceGroup: "resource-group"},
+		},
+	}
+
+	cluster, err := CreateAKSClusterFromImportRequest(request, 1, userId)
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	if !isImportedCluster(cluster) {
+		t.Error("Expected an imported cluster to be imported")
+	}
+}
@@ -296,9 +296,9 @@ func deployAutoscalerChart(cluster CommonCluster, nodeGroups []nodeGroup, kubeCo
 	}
 	switch action {
 	case install:
-		_, err = helm.CreateDeployment(autoScalerChart, "", nil, helm.SystemNamespace, releaseName, yamlValues, kubeConfig, helm.GenerateHelmRepoEnv(org.Name))
+		_, err = helm.CreateDeployment(autoScalerChart, "", nil, helm.SystemNamespace, releaseName, false, yamlValues, kubeConfig, helm.GenerateHelmRepoEnv(org.Name, org.ID))
 	case upgrade:
-		_, err = helm.UpgradeDeployment(releaseName, autoScalerChart, "", nil, yamlValues, false, kubeConfig, helm.GenerateHelmRepoEnv(org.Name))
+		_, err = helm.UpgradeDeployment(releaseName, autoScalerChart, "", nil, yamlValues, false, false, kubeConfig, helm.GenerateHelmRepoEnv(org.Name, org.ID))
 	default:
 		return err
 	}
@@ -417,6 +417,27 @@ func CreateCommonClusterFromRequest(createClusterRequest *pkgCluster.CreateClust
 	return nil, pkgErrors.ErrorNotSupportedCloudType
 }
 
+// CreateCommonClusterFromImportRequest creates a CommonCluster from an import request.
+func CreateCommonClusterFromImportRequest(importClusterRequest *pkgCluster.ImportClusterRequest, orgId, userId uint) (CommonCluster, error) {
+
+	if err := importClusterRequest.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch importClusterRequest.Cloud {
+	case pkgCluster.Amazon:
+		return CreateEKSClusterFromImportRequest(importClusterRequest, orgId, userId)
+
+	case pkgCluster.Azure:
+		return CreateAKSClusterFromImportRequest(importClusterRequest, orgId, userId)
+
+	case pkgCluster.Google:
+		return CreateGKEClusterFromImportRequest(importClusterRequest, orgId, userId)
+	}
+
+	return nil, pkgErrors.ErrorNotSupportedCloudType
+}
+
 func getSigner(pemBytes []byte) (ssh.Signer, error) {
 	signerwithoutpassphrase, err := ssh.ParsePrivateKey(pemBytes)
 	if err != nil {
@@ -0,0 +1,222 @@
+package cluster
+
+import (
+	"encoding/json"
+
+	pipConfig "github.com/banzaicloud/pipeline/config"
+	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
+	"github.com/banzaicloud/pipeline/model"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	"github.com/banzaicloud/pipeline/pkg/cluster/acsk"
+	"github.com/banzaicloud/pipeline/pkg/cluster/aks"
+	"github.com/banzaicloud/pipeline/pkg/cluster/dummy"
+	"github.com/banzaicloud/pipeline/pkg/cluster/ec2"
+	"github.com/banzaicloud/pipeline/pkg/cluster/eks"
+	"github.com/banzaicloud/pipeline/pkg/cluster/gke"
+	"github.com/pkg/errors"
+)
+
+// GetClusterDefinition builds a create request reproducing the stored definition of a cluster
+// along with the posthooks it was created with.
+func GetClusterDefinition(cluster CommonCluster) (*pkgCluster.CreateClusterRequest, error) {
+	c, ok := cluster.(interface {
+		GetModel() *model.ClusterModel
+	})
+	if !ok || c.GetModel() == nil {
+		return nil, ErrInvalidClusterInstance
+	}
+
+	request, err := CreateClusterRequestFromModel(c.GetModel())
+	if err != nil {
+		return nil, err
+	}
+
+	request.PostHooks, err = getCreationPostHooks(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// CreateClusterRequestFromModel creates a create request from the stored node pools, instance types and versions of a cluster.
+func CreateClusterRequestFromModel(clusterModel *model.ClusterModel) (*pkgCluster.CreateClusterRequest, error) {
+	request := &pkgCluster.CreateClusterRequest{
+		Name:       clusterModel.Name,
+		Location:   clusterModel.Location,
+		Cloud:      clusterModel.Cloud,
+		SecretId:   clusterModel.SecretId,
+		Properties: &pkgCluster.CreateClusterProperties{},
+	}
+
+	switch clusterModel.Distribution {
+	case pkgCluster.ACSK:
+		nodePools := make(acsk.NodePools, len(clusterModel.ACSK.NodePools))
+		for _, np := range clusterModel.ACSK.NodePools {
+			nodePools[np.Name] = &acsk.NodePool{
+				InstanceType:       np.InstanceType,
+				SystemDiskCategory: np.SystemDiskCategory,
+				SystemDiskSize:     np.SystemDiskSize,
+				Count:              np.Count,
+				Image:              np.Image,
+			}
+		}
+
+		request.Properties.CreateClusterACSK = &acsk.CreateClusterACSK{
+			RegionID:                 clusterModel.ACSK.RegionID,
+			ZoneID:                   clusterModel.ACSK.ZoneID,
+			MasterInstanceType:       clusterModel.ACSK.MasterInstanceType,
+			MasterSystemDiskCategory: clusterModel.ACSK.MasterSystemDiskCategory,
+			MasterSystemDiskSize:     clusterModel.ACSK.MasterSystemDiskSize,
+			NodePools:                nodePools,
+		}
+
+	case pkgCluster.EC2:
+		request.Properties.CreateClusterEC2 = &ec2.CreateClusterEC2{
+			NodePools: createAmazonNodePoolsFromModel(clusterModel.EC2.NodePools),
+			Master: &ec2.CreateAmazonMaster{
+				InstanceType: clusterModel.EC2.MasterInstanceType,
+				Image:        clusterModel.EC2.MasterImage,
+			},
+		}
+
+	case pkgCluster.EKS:
+		request.Properties.CreateClusterEKS = &eks.CreateClusterEKS{
+			Version:   clusterModel.EKS.Version,
+			NodePools: createAmazonNodePoolsFromModel(clusterModel.EKS.NodePools),
+		}
+
+	case pkgCluster.AKS:
+		nodePools := make(map[string]*aks.NodePoolCreate, len(clusterModel.AKS.NodePools))
+		for _, np := range clusterModel.AKS.NodePools {
+			nodePools[np.Name] = &aks.NodePoolCreate{
+				Autoscaling:      np.Autoscaling,
+				MinCount:         np.NodeMinCount,
+				MaxCount:         np.NodeMaxCount,
+				Count:            np.Count,
+				NodeInstanceType: np.NodeInstanceType,
+			}
+		}
+
+		request.Properties.CreateClusterAKS = &aks.CreateClusterAKS{
+			ResourceGroup:     clusterModel.AKS.ResourceGroup,
+			KubernetesVersion: clusterModel.AKS.KubernetesVersion,
+			NodePools:         nodePools,
+		}
+
+	case pkgCluster.GKE:
+		nodePools := make(map[string]*gke.NodePool, len(clusterModel.GKE.NodePools))
+		for _, np := range clusterModel.GKE.NodePools {
+			nodePools[np.Name] = &gke.NodePool{
+				Autoscaling:      np.Autoscaling,
+				MinCount:         np.NodeMinCount,
+				MaxCount:         np.NodeMaxCount,
+				Count:            np.NodeCount,
+				NodeInstanceType: np.NodeInstanceType,
+			}
+		}
+
+		request.Properties.CreateClusterGKE = &gke.CreateClusterGKE{
+			NodeVersion: clusterModel.GKE.NodeVersion,
+			NodePools:   nodePools,
+			Master: &gke.Master{
+				Version: clusterModel.GKE.MasterVersion,
+			},
+		}
+
+	case pkgCluster.OKE:
+		request.Properties.CreateClusterOKE = clusterModel.OKE.GetClusterRequestFromModel()
+
+	case pkgCluster.Dummy:
+		request.Properties.CreateClusterDummy = &dummy.CreateClusterDummy{
+			Node: &dummy.Node{
+				KubernetesVersion: clusterModel.Dummy.KubernetesVersion,
+				Count:             clusterModel.Dummy.NodeCount,
+			},
+		}
+
+	default:
+		return nil, &invalidError{errors.Errorf("exporting the definition of %s clusters is not supported", clusterModel.Distribution)}
+	}
+
+	return request, nil
+}
+
+// RelocateClusterRequest moves a create request to another location. The images, regions and zones of the old location
+// are cleared, so that the defaults of the new location are used or they have to be given.
+func RelocateClusterRequest(request *pkgCluster.CreateClusterRequest, location string) {
+	if location == "" || location == request.Location {
+		return
+	}
+
+	request.Location = location
+
+	if request.Properties == nil {
+		return
+	}
+
+	if acskProperties := request.Properties.CreateClusterACSK; acskProperties != nil {
+		acskProperties.RegionID = location
+		acskProperties.ZoneID = ""
+	}
+
+	if ec2Properties := request.Properties.CreateClusterEC2; ec2Properties != nil {
+		if ec2Properties.Master != nil {
+			ec2Properties.Master.Image = ""
+		}
+
+		for _, np := range ec2Properties.NodePools {
+			np.Image = ""
+		}
+	}
+
+	if eksProperties := request.Properties.CreateClusterEKS; eksProperties != nil {
+		for _, np := range eksProperties.NodePools {
+			np.Image = ""
+		}
+	}
+}
+
+func createAmazonNodePoolsFromModel(nodePoolModels []*model.AmazonNodePoolsModel) map[string]*ec2.NodePool {
+	nodePools := make(map[string]*ec2.NodePool, len(nodePoolModels))
+	for _, np := range nodePoolModels {
+		nodePools[np.Name] = &ec2.NodePool{
+			InstanceType: np.NodeInstanceType,
+			SpotPrice:    np.NodeSpotPrice,
+			Autoscaling:  np.Autoscaling,
+			MinCount:     np.NodeMinCount,
+			MaxCount:     np.NodeMaxCount,
+			Count:        np.Count,
+			Image:        np.NodeImage,
+		}
+	}
+
+	return nodePools
+}
+
+// getCreationPostHooks returns the posthooks requested when the cluster was created through Pipeline
+func getCreationPostHooks(cluster CommonCluster) (pkgCluster.PostHooks, error) {
+	operations, err := intCluster.NewOperations(pipConfig.DB()).FindByCluster(cluster.GetOrganizationId(), cluster.GetID())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, operation := range operations {
+		if operation.Type != pkgCluster.OperationCreate {
+			continue
+		}
+
+		if operation.PostHooks == "" {
+			return nil, nil
+		}
+
+		var postHooks pkgCluster.PostHooks
+		if err := json.Unmarshal([]byte(operation.PostHooks), &postHooks); err != nil {
+			return nil, errors.Wrap(err, "could not decode posthooks")
+		}
+
+		return postHooks, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,54 @@
+package cluster
+
+import (
+	"testing"
+
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	"github.com/banzaicloud/pipeline/pkg/cluster/acsk"
+	"github.com/banzaicloud/pipeline/pkg/cluster/ec2"
+)
+
+func TestRelocateClusterRequest(t *testing.T) {
+	newRequest := func() *pkgCluster.CreateClusterRequest {
+		return &pkgCluster.CreateClusterRequest{
+			Location: "eu-west-1",
+			Properties: &pkgCluster.CreateClusterProperties{
+				CreateClusterEC2: &ec2.CreateClusterEC2{
+					Master: &ec2.CreateAmazonMaster{InstanceType: "m4.xlarge", Image: "ami-eu-west-1"},
+					NodePools: map[string]*ec2.NodePool{
+						"pool1": {InstanceType: "m4.xlarge", Image: "ami-eu-west-1"},
+					},
+				},
+				CreateClusterACSK: &acsk.CreateClusterACSK{RegionID: "eu-west-1", ZoneID: "eu-west-1a"},
+			},
+		}
+	}
+
+	request := newRequest()
+	RelocateClusterRequest(request, "us-east-1")
+
+	if request.Location != "us-east-1" {
+		t.Errorf("Expected location us-east-1, got: %s", request.Location)
+	}
+
+	ec2Properties := request.Properties.CreateClusterEC2
+	if ec2Properties.Master.Image != "" || ec2Properties.NodePools["pool1"].Image != "" {
+		t.Error("Expected the images of the old location to be cleared")
+	}
+
+	if ec2Properties.NodePools["pool1"].InstanceType != "m4.xlarge" {
+		t.Error("Expected the instance types to be kept")
+	}
+
+	acskProperties := request.Properties.CreateClusterACSK
+	if acskProperties.RegionID != "us-east-1" || acskProperties.ZoneID != "" {
+		t.Errorf("Expected the region of the new location without a zone, got: %s, %s", acskProperties.RegionID, acskProperties.ZoneID)
+	}
+
+	request = newRequest()
+	RelocateClusterRequest(request, "eu-west-1")
+
+	if request.Properties.CreateClusterEC2.Master.Image != "ami-eu-west-1" || request.Properties.CreateClusterACSK.ZoneID != "eu-west-1a" {
+		t.Error("Expected the properties to be kept in the same location")
+	}
+}
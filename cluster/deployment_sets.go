@@ -0,0 +1,773 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/banzaicloud/pipeline/auth"
+	pipConfig "github.com/banzaicloud/pipeline/config"
+	"github.com/banzaicloud/pipeline/helm"
+	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
+	"github.com/banzaicloud/pipeline/model"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	pkgHelm "github.com/banzaicloud/pipeline/pkg/helm"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrDeploymentSetAlreadyExists = stderrors.New("deployment set already exists with this name")
+
+// errDeploymentSetReleaseNotOwned is returned for releases of the same name not deployed by the deployment set
+var errDeploymentSetReleaseNotOwned = stderrors.New("release is not owned by the deployment set")
+
+// deploymentSetOwnerValue is the value the releases of a deployment set hold the ID of their set in,
+// so that a deployment set never upgrades or deletes a release it didn't deploy
+const deploymentSetOwnerValue = "pipelineDeploymentSet"
+
+// deploymentSetRollout is a rollout of a deployment set running in the background
+type deploymentSetRollout struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// rerun is set when the clusters changed during the rollout, it is started again when it finishes
+	rerun bool
+}
+
+// deploymentSetRollouts holds the running rollouts by deployment set ID,
+// a new rollout of a deployment set cancels the running one and waits for it to finish
+var deploymentSetRollouts = struct {
+	sync.Mutex
+	rollouts map[uint]*deploymentSetRollout
+}{rollouts: make(map[uint]*deploymentSetRollout)}
+
+// deploymentSetTask is a release of a deployment set to be installed into or upgraded in a cluster
+type deploymentSetTask struct {
+	target  *model.DeploymentSetTargetModel
+	cluster *model.ClusterModel
+}
+
+// ListDeploymentSets returns the deployment sets of an organization.
+func (m *Manager) ListDeploymentSets(ctx context.Context, organizationID uint) ([]*pkgHelm.DeploymentSetResponse, error) {
+	sets := intCluster.NewDeploymentSets(pipConfig.DB())
+
+	setModels, err := sets.FindByOrganization(organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*pkgHelm.DeploymentSetResponse, 0, len(setModels))
+	for _, setModel := range setModels {
+		response, err := getDeploymentSetResponse(sets, setModel)
+		if err != nil {
+			return nil, err
+		}
+
+		responses = append(responses, response)
+	}
+
+	return responses, nil
+}
+
+// GetDeploymentSet returns a deployment set of an organization along with the status of its releases.
+func (m *Manager) GetDeploymentSet(ctx context.Context, organizationID uint, name string) (*pkgHelm.DeploymentSetResponse, error) {
+	sets := intCluster.NewDeploymentSets(pipConfig.DB())
+
+	setModel, err := sets.FindOneByName(organizationID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return getDeploymentSetResponse(sets, setModel)
+}
+
+// CreateDeploymentSet creates a deployment set and starts rolling it out to the selected clusters.
+func (m *Manager) CreateDeploymentSet(
+	ctx context.Context,
+	organizationID uint,
+	userID uint,
+	request *pkgHelm.DeploymentSetRequest,
+) (*pkgHelm.DeploymentSetResponse, error) {
+	if err := request.Validate(); err != nil {
+		return nil, errors.Wrap(&invalidError{err}, "validation failed")
+	}
+
+	sets := intCluster.NewDeploymentSets(pipConfig.DB())
+
+	_, err := sets.FindOneByName(organizationID, request.Name)
+	if err == nil {
+		return nil, ErrDeploymentSetAlreadyExists
+	} else if !isNotFoundError(err) {
+		return nil, err
+	}
+
+	setModel := &model.DeploymentSetModel{
+		OrganizationID: organizationID,
+		Name:           request.Name,
+		CreatedBy:      userID,
+		Generation:     1,
+	}
+
+	if err := setDeploymentSetSpec(setModel, request); err != nil {
+		return nil, err
+	}
+
+	if err := sets.Save(setModel); err != nil {
+		return nil, err
+	}
+
+	m.startDeploymentSetRollout(ctx, setModel)
+
+	return getDeploymentSetResponse(sets, setModel)
+}
+
+// UpdateDeploymentSet replaces the spec of a deployment set and starts a rolling upgrade of its releases.
+func (m *Manager) UpdateDeploymentSet(
+	ctx context.Context,
+	organizationID uint,
+	request *pkgHelm.DeploymentSetRequest,
+) (*pkgHelm.DeploymentSetResponse, error) {
+	if err := request.Validate(); err != nil {
+		return nil, errors.Wrap(&invalidError{err}, "validation failed")
+	}
+
+	sets := intCluster.NewDeploymentSets(pipConfig.DB())
+
+	setModel, err := sets.FindOneByName(organizationID, request.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// the existing releases can not be moved
+	if request.ReleaseName == "" {
+		request.ReleaseName = setModel.ReleaseName
+	}
+
+	if request.Namespace == "" {
+		request.Namespace = setModel.Namespace
+	}
+
+	if request.ReleaseName != setModel.ReleaseName || request.Namespace != setModel.Namespace {
+		return nil, errors.Wrap(&invalidError{errors.New("release name and namespace can not be changed")}, "validation failed")
+	}
+
+	if err := setDeploymentSetSpec(setModel, request); err != nil {
+		return nil, err
+	}
+
+	setModel.Generation++
+
+	if err := sets.Save(setModel); err != nil {
+		return nil, err
+	}
+
+	m.startDeploymentSetRollout(ctx, setModel)
+
+	return getDeploymentSetResponse(sets, setModel)
+}
+
+// DeleteDeploymentSet deletes a deployment set, its releases are deleted from the clusters in the background.
+func (m *Manager) DeleteDeploymentSet(ctx context.Context, organizationID uint, name string) error {
+	logger := m.getLogger(ctx).WithFields(logrus.Fields{
+		"organization":  organizationID,
+		"deploymentSet": name,
+	})
+
+	sets := intCluster.NewDeploymentSets(pipConfig.DB())
+
+	setModel, err := sets.FindOneByName(organizationID, name)
+	if err != nil {
+		return err
+	}
+
+	stopDeploymentSetRollout(setModel.ID)
+
+	targets, err := sets.FindTargets(setModel.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := sets.Delete(setModel); err != nil {
+		return err
+	}
+
+	go func() {
+		for _, target := range targets {
+			logger := logger.WithField("cluster", target.ClusterName)
+
+			clusterModel, err := m.clusters.FindOneByID(organizationID, target.ClusterID)
+			if isNotFoundError(err) {
+				continue
+			} else if err != nil {
+				logger.Errorf("getting cluster failed: %s", err.Error())
+				continue
+			}
+
+			err = m.deleteDeploymentSetRelease(setModel, clusterModel)
+			if err == errDeploymentSetReleaseNotOwned {
+				logger.Warn("release of deployment set was replaced, it is left in the cluster")
+			} else if err != nil {
+				logger.Errorf("deleting deployment set release failed: %s", err.Error())
+			}
+		}
+	}()
+
+	return nil
+}
+
+// ResumeDeploymentSets rolls out every deployment set, releases not deployed before a restart are deployed again.
+func (m *Manager) ResumeDeploymentSets(ctx context.Context) error {
+	setModels, err := intCluster.NewDeploymentSets(pipConfig.DB()).All()
+	if err != nil {
+		return err
+	}
+
+	for _, setModel := range setModels {
+		m.startDeploymentSetRollout(ctx, setModel)
+	}
+
+	return nil
+}
+
+// rolloutDeploymentSets rolls out the deployment sets selecting a cluster or having a release in it after the cluster changed,
+// releases already deployed with the current spec of their set are left intact
+func (m *Manager) rolloutDeploymentSets(ctx context.Context, cluster CommonCluster) error {
+	sets := intCluster.NewDeploymentSets(pipConfig.DB())
+
+	setModels, err := sets.FindByOrganization(cluster.GetOrganizationId())
+	if err != nil {
+		return err
+	}
+
+	labels, err := intCluster.NewLabels(pipConfig.DB()).FindByCluster(cluster.GetOrganizationId(), cluster.GetID())
+	if err != nil {
+		return err
+	}
+
+	for _, setModel := range setModels {
+		affected, err := isDeploymentSetAffected(sets, setModel, cluster.GetID(), labels)
+		if err != nil {
+			return err
+		}
+
+		if affected {
+			m.requestDeploymentSetRollout(ctx, setModel)
+		}
+	}
+
+	return nil
+}
+
+// isDeploymentSetAffected returns true if a deployment set selects the cluster or has a release in it
+func isDeploymentSetAffected(sets *intCluster.DeploymentSets, setModel *model.DeploymentSetModel, clusterID uint, labels map[string]string) (bool, error) {
+	spec, err := getDeploymentSetSpec(setModel)
+	if err != nil {
+		return false, err
+	}
+
+	if spec.Selector.Matches(clusterID, labels) {
+		return true, nil
+	}
+
+	targets, err := sets.FindTargets(setModel.ID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, target := range targets {
+		if target.ClusterID == clusterID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// requestDeploymentSetRollout rolls out a deployment set in the background, a running rollout of the set is not
+// interrupted, the set is rolled out again once it finishes
+func (m *Manager) requestDeploymentSetRollout(ctx context.Context, setModel *model.DeploymentSetModel) {
+	deploymentSetRollouts.Lock()
+	if rollout, ok := deploymentSetRollouts.rollouts[setModel.ID]; ok {
+		rollout.rerun = true
+		deploymentSetRollouts.Unlock()
+
+		return
+	}
+	deploymentSetRollouts.Unlock()
+
+	m.startDeploymentSetRollout(ctx, setModel)
+}
+
+// startDeploymentSetRollout rolls out a deployment set in the background, replacing its running rollout
+func (m *Manager) startDeploymentSetRollout(ctx context.Context, setModel *model.DeploymentSetModel) {
+	rolloutCtx, cancel := context.WithCancel(ctx)
+	rollout := &deploymentSetRollout{cancel: cancel, done: make(chan struct{})}
+
+	deploymentSetRollouts.Lock()
+	previous := deploymentSetRollouts.rollouts[setModel.ID]
+	deploymentSetRollouts.rollouts[setModel.ID] = rollout
+	deploymentSetRollouts.Unlock()
+
+	logger := m.getLogger(ctx).WithFields(logrus.Fields{
+		"organization":  setModel.OrganizationID,
+		"deploymentSet": setModel.Name,
+	})
+
+	go func() {
+		defer close(rollout.done)
+		defer cancel()
+
+		if previous != nil {
+			previous.cancel()
+			<-previous.done
+		}
+
+		if rolloutCtx.Err() == nil {
+			if err := m.rolloutDeploymentSet(rolloutCtx, setModel.OrganizationID, setModel.Name, logger); err != nil {
+				m.getErrorHandler(ctx).Handle(errors.WithMessage(err, "deployment set rollout failed"))
+			}
+		}
+
+		deploymentSetRollouts.Lock()
+		current := deploymentSetRollouts.rollouts[setModel.ID] == rollout
+		rerun := current && rollout.rerun && rolloutCtx.Err() == nil
+		if current && !rerun {
+			delete(deploymentSetRollouts.rollouts, setModel.ID)
+		}
+		deploymentSetRollouts.Unlock()
+
+		// the next rollout waits for this one to finish
+		if rerun {
+			m.startDeploymentSetRollout(ctx, setModel)
+		}
+	}()
+}
+
+// stopDeploymentSetRollout cancels the running rollout of a deployment set and waits for it to finish
+func stopDeploymentSetRollout(setID uint) {
+	deploymentSetRollouts.Lock()
+	rollout := deploymentSetRollouts.rollouts[setID]
+	delete(deploymentSetRollouts.rollouts, setID)
+	deploymentSetRollouts.Unlock()
+
+	if rollout != nil {
+		rollout.cancel()
+		<-rollout.done
+	}
+}
+
+// rolloutDeploymentSet installs or upgrades the release of a deployment set in the running clusters selected by it,
+// at most concurrency clusters at once, and deletes it from the clusters not selected any more
+func (m *Manager) rolloutDeploymentSet(ctx context.Context, organizationID uint, name string, logger logrus.FieldLogger) error {
+	sets := intCluster.NewDeploymentSets(pipConfig.DB())
+
+	// the deployment set is loaded again, it might have changed while the previous rollout was finishing
+	setModel, err := sets.FindOneByName(organizationID, name)
+	if isNotFoundError(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	logger = logger.WithField("generation", setModel.Generation)
+
+	spec, err := getDeploymentSetSpec(setModel)
+	if err != nil {
+		return err
+	}
+
+	clusterModels, err := m.clusters.FindByOrganization(setModel.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	labels, err := intCluster.NewLabels(pipConfig.DB()).FindByOrganization(setModel.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	targets, err := sets.FindTargets(setModel.ID)
+	if err != nil {
+		return err
+	}
+
+	targetsByCluster := make(map[uint]*model.DeploymentSetTargetModel, len(targets))
+	for _, target := range targets {
+		targetsByCluster[target.ClusterID] = target
+	}
+
+	var tasks []deploymentSetTask
+	clustersByID := make(map[uint]*model.ClusterModel, len(clusterModels))
+
+	for _, clusterModel := range clusterModels {
+		clustersByID[clusterModel.ID] = clusterModel
+
+		if !spec.Selector.Matches(clusterModel.ID, labels[clusterModel.ID]) {
+			continue
+		}
+
+		target, ok := targetsByCluster[clusterModel.ID]
+		delete(targetsByCluster, clusterModel.ID)
+
+		// clusters being created get the release once they are running
+		if clusterModel.Status != pkgCluster.Running {
+			continue
+		}
+
+		if ok && target.Generation == setModel.Generation && target.Status == pkgHelm.DeploymentSetTargetDeployed {
+			continue
+		}
+
+		if !ok {
+			target = &model.DeploymentSetTargetModel{
+				DeploymentSetID: setModel.ID,
+				ClusterID:       clusterModel.ID,
+			}
+		}
+
+		target.ClusterName = clusterModel.Name
+		target.Status = pkgHelm.DeploymentSetTargetPending
+		target.StatusMessage = ""
+		target.Generation = setModel.Generation
+
+		if err := sets.SaveTarget(target); err != nil {
+			return err
+		}
+
+		tasks = append(tasks, deploymentSetTask{target: target, cluster: clusterModel})
+	}
+
+	// the remaining targets are in clusters not selected any more or deleted
+	for _, target := range targetsByCluster {
+		if clusterModel, ok := clustersByID[target.ClusterID]; ok {
+			logger := logger.WithField("cluster", clusterModel.Name)
+			logger.Info("deleting release from cluster not selected by deployment set")
+
+			err := m.deleteDeploymentSetRelease(setModel, clusterModel)
+			if err == errDeploymentSetReleaseNotOwned {
+				logger.Warn("release of deployment set was replaced, it is left in the cluster")
+			} else if err != nil {
+				logger.Errorf("deleting deployment set release failed: %s", err.Error())
+
+				target.Status = pkgHelm.DeploymentSetTargetFailed
+				target.StatusMessage = err.Error()
+				if err := sets.SaveTarget(target); err != nil {
+					return err
+				}
+
+				continue
+			}
+		}
+
+		if err := sets.DeleteTarget(target); err != nil {
+			return err
+		}
+	}
+
+	concurrency := setModel.Concurrency
+	if concurrency <= 0 {
+		concurrency = pkgHelm.DefaultDeploymentSetConcurrency
+	}
+
+	logger.WithField("clusters", len(tasks)).Info("rolling out deployment set")
+
+	started := runDeploymentSetTasks(ctx, tasks, concurrency, func(task deploymentSetTask) bool {
+		return m.deployDeploymentSetTarget(setModel, task, logger.WithField("cluster", task.cluster.Name))
+	})
+
+	if ctx.Err() != nil {
+		logger.Info("deployment set rollout cancelled")
+	} else if started < len(tasks) {
+		logger.WithField("clusters", len(tasks)-started).Warn("deployment set rollout halted after a failed release")
+
+		for _, task := range tasks[started:] {
+			task.target.StatusMessage = "rollout halted after a failed release"
+			if err := sets.SaveTarget(task.target); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// runDeploymentSetTasks deploys the tasks in order, at most concurrency at once, until the context is cancelled
+// or a deployment fails, so that a broken chart or values are not rolled out to every cluster.
+// The number of tasks started is returned.
+func runDeploymentSetTasks(ctx context.Context, tasks []deploymentSetTask, concurrency int, deploy func(task deploymentSetTask) bool) int {
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var failed int32
+
+	started := 0
+	for _, task := range tasks {
+		select {
+		case <-ctx.Done():
+		case semaphore <- struct{}{}:
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		if atomic.LoadInt32(&failed) != 0 {
+			break
+		}
+
+		started++
+
+		wg.Add(1)
+		go func(task deploymentSetTask) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			if !deploy(task) {
+				atomic.StoreInt32(&failed, 1)
+			}
+		}(task)
+	}
+
+	wg.Wait()
+
+	return started
+}
+
+// deployDeploymentSetTarget deploys the release of a deployment set into a cluster and records the outcome,
+// false is returned if the release failed
+func (m *Manager) deployDeploymentSetTarget(setModel *model.DeploymentSetModel, task deploymentSetTask, logger logrus.FieldLogger) bool {
+	sets := intCluster.NewDeploymentSets(pipConfig.DB())
+
+	task.target.Status = pkgHelm.DeploymentSetTargetDeploying
+	if err := sets.SaveTarget(task.target); err != nil {
+		logger.Errorf("saving deployment set target failed: %s", err.Error())
+	}
+
+	logger.Info("deploying deployment set release")
+
+	task.target.Status = pkgHelm.DeploymentSetTargetDeployed
+	task.target.StatusMessage = ""
+
+	if err := m.deployDeploymentSetRelease(setModel, task.cluster); err != nil {
+		logger.Errorf("deploying deployment set release failed: %s", err.Error())
+
+		task.target.Status = pkgHelm.DeploymentSetTargetFailed
+		task.target.StatusMessage = err.Error()
+	}
+
+	if err := sets.SaveTarget(task.target); err != nil {
+		logger.Errorf("saving deployment set target failed: %s", err.Error())
+	}
+
+	return task.target.Status == pkgHelm.DeploymentSetTargetDeployed
+}
+
+// deployDeploymentSetRelease installs the release of a deployment set into a cluster or upgrades the existing one,
+// the values of the set are merged with the values of the cluster. A release of the same name deployed otherwise
+// is not touched.
+func (m *Manager) deployDeploymentSetRelease(setModel *model.DeploymentSetModel, clusterModel *model.ClusterModel) error {
+	// the spec is decoded for every cluster, merging values modifies them
+	spec, err := getDeploymentSetSpec(setModel)
+	if err != nil {
+		return err
+	}
+
+	values := spec.Values
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+
+	values = helm.MergeValues(values, spec.ClusterValues[clusterModel.Name])
+	values[deploymentSetOwnerValue] = deploymentSetOwner(setModel)
+
+	valuesYAML, err := yaml.Marshal(values)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal values")
+	}
+
+	cluster, err := m.getClusterFromModel(clusterModel)
+	if err != nil {
+		return err
+	}
+
+	kubeConfig, err := cluster.GetK8sConfig()
+	if err != nil {
+		return errors.Wrap(err, "could not get kubeconfig")
+	}
+
+	org, err := auth.GetOrganizationById(cluster.GetOrganizationId())
+	if err != nil {
+		return errors.Wrap(err, "could not get organization")
+	}
+
+	env := helm.GenerateHelmRepoEnv(org.Name, org.ID)
+
+	deployment, err := helm.GetDeployment(setModel.ReleaseName, kubeConfig)
+	if _, ok := err.(*helm.DeploymentNotFoundError); ok {
+		_, err = helm.CreateDeployment(spec.Chart, spec.Version, nil, setModel.Namespace, setModel.ReleaseName, false, valuesYAML, kubeConfig, env)
+
+		return err
+	} else if err != nil {
+		return errors.Wrap(err, "could not get deployment")
+	}
+
+	if !isDeploymentSetRelease(setModel, deployment) {
+		return errors.Wrapf(errDeploymentSetReleaseNotOwned, "release %s already exists", setModel.ReleaseName)
+	}
+
+	_, err = helm.UpgradeDeployment(setModel.ReleaseName, spec.Chart, spec.Version, nil, valuesYAML, false, false, kubeConfig, env)
+
+	return err
+}
+
+// deleteDeploymentSetRelease deletes the release of a deployment set from a cluster
+func (m *Manager) deleteDeploymentSetRelease(setModel *model.DeploymentSetModel, clusterModel *model.ClusterModel) error {
+	cluster, err := m.getClusterFromModel(clusterModel)
+	if err != nil {
+		return err
+	}
+
+	kubeConfig, err := cluster.GetK8sConfig()
+	if err != nil {
+		return errors.Wrap(err, "could not get kubeconfig")
+	}
+
+	deployment, err := helm.GetDeployment(setModel.ReleaseName, kubeConfig)
+	if _, ok := err.(*helm.DeploymentNotFoundError); ok {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "could not get deployment")
+	}
+
+	if !isDeploymentSetRelease(setModel, deployment) {
+		return errDeploymentSetReleaseNotOwned
+	}
+
+	err = helm.DeleteDeployment(setModel.ReleaseName, kubeConfig)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return err
+	}
+
+	return nil
+}
+
+// deploymentSetOwner returns the owner value of the releases of a deployment set
+func deploymentSetOwner(setModel *model.DeploymentSetModel) string {
+	return strconv.FormatUint(uint64(setModel.ID), 10)
+}
+
+// isDeploymentSetRelease returns true if a release was deployed by the deployment set
+func isDeploymentSetRelease(setModel *model.DeploymentSetModel, deployment *pkgHelm.GetDeploymentResponse) bool {
+	owner, ok := deployment.Values[deploymentSetOwnerValue].(string)
+
+	return ok && owner == deploymentSetOwner(setModel) && deployment.Namespace == setModel.Namespace
+}
+
+// setDeploymentSetSpec stores the spec of a deployment set request in the deployment set model
+func setDeploymentSetSpec(setModel *model.DeploymentSetModel, request *pkgHelm.DeploymentSetRequest) error {
+	values, err := json.Marshal(request.Values)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal values")
+	}
+
+	clusterValues, err := json.Marshal(request.ClusterValues)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal cluster values")
+	}
+
+	selector, err := json.Marshal(request.Selector)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal selector")
+	}
+
+	setModel.ReleaseName = request.ReleaseName
+	if setModel.ReleaseName == "" {
+		setModel.ReleaseName = request.Name
+	}
+
+	setModel.Namespace = request.Namespace
+	if setModel.Namespace == "" {
+		setModel.Namespace = helm.DefaultNamespace
+	}
+
+	setModel.Chart = request.Chart
+	setModel.ChartVersion = request.Version
+	setModel.Values = string(values)
+	setModel.ClusterValues = string(clusterValues)
+	setModel.Selector = string(selector)
+	setModel.Concurrency = request.Concurrency
+
+	return nil
+}
+
+// getDeploymentSetSpec returns the spec of a deployment set stored in the deployment set model
+func getDeploymentSetSpec(setModel *model.DeploymentSetModel) (*pkgHelm.DeploymentSetRequest, error) {
+	spec := &pkgHelm.DeploymentSetRequest{
+		Name:        setModel.Name,
+		ReleaseName: setModel.ReleaseName,
+		Chart:       setModel.Chart,
+		Version:     setModel.ChartVersion,
+		Namespace:   setModel.Namespace,
+		Concurrency: setModel.Concurrency,
+	}
+
+	if err := json.Unmarshal([]byte(setModel.Values), &spec.Values); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal values")
+	}
+
+	if err := json.Unmarshal([]byte(setModel.ClusterValues), &spec.ClusterValues); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal cluster values")
+	}
+
+	if err := json.Unmarshal([]byte(setModel.Selector), &spec.Selector); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal selector")
+	}
+
+	return spec, nil
+}
+
+// getDeploymentSetResponse returns a deployment set along with the status of its releases
+func getDeploymentSetResponse(sets *intCluster.DeploymentSets, setModel *model.DeploymentSetModel) (*pkgHelm.DeploymentSetResponse, error) {
+	spec, err := getDeploymentSetSpec(setModel)
+	if err != nil {
+		return nil, err
+	}
+
+	targets, err := sets.FindTargets(setModel.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := make([]pkgHelm.DeploymentSetClusterStatus, 0, len(targets))
+	for _, target := range targets {
+		clusters = append(clusters, pkgHelm.DeploymentSetClusterStatus{
+			ClusterID:     target.ClusterID,
+			ClusterName:   target.ClusterName,
+			Status:        target.Status,
+			StatusMessage: target.StatusMessage,
+			Generation:    target.Generation,
+			UpdatedAt:     target.UpdatedAt,
+		})
+	}
+
+	return &pkgHelm.DeploymentSetResponse{
+		ID:            setModel.ID,
+		Name:          setModel.Name,
+		ReleaseName:   setModel.ReleaseName,
+		Chart:         setModel.Chart,
+		Version:       setModel.ChartVersion,
+		Namespace:     setModel.Namespace,
+		Values:        spec.Values,
+		ClusterValues: spec.ClusterValues,
+		Selector:      spec.Selector,
+		Concurrency:   setModel.Concurrency,
+		Generation:    setModel.Generation,
+		CreatedAt:     setModel.CreatedAt,
+		UpdatedAt:     setModel.UpdatedAt,
+		Clusters:      clusters,
+	}, nil
+}
@@ -0,0 +1,98 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/banzaicloud/pipeline/model"
+	pkgHelm "github.com/banzaicloud/pipeline/pkg/helm"
+)
+
+func TestIsDeploymentSetRelease(t *testing.T) {
+	setModel := &model.DeploymentSetModel{ID: 12, ReleaseName: "monitoring", Namespace: "default"}
+
+	cases := []struct {
+		name       string
+		deployment *pkgHelm.GetDeploymentResponse
+		owned      bool
+	}{
+		{
+			name: "release of the deployment set",
+			deployment: &pkgHelm.GetDeploymentResponse{
+				Namespace: "default",
+				Values:    map[string]interface{}{deploymentSetOwnerValue: "12"},
+			},
+			owned: true,
+		},
+		{
+			name: "release deployed by hand",
+			deployment: &pkgHelm.GetDeploymentResponse{
+				Namespace: "default",
+				Values:    map[string]interface{}{"replicas": 2},
+			},
+			owned: false,
+		},
+		{
+			name: "release of another deployment set",
+			deployment: &pkgHelm.GetDeploymentResponse{
+				Namespace: "default",
+				Values:    map[string]interface{}{deploymentSetOwnerValue: "13"},
+			},
+			owned: false,
+		},
+		{
+			name: "release in another namespace",
+			deployment: &pkgHelm.GetDeploymentResponse{
+				Namespace: "kube-system",
+				Values:    map[string]interface{}{deploymentSetOwnerValue: "12"},
+			},
+			owned: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if owned := isDeploymentSetRelease(setModel, tc.deployment); owned != tc.owned {
+				t.Errorf("Expected owned %t, got: %t", tc.owned, owned)
+			}
+		})
+	}
+}
+
+func TestRunDeploymentSetTasksHaltsOnFailure(t *testing.T) {
+	tasks := make([]deploymentSetTask, 4)
+	for i := range tasks {
+		tasks[i] = deploymentSetTask{cluster: &model.ClusterModel{ID: uint(i + 1)}}
+	}
+
+	var deployed []uint
+
+	started := runDeploymentSetTasks(context.Background(), tasks, 1, func(task deploymentSetTask) bool {
+		deployed = append(deployed, task.cluster.ID)
+
+		return task.cluster.ID != 2
+	})
+
+	if started != 2 {
+		t.Errorf("Expected the rollout to halt after the failed release, got %d started", started)
+	}
+
+	if len(deployed) != 2 || deployed[0] != 1 || deployed[1] != 2 {
+		t.Errorf("Expected clusters 1 and 2 to be deployed, got: %v", deployed)
+	}
+}
+
+func TestRunDeploymentSetTasks(t *testing.T) {
+	tasks := make([]deploymentSetTask, 4)
+	for i := range tasks {
+		tasks[i] = deploymentSetTask{cluster: &model.ClusterModel{ID: uint(i + 1)}}
+	}
+
+	started := runDeploymentSetTasks(context.Background(), tasks, 2, func(task deploymentSetTask) bool {
+		return true
+	})
+
+	if started != len(tasks) {
+		t.Errorf("Expected every release to be deployed, got %d started", started)
+	}
+}
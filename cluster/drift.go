@@ -0,0 +1,245 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/banzaicloud/pipeline/helm"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	"github.com/banzaicloud/pipeline/pkg/cluster/aks"
+	"github.com/banzaicloud/pipeline/pkg/cluster/ec2"
+	"github.com/banzaicloud/pipeline/pkg/cluster/eks"
+	"github.com/banzaicloud/pipeline/pkg/cluster/gke"
+	pkgCommon "github.com/banzaicloud/pipeline/pkg/common"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// nodePoolLabelKeys are the node labels holding the node pool name, in order of precedence
+var nodePoolLabelKeys = []string{
+	pkgCommon.LabelKey,
+	"cloud.google.com/gke-nodepool",
+}
+
+// DetectDrift compares the node pools stored for a cluster with the nodes running in the cloud.
+func DetectDrift(cluster CommonCluster) ([]pkgCluster.NodePoolDrift, error) {
+	status, err := cluster.GetStatus()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get cluster status")
+	}
+
+	// there is nothing to compare to for clusters without node pool spec
+	if len(status.NodePools) == 0 {
+		return nil, nil
+	}
+
+	liveNodes, err := listLiveNodes(cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list cluster nodes")
+	}
+
+	drifts := make([]pkgCluster.NodePoolDrift, 0)
+
+	for _, name := range sortedNodePoolNames(status.NodePools) {
+		nodePool := status.NodePools[name]
+		actual := len(liveNodes[name])
+
+		desired, desiredMin, desiredMax := fmt.Sprint(nodePool.Count), nodePool.Count, nodePool.Count
+		if nodePool.Autoscaling {
+			desired, desiredMin, desiredMax = fmt.Sprintf("%d-%d", nodePool.MinCount, nodePool.MaxCount), nodePool.MinCount, nodePool.MaxCount
+		}
+
+		drift := pkgCluster.NodePoolDrift{
+			NodePool: name,
+			Desired:  desired,
+			Actual:   fmt.Sprint(actual),
+		}
+
+		if actual == 0 && desiredMin > 0 {
+			drift.Type = pkgCluster.DriftMissingNodePool
+		} else if actual < desiredMin || actual > desiredMax {
+			drift.Type = pkgCluster.DriftNodeCount
+		} else {
+			continue
+		}
+
+		drifts = append(drifts, drift)
+	}
+
+	liveNodePoolNames := make([]string, 0, len(liveNodes))
+	for name := range liveNodes {
+		liveNodePoolNames = append(liveNodePoolNames, name)
+	}
+	sort.Strings(liveNodePoolNames)
+
+	for _, name := range liveNodePoolNames {
+		if _, ok := status.NodePools[name]; ok {
+			continue
+		}
+
+		drifts = append(drifts, pkgCluster.NodePoolDrift{
+			NodePool: name,
+			Type:     pkgCluster.DriftUnknownNodePool,
+			Desired:  "0",
+			Actual:   fmt.Sprint(len(liveNodes[name])),
+		})
+	}
+
+	return drifts, nil
+}
+
+// listLiveNodes returns the names of the nodes running in the cloud grouped by node pool
+func listLiveNodes(cluster CommonCluster) (pkgCommon.NodeNames, error) {
+	nodeNames, err := cluster.ListNodeNames()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nodeNames) != 0 {
+		return nodeNames, nil
+	}
+
+	// providers which label the nodes on creation don't list them, so they are looked up in Kubernetes
+	kubeConfig, err := cluster.GetK8sConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := helm.GetK8sConnection(kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := client.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	nodeNames = make(pkgCommon.NodeNames)
+	for _, node := range nodes.Items {
+		for _, key := range nodePoolLabelKeys {
+			if name, ok := node.Labels[key]; ok {
+				nodeNames[name] = append(nodeNames[name], node.Name)
+				break
+			}
+		}
+	}
+
+	return nodeNames, nil
+}
+
+// ReapplyClusterSpec updates the cluster in the cloud to match the node pools stored for it through the update flow
+// of the cluster manager, the update runs in the background.
+func ReapplyClusterSpec(ctx context.Context, manager *Manager, cluster CommonCluster, userID uint) error {
+	status, err := cluster.GetStatus()
+	if err != nil {
+		return errors.Wrap(err, "could not get cluster status")
+	}
+
+	request, err := createUpdateRequestFromStatus(cluster, status)
+	if err != nil {
+		return err
+	}
+
+	updateCtx := UpdateContext{
+		OrganizationID: cluster.GetOrganizationId(),
+		UserID:         userID,
+		ClusterID:      cluster.GetID(),
+	}
+
+	updater := &specReapplier{commonUpdater: NewCommonClusterUpdater(request, cluster, userID)}
+
+	return errors.Wrap(manager.UpdateCluster(ctx, updateCtx, updater), "could not re-apply cluster spec")
+}
+
+// specReapplier updates a cluster with its stored spec, it differs from the common updater in not requiring
+// the request to change the stored spec, as only the cluster in the cloud differs from it
+type specReapplier struct {
+	*commonUpdater
+}
+
+// Prepare implements the clusterUpdater interface.
+func (c *specReapplier) Prepare(ctx context.Context) (CommonCluster, error) {
+	c.cluster.AddDefaultsToUpdate(c.request)
+
+	if err := c.request.Validate(); err != nil {
+		return nil, &commonUpdateValidationError{
+			msg:            err.Error(),
+			invalidRequest: true,
+		}
+	}
+
+	return c.cluster, c.cluster.Persist(pkgCluster.Updating, pkgCluster.UpdatingMessage)
+}
+
+// createUpdateRequestFromStatus creates an update request describing the stored node pools of a cluster
+func createUpdateRequestFromStatus(cluster CommonCluster, status *pkgCluster.GetClusterStatusResponse) (*pkgCluster.UpdateClusterRequest, error) {
+	request := &pkgCluster.UpdateClusterRequest{
+		Cloud: cluster.GetCloud(),
+	}
+
+	switch cluster.GetDistribution() {
+	case pkgCluster.EC2, pkgCluster.EKS:
+		nodePools := make(map[string]*ec2.NodePool, len(status.NodePools))
+		for name, np := range status.NodePools {
+			nodePools[name] = &ec2.NodePool{
+				InstanceType: np.InstanceType,
+				SpotPrice:    np.SpotPrice,
+				Autoscaling:  np.Autoscaling,
+				MinCount:     np.MinCount,
+				MaxCount:     np.MaxCount,
+				Count:        np.Count,
+				Image:        np.Image,
+			}
+		}
+
+		if cluster.GetDistribution() == pkgCluster.EKS {
+			request.EKS = &eks.UpdateClusterAmazonEKS{NodePools: nodePools}
+		} else {
+			request.EC2 = &ec2.UpdateClusterAmazon{NodePools: nodePools}
+		}
+
+	case pkgCluster.AKS:
+		nodePools := make(map[string]*aks.NodePoolUpdate, len(status.NodePools))
+		for name, np := range status.NodePools {
+			nodePools[name] = &aks.NodePoolUpdate{
+				Autoscaling: np.Autoscaling,
+				MinCount:    np.MinCount,
+				MaxCount:    np.MaxCount,
+				Count:       np.Count,
+			}
+		}
+
+		request.AKS = &aks.UpdateClusterAzure{NodePools: nodePools}
+
+	case pkgCluster.GKE:
+		nodePools := make(map[string]*gke.NodePool, len(status.NodePools))
+		for name, np := range status.NodePools {
+			nodePools[name] = &gke.NodePool{
+				Autoscaling:      np.Autoscaling,
+				MinCount:         np.MinCount,
+				MaxCount:         np.MaxCount,
+				Count:            np.Count,
+				NodeInstanceType: np.InstanceType,
+			}
+		}
+
+		request.GKE = &gke.UpdateClusterGoogle{NodePools: nodePools}
+
+	default:
+		return nil, errors.Errorf("re-applying the spec of %s clusters is not supported", cluster.GetDistribution())
+	}
+
+	return request, nil
+}
+
+func sortedNodePoolNames(nodePools map[string]*pkgCluster.NodePoolStatus) []string {
+	names := make([]string, 0, len(nodePools))
+	for name := range nodePools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,127 @@
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/banzaicloud/pipeline/model"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	"github.com/goph/emperror"
+	"github.com/sirupsen/logrus"
+)
+
+type driftEventRepository interface {
+	Save(event *model.ClusterDriftEventModel) error
+}
+
+// DriftReconciler periodically compares the stored node pools of the running clusters with their state in the cloud.
+type DriftReconciler struct {
+	clusters clusterRepository
+	events   driftEventRepository
+	manager  *Manager
+	interval time.Duration
+	reapply  bool
+
+	logger       logrus.FieldLogger
+	errorHandler emperror.Handler
+}
+
+// NewDriftReconciler returns a new DriftReconciler instance.
+func NewDriftReconciler(
+	clusters clusterRepository,
+	events driftEventRepository,
+	manager *Manager,
+	interval time.Duration,
+	reapply bool,
+	logger logrus.FieldLogger,
+	errorHandler emperror.Handler,
+) *DriftReconciler {
+	return &DriftReconciler{
+		clusters:     clusters,
+		events:       events,
+		manager:      manager,
+		interval:     interval,
+		reapply:      reapply,
+		logger:       logger,
+		errorHandler: errorHandler,
+	}
+}
+
+// Start starts reconciling the clusters in the background.
+func (r *DriftReconciler) Start() {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			r.Reconcile()
+		}
+	}()
+}
+
+// Reconcile checks every running cluster for drift once.
+func (r *DriftReconciler) Reconcile() {
+	clusters, err := r.clusters.All()
+	if err != nil {
+		r.errorHandler.Handle(emperror.Wrap(err, "could not list clusters for drift detection"))
+		return
+	}
+
+	for _, clusterModel := range clusters {
+		// clusters under creation, update or deletion are expected to differ from their spec
+		if clusterModel.Status != pkgCluster.Running {
+			continue
+		}
+
+		if err := r.reconcileCluster(clusterModel); err != nil {
+			r.errorHandler.Handle(emperror.With(err, "organization", clusterModel.OrganizationId, "cluster", clusterModel.ID))
+		}
+	}
+}
+
+func (r *DriftReconciler) reconcileCluster(clusterModel *model.ClusterModel) error {
+	logger := r.logger.WithFields(logrus.Fields{
+		"organization": clusterModel.OrganizationId,
+		"cluster":      clusterModel.ID,
+	})
+
+	cluster, err := GetCommonClusterFromModel(clusterModel)
+	if err != nil {
+		return err
+	}
+
+	drifts, err := DetectDrift(cluster)
+	if err != nil {
+		return emperror.Wrap(err, "drift detection failed")
+	}
+
+	if len(drifts) == 0 {
+		return nil
+	}
+
+	logger.WithField("differences", len(drifts)).Warn("cluster drifted from its spec")
+
+	var reapplyErr error
+	if r.reapply {
+		logger.Info("re-applying cluster spec")
+
+		reapplyErr = ReapplyClusterSpec(context.Background(), r.manager, cluster, clusterModel.CreatedBy)
+	}
+
+	for _, drift := range drifts {
+		event := &model.ClusterDriftEventModel{
+			ClusterID: clusterModel.ID,
+			NodePool:  drift.NodePool,
+			Type:      drift.Type,
+			Desired:   drift.Desired,
+			Actual:    drift.Actual,
+			Reapplied: r.reapply && reapplyErr == nil,
+		}
+
+		if err := r.events.Save(event); err != nil {
+			r.errorHandler.Handle(err)
+		}
+	}
+
+	return reapplyErr
+}
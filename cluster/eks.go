@@ -23,6 +23,7 @@ import (
 	"github.com/banzaicloud/pipeline/pkg/cluster/eks/action"
 	pkgCommon "github.com/banzaicloud/pipeline/pkg/common"
 	pkgErrors "github.com/banzaicloud/pipeline/pkg/errors"
+	pkgSecret "github.com/banzaicloud/pipeline/pkg/secret"
 	"github.com/banzaicloud/pipeline/secret"
 	"github.com/banzaicloud/pipeline/secret/verify"
 	"github.com/banzaicloud/pipeline/utils"
@@ -72,6 +73,26 @@ func CreateEKSClusterFromRequest(request *pkgCluster.CreateClusterRequest, orgId
 	return &cluster, nil
 }
 
+//CreateEKSClusterFromImportRequest creates ClusterModel struct from the import request
+func CreateEKSClusterFromImportRequest(request *pkgCluster.ImportClusterRequest, orgId uint, userId uint) (*EKSCluster, error) {
+	log.Debug("Create ClusterModel struct from the import request")
+	cluster := EKSCluster{
+		log: log.WithField("cluster", request.Name),
+	}
+
+	cluster.modelCluster = &model.ClusterModel{
+		Name:           request.Name,
+		Location:       request.Location,
+		Cloud:          request.Cloud,
+		OrganizationId: orgId,
+		SecretId:       request.SecretId,
+		Distribution:   pkgCluster.EKS,
+		Imported:       true,
+		CreatedBy:      userId,
+	}
+	return &cluster, nil
+}
+
 //EKSCluster struct for EKS cluster
 type EKSCluster struct {
 	modelCluster             *model.ClusterModel
@@ -131,6 +152,115 @@ func (c *EKSCluster) createAWSCredentialsFromSecret() (*credentials.Credentials,
 	return verify.CreateAWSCredentials(clusterSecret.Values), nil
 }
 
+// ImportCluster reads the definition of an existing EKS cluster and its worker node groups into the cluster model
+func (c *EKSCluster) ImportCluster() error {
+	c.log.Info("Start importing EKS cluster")
+
+	awsCred, err := c.createAWSCredentialsFromSecret()
+	if err != nil {
+		return err
+	}
+
+	session, err := session.NewSession(&aws.Config{
+		Region:      aws.String(c.modelCluster.Location),
+		Credentials: awsCred,
+	})
+	if err != nil {
+		return err
+	}
+
+	describeClusterOutput, err := eks.New(session).DescribeCluster(&eks.DescribeClusterInput{
+		Name: aws.String(c.modelCluster.Name),
+	})
+	if err != nil {
+		return err
+	}
+
+	eksCluster := describeClusterOutput.Cluster
+	if status := aws.StringValue(eksCluster.Status); status != eks.ClusterStatusActive {
+		return &preconditionFailedError{fmt.Errorf("EKS cluster is not active, its status is %s", status)}
+	}
+
+	c.modelCluster.EKS.Version = aws.StringValue(eksCluster.Version)
+	c.APIEndpoint = aws.StringValue(eksCluster.Endpoint)
+	if eksCluster.CertificateAuthority != nil {
+		c.CertificateAuthorityData, err = base64.StdEncoding.DecodeString(aws.StringValue(eksCluster.CertificateAuthority.Data))
+		if err != nil {
+			return err
+		}
+	}
+
+	nodePools, err := c.getImportedNodePools(autoscaling.New(session))
+	if err != nil {
+		return err
+	}
+
+	if len(nodePools) == 0 {
+		return &preconditionFailedError{pkgErrors.ErrorNodePoolNotProvided}
+	}
+
+	c.modelCluster.EKS.NodePools = nodePools
+
+	return nil
+}
+
+// getImportedNodePools reads the auto scaling groups owned by the cluster as node pools
+func (c *EKSCluster) getImportedNodePools(autoscalingSrv *autoscaling.AutoScaling) ([]*model.AmazonNodePoolsModel, error) {
+	ownerTagKey := "kubernetes.io/cluster/" + c.modelCluster.Name
+
+	var groups []*autoscaling.Group
+	err := autoscalingSrv.DescribeAutoScalingGroupsPages(&autoscaling.DescribeAutoScalingGroupsInput{},
+		func(page *autoscaling.DescribeAutoScalingGroupsOutput, lastPage bool) bool {
+			for _, group := range page.AutoScalingGroups {
+				for _, tag := range group.Tags {
+					if aws.StringValue(tag.Key) == ownerTagKey && aws.StringValue(tag.Value) == "owned" {
+						groups = append(groups, group)
+						break
+					}
+				}
+			}
+			return true
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	nodePools := make([]*model.AmazonNodePoolsModel, 0, len(groups))
+	for _, group := range groups {
+		minSize := int(aws.Int64Value(group.MinSize))
+		maxSize := int(aws.Int64Value(group.MaxSize))
+
+		nodePool := &model.AmazonNodePoolsModel{
+			CreatedBy:    c.modelCluster.CreatedBy,
+			Name:         aws.StringValue(group.AutoScalingGroupName),
+			Autoscaling:  minSize != maxSize,
+			NodeMinCount: minSize,
+			NodeMaxCount: maxSize,
+			Count:        int(aws.Int64Value(group.DesiredCapacity)),
+		}
+
+		if group.LaunchConfigurationName != nil {
+			describeLaunchConfigurationsOutput, err := autoscalingSrv.DescribeLaunchConfigurations(&autoscaling.DescribeLaunchConfigurationsInput{
+				LaunchConfigurationNames: []*string{group.LaunchConfigurationName},
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			if len(describeLaunchConfigurationsOutput.LaunchConfigurations) != 0 {
+				launchConfiguration := describeLaunchConfigurationsOutput.LaunchConfigurations[0]
+				nodePool.NodeInstanceType = aws.StringValue(launchConfiguration.InstanceType)
+				nodePool.NodeImage = aws.StringValue(launchConfiguration.ImageId)
+				nodePool.NodeSpotPrice = aws.StringValue(launchConfiguration.SpotPrice)
+			}
+		}
+
+		nodePools = append(nodePools, nodePool)
+	}
+
+	return nodePools, nil
+}
+
 // CreateCluster creates an EKS cluster with cloudformation templates.
 func (c *EKSCluster) CreateCluster() error {
 	c.log.Info("Start creating EKS cluster")
@@ -1003,6 +1133,17 @@ func (c *EKSCluster) loadEksMasterSettings(context *action.EksClusterCreateUpdat
 
 // loadClusterUserCredentials get the cluster user credentials from AWS and populates into this EKSCluster instance
 func (c *EKSCluster) loadClusterUserCredentials(context *action.EksClusterCreateUpdateContext) error {
+	// imported clusters have no cluster user, so the credentials of the secret are used
+	if c.modelCluster.Imported && (c.awsAccessKeyID == "" || c.awsSecretAccessKey == "") {
+		clusterSecret, err := c.GetSecretWithValidation()
+		if err != nil {
+			return err
+		}
+
+		c.awsAccessKeyID = clusterSecret.GetValue(pkgSecret.AwsAccessKeyId)
+		c.awsSecretAccessKey = clusterSecret.GetValue(pkgSecret.AwsSecretAccessKey)
+	}
+
 	// Get IAM user access key id and secret from stack
 	if c.awsAccessKeyID == "" || c.awsSecretAccessKey == "" {
 		eksStackName := c.generateStackNameForCluster()
@@ -1,6 +1,13 @@
 package cluster
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"google.golang.org/api/googleapi"
+)
 
 var ErrInvalidClusterInstance = errors.New("invalid cluster instance")
 
@@ -15,3 +22,72 @@ func (e *invalidError) Error() string {
 func (invalidError) IsInvalid() bool {
 	return true
 }
+
+type notFoundError struct {
+	err error
+}
+
+func (e *notFoundError) Error() string {
+	return e.err.Error()
+}
+
+func (notFoundError) NotFound() bool {
+	return true
+}
+
+type forbiddenError struct {
+	err error
+}
+
+func (e *forbiddenError) Error() string {
+	return e.err.Error()
+}
+
+func (forbiddenError) Forbidden() bool {
+	return true
+}
+
+type preconditionFailedError struct {
+	err error
+}
+
+func (e *preconditionFailedError) Error() string {
+	return e.err.Error()
+}
+
+func (preconditionFailedError) PreconditionFailed() bool {
+	return true
+}
+
+// classifyCloudError marks the error of a cloud API call with the reason it failed for, based on the HTTP status of the response
+func classifyCloudError(err error) error {
+	switch cloudErrorStatusCode(err) {
+	case http.StatusBadRequest:
+		return &invalidError{err}
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return &forbiddenError{err}
+	case http.StatusNotFound:
+		return &notFoundError{err}
+	}
+
+	return err
+}
+
+// cloudErrorStatusCode returns the HTTP status of the response a cloud API call failed with, 0 if it is unknown
+func cloudErrorStatusCode(err error) int {
+	switch e := err.(type) {
+	case awserr.RequestFailure:
+		return e.StatusCode()
+	case *googleapi.Error:
+		return e.Code
+	case autorest.DetailedError:
+		if e.Response != nil {
+			return e.Response.StatusCode
+		}
+		if statusCode, ok := e.StatusCode.(int); ok {
+			return statusCode
+		}
+	}
+
+	return 0
+}
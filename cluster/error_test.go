@@ -0,0 +1,61 @@
+package cluster
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"google.golang.org/api/googleapi"
+)
+
+func TestClassifyCloudError(t *testing.T) {
+	cases := []struct {
+		name               string
+		err                error
+		invalid            bool
+		forbidden          bool
+		notFound           bool
+		preconditionFailed bool
+	}{
+		{
+			name:     "amazon not found",
+			err:      awserr.NewRequestFailure(awserr.New("ResourceNotFoundException", "No cluster found", nil), http.StatusNotFound, "request"),
+			notFound: true,
+		},
+		{
+			name:      "google forbidden",
+			err:       &googleapi.Error{Code: http.StatusForbidden, Message: "Required container.clusters.get permission"},
+			forbidden: true,
+		},
+		{
+			name:    "google bad request",
+			err:     &googleapi.Error{Code: http.StatusBadRequest, Message: "Invalid zone"},
+			invalid: true,
+		},
+		{
+			name:               "cluster not ready",
+			err:                &preconditionFailedError{errors.New("GKE cluster is not running")},
+			preconditionFailed: true,
+		},
+		{
+			name: "server error",
+			err:  &googleapi.Error{Code: http.StatusInternalServerError},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := classifyCloudError(tc.err)
+
+			_, invalid := err.(*invalidError)
+			_, forbidden := err.(*forbiddenError)
+			_, notFound := err.(*notFoundError)
+			_, preconditionFailed := err.(*preconditionFailedError)
+
+			if invalid != tc.invalid || forbidden != tc.forbidden || notFound != tc.notFound || preconditionFailed != tc.preconditionFailed {
+				t.Errorf("Unexpected classification of error: %T", err)
+			}
+		})
+	}
+}
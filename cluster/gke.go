@@ -81,6 +81,24 @@ func CreateGKEClusterFromRequest(request *pkgCluster.CreateClusterRequest, orgId
 	return &cluster, nil
 }
 
+//CreateGKEClusterFromImportRequest creates ClusterModel struct from the import request
+func CreateGKEClusterFromImportRequest(request *pkgCluster.ImportClusterRequest, orgId, userId uint) (*GKECluster, error) {
+	log.Debug("Create ClusterModel struct from the import request")
+	var cluster GKECluster
+
+	cluster.modelCluster = &model.ClusterModel{
+		Name:           request.Name,
+		Location:       request.Location,
+		Cloud:          request.Cloud,
+		OrganizationId: orgId,
+		SecretId:       request.SecretId,
+		CreatedBy:      userId,
+		Distribution:   pkgCluster.GKE,
+		Imported:       true,
+	}
+	return &cluster, nil
+}
+
 //createNodePoolsModelFromRequestData creates an array of GoogleNodePoolModel from the nodePoolsData received through create/update requests
 func createNodePoolsModelFromRequestData(nodePoolsData map[string]*pkgClusterGoogle.NodePool, userId uint) ([]*model.GKENodePoolModel, error) {
 
@@ -259,6 +277,38 @@ func (c *GKECluster) CreateCluster() error {
 
 }
 
+// ImportCluster reads the definition of an existing GKE cluster into the cluster model
+func (c *GKECluster) ImportCluster() error {
+
+	log.Info("Start import cluster (Google)")
+
+	gkeCluster, err := c.GetGoogleCluster()
+	if err != nil {
+		return err
+	}
+
+	if gkeCluster.Status != statusRunning {
+		return &preconditionFailedError{errors.Errorf("GKE cluster is not running, its status is %s", gkeCluster.Status)}
+	}
+
+	c.updateModel(gkeCluster, gkeCluster.NodePools)
+	for _, nodePool := range c.modelCluster.GKE.NodePools {
+		nodePool.CreatedBy = c.modelCluster.CreatedBy
+	}
+
+	projectId, err := c.getProjectId()
+	if err != nil {
+		return err
+	}
+
+	c.modelCluster.GKE.Region, err = c.getRegionByZone(projectId, gkeCluster.Zone)
+	if err != nil {
+		log.Warnf("error during getting region: %s", err.Error())
+	}
+
+	return nil
+}
+
 func (c *GKECluster) updateCurrentVersions(gkeCluster *gke.Cluster) {
 
 	c.modelCluster.GKE.MasterVersion = gkeCluster.CurrentMasterVersion
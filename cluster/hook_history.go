@@ -0,0 +1,186 @@
+package cluster
+
+import (
+	"encoding/json"
+	"time"
+
+	pipConfig "github.com/banzaicloud/pipeline/config"
+	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
+	"github.com/banzaicloud/pipeline/model"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// hookHistory records the executions of a posthook or pre-delete hook run.
+type hookHistory struct {
+	executions *intCluster.HookExecutions
+	records    []*model.HookExecutionModel
+	log        logrus.FieldLogger
+}
+
+// hookDescription describes a hook of a run, hooks without name are not recorded.
+type hookDescription struct {
+	name   string
+	params interface{}
+}
+
+// newPostHookHistory persists the posthooks of a run as pending executions.
+func newPostHookHistory(postHooks []PostFunctioner, cluster CommonCluster, log logrus.FieldLogger) *hookHistory {
+	hooks := make([]hookDescription, len(postHooks))
+	for i, postHook := range postHooks {
+		if postHook != nil {
+			hooks[i].name, hooks[i].params = describePostHook(postHook)
+		}
+	}
+
+	return newHookHistory(cluster, pkgCluster.HookPhasePostHook, hooks, log)
+}
+
+// newPreDeleteHookHistory persists the pre-delete hooks of a run as pending executions.
+func newPreDeleteHookHistory(preDeleteHooks []PreDeleteFunctioner, cluster CommonCluster, log logrus.FieldLogger) *hookHistory {
+	hooks := make([]hookDescription, len(preDeleteHooks))
+	for i, preDeleteHook := range preDeleteHooks {
+		if preDeleteHook != nil {
+			hooks[i].name, hooks[i].params = describePreDeleteHook(preDeleteHook)
+		}
+	}
+
+	return newHookHistory(cluster, pkgCluster.HookPhasePreDelete, hooks, log)
+}
+
+// newHookHistory persists the hooks of a run as pending executions.
+// Failing to record the history is logged only, it never prevents hooks from running.
+func newHookHistory(cluster CommonCluster, phase string, hooks []hookDescription, log logrus.FieldLogger) *hookHistory {
+	history := &hookHistory{
+		executions: intCluster.NewHookExecutions(pipConfig.DB()),
+		records:    make([]*model.HookExecutionModel, len(hooks)),
+		log:        log,
+	}
+
+	runID := uuid.NewV4().String()
+
+	for i, hook := range hooks {
+		if hook.name == "" {
+			continue
+		}
+
+		record := &model.HookExecutionModel{
+			ClusterID: cluster.GetID(),
+			Phase:     phase,
+			RunID:     runID,
+			Position:  i,
+			Name:      hook.name,
+			Status:    pkgCluster.HookPending,
+		}
+
+		if hook.params != nil {
+			encoded, err := json.Marshal(hook.params)
+			if err != nil {
+				log.Warnf("could not encode params of hook [%s]: %s", hook.name, err.Error())
+			}
+			record.Params = string(encoded)
+		}
+
+		history.records[i] = record
+		history.save(record)
+	}
+
+	return history
+}
+
+// start records the start of the hook at the given position.
+func (h *hookHistory) start(position int) {
+	record := h.records[position]
+	if record == nil {
+		return
+	}
+
+	now := time.Now()
+	record.StartedAt = &now
+	record.Status = pkgCluster.HookRunning
+
+	h.save(record)
+}
+
+// finish records the outcome of the hook at the given position.
+func (h *hookHistory) finish(position int, err error) {
+	record := h.records[position]
+	if record == nil {
+		return
+	}
+
+	now := time.Now()
+	record.FinishedAt = &now
+
+	if err != nil {
+		record.Status = pkgCluster.HookFailed
+		record.Error = err.Error()
+	} else {
+		record.Status = pkgCluster.HookSucceeded
+	}
+
+	h.save(record)
+}
+
+func (h *hookHistory) save(record *model.HookExecutionModel) {
+	if err := h.executions.Save(record); err != nil {
+		h.log.Warnf("could not record execution of hook [%s]: %s", record.Name, err.Error())
+	}
+}
+
+// GetPostHookFunctionsToRetry returns the posthook functions of the last posthook run of a cluster which should run again.
+// In resume mode the first posthook not succeeded and every posthook after it is returned,
+// in failed mode the posthooks not succeeded from the first failed one are returned, so the ones left pending run as well.
+func GetPostHookFunctionsToRetry(clusterID uint, mode string) ([]PostFunctioner, error) {
+	if mode != pkgCluster.PostHookRetryResume && mode != pkgCluster.PostHookRetryFailed {
+		return nil, errors.Errorf("unknown posthook retry mode: %s", mode)
+	}
+
+	executions, err := intCluster.NewHookExecutions(pipConfig.DB()).FindLastRun(clusterID, pkgCluster.HookPhasePostHook)
+	if err != nil {
+		return nil, err
+	}
+
+	var postHooks []PostFunctioner
+
+	for _, execution := range selectExecutionsToRetry(executions, mode) {
+		var params pkgCluster.PostHookParam
+		if execution.Params != "" {
+			if err := json.Unmarshal([]byte(execution.Params), &params); err != nil {
+				return nil, errors.Wrapf(err, "could not decode params of posthook [%s]", execution.Name)
+			}
+		}
+
+		if function := getPostHookFunction(execution.Name, params); function != nil {
+			postHooks = append(postHooks, function)
+		}
+	}
+
+	return postHooks, nil
+}
+
+// selectExecutionsToRetry returns the executions of a posthook run which should run again in the given retry mode
+func selectExecutionsToRetry(executions []*model.HookExecutionModel, mode string) []*model.HookExecutionModel {
+	var selected []*model.HookExecutionModel
+	resuming := false
+
+	for _, execution := range executions {
+		if mode == pkgCluster.PostHookRetryResume {
+			resuming = resuming || execution.Status != pkgCluster.HookSucceeded
+			if !resuming {
+				continue
+			}
+		} else {
+			resuming = resuming || execution.Status == pkgCluster.HookFailed
+			if !resuming || execution.Status == pkgCluster.HookSucceeded {
+				continue
+			}
+		}
+
+		selected = append(selected, execution)
+	}
+
+	return selected
+}
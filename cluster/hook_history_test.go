@@ -0,0 +1,56 @@
+package cluster
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/banzaicloud/pipeline/model"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+)
+
+func TestSelectExecutionsToRetry(t *testing.T) {
+	executions := []*model.HookExecutionModel{
+		{Name: "first", Status: pkgCluster.HookSucceeded},
+		{Name: "second", Status: pkgCluster.HookFailed},
+		{Name: "third", Status: pkgCluster.HookSucceeded},
+		{Name: "fourth", Status: pkgCluster.HookPending},
+	}
+
+	cases := []struct {
+		mode     string
+		expected []string
+	}{
+		{mode: pkgCluster.PostHookRetryResume, expected: []string{"second", "third", "fourth"}},
+		{mode: pkgCluster.PostHookRetryFailed, expected: []string{"second", "fourth"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.mode, func(t *testing.T) {
+			var names []string
+			for _, execution := range selectExecutionsToRetry(executions, tc.mode) {
+				names = append(names, execution.Name)
+			}
+
+			if !reflect.DeepEqual(names, tc.expected) {
+				t.Errorf("Expected posthooks %v, got: %v", tc.expected, names)
+			}
+		})
+	}
+}
+
+func TestSelectExecutionsToRetryWithoutFailure(t *testing.T) {
+	// the run was interrupted, so nothing failed
+	executions := []*model.HookExecutionModel{
+		{Name: "first", Status: pkgCluster.HookSucceeded},
+		{Name: "second", Status: pkgCluster.HookRunning},
+		{Name: "third", Status: pkgCluster.HookPending},
+	}
+
+	if selected := selectExecutionsToRetry(executions, pkgCluster.PostHookRetryFailed); len(selected) != 0 {
+		t.Errorf("Expected no posthooks to retry in failed mode, got: %d", len(selected))
+	}
+
+	if selected := selectExecutionsToRetry(executions, pkgCluster.PostHookRetryResume); len(selected) != 2 {
+		t.Errorf("Expected the interrupted posthooks to be resumed, got: %d", len(selected))
+	}
+}
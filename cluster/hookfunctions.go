@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"fmt"
 	"reflect"
 	"runtime"
 	"strings"
@@ -78,6 +79,94 @@ var BasePostHookFunctions = []PostFunctioner{
 	HookMap[pkgCluster.LabelNodes],
 }
 
+// GetPostHookFunctions returns the posthook functions with their params set based on the posthook names
+func GetPostHookFunctions(postHooks pkgCluster.PostHooks) (ph []PostFunctioner) {
+
+	log.Info("Get posthook function(s)")
+
+	for postHookName, param := range postHooks {
+
+		function := getPostHookFunction(postHookName, param)
+		if function != nil {
+			ph = append(ph, function)
+		}
+	}
+
+	log.Infof("Found posthooks: %v", ph)
+
+	return
+}
+
+// GetImportPostHookFunctions returns the requested base posthook functions in their default order.
+// Storing the kubeconfig is always part of a cluster import, whether it is requested or not.
+func GetImportPostHookFunctions(postHookNames []string) ([]PostFunctioner, error) {
+	requested := map[string]bool{
+		pkgCluster.StoreKubeConfig: true,
+	}
+
+	for _, name := range postHookNames {
+		requested[name] = true
+	}
+
+	var ph []PostFunctioner
+	for _, postHook := range BasePostHookFunctions {
+		name, _ := describePostHook(postHook)
+		if requested[name] {
+			ph = append(ph, postHook)
+			delete(requested, name)
+		}
+	}
+
+	for name := range requested {
+		return nil, fmt.Errorf("posthook [%s] can not be run on import", name)
+	}
+
+	return ph, nil
+}
+
+// postHookNames returns the names of the given posthook functions
+func postHookNames(postHooks []PostFunctioner) []string {
+	names := make([]string, 0, len(postHooks))
+	for _, postHook := range postHooks {
+		name, _ := describePostHook(postHook)
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// getPostHookFunction returns the posthook function registered with the given name with its params set
+func getPostHookFunction(postHookName string, param pkgCluster.PostHookParam) PostFunctioner {
+	function := HookMap[postHookName]
+	if function == nil {
+		log.Warnf("there's no function with this name [%s]", postHookName)
+		return nil
+	}
+
+	if f, isOk := function.(*PostFunctionWithParam); isOk {
+		fa := *f
+		fa.SetParams(param)
+		function = &fa
+	}
+
+	log.Infof("posthook function: %s", function)
+	log.Infof("posthook params: %#v", param)
+
+	return function
+}
+
+// describePostHook returns the name and the params of a posthook function
+func describePostHook(postHook PostFunctioner) (string, pkgCluster.PostHookParam) {
+	switch f := postHook.(type) {
+	case *PostFunctionWithParam:
+		return f.String(), f.params
+	case *BasePostFunction:
+		return f.String(), nil
+	}
+
+	return fmt.Sprint(postHook), nil
+}
+
 // PostFunctioner manages posthook functions
 type PostFunctioner interface {
 	Do(CommonCluster) error
@@ -36,10 +36,14 @@ func RunPostHooks(postHooks []PostFunctioner, cluster CommonCluster) (err error)
 
 	log := log.WithFields(logrus.Fields{"cluster": cluster.GetName(), "org": cluster.GetOrganizationId()})
 
-	for _, postHook := range postHooks {
+	history := newPostHookHistory(postHooks, cluster, log)
+
+	for i, postHook := range postHooks {
 		if postHook != nil {
 			log.Infof("Start posthook function[%s]", postHook)
+			history.start(i)
 			err = postHook.Do(cluster)
+			history.finish(i, err)
 			if err != nil {
 				log.Errorf("Error during posthook function[%s]: %s", postHook, err.Error())
 				postHook.Error(cluster, err)
@@ -479,7 +483,7 @@ func installDeployment(cluster CommonCluster, namespace string, deploymentName s
 		}
 	}
 
-	_, err = helm.CreateDeployment(deploymentName, chartVersion, nil, namespace, releaseName, values, kubeConfig, helm.GenerateHelmRepoEnv(org.Name))
+	_, err = helm.CreateDeployment(deploymentName, chartVersion, nil, namespace, releaseName, false, values, kubeConfig, helm.GenerateHelmRepoEnv(org.Name, org.ID))
 	if err != nil {
 		log.Errorf("Deploying '%s' failed due to: %s", deploymentName, err.Error())
 		return err
@@ -0,0 +1,46 @@
+package cluster
+
+import (
+	"context"
+	"strings"
+
+	pipConfig "github.com/banzaicloud/pipeline/config"
+	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ValidateLabels validates cluster labels against the rules of Kubernetes labels
+func ValidateLabels(labels map[string]string) error {
+	for key, value := range labels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return errors.Errorf("invalid label key %q: %s", key, strings.Join(errs, "; "))
+		}
+
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return errors.Errorf("invalid value of label %q: %s", key, strings.Join(errs, "; "))
+		}
+	}
+
+	return nil
+}
+
+// GetClusterLabels returns the labels of a cluster.
+func (m *Manager) GetClusterLabels(ctx context.Context, cluster CommonCluster) (map[string]string, error) {
+	return intCluster.NewLabels(pipConfig.DB()).FindByCluster(cluster.GetOrganizationId(), cluster.GetID())
+}
+
+// SetClusterLabels replaces the labels of a cluster and rolls out the deployment sets selecting it or having a release in it,
+// so that the cluster gets the releases of the sets selecting it and loses the ones not selecting it any more.
+func (m *Manager) SetClusterLabels(ctx context.Context, cluster CommonCluster, labels map[string]string) error {
+	if err := ValidateLabels(labels); err != nil {
+		return errors.Wrap(&invalidError{err}, "validation failed")
+	}
+
+	err := intCluster.NewLabels(pipConfig.DB()).Save(cluster.GetOrganizationId(), cluster.GetID(), labels)
+	if err != nil {
+		return err
+	}
+
+	return m.rolloutDeploymentSets(ctx, cluster)
+}
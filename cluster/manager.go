@@ -15,6 +15,7 @@ type clusterRepository interface {
 	FindByOrganization(organizationID uint) ([]*model.ClusterModel, error)
 	FindOneByID(organizationID uint, clusterID uint) (*model.ClusterModel, error)
 	FindOneByName(organizationID uint, clusterName string) (*model.ClusterModel, error)
+	FindOneByUID(organizationID uint, clusterUID string) (*model.ClusterModel, error)
 	FindBySecret(organizationID uint, secretID string) ([]*model.ClusterModel, error)
 }
 
@@ -23,17 +24,19 @@ type secretValidator interface {
 }
 
 type Manager struct {
-	clusters clusterRepository
-	secrets  secretValidator
+	clusters   clusterRepository
+	secrets    secretValidator
+	operations *OperationQueue
 
 	logger       logrus.FieldLogger
 	errorHandler emperror.Handler
 }
 
-func NewManager(clusters clusterRepository, secrets secretValidator, logger logrus.FieldLogger, errorHandler emperror.Handler) *Manager {
+func NewManager(clusters clusterRepository, secrets secretValidator, operations *OperationQueue, logger logrus.FieldLogger, errorHandler emperror.Handler) *Manager {
 	return &Manager{
 		clusters:     clusters,
 		secrets:      secrets,
+		operations:   operations,
 		logger:       logger,
 		errorHandler: errorHandler,
 	}
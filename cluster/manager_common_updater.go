@@ -29,7 +29,7 @@ func (e *commonUpdateValidationError) IsInvalid() bool {
 	return e.invalidRequest
 }
 
-func (e *commonUpdateValidationError) IsPreconditionFailed() bool {
+func (e *commonUpdateValidationError) PreconditionFailed() bool {
 	return e.preconditionFailed
 }
 
@@ -51,6 +51,14 @@ func (c *commonUpdater) Validate(ctx context.Context) error {
 		}
 	}
 
+	// imported EKS clusters have no CloudFormation stacks the node pools could be updated with
+	if c.cluster.GetDistribution() == cluster.EKS && isImportedCluster(c.cluster) {
+		return &commonUpdateValidationError{
+			msg:                "updating imported EKS clusters is not supported",
+			preconditionFailed: true,
+		}
+	}
+
 	status, err := c.cluster.GetStatus()
 	if err != nil {
 		return emperror.Wrap(err, "could not get cluster status")
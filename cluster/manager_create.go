@@ -4,8 +4,12 @@ import (
 	"context"
 	stderrors "errors"
 
+	pipConfig "github.com/banzaicloud/pipeline/config"
+	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
+	"github.com/banzaicloud/pipeline/model"
 	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
 	"github.com/banzaicloud/pipeline/secret"
+	"github.com/goph/emperror"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -18,6 +22,7 @@ type CreationContext struct {
 	Provider       string
 	SecretID       string
 	PostHooks      []PostFunctioner
+	Labels         map[string]string
 }
 
 var ErrAlreadyExists = stderrors.New("cluster already exists with this name")
@@ -42,7 +47,7 @@ func (m *Manager) CreateCluster(ctx context.Context, creationCtx CreationContext
 	})
 
 	logger.Info("looking for existing cluster")
-	if err := m.assertNotExists(creationCtx); err != nil {
+	if err := m.assertNotExists(creationCtx.OrganizationID, creationCtx.Name); err != nil {
 		return nil, err
 	}
 
@@ -58,6 +63,10 @@ func (m *Manager) CreateCluster(ctx context.Context, creationCtx CreationContext
 		return nil, errors.Wrap(&invalidError{err}, "validation failed")
 	}
 
+	if err := ValidateLabels(creationCtx.Labels); err != nil {
+		return nil, errors.Wrap(&invalidError{err}, "validation failed")
+	}
+
 	logger.Info("creation context is valid")
 	logger.Info("preparing cluster creation")
 
@@ -66,24 +75,51 @@ func (m *Manager) CreateCluster(ctx context.Context, creationCtx CreationContext
 		return nil, err
 	}
 
+	if len(creationCtx.Labels) > 0 {
+		err := intCluster.NewLabels(pipConfig.DB()).Save(creationCtx.OrganizationID, cluster.GetID(), creationCtx.Labels)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if err := cluster.UpdateStatus(pkgCluster.Creating, pkgCluster.CreatingMessage); err != nil {
 		return nil, err
 	}
 
+	postHooks, err := encodePostHooks(creationCtx.PostHooks)
+	if err != nil {
+		return nil, err
+	}
+
+	errorHandler := emperror.HandlerWith(
+		m.getErrorHandler(ctx),
+		"organization", creationCtx.OrganizationID,
+		"user", creationCtx.UserID,
+		"cluster", cluster.GetID(),
+	)
+
+	operation := &model.ClusterOperationModel{
+		OrganizationID: creationCtx.OrganizationID,
+		ClusterID:      cluster.GetID(),
+		UserID:         creationCtx.UserID,
+		Type:           pkgCluster.OperationCreate,
+		PostHooks:      postHooks,
+	}
+
 	logger.Info("creating cluster")
 
-	go func() {
-		err := m.createCluster(ctx, cluster, creator, creationCtx.PostHooks, logger)
-		if err != nil {
-			logger.Errorf("failed to create cluster: %s", err.Error())
-		}
-	}()
+	err = m.operations.enqueue(operation, errorHandler, func(operation *model.ClusterOperationModel) error {
+		return m.createCluster(ctx, operation, cluster, creator, creationCtx.PostHooks, logger)
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "could not queue cluster creation")
+	}
 
 	return cluster, nil
 }
 
-func (m *Manager) assertNotExists(ctx CreationContext) error {
-	exists, err := m.clusters.Exists(ctx.OrganizationID, ctx.Name)
+func (m *Manager) assertNotExists(organizationID uint, name string) error {
+	exists, err := m.clusters.Exists(organizationID, name)
 	if err != nil {
 		return err
 	}
@@ -97,11 +133,45 @@ func (m *Manager) assertNotExists(ctx CreationContext) error {
 
 func (m *Manager) createCluster(
 	ctx context.Context,
+	operation *model.ClusterOperationModel,
 	cluster CommonCluster,
 	creator clusterCreator,
 	postHooks []PostFunctioner,
 	logger logrus.FieldLogger,
 ) error {
+	if stepPending(createOperationSteps, operation.Step, pkgCluster.OperationStepCreateCluster) {
+		m.operations.step(operation, pkgCluster.OperationStepCreateCluster)
+
+		if err := m.createClusterInCloud(ctx, cluster, creator, logger); err != nil {
+			return err
+		}
+	}
+
+	m.operations.step(operation, pkgCluster.OperationStepRunPostHooks)
+
+	// Apply PostHooks
+	// These are hardcoded posthooks maybe we will want a bit more dynamic
+	postHookFunctions := BasePostHookFunctions
+
+	if postHooks != nil && len(postHooks) != 0 {
+		postHookFunctions = append(postHookFunctions, postHooks...)
+	}
+
+	err := RunPostHooks(postHookFunctions, cluster)
+
+	if err != nil {
+		return errors.Wrap(err, "error during running cluster posthooks")
+	}
+
+	// the releases of the deployment sets selecting the cluster are installed in the background
+	if err := m.rolloutDeploymentSets(ctx, cluster); err != nil {
+		logger.Errorf("rolling out deployment sets failed: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (m *Manager) createClusterInCloud(ctx context.Context, cluster CommonCluster, creator clusterCreator, logger logrus.FieldLogger) error {
 	// Check if public ssh key is needed for the cluster. If so and there is generate one and store it Vault
 	if len(cluster.GetSshSecretId()) == 0 && cluster.RequiresSshPublicKey() {
 		logger.Info("generating SSH Key for the cluster")
@@ -127,19 +197,5 @@ func (m *Manager) createCluster(
 		return err
 	}
 
-	// Apply PostHooks
-	// These are hardcoded posthooks maybe we will want a bit more dynamic
-	postHookFunctions := BasePostHookFunctions
-
-	if postHooks != nil && len(postHooks) != 0 {
-		postHookFunctions = append(postHookFunctions, postHooks...)
-	}
-
-	err = RunPostHooks(postHookFunctions, cluster)
-
-	if err != nil {
-		return errors.Wrap(err, "error during running cluster posthooks")
-	}
-
 	return nil
 }
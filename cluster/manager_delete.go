@@ -5,14 +5,21 @@ import (
 	"fmt"
 	"sync"
 
-	"github.com/banzaicloud/pipeline/helm"
+	"github.com/banzaicloud/pipeline/model"
 	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
 	"github.com/goph/emperror"
 	"github.com/sirupsen/logrus"
 )
 
 // DeleteCluster deletes a cluster.
-func (m *Manager) DeleteCluster(ctx context.Context, cluster CommonCluster, force bool, kubeProxyCache *sync.Map) error {
+// The given pre-delete hooks are run along with the base ones before the cluster is deleted.
+func (m *Manager) DeleteCluster(
+	ctx context.Context,
+	cluster CommonCluster,
+	force bool,
+	preDeleteHooks []PreDeleteFunctioner,
+	kubeProxyCache *sync.Map,
+) error {
 	errorHandler := emperror.HandlerWith(
 		m.getErrorHandler(ctx),
 		"organization", cluster.GetOrganizationId(),
@@ -20,17 +27,37 @@ func (m *Manager) DeleteCluster(ctx context.Context, cluster CommonCluster, forc
 		"force", force,
 	)
 
-	go func() {
-		err := m.deleteCluster(ctx, cluster, force, kubeProxyCache)
-		if err != nil {
-			errorHandler.Handle(err)
-		}
-	}()
+	encodedPreDeleteHooks, err := encodePreDeleteHooks(preDeleteHooks)
+	if err != nil {
+		return err
+	}
+
+	operation := &model.ClusterOperationModel{
+		OrganizationID: cluster.GetOrganizationId(),
+		ClusterID:      cluster.GetID(),
+		Type:           pkgCluster.OperationDelete,
+		Force:          force,
+		PreDeleteHooks: encodedPreDeleteHooks,
+	}
+
+	err = m.operations.enqueue(operation, errorHandler, func(operation *model.ClusterOperationModel) error {
+		return m.deleteCluster(ctx, operation, cluster, force, preDeleteHooks, kubeProxyCache)
+	})
+	if err != nil {
+		return emperror.Wrap(err, "could not queue cluster deletion")
+	}
 
 	return nil
 }
 
-func (m *Manager) deleteCluster(ctx context.Context, cluster CommonCluster, force bool, kubeProxyCache *sync.Map) error {
+func (m *Manager) deleteCluster(
+	ctx context.Context,
+	operation *model.ClusterOperationModel,
+	cluster CommonCluster,
+	force bool,
+	preDeleteHooks []PreDeleteFunctioner,
+	kubeProxyCache *sync.Map,
+) error {
 	logger := m.getLogger(ctx).WithFields(logrus.Fields{
 		"organization": cluster.GetOrganizationId(),
 		"cluster":      cluster.GetID(),
@@ -47,66 +74,55 @@ func (m *Manager) deleteCluster(ctx context.Context, cluster CommonCluster, forc
 		)
 	}
 
-	// get kubeconfig
-	c, err := cluster.GetK8sConfig()
-	if err != nil {
-		if !force {
-			cluster.UpdateStatus(pkgCluster.Error, err.Error())
+	if stepPending(deleteOperationSteps, operation.Step, pkgCluster.OperationStepRunPreDeleteHooks) {
+		m.operations.step(operation, pkgCluster.OperationStepRunPreDeleteHooks)
 
-			return emperror.Wrap(err, "error getting kubeconfig")
+		err = RunPreDeleteHooks(withBasePreDeleteHooks(preDeleteHooks), cluster, force)
+		if err != nil {
+			return emperror.Wrap(err, "error during running cluster pre-delete hooks")
 		}
-
-		logger.Errorf("error during getting kubeconfig: %s", err.Error())
 	}
 
-	if !(force && c == nil) {
-		// delete deployments
-		err = helm.DeleteAllDeployment(c)
-		if err != nil && !force {
-			return emperror.Wrap(err, "deleting deployments failed")
-		} else if err != nil {
-			logger.Errorf("deleting deployments failed: %s", err.Error())
-		}
-	} else {
-		logger.Info("skipping deployment deletion without kubeconfig")
-	}
+	if stepPending(deleteOperationSteps, operation.Step, pkgCluster.OperationStepDeleteCluster) {
+		m.operations.step(operation, pkgCluster.OperationStepDeleteCluster)
 
-	// delete cluster
-	err = cluster.DeleteCluster()
-	if err != nil {
-		if !force {
-			cluster.UpdateStatus(pkgCluster.Error, err.Error())
+		// imported clusters are only detached from Pipeline, they keep running in the cloud
+		if isImportedCluster(cluster) {
+			logger.Info("leaving imported cluster in the cloud")
+		} else if err = cluster.DeleteCluster(); err != nil {
+			if !force {
+				cluster.UpdateStatus(pkgCluster.Error, err.Error())
 
-			return emperror.Wrap(err, "error deleting cluster")
-		}
+				return emperror.Wrap(err, "error deleting cluster")
+			}
 
-		logger.Errorf("error during deleting cluster: %s", err.Error())
+			logger.Errorf("error during deleting cluster: %s", err.Error())
+		}
 	}
 
 	// delete from proxy from kubeProxyCache if any
 	// TODO: this should be handled somewhere else
 	kubeProxyCache.Delete(fmt.Sprint(cluster.GetOrganizationId(), "-", cluster.GetID()))
 
-	// delete cluster from database
 	deleteName := cluster.GetName()
-	err = cluster.DeleteFromDatabase()
-	if err != nil {
-		if !force {
-			cluster.UpdateStatus(pkgCluster.Error, err.Error())
 
-			return emperror.Wrap(err, "error deleting cluster from the database")
-		}
+	if stepPending(deleteOperationSteps, operation.Step, pkgCluster.OperationStepDeleteFromDatabase) {
+		m.operations.step(operation, pkgCluster.OperationStepDeleteFromDatabase)
 
-		logger.Errorf("error during deleting cluster from the database: %s", err.Error())
-	}
-
-	// Asyncron update prometheus
-	go func() {
-		err := UpdatePrometheusConfig()
+		// delete cluster from database
+		err = cluster.DeleteFromDatabase()
 		if err != nil {
-			logger.Warnf("could not update prometheus configmap: %v", err)
+			if !force {
+				cluster.UpdateStatus(pkgCluster.Error, err.Error())
+
+				return emperror.Wrap(err, "error deleting cluster from the database")
+			}
+
+			logger.Errorf("error during deleting cluster from the database: %s", err.Error())
 		}
-	}()
+	}
+
+	m.operations.step(operation, pkgCluster.OperationStepCleanStateStore)
 
 	// clean statestore
 	logger.Info("cleaning cluster's statestore folder")
@@ -0,0 +1,121 @@
+package cluster
+
+import (
+	"context"
+
+	"github.com/banzaicloud/pipeline/model"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	"github.com/goph/emperror"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// ImportContext represents the data necessary to adopt an existing cloud cluster.
+type ImportContext struct {
+	OrganizationID uint
+	UserID         uint
+	Name           string
+	Provider       string
+	SecretID       string
+	PostHooks      []PostFunctioner
+}
+
+type clusterImporter interface {
+	// ImportCluster reads the definition of the existing cloud cluster into the cluster model.
+	ImportCluster() error
+}
+
+// ImportCluster registers an existing cloud cluster as a managed cluster.
+func (m *Manager) ImportCluster(ctx context.Context, importCtx ImportContext, cluster CommonCluster) (CommonCluster, error) {
+	logger := m.getLogger(ctx).WithFields(logrus.Fields{
+		"organization": importCtx.OrganizationID,
+		"user":         importCtx.UserID,
+		"cluster":      importCtx.Name,
+	})
+
+	logger.Info("looking for existing cluster")
+	if err := m.assertNotExists(importCtx.OrganizationID, importCtx.Name); err != nil {
+		return nil, err
+	}
+
+	logger.Info("validating secret")
+	err := m.secrets.ValidateSecretType(importCtx.OrganizationID, importCtx.SecretID, importCtx.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	importer, ok := cluster.(clusterImporter)
+	if !ok {
+		return nil, &invalidError{errors.Errorf("importing %s clusters is not supported", cluster.GetDistribution())}
+	}
+
+	logger.Info("reading cluster definition from the cloud")
+
+	if err := importer.ImportCluster(); err != nil {
+		return nil, errors.Wrap(classifyCloudError(err), "could not read cluster definition")
+	}
+
+	if err := cluster.Persist(pkgCluster.Creating, pkgCluster.ImportingMessage); err != nil {
+		return nil, err
+	}
+
+	postHooks, err := encodePostHooks(importCtx.PostHooks)
+	if err != nil {
+		return nil, err
+	}
+
+	errorHandler := emperror.HandlerWith(
+		m.getErrorHandler(ctx),
+		"organization", importCtx.OrganizationID,
+		"user", importCtx.UserID,
+		"cluster", cluster.GetID(),
+	)
+
+	operation := &model.ClusterOperationModel{
+		OrganizationID: importCtx.OrganizationID,
+		ClusterID:      cluster.GetID(),
+		UserID:         importCtx.UserID,
+		Type:           pkgCluster.OperationImport,
+		PostHooks:      postHooks,
+	}
+
+	logger.Info("importing cluster")
+
+	err = m.operations.enqueue(operation, errorHandler, func(operation *model.ClusterOperationModel) error {
+		return m.importCluster(ctx, operation, cluster, importCtx.PostHooks)
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "could not queue cluster import")
+	}
+
+	return cluster, nil
+}
+
+func (m *Manager) importCluster(
+	ctx context.Context,
+	operation *model.ClusterOperationModel,
+	cluster CommonCluster,
+	postHooks []PostFunctioner,
+) error {
+	m.operations.step(operation, pkgCluster.OperationStepRunPostHooks)
+
+	if err := RunPostHooks(postHooks, cluster); err != nil {
+		return errors.Wrap(err, "error during running cluster posthooks")
+	}
+
+	// the releases of the deployment sets selecting the cluster are installed in the background
+	if err := m.rolloutDeploymentSets(ctx, cluster); err != nil {
+		m.getLogger(ctx).WithField("cluster", cluster.GetName()).Errorf("rolling out deployment sets failed: %s", err.Error())
+	}
+
+	return nil
+}
+
+// isImportedCluster tells whether a cluster was imported instead of being created by Pipeline
+func isImportedCluster(cluster CommonCluster) bool {
+	c, ok := cluster.(interface {
+		GetModel() *model.ClusterModel
+	})
+
+	return ok && c.GetModel() != nil && c.GetModel().Imported
+}
@@ -0,0 +1,149 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+
+	pipConfig "github.com/banzaicloud/pipeline/config"
+	"github.com/banzaicloud/pipeline/model"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	"github.com/goph/emperror"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// ResumeOperations queues the cluster operations interrupted by a previous shutdown again.
+func (m *Manager) ResumeOperations(ctx context.Context) error {
+	logger := m.getLogger(ctx)
+
+	logger.Info("looking for unfinished cluster operations")
+
+	operations, err := m.operations.operations.FindUnfinished()
+	if err != nil {
+		return err
+	}
+
+	maxAttempts := viper.GetInt(pipConfig.ClusterOperationMaxAttempts)
+
+	for _, operation := range operations {
+		logger := logger.WithFields(logrus.Fields{
+			"operation":    operation.ID,
+			"type":         operation.Type,
+			"organization": operation.OrganizationID,
+			"cluster":      operation.ClusterID,
+		})
+
+		errorHandler := emperror.HandlerWith(
+			m.getErrorHandler(ctx),
+			"operation", operation.ID,
+			"organization", operation.OrganizationID,
+			"cluster", operation.ClusterID,
+		)
+
+		err := m.resumeOperation(ctx, operation, maxAttempts, logger, errorHandler)
+		if err != nil {
+			errorHandler.Handle(err)
+		}
+	}
+
+	return nil
+}
+
+func (m *Manager) resumeOperation(
+	ctx context.Context,
+	operation *model.ClusterOperationModel,
+	maxAttempts int,
+	logger logrus.FieldLogger,
+	errorHandler emperror.Handler,
+) error {
+	clusterModel, err := m.clusters.FindOneByID(operation.OrganizationID, operation.ClusterID)
+	if isNotFoundError(err) && operation.Type == pkgCluster.OperationDelete {
+		logger.Info("cluster is already removed from the database, finishing operation")
+
+		return m.operations.finish(operation, nil)
+	} else if err != nil {
+		return m.failOperation(operation, err)
+	}
+
+	cluster, err := GetCommonClusterFromModel(clusterModel)
+	if err != nil {
+		return m.failOperation(operation, err)
+	}
+
+	if operation.Attempts >= maxAttempts {
+		err := errors.Errorf("cluster operation abandoned after %d attempts", operation.Attempts)
+		cluster.UpdateStatus(pkgCluster.Error, err.Error())
+
+		return m.failOperation(operation, err)
+	}
+
+	SetStatusContext(ctx, cluster, operation.UserID)
+
+	logger.WithField("step", operation.Step).Info("resuming cluster operation")
+
+	switch operation.Type {
+	case pkgCluster.OperationCreate:
+		postHooks, err := decodePostHooks(operation.PostHooks)
+		if err != nil {
+			return m.failOperation(operation, err)
+		}
+
+		creator := NewCommonClusterCreator(nil, cluster)
+
+		return m.operations.enqueue(operation, errorHandler, func(operation *model.ClusterOperationModel) error {
+			return m.createCluster(ctx, operation, cluster, creator, postHooks, logger)
+		})
+
+	case pkgCluster.OperationImport:
+		postHooks, err := decodePostHooks(operation.PostHooks)
+		if err != nil {
+			return m.failOperation(operation, err)
+		}
+
+		// restore the default order of the posthooks which is lost on encoding
+		postHooks, err = GetImportPostHookFunctions(postHookNames(postHooks))
+		if err != nil {
+			return m.failOperation(operation, err)
+		}
+
+		return m.operations.enqueue(operation, errorHandler, func(operation *model.ClusterOperationModel) error {
+			return m.importCluster(ctx, operation, cluster, postHooks)
+		})
+
+	case pkgCluster.OperationDelete:
+		preDeleteHooks, err := decodePreDeleteHooks(operation.PreDeleteHooks)
+		if err != nil {
+			return m.failOperation(operation, err)
+		}
+
+		// the proxy cache does not survive a restart, so there is nothing to clean up in it
+		kubeProxyCache := &sync.Map{}
+
+		return m.operations.enqueue(operation, errorHandler, func(operation *model.ClusterOperationModel) error {
+			return m.deleteCluster(ctx, operation, cluster, operation.Force, preDeleteHooks, kubeProxyCache)
+		})
+
+	default:
+		return m.failOperation(operation, errors.Errorf("unknown cluster operation type: %s", operation.Type))
+	}
+}
+
+// failOperation marks an operation failed and returns the original error.
+func (m *Manager) failOperation(operation *model.ClusterOperationModel, err error) error {
+	if ferr := m.operations.finish(operation, err); ferr != nil {
+		m.errorHandler.Handle(ferr)
+	}
+
+	return err
+}
+
+func isNotFoundError(err error) bool {
+	if e, ok := errors.Cause(err).(interface {
+		NotFound() bool
+	}); ok {
+		return e.NotFound()
+	}
+
+	return false
+}
@@ -0,0 +1,151 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	pipConfig "github.com/banzaicloud/pipeline/config"
+	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
+	"github.com/banzaicloud/pipeline/model"
+	secretTypes "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/banzaicloud/pipeline/secret"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const clusterUIDTagPrefix = "clusterUID:"
+
+// PropagateSecret installs the current version of a secret into every namespace of the clusters it was installed into.
+// Clusters created with the secret or owning it (through a clusterUID tag) are reported even if they hold no copy of it.
+func (m *Manager) PropagateSecret(ctx context.Context, organizationID uint, secretItem *secret.SecretItemResponse) ([]secretTypes.ClusterPropagationResult, error) {
+	logger := m.getLogger(ctx).WithFields(logrus.Fields{
+		"organization": organizationID,
+		"secret":       secretItem.ID,
+	})
+
+	clusterModels, namespaces, err := m.findSecretDependents(organizationID, secretItem, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]secretTypes.ClusterPropagationResult, 0, len(clusterModels))
+
+	for _, clusterModel := range clusterModels {
+		logger := logger.WithField("cluster", clusterModel.Name)
+
+		result := secretTypes.ClusterPropagationResult{
+			ClusterID:   clusterModel.ID,
+			ClusterName: clusterModel.Name,
+			Namespaces:  namespaces[clusterModel.ID],
+			Status:      secretTypes.PropagationSkipped,
+		}
+
+		if len(result.Namespaces) == 0 {
+			results = append(results, result)
+			continue
+		}
+
+		cluster, err := m.getClusterFromModel(clusterModel)
+		if err != nil {
+			logger.Errorf("converting cluster model to common cluster failed: %s", err.Error())
+
+			result.Status = secretTypes.PropagationFailed
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		var failures []string
+		for _, namespace := range result.Namespaces {
+			logger.WithField("namespace", namespace).Info("installing rotated secret")
+
+			if err := ReinstallSecret(cluster, secretItem, namespace); err != nil {
+				logger.WithField("namespace", namespace).Errorf("installing rotated secret failed: %s", err.Error())
+
+				failures = append(failures, fmt.Sprintf("%s: %s", namespace, err.Error()))
+			}
+		}
+
+		result.Status = secretTypes.PropagationSucceeded
+		if len(failures) > 0 {
+			result.Status = secretTypes.PropagationFailed
+			result.Error = strings.Join(failures, "; ")
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// findSecretDependents returns the clusters depending on a secret ordered by ID,
+// along with the namespaces the secret was installed into per cluster
+func (m *Manager) findSecretDependents(
+	organizationID uint,
+	secretItem *secret.SecretItemResponse,
+	logger logrus.FieldLogger,
+) ([]*model.ClusterModel, map[uint][]string, error) {
+	clusterModels := make(map[uint]*model.ClusterModel)
+	namespaces := make(map[uint][]string)
+
+	installations, err := intCluster.NewSecretInstallations(pipConfig.DB()).FindBySecret(organizationID, secretItem.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, installation := range installations {
+		namespaces[installation.ClusterID] = append(namespaces[installation.ClusterID], installation.Namespace)
+
+		if _, ok := clusterModels[installation.ClusterID]; ok {
+			continue
+		}
+
+		clusterModel, err := m.clusters.FindOneByID(organizationID, installation.ClusterID)
+		if isNotFoundError(err) {
+			logger.WithField("cluster", installation.ClusterID).Warn("secret installed into a cluster not found")
+
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		clusterModels[clusterModel.ID] = clusterModel
+	}
+
+	bySecret, err := m.clusters.FindBySecret(organizationID, secretItem.ID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not get clusters by secret")
+	}
+
+	for _, clusterModel := range bySecret {
+		clusterModels[clusterModel.ID] = clusterModel
+	}
+
+	for _, tag := range secretItem.Tags {
+		if !strings.HasPrefix(tag, clusterUIDTagPrefix) {
+			continue
+		}
+
+		clusterModel, err := m.clusters.FindOneByUID(organizationID, strings.TrimPrefix(tag, clusterUIDTagPrefix))
+		if isNotFoundError(err) {
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		clusterModels[clusterModel.ID] = clusterModel
+	}
+
+	dependents := make([]*model.ClusterModel, 0, len(clusterModels))
+	for _, clusterModel := range clusterModels {
+		dependents = append(dependents, clusterModel)
+	}
+
+	sort.Slice(dependents, func(i, j int) bool {
+		return dependents[i].ID < dependents[j].ID
+	})
+
+	return dependents, namespaces, nil
+}
@@ -27,6 +27,11 @@ type PrometheusCfg struct {
 
 //UpdatePrometheusConfig updates the Prometheus configuration
 func UpdatePrometheusConfig() error {
+	return updatePrometheusConfig()
+}
+
+// updatePrometheusConfig updates the Prometheus configuration leaving out the excluded clusters
+func updatePrometheusConfig(excludedClusterIDs ...uint) error {
 	//TODO configsets
 	if !viper.GetBool("monitor.enabled") {
 		log.Warn("Update monitoring configuration is disabled")
@@ -46,7 +51,7 @@ func UpdatePrometheusConfig() error {
 
 	// TODO: move these to a struct and create them only once upon application init
 	secretValidator := providers.NewSecretValidator(secret.Store)
-	clusterManager := NewManager(intCluster.NewClusters(pipConfig.DB()), secretValidator, log, errorHandler)
+	clusterManager := NewManager(intCluster.NewClusters(pipConfig.DB()), secretValidator, nil, log, errorHandler)
 
 	clusters, err := clusterManager.GetAllClusters(context.Background())
 	if err != nil {
@@ -56,6 +61,11 @@ func UpdatePrometheusConfig() error {
 	var prometheusConfig []PrometheusCfg
 	//Gathering information about clusters
 	for _, cluster := range clusters {
+		if isExcludedCluster(cluster.GetID(), excludedClusterIDs) {
+			log.Debugf("Cluster %s is excluded from Prometheus config", cluster.GetName())
+			continue
+		}
+
 		kubeEndpoint, err := cluster.GetAPIEndpoint()
 		if err != nil {
 			log.Errorf("Cluster endpoint is not available for cluster: %s, err: %s", cluster.GetName(), err)
@@ -103,3 +113,13 @@ func UpdatePrometheusConfig() error {
 
 	return nil
 }
+
+func isExcludedCluster(clusterID uint, excludedClusterIDs []uint) bool {
+	for _, excludedClusterID := range excludedClusterIDs {
+		if clusterID == excludedClusterID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,239 @@
+package cluster
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/banzaicloud/pipeline/model"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	"github.com/goph/emperror"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Steps of the cluster operations in their execution order.
+var (
+	createOperationSteps = []string{
+		pkgCluster.OperationStepCreateCluster,
+		pkgCluster.OperationStepRunPostHooks,
+	}
+
+	deleteOperationSteps = []string{
+		pkgCluster.OperationStepRunPreDeleteHooks,
+		pkgCluster.OperationStepDeleteCluster,
+		pkgCluster.OperationStepDeleteFromDatabase,
+		pkgCluster.OperationStepCleanStateStore,
+	}
+)
+
+type operationRepository interface {
+	Save(operation *model.ClusterOperationModel) error
+	FindUnfinished() ([]*model.ClusterOperationModel, error)
+}
+
+type operationFunc func(operation *model.ClusterOperationModel) error
+
+type operationJob struct {
+	operation    *model.ClusterOperationModel
+	run          operationFunc
+	errorHandler emperror.Handler
+}
+
+// OperationQueue executes persisted cluster operations on a fixed number of workers.
+type OperationQueue struct {
+	operations operationRepository
+	workers    int
+	jobs       chan operationJob
+
+	logger       logrus.FieldLogger
+	errorHandler emperror.Handler
+}
+
+// NewOperationQueue returns a new OperationQueue instance.
+func NewOperationQueue(operations operationRepository, workers int, logger logrus.FieldLogger, errorHandler emperror.Handler) *OperationQueue {
+	if workers < 1 {
+		workers = 1
+	}
+
+	return &OperationQueue{
+		operations:   operations,
+		workers:      workers,
+		jobs:         make(chan operationJob),
+		logger:       logger,
+		errorHandler: errorHandler,
+	}
+}
+
+// Start starts the workers of the queue.
+func (q *OperationQueue) Start() {
+	for i := 0; i < q.workers; i++ {
+		go func() {
+			for job := range q.jobs {
+				q.run(job)
+			}
+		}()
+	}
+}
+
+// enqueue persists an operation as pending and schedules it for execution.
+func (q *OperationQueue) enqueue(operation *model.ClusterOperationModel, errorHandler emperror.Handler, run operationFunc) error {
+	operation.Status = pkgCluster.OperationPending
+
+	if err := q.operations.Save(operation); err != nil {
+		return err
+	}
+
+	// the job is handed over in the background so that callers are never blocked by busy workers
+	go func() {
+		q.jobs <- operationJob{
+			operation:    operation,
+			run:          run,
+			errorHandler: errorHandler,
+		}
+	}()
+
+	return nil
+}
+
+func (q *OperationQueue) run(job operationJob) {
+	operation := job.operation
+
+	logger := q.logger.WithFields(logrus.Fields{
+		"operation":    operation.ID,
+		"type":         operation.Type,
+		"organization": operation.OrganizationID,
+		"cluster":      operation.ClusterID,
+	})
+
+	operation.Status = pkgCluster.OperationRunning
+	operation.Attempts++
+
+	if err := q.operations.Save(operation); err != nil {
+		job.errorHandler.Handle(err)
+	}
+
+	logger.WithField("attempt", operation.Attempts).Info("executing cluster operation")
+
+	err := job.run(operation)
+	if err != nil {
+		job.errorHandler.Handle(emperror.With(err, "operation", operation.ID))
+	}
+
+	if err := q.finish(operation, err); err != nil {
+		job.errorHandler.Handle(err)
+	}
+
+	logger.WithField("status", operation.Status).Info("cluster operation finished")
+}
+
+// step records that an operation has reached the given step.
+func (q *OperationQueue) step(operation *model.ClusterOperationModel, step string) {
+	operation.Step = step
+
+	if err := q.operations.Save(operation); err != nil {
+		q.errorHandler.Handle(emperror.With(err, "operation", operation.ID, "step", step))
+	}
+}
+
+// finish marks an operation as finished or failed depending on the error.
+func (q *OperationQueue) finish(operation *model.ClusterOperationModel, err error) error {
+	now := time.Now()
+	operation.FinishedAt = &now
+
+	if err != nil {
+		operation.Status = pkgCluster.OperationFailed
+		operation.LastError = err.Error()
+	} else {
+		operation.Status = pkgCluster.OperationFinished
+		operation.LastError = ""
+	}
+
+	return q.operations.Save(operation)
+}
+
+// stepPending tells whether a step still has to be executed when an operation is (re)started from its current step.
+func stepPending(steps []string, current string, step string) bool {
+	currentIndex, stepIndex := -1, -1
+
+	for i, s := range steps {
+		if s == current {
+			currentIndex = i
+		}
+
+		if s == step {
+			stepIndex = i
+		}
+	}
+
+	return currentIndex < 0 || stepIndex >= currentIndex
+}
+
+// encodePostHooks serializes posthook functions so that they can be rebuilt when an operation is resumed.
+func encodePostHooks(postHooks []PostFunctioner) (string, error) {
+	hooks := pkgCluster.PostHooks{}
+
+	for _, postHook := range postHooks {
+		name, params := describePostHook(postHook)
+		hooks[name] = params
+	}
+
+	if len(hooks) == 0 {
+		return "", nil
+	}
+
+	encoded, err := json.Marshal(hooks)
+	if err != nil {
+		return "", errors.Wrap(err, "could not encode posthooks")
+	}
+
+	return string(encoded), nil
+}
+
+// decodePostHooks rebuilds the posthook functions serialized by encodePostHooks.
+func decodePostHooks(encoded string) ([]PostFunctioner, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+
+	var hooks pkgCluster.PostHooks
+	if err := json.Unmarshal([]byte(encoded), &hooks); err != nil {
+		return nil, errors.Wrap(err, "could not decode posthooks")
+	}
+
+	return GetPostHookFunctions(hooks), nil
+}
+
+// encodePreDeleteHooks serializes pre-delete hook functions so that they can be rebuilt when an operation is resumed.
+func encodePreDeleteHooks(preDeleteHooks []PreDeleteFunctioner) (string, error) {
+	hooks := pkgCluster.PreDeleteHooks{}
+
+	for _, preDeleteHook := range preDeleteHooks {
+		name, params := describePreDeleteHook(preDeleteHook)
+		hooks[name] = params
+	}
+
+	if len(hooks) == 0 {
+		return "", nil
+	}
+
+	encoded, err := json.Marshal(hooks)
+	if err != nil {
+		return "", errors.Wrap(err, "could not encode pre-delete hooks")
+	}
+
+	return string(encoded), nil
+}
+
+// decodePreDeleteHooks rebuilds the pre-delete hook functions serialized by encodePreDeleteHooks.
+func decodePreDeleteHooks(encoded string) ([]PreDeleteFunctioner, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+
+	var hooks pkgCluster.PreDeleteHooks
+	if err := json.Unmarshal([]byte(encoded), &hooks); err != nil {
+		return nil, errors.Wrap(err, "could not decode pre-delete hooks")
+	}
+
+	return GetPreDeleteHookFunctions(hooks), nil
+}
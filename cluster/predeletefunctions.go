@@ -0,0 +1,147 @@
+package cluster
+
+import (
+	"fmt"
+
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+)
+
+// PreDeleteHookMap for api pre-delete hook parameters
+var PreDeleteHookMap = map[string]PreDeleteFunctioner{
+	pkgCluster.DeleteHelmDeployments: &BasePreDeleteFunction{
+		f: DeleteHelmDeployments,
+	},
+	pkgCluster.DrainPersistentVolumes: &PreDeleteFunctionWithParam{
+		f: DrainPersistentVolumes,
+	},
+	pkgCluster.UnregisterDomainPreDelete: &BasePreDeleteFunction{
+		f: UnregisterDomainPreDelete,
+	},
+	pkgCluster.UpdatePrometheusPreDelete: &BasePreDeleteFunction{
+		f: UpdatePrometheusPreDelete,
+	},
+	pkgCluster.DeleteClusterSecrets: &BasePreDeleteFunction{
+		f: DeleteClusterSecrets,
+	},
+}
+
+// preDeleteHookOrder is the order pre-delete hooks are executed in regardless of the order they are requested in
+var preDeleteHookOrder = []string{
+	pkgCluster.DeleteHelmDeployments,
+	pkgCluster.DrainPersistentVolumes,
+	pkgCluster.UnregisterDomainPreDelete,
+	pkgCluster.UpdatePrometheusPreDelete,
+	pkgCluster.DeleteClusterSecrets,
+}
+
+// BasePreDeleteHookFunctions default pre-delete hook functions before cluster delete
+var BasePreDeleteHookFunctions = []PreDeleteFunctioner{
+	PreDeleteHookMap[pkgCluster.DeleteHelmDeployments],
+	PreDeleteHookMap[pkgCluster.UpdatePrometheusPreDelete],
+}
+
+// PreDeleteFunctioner manages pre-delete hook functions
+type PreDeleteFunctioner interface {
+	Do(CommonCluster) error
+}
+
+// BasePreDeleteFunction describes a default pre-delete hook function
+type BasePreDeleteFunction struct {
+	f func(interface{}) error
+}
+
+// PreDeleteFunctionWithParam describes a pre-delete hook function with params
+type PreDeleteFunctionWithParam struct {
+	f      func(interface{}, pkgCluster.PreDeleteHookParam) error
+	params pkgCluster.PreDeleteHookParam
+}
+
+// Do call function and pass CommonCluster as param
+func (b *BasePreDeleteFunction) Do(cluster CommonCluster) error {
+	return b.f(cluster)
+}
+
+func (b *BasePreDeleteFunction) String() string {
+	return getFunctionName(b.f)
+}
+
+// Do call function and pass CommonCluster and pre-delete hook params
+func (p *PreDeleteFunctionWithParam) Do(cluster CommonCluster) error {
+	return p.f(cluster, p.params)
+}
+
+func (p *PreDeleteFunctionWithParam) String() string {
+	return getFunctionName(p.f)
+}
+
+// SetParams sets pre-delete hook params
+func (p *PreDeleteFunctionWithParam) SetParams(params pkgCluster.PreDeleteHookParam) {
+	p.params = params
+}
+
+// GetPreDeleteHookFunctions returns the requested pre-delete hook functions with their params set in execution order
+func GetPreDeleteHookFunctions(preDeleteHooks pkgCluster.PreDeleteHooks) (ph []PreDeleteFunctioner) {
+
+	for name := range preDeleteHooks {
+		if PreDeleteHookMap[name] == nil {
+			log.Warnf("there's no pre-delete function with this name [%s]", name)
+		}
+	}
+
+	for _, name := range preDeleteHookOrder {
+		param, ok := preDeleteHooks[name]
+		if !ok {
+			continue
+		}
+
+		function := PreDeleteHookMap[name]
+		if f, isOk := function.(*PreDeleteFunctionWithParam); isOk {
+			fa := *f
+			fa.SetParams(param)
+			function = &fa
+		}
+
+		ph = append(ph, function)
+	}
+
+	log.Infof("Found pre-delete hooks: %v", ph)
+
+	return
+}
+
+// withBasePreDeleteHooks returns the base pre-delete hook functions extended with the given ones in execution order
+func withBasePreDeleteHooks(preDeleteHooks []PreDeleteFunctioner) []PreDeleteFunctioner {
+	byName := make(map[string]PreDeleteFunctioner, len(BasePreDeleteHookFunctions)+len(preDeleteHooks))
+
+	for _, preDeleteHook := range BasePreDeleteHookFunctions {
+		name, _ := describePreDeleteHook(preDeleteHook)
+		byName[name] = preDeleteHook
+	}
+
+	// requested hooks take precedence as they may carry params
+	for _, preDeleteHook := range preDeleteHooks {
+		name, _ := describePreDeleteHook(preDeleteHook)
+		byName[name] = preDeleteHook
+	}
+
+	var functions []PreDeleteFunctioner
+	for _, name := range preDeleteHookOrder {
+		if function, ok := byName[name]; ok {
+			functions = append(functions, function)
+		}
+	}
+
+	return functions
+}
+
+// describePreDeleteHook returns the name and the params of a pre-delete hook function
+func describePreDeleteHook(preDeleteHook PreDeleteFunctioner) (string, pkgCluster.PreDeleteHookParam) {
+	switch f := preDeleteHook.(type) {
+	case *PreDeleteFunctionWithParam:
+		return f.String(), f.params
+	case *BasePreDeleteFunction:
+		return f.String(), nil
+	}
+
+	return fmt.Sprint(preDeleteHook), nil
+}
@@ -0,0 +1,241 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/banzaicloud/pipeline/auth"
+	pipConfig "github.com/banzaicloud/pipeline/config"
+	"github.com/banzaicloud/pipeline/dns"
+	"github.com/banzaicloud/pipeline/helm"
+	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	"github.com/banzaicloud/pipeline/secret"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	drainPersistentVolumesWaitAttempts = 30
+	drainPersistentVolumesWaitSeconds  = 10
+)
+
+// RunPreDeleteHooks calls pre-delete hook functions with the cluster being deleted.
+// A failing function stops the deletion unless it is forced.
+func RunPreDeleteHooks(preDeleteHooks []PreDeleteFunctioner, cluster CommonCluster, force bool) error {
+
+	log := log.WithFields(logrus.Fields{"cluster": cluster.GetName(), "org": cluster.GetOrganizationId(), "force": force})
+
+	history := newPreDeleteHookHistory(preDeleteHooks, cluster, log)
+
+	for i, preDeleteHook := range preDeleteHooks {
+		if preDeleteHook == nil {
+			continue
+		}
+
+		log.Infof("Start pre-delete function[%s]", preDeleteHook)
+		history.start(i)
+		err := preDeleteHook.Do(cluster)
+		history.finish(i, err)
+		if err != nil {
+			if !force {
+				log.Errorf("Error during pre-delete function[%s]: %s", preDeleteHook, err.Error())
+				cluster.UpdateStatus(pkgCluster.Error, err.Error())
+
+				return errors.Wrapf(err, "pre-delete function [%s] failed", preDeleteHook)
+			}
+
+			log.Warnf("Error during pre-delete function[%s], continuing forced deletion: %s", preDeleteHook, err.Error())
+			continue
+		}
+
+		statusMsg := fmt.Sprintf("Pre-delete function finished: %s", preDeleteHook)
+		if err := cluster.UpdateStatus(pkgCluster.Deleting, statusMsg); err != nil {
+			log.Errorf("Error during pre-delete status update in db [%s]: %s", preDeleteHook, err.Error())
+		}
+	}
+
+	log.Info("Run all pre-delete functions for cluster.")
+
+	return nil
+}
+
+// DeleteHelmDeployments deletes all Helm deployments of the cluster
+func DeleteHelmDeployments(input interface{}) error {
+	cluster, ok := input.(CommonCluster)
+	if !ok {
+		return errors.Errorf("Wrong parameter type: %T", cluster)
+	}
+
+	kubeConfig, err := cluster.GetK8sConfig()
+	if err != nil {
+		return errors.Wrap(err, "error getting kubeconfig")
+	}
+
+	if err := helm.DeleteAllDeployment(kubeConfig); err != nil {
+		return errors.Wrap(err, "deleting deployments failed")
+	}
+
+	return nil
+}
+
+// DrainPersistentVolumes deletes the persistent volume claims of the cluster and waits
+// for the dynamically provisioned volumes to be released, so no cloud disks are left behind
+func DrainPersistentVolumes(input interface{}, param pkgCluster.PreDeleteHookParam) error {
+	cluster, ok := input.(CommonCluster)
+	if !ok {
+		return errors.Errorf("Wrong parameter type: %T", cluster)
+	}
+
+	var drainParam pkgCluster.DrainPersistentVolumesParam
+	if param != nil {
+		postHookParam := pkgCluster.PostHookParam(param)
+		if err := castToPostHookParam(&postHookParam, &drainParam); err != nil {
+			return errors.Wrap(err, "invalid params")
+		}
+	}
+
+	kubeConfig, err := cluster.GetK8sConfig()
+	if err != nil {
+		return errors.Wrap(err, "error getting kubeconfig")
+	}
+
+	client, err := helm.GetK8sConnection(kubeConfig)
+	if err != nil {
+		return errors.Wrap(err, "error creating Kubernetes client")
+	}
+
+	namespaces := drainParam.Namespaces
+	if len(namespaces) == 0 {
+		namespaceList, err := client.CoreV1().Namespaces().List(metav1.ListOptions{})
+		if err != nil {
+			return errors.Wrap(err, "listing namespaces failed")
+		}
+
+		for _, namespace := range namespaceList.Items {
+			namespaces = append(namespaces, namespace.Name)
+		}
+	}
+
+	drained := make(map[string]bool, len(namespaces))
+	for _, namespace := range namespaces {
+		log.Infof("Deleting persistent volume claims in namespace %s", namespace)
+
+		err := client.CoreV1().PersistentVolumeClaims(namespace).DeleteCollection(&metav1.DeleteOptions{}, metav1.ListOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "deleting persistent volume claims in namespace %s failed", namespace)
+		}
+
+		drained[namespace] = true
+	}
+
+	for i := 0; i < drainPersistentVolumesWaitAttempts; i++ {
+		volumes, err := client.CoreV1().PersistentVolumes().List(metav1.ListOptions{})
+		if err != nil {
+			return errors.Wrap(err, "listing persistent volumes failed")
+		}
+
+		var pending int
+		for _, volume := range volumes.Items {
+			if volume.Spec.PersistentVolumeReclaimPolicy != v1.PersistentVolumeReclaimDelete || volume.Spec.ClaimRef == nil {
+				continue
+			}
+
+			if drained[volume.Spec.ClaimRef.Namespace] {
+				pending++
+			}
+		}
+
+		if pending == 0 {
+			return nil
+		}
+
+		log.Infof("Waiting for %d persistent volumes to be released", pending)
+		time.Sleep(drainPersistentVolumesWaitSeconds * time.Second)
+	}
+
+	return errors.New("timeout during waiting for persistent volumes to be released")
+}
+
+// UnregisterDomainPreDelete unregisters the domain of the organization when its last cluster is deleted
+func UnregisterDomainPreDelete(input interface{}) error {
+	cluster, ok := input.(CommonCluster)
+	if !ok {
+		return errors.Errorf("Wrong parameter type: %T", cluster)
+	}
+
+	orgId := cluster.GetOrganizationId()
+
+	clusters, err := intCluster.NewClusters(pipConfig.DB()).FindByOrganization(orgId)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range clusters {
+		if c.ID != cluster.GetID() {
+			log.Infof("Organization %d has other clusters, keeping its domain", orgId)
+			return nil
+		}
+	}
+
+	dnsSvc, err := dns.GetExternalDnsServiceClient()
+	if err != nil {
+		return errors.Wrap(err, "getting external dns service client failed")
+	}
+
+	if dnsSvc == nil {
+		log.Info("Exiting as external dns service functionality is not enabled")
+		return nil
+	}
+
+	org, err := auth.GetOrganizationById(orgId)
+	if err != nil {
+		return errors.Wrapf(err, "retrieving organization with id %d failed", orgId)
+	}
+
+	domain := fmt.Sprintf("%s.%s", org.Name, viper.GetString(pipConfig.DNSBaseDomain))
+
+	registered, err := dnsSvc.IsDomainRegistered(orgId, domain)
+	if err != nil {
+		return errors.Wrapf(err, "checking if domain '%s' is registered failed", domain)
+	}
+
+	if !registered {
+		log.Infof("Domain '%s' is not registered", domain)
+		return nil
+	}
+
+	if err := dnsSvc.UnregisterDomain(orgId, domain); err != nil {
+		return errors.Wrapf(err, "unregistering domain '%s' failed", domain)
+	}
+
+	return nil
+}
+
+// UpdatePrometheusPreDelete removes the cluster from the Prometheus configuration.
+// It's best-effort only, monitoring errors never block the deletion of the cluster.
+func UpdatePrometheusPreDelete(input interface{}) error {
+	cluster, ok := input.(CommonCluster)
+	if !ok {
+		return errors.Errorf("Wrong parameter type: %T", cluster)
+	}
+
+	if err := updatePrometheusConfig(cluster.GetID()); err != nil {
+		log.Warnf("could not update prometheus configmap: %s", err.Error())
+	}
+
+	return nil
+}
+
+// DeleteClusterSecrets deletes the secrets tagged with the UID of the cluster
+func DeleteClusterSecrets(input interface{}) error {
+	cluster, ok := input.(CommonCluster)
+	if !ok {
+		return errors.Errorf("Wrong parameter type: %T", cluster)
+	}
+
+	return secret.Store.DeleteByClusterUID(cluster.GetOrganizationId(), cluster.GetUID())
+}
@@ -0,0 +1,181 @@
+package cluster
+
+import (
+	"time"
+
+	"github.com/banzaicloud/pipeline/model"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	secretTypes "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/banzaicloud/pipeline/secret"
+	"github.com/goph/emperror"
+	"github.com/sirupsen/logrus"
+)
+
+type secretInstallationRepository interface {
+	FindAll() ([]*model.ClusterSecretInstallationModel, error)
+}
+
+type secretGetter interface {
+	Get(organizationID uint, secretID string) (*secret.SecretItemResponse, error)
+}
+
+// RegistrySecretRefresher periodically installs the new credentials of the registry secrets minting short-lived
+// credentials (ECR, GCR and ACR) into the namespaces they were installed into, so that the clusters keep pulling images.
+// The credentials are minted again shortly before they expire, the refresh interval has to be shorter than that margin.
+type RegistrySecretRefresher struct {
+	clusters      clusterRepository
+	installations secretInstallationRepository
+	definitions   secretGetter
+	credentials   secretGetter
+	interval      time.Duration
+
+	// install installs the credentials into a namespace of a cluster
+	install func(clusterModel *model.ClusterModel, secretItem *secret.SecretItemResponse, namespace string) error
+
+	// installed holds the expiration of the credentials installed last by installation
+	installed map[uint]string
+
+	logger       logrus.FieldLogger
+	errorHandler emperror.Handler
+}
+
+// NewRegistrySecretRefresher returns a new RegistrySecretRefresher instance.
+func NewRegistrySecretRefresher(
+	clusters clusterRepository,
+	installations secretInstallationRepository,
+	interval time.Duration,
+	logger logrus.FieldLogger,
+	errorHandler emperror.Handler,
+) *RegistrySecretRefresher {
+	return &RegistrySecretRefresher{
+		clusters:      clusters,
+		installations: installations,
+		definitions:   secret.Backend,
+		credentials:   secret.Store,
+		interval:      interval,
+		install:       installClusterSecret,
+		installed:     make(map[uint]string),
+		logger:        logger,
+		errorHandler:  errorHandler,
+	}
+}
+
+// Start starts refreshing the registry secrets in the background.
+func (r *RegistrySecretRefresher) Start() {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			r.Refresh()
+		}
+	}()
+}
+
+// Refresh installs the registry secrets the credentials of which changed since they were installed last.
+func (r *RegistrySecretRefresher) Refresh() {
+	installations, err := r.installations.FindAll()
+	if err != nil {
+		r.errorHandler.Handle(emperror.Wrap(err, "could not list secret installations"))
+		return
+	}
+
+	// installations are ordered by secret, so the installations of a secret are adjacent
+	for start := 0; start < len(installations); {
+		end := start + 1
+		for end < len(installations) &&
+			installations[end].OrganizationID == installations[start].OrganizationID &&
+			installations[end].SecretID == installations[start].SecretID {
+			end++
+		}
+
+		if err := r.refreshSecret(installations[start:end]); err != nil {
+			r.errorHandler.Handle(emperror.With(
+				err,
+				"organization", installations[start].OrganizationID,
+				"secret", installations[start].SecretID,
+			))
+		}
+
+		start = end
+	}
+}
+
+// refreshSecret installs the current credentials of a secret into its installations, unless they were installed there already
+func (r *RegistrySecretRefresher) refreshSecret(installations []*model.ClusterSecretInstallationModel) error {
+	organizationID := installations[0].OrganizationID
+	secretID := installations[0].SecretID
+
+	definition, err := r.definitions.Get(organizationID, secretID)
+	if err == secret.ErrSecretNotExists {
+		return nil
+	} else if err != nil {
+		return emperror.Wrap(err, "could not get secret")
+	}
+
+	if _, ok := secretTypes.RegistrySourceTypes[definition.Type]; !ok {
+		return nil
+	}
+
+	secretItem, err := r.credentials.Get(organizationID, secretID)
+	if err != nil {
+		return emperror.Wrap(err, "could not mint registry credentials")
+	}
+
+	expiration := secretItem.Values[secretTypes.CredentialsExpiration]
+
+	clusterModels := make(map[uint]*model.ClusterModel)
+
+	for _, installation := range installations {
+		if r.installed[installation.ID] == expiration {
+			continue
+		}
+
+		logger := r.logger.WithFields(logrus.Fields{
+			"organization": organizationID,
+			"secret":       secretID,
+			"cluster":      installation.ClusterID,
+			"namespace":    installation.Namespace,
+		})
+
+		clusterModel, ok := clusterModels[installation.ClusterID]
+		if !ok {
+			clusterModel, err = r.clusters.FindOneByID(organizationID, installation.ClusterID)
+			if isNotFoundError(err) {
+				continue
+			} else if err != nil {
+				logger.Errorf("could not get cluster: %s", err.Error())
+				continue
+			}
+
+			clusterModels[installation.ClusterID] = clusterModel
+		}
+
+		// the credentials are installed when the cluster is running again
+		if clusterModel.Status != pkgCluster.Running {
+			continue
+		}
+
+		logger.Info("installing refreshed registry credentials")
+
+		// failed installations are retried at the next refresh
+		if err := r.install(clusterModel, secretItem, installation.Namespace); err != nil {
+			logger.Errorf("installing refreshed registry credentials failed: %s", err.Error())
+			continue
+		}
+
+		r.installed[installation.ID] = expiration
+	}
+
+	return nil
+}
+
+// installClusterSecret installs a secret into a namespace of a cluster
+func installClusterSecret(clusterModel *model.ClusterModel, secretItem *secret.SecretItemResponse, namespace string) error {
+	cluster, err := GetCommonClusterFromModel(clusterModel)
+	if err != nil {
+		return err
+	}
+
+	return ReinstallSecret(cluster, secretItem, namespace)
+}
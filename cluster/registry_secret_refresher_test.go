@@ -0,0 +1,111 @@
+package cluster
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/banzaicloud/pipeline/model"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	secretTypes "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/banzaicloud/pipeline/secret"
+	"github.com/sirupsen/logrus"
+)
+
+type refresherClusters struct {
+	clusterRepository
+	clusters map[uint]*model.ClusterModel
+}
+
+func (r *refresherClusters) FindOneByID(organizationID uint, clusterID uint) (*model.ClusterModel, error) {
+	return r.clusters[clusterID], nil
+}
+
+type refresherInstallations []*model.ClusterSecretInstallationModel
+
+func (r refresherInstallations) FindAll() ([]*model.ClusterSecretInstallationModel, error) {
+	return r, nil
+}
+
+type refresherSecrets map[string]*secret.SecretItemResponse
+
+func (s refresherSecrets) Get(organizationID uint, secretID string) (*secret.SecretItemResponse, error) {
+	if item, ok := s[secretID]; ok {
+		return item, nil
+	}
+
+	return nil, secret.ErrSecretNotExists
+}
+
+type refresherErrorHandler struct {
+	errors []error
+}
+
+func (h *refresherErrorHandler) Handle(err error) {
+	h.errors = append(h.errors, err)
+}
+
+func TestRegistrySecretRefresher(t *testing.T) {
+	expiration := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	credentials := refresherSecrets{
+		"ecr": {ID: "ecr", Type: secretTypes.DockerRegistrySecretType, Values: map[string]string{
+			secretTypes.CredentialsExpiration: expiration,
+		}},
+	}
+
+	var installed []string
+
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	errorHandler := &refresherErrorHandler{}
+
+	refresher := &RegistrySecretRefresher{
+		clusters: &refresherClusters{clusters: map[uint]*model.ClusterModel{
+			1: {ID: 1, Status: pkgCluster.Running},
+			2: {ID: 2, Status: pkgCluster.Error},
+		}},
+		installations: refresherInstallations{
+			{ID: 1, OrganizationID: 1, SecretID: "ecr", ClusterID: 1, Namespace: "default"},
+			{ID: 2, OrganizationID: 1, SecretID: "ecr", ClusterID: 2, Namespace: "default"},
+			{ID: 3, OrganizationID: 1, SecretID: "password", ClusterID: 1, Namespace: "default"},
+		},
+		definitions: refresherSecrets{
+			"ecr":      {ID: "ecr", Type: secretTypes.ECRRegistrySecretType},
+			"password": {ID: "password", Type: secretTypes.PasswordSecretType},
+		},
+		credentials: credentials,
+		install: func(clusterModel *model.ClusterModel, secretItem *secret.SecretItemResponse, namespace string) error {
+			installed = append(installed, secretItem.ID)
+			return nil
+		},
+		installed:    make(map[uint]string),
+		logger:       logger,
+		errorHandler: errorHandler,
+	}
+
+	refresher.Refresh()
+
+	if len(errorHandler.errors) != 0 {
+		t.Fatalf("Expected no errors, got: %v", errorHandler.errors)
+	}
+
+	// only the registry secret is installed, into the running cluster
+	if len(installed) != 1 || installed[0] != "ecr" {
+		t.Fatalf("Expected the registry secret to be installed once, got: %v", installed)
+	}
+
+	refresher.Refresh()
+
+	if len(installed) != 1 {
+		t.Fatalf("Expected unchanged credentials not to be installed again, got: %v", installed)
+	}
+
+	credentials["ecr"].Values[secretTypes.CredentialsExpiration] = time.Now().Add(2 * time.Hour).Format(time.RFC3339)
+
+	refresher.Refresh()
+
+	if len(installed) != 2 {
+		t.Errorf("Expected new credentials to be installed, got: %v", installed)
+	}
+}
@@ -3,13 +3,20 @@ package cluster
 import (
 	"fmt"
 
+	pipConfig "github.com/banzaicloud/pipeline/config"
 	"github.com/banzaicloud/pipeline/helm"
+	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
 	secretTypes "github.com/banzaicloud/pipeline/pkg/secret"
 	"github.com/banzaicloud/pipeline/secret"
+	"github.com/pkg/errors"
 	"k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
+const defaultServiceAccount = "default"
+
 // InstallSecrets installs all secrets thats matches the query under the name into namespace of a Kubernetes cluster.
 // It returns the list of installed secret names and meta about how to mount them.
 func InstallSecrets(cc CommonCluster, query *secretTypes.ListSecretsQuery, namespace string) ([]secretTypes.K8SSourceMeta, error) {
@@ -20,7 +27,14 @@ func InstallSecrets(cc CommonCluster, query *secretTypes.ListSecretsQuery, names
 		return nil, err
 	}
 
-	return InstallSecretsByK8SConfig(k8sConfig, cc.GetOrganizationId(), query, namespace)
+	secretSources, err := InstallSecretsByK8SConfig(k8sConfig, cc.GetOrganizationId(), query, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	recordSecretInstallations(cc, namespace, secretSources...)
+
+	return secretSources, nil
 }
 
 // InstallSecretsByK8SConfig is the same as InstallSecrets but use this if you already have a K8S config at hand.
@@ -44,14 +58,17 @@ func InstallSecretsByK8SConfig(k8sConfig []byte, orgID uint, query *secretTypes.
 	var secretSources []secretTypes.K8SSourceMeta
 
 	for _, s := range secrets {
+		meta := getSecretTypeMeta(orgID, s)
+
 		k8sSecret := &v1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      s.Name,
 				Namespace: namespace,
 			},
+			Type:       v1.SecretType(meta.KubernetesType),
 			StringData: map[string]string{},
 		}
-		for k, v := range s.Values {
+		for k, v := range s.K8SData() {
 			k8sSecret.StringData[k] = v
 		}
 
@@ -61,7 +78,7 @@ func InstallSecretsByK8SConfig(k8sConfig []byte, orgID uint, query *secretTypes.
 			return nil, err
 		}
 
-		secretSources = append(secretSources, s.K8SSourceMeta())
+		secretSources = append(secretSources, secretTypes.K8SSourceMeta{Name: s.Name, Sourcing: meta.Sourcing})
 	}
 
 	return secretSources, nil
@@ -77,7 +94,14 @@ func InstallOrUpdateSecrets(cc CommonCluster, query *secretTypes.ListSecretsQuer
 		return nil, err
 	}
 
-	return InstallOrUpdateSecretsByK8SConfig(k8sConfig, cc.GetOrganizationId(), query, namespace)
+	secretSources, err := InstallOrUpdateSecretsByK8SConfig(k8sConfig, cc.GetOrganizationId(), query, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	recordSecretInstallations(cc, namespace, secretSources...)
+
+	return secretSources, nil
 }
 
 // InstallOrUpdateSecretsByK8SConfig is the same as InstallSecrets but use this if you already have a K8S config at hand.
@@ -110,6 +134,8 @@ func InstallOrUpdateSecretsByK8SConfig(k8sConfig []byte, orgID uint, query *secr
 		var k8sSecret *v1.Secret
 		create := true
 
+		meta := getSecretTypeMeta(orgID, s)
+
 		for i := 0; i < len(clusterSecretList.Items); i++ {
 			if clusterSecretList.Items[i].Name == s.Name {
 				k8sSecret = &clusterSecretList.Items[i]
@@ -135,11 +161,12 @@ func InstallOrUpdateSecretsByK8SConfig(k8sConfig []byte, orgID uint, query *secr
 					Name:      s.Name,
 					Namespace: namespace,
 				},
+				Type:       v1.SecretType(meta.KubernetesType),
 				StringData: map[string]string{},
 			}
 		}
 
-		for k, v := range s.Values {
+		for k, v := range s.K8SData() {
 			k8sSecret.StringData[k] = v
 		}
 
@@ -154,7 +181,7 @@ func InstallOrUpdateSecretsByK8SConfig(k8sConfig []byte, orgID uint, query *secr
 			return nil, err
 		}
 
-		secretSources = append(secretSources, s.K8SSourceMeta())
+		secretSources = append(secretSources, secretTypes.K8SSourceMeta{Name: s.Name, Sourcing: meta.Sourcing})
 	}
 
 	return secretSources, nil
@@ -166,7 +193,14 @@ func InstallSecretWithVaultID(cc CommonCluster, secretID, namespace string) (*se
 	if err != nil {
 		return nil, fmt.Errorf("error during getting config: %s", err.Error())
 	}
-	return InstallSecretWithVaultIDByK8SConfig(k8sConfig, cc.GetOrganizationId(), secretID, namespace)
+	secretSource, err := InstallSecretWithVaultIDByK8SConfig(k8sConfig, cc.GetOrganizationId(), secretID, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	recordSecretInstallations(cc, namespace, *secretSource)
+
+	return secretSource, nil
 }
 
 // InstallSecretWithVaultIDByK8SConfig is the same as InstallSecretWithVaultID but use this if you already have a K8S config at hand.
@@ -182,16 +216,17 @@ func InstallSecretWithVaultIDByK8SConfig(k8sConfig []byte, orgID uint, secretID,
 		return nil, fmt.Errorf("error during getting secrets with ID %s: %s", secretID, err.Error())
 	}
 
-	var secretSources secretTypes.K8SSourceMeta
+	meta := getSecretTypeMeta(orgID, resolvedSecret)
 
 	k8sSecret := &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      resolvedSecret.Name,
 			Namespace: namespace,
 		},
+		Type:       v1.SecretType(meta.KubernetesType),
 		StringData: map[string]string{},
 	}
-	for k, v := range resolvedSecret.Values {
+	for k, v := range resolvedSecret.K8SData() {
 		k8sSecret.StringData[k] = v
 	}
 
@@ -200,7 +235,157 @@ func InstallSecretWithVaultIDByK8SConfig(k8sConfig []byte, orgID uint, secretID,
 		return nil, fmt.Errorf("error during creating k8s secret: %s", err.Error())
 	}
 
-	secretSources = resolvedSecret.K8SSourceMeta()
+	return &secretTypes.K8SSourceMeta{Name: resolvedSecret.Name, Sourcing: meta.Sourcing}, nil
+}
+
+// ReinstallSecret replaces the values of a secret installed into the namespace of a Kubernetes cluster,
+// the secret is installed again if it was removed from the cluster.
+func ReinstallSecret(cc CommonCluster, secretItem *secret.SecretItemResponse, namespace string) error {
+	k8sConfig, err := cc.GetK8sConfig()
+	if err != nil {
+		return fmt.Errorf("error during getting config: %s", err.Error())
+	}
+
+	clusterClient, err := helm.GetK8sConnection(k8sConfig)
+	if err != nil {
+		return fmt.Errorf("error during building k8s client: %s", err.Error())
+	}
+
+	k8sSecret, err := clusterClient.CoreV1().Secrets(namespace).Get(secretItem.Name, metav1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		if err := helm.CreateNamespaceIfNotExist(k8sConfig, namespace); err != nil {
+			return fmt.Errorf("error checking namespace: %s", err.Error())
+		}
+
+		k8sSecret = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretItem.Name,
+				Namespace: namespace,
+			},
+			Type: v1.SecretType(getSecretTypeMeta(cc.GetOrganizationId(), secretItem).KubernetesType),
+		}
+	} else if err != nil {
+		return fmt.Errorf("error during getting k8s secret: %s", err.Error())
+	}
+
+	// values missing from the new version must not survive in the cluster
+	k8sSecret.Data = nil
+	k8sSecret.StringData = map[string]string{}
+	for k, v := range secretItem.K8SData() {
+		k8sSecret.StringData[k] = v
+	}
+
+	if k8sSecret.ResourceVersion == "" {
+		_, err = clusterClient.CoreV1().Secrets(namespace).Create(k8sSecret)
+	} else {
+		_, err = clusterClient.CoreV1().Secrets(namespace).Update(k8sSecret)
+	}
+	if err != nil {
+		return fmt.Errorf("error during updating k8s secret: %s", err.Error())
+	}
+
+	return nil
+}
+
+// InstallImagePullSecret installs a docker registry secret into the namespaces of a Kubernetes cluster
+// and adds it to the image pull secrets of the default service account of the namespaces
+func InstallImagePullSecret(cc CommonCluster, secretName string, namespaces []string) error {
+	secretItem, err := secret.Store.GetByName(cc.GetOrganizationId(), secretName)
+	if err != nil {
+		return errors.Wrapf(err, "error during getting secret %s", secretName)
+	}
+
+	if err := secretItem.ValidateSecretType(secretTypes.DockerRegistrySecretType); err != nil {
+		return err
+	}
+
+	k8sConfig, err := cc.GetK8sConfig()
+	if err != nil {
+		return fmt.Errorf("error during getting config: %s", err.Error())
+	}
+
+	clusterClient, err := helm.GetK8sConnection(k8sConfig)
+	if err != nil {
+		return fmt.Errorf("error during building k8s client: %s", err.Error())
+	}
+
+	for _, namespace := range namespaces {
+		if err := ReinstallSecret(cc, secretItem, namespace); err != nil {
+			return err
+		}
+
+		recordSecretInstallations(cc, namespace, secretTypes.K8SSourceMeta{Name: secretItem.Name, Sourcing: secretTypes.Volume})
+
+		if err := addImagePullSecret(clusterClient, namespace, secretItem.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addImagePullSecret adds the secret to the image pull secrets of the default service account of the namespace,
+// the service account of a new namespace may not be created yet, in that case it is created with the secret
+func addImagePullSecret(client kubernetes.Interface, namespace string, secretName string) error {
+	serviceAccount, err := client.CoreV1().ServiceAccounts(namespace).Get(defaultServiceAccount, metav1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		_, err = client.CoreV1().ServiceAccounts(namespace).Create(&v1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      defaultServiceAccount,
+				Namespace: namespace,
+			},
+			ImagePullSecrets: []v1.LocalObjectReference{{Name: secretName}},
+		})
+		if err == nil {
+			return nil
+		} else if !k8sErrors.IsAlreadyExists(err) {
+			return fmt.Errorf("error during creating default service account of namespace %s: %s", namespace, err.Error())
+		}
+
+		// the service account controller created it meanwhile
+		serviceAccount, err = client.CoreV1().ServiceAccounts(namespace).Get(defaultServiceAccount, metav1.GetOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("error during getting default service account of namespace %s: %s", namespace, err.Error())
+	}
+
+	for _, pullSecret := range serviceAccount.ImagePullSecrets {
+		if pullSecret.Name == secretName {
+			return nil
+		}
+	}
+
+	serviceAccount.ImagePullSecrets = append(serviceAccount.ImagePullSecrets, v1.LocalObjectReference{Name: secretName})
+
+	if _, err := client.CoreV1().ServiceAccounts(namespace).Update(serviceAccount); err != nil {
+		return fmt.Errorf("error during updating default service account of namespace %s: %s", namespace, err.Error())
+	}
+
+	return nil
+}
+
+// recordSecretInstallations stores the namespace the secrets were installed into
+// so that the new versions of rotated secrets can be installed there again
+func recordSecretInstallations(cc CommonCluster, namespace string, secretSources ...secretTypes.K8SSourceMeta) {
+	installations := intCluster.NewSecretInstallations(pipConfig.DB())
+
+	for _, s := range secretSources {
+		err := installations.Save(cc.GetOrganizationId(), cc.GetID(), secret.GenerateSecretIDFromName(s.Name), namespace)
+		if err != nil {
+			log.Errorf("Error during recording installation of secret %s: %s", s.Name, err.Error())
+		}
+	}
+}
+
+// getSecretTypeMeta returns the built-in or custom type of a secret of the organization,
+// secrets of unknown types are installed as Opaque secrets sourced as env vars
+func getSecretTypeMeta(orgID uint, s *secret.SecretItemResponse) secretTypes.Meta {
+	meta, err := secret.GetSecretTypeMeta(orgID, s.Type)
+	if err != nil {
+		log.Warnf("Error during getting type of secret %s: %s", s.Name, err.Error())
+
+		return secretTypes.DefaultRules[secretTypes.GenericSecret]
+	}
 
-	return &secretSources, nil
+	return meta
 }
@@ -0,0 +1,23 @@
+package cluster
+
+import (
+	"context"
+
+	pipelineContext "github.com/banzaicloud/pipeline/internal/platform/context"
+	"github.com/banzaicloud/pipeline/model"
+)
+
+// SetStatusContext makes the status transitions of a cluster record the given user
+// and the correlation ID of the context in the status history.
+func SetStatusContext(ctx context.Context, cluster CommonCluster, userID uint) {
+	c, ok := cluster.(interface {
+		GetModel() *model.ClusterModel
+	})
+	if !ok || c.GetModel() == nil {
+		return
+	}
+
+	clusterModel := c.GetModel()
+	clusterModel.StatusChangedBy = userID
+	clusterModel.CorrelationID = pipelineContext.CorrelationID(ctx)
+}
@@ -54,6 +54,51 @@ const (
 	// Config keys to OKE nodepool wait
 	OKEWaitAttemptsForNodepoolActive = "oke.waitAttemptsForNodepoolActive"
 	OKESleepSecondsForNodepoolActive = "oke.sleepSecondsForNodepoolActive"
+
+	// ClusterOperationWorkers is the configuration key for the number of workers executing cluster operations
+	ClusterOperationWorkers = "cluster.operation.workers"
+
+	// ClusterOperationMaxAttempts is the configuration key for the number of times an interrupted
+	// cluster operation is started before it's marked as failed
+	ClusterOperationMaxAttempts = "cluster.operation.maxAttempts"
+
+	// ClusterDriftEnabled is the configuration key for enabling the periodic cluster drift detection
+	ClusterDriftEnabled = "cluster.drift.enabled"
+
+	// ClusterDriftInterval is the configuration key for the time between two cluster drift detections
+	ClusterDriftInterval = "cluster.drift.interval"
+
+	// ClusterDriftReapply is the configuration key for re-applying the stored spec of drifted clusters
+	ClusterDriftReapply = "cluster.drift.reapply"
+
+	// SecretBackend is the configuration key for the backend storing secrets: vault or sql
+	SecretBackend = "secret.backend"
+
+	// SecretSQLEncryptionKey is the configuration key for the base64 encoded AES-256 key
+	// encrypting the data keys of the secrets stored by the sql backend
+	SecretSQLEncryptionKey = "secret.sql.encryptionKey"
+
+	// SecretDynamicCredentialsTTL is the configuration key for the lifetime of the credentials minted for dynamic secrets
+	SecretDynamicCredentialsTTL = "secret.dynamicCredentialsTTL"
+
+	// SecretRegistryRefreshInterval is the configuration key for the time between two refreshes of the installed registry secrets
+	SecretRegistryRefreshInterval = "secret.registryRefreshInterval"
+
+	// SecretDynamicAllowedTargets is the configuration key for the Google service accounts and Azure applications
+	// the organizations can mint credentials for, by organization ID
+	SecretDynamicAllowedTargets = "secret.dynamicAllowedTargets"
+
+	// AuthTokenPurgeInterval is the configuration key for the time between two purges of the expired API tokens
+	AuthTokenPurgeInterval = "auth.tokenPurgeInterval"
+
+	// AuthInvitationTTL is the configuration key for the time an organization invitation can be accepted within
+	AuthInvitationTTL = "auth.invitationTTL"
+
+	// AuditSinkType is the configuration key for the type of the sink streaming audit events: file or webhook
+	AuditSinkType = "audit.sink.type"
+
+	// AuditSinkTarget is the configuration key for the file path or the webhook URL of the audit sink
+	AuditSinkTarget = "audit.sink.target"
 )
 
 //Init initializes the configurations
@@ -90,6 +135,8 @@ func init() {
 	viper.SetDefault("auth.jwtissuer", "https://banzaicloud.com/")
 	viper.SetDefault("auth.jwtaudience", "https://pipeline.banzaicloud.com")
 	viper.SetDefault("auth.secureCookie", true)
+	viper.SetDefault(AuthTokenPurgeInterval, "1h")
+	viper.SetDefault(AuthInvitationTTL, "168h")
 
 	viper.SetDefault("pipeline.listenport", 9090)
 	viper.SetDefault("pipeline.certfile", "")
@@ -122,6 +169,16 @@ func init() {
 	viper.SetDefault(OKEWaitAttemptsForNodepoolActive, 60)
 	viper.SetDefault(OKESleepSecondsForNodepoolActive, 30)
 
+	viper.SetDefault(ClusterOperationWorkers, 10)
+	viper.SetDefault(ClusterOperationMaxAttempts, 3)
+	viper.SetDefault(ClusterDriftEnabled, false)
+	viper.SetDefault(ClusterDriftInterval, "10m")
+	viper.SetDefault(ClusterDriftReapply, false)
+
+	viper.SetDefault(SecretBackend, "vault")
+	viper.SetDefault(SecretDynamicCredentialsTTL, "1h")
+	viper.SetDefault(SecretRegistryRefreshInterval, "2m")
+
 	ReleaseName := os.Getenv("KUBERNETES_RELEASE_NAME")
 	if ReleaseName == "" {
 		ReleaseName = "pipeline"
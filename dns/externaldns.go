@@ -4,10 +4,12 @@ import (
 	"sync"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/banzaicloud/pipeline/config"
 	"github.com/banzaicloud/pipeline/dns/route53"
 	secretTypes "github.com/banzaicloud/pipeline/pkg/secret"
 	"github.com/banzaicloud/pipeline/secret"
+	"github.com/banzaicloud/pipeline/secret/dynamic"
 	"github.com/satori/go.uuid"
 	"github.com/spf13/cast"
 	"github.com/spf13/viper"
@@ -52,9 +54,17 @@ func newExternalDnsServiceClientInstance() {
 
 	// This is how the secrets are expected to be written in Vault:
 	// vault kv put secret/banzaicloud/aws AWS_REGION=... AWS_ACCESS_KEY_ID=... AWS_SECRET_ACCESS_KEY=...
+	// or, to assume a role with the credentials of Pipeline instead of storing keys:
+	// vault kv put secret/banzaicloud/aws AWS_REGION=... AWS_ROLE_ARN=... AWS_EXTERNAL_ID=...
 	awsCredentialsPath := viper.GetString(config.AwsCredentialPath)
 
-	secret, err := secret.Store.Logical.Read(awsCredentialsPath)
+	vaultStore, ok := secret.Backend.(*secret.VaultSecretStore)
+	if !ok {
+		log.Infoln("No AWS credentials for Route53 provided, they can only be read from Vault")
+		return
+	}
+
+	secret, err := vaultStore.Logical.Read(awsCredentialsPath)
 	if err != nil {
 		log.Errorf("Failed to read AWS credentials from Vault: %s", err.Error())
 		errCreate = err
@@ -68,16 +78,35 @@ func newExternalDnsServiceClientInstance() {
 
 	awsCredentials := cast.ToStringMapString(secret.Data["data"])
 	region := awsCredentials[secretTypes.AwsRegion]
-	awsSecretId := awsCredentials[secretTypes.AwsAccessKeyId]
-	awsSecretKey := awsCredentials[secretTypes.AwsSecretAccessKey]
 
-	if len(region) == 0 || len(awsSecretId) == 0 || len(awsSecretKey) == 0 {
+	if len(region) == 0 {
 		log.Infoln("No AWS credentials for Route53 provided in Vault")
 		return
 	}
 
+	var creds *credentials.Credentials
+
+	if roleArn := awsCredentials[secretTypes.AwsRoleArn]; len(roleArn) != 0 {
+		creds, err = dynamic.NewAmazonRoleCredentials(region, roleArn, awsCredentials[secretTypes.AwsExternalId])
+		if err != nil {
+			log.Errorf("Failed to create AWS role credentials for Route53: %s", err.Error())
+			errCreate = err
+			return
+		}
+	} else {
+		awsSecretId := awsCredentials[secretTypes.AwsAccessKeyId]
+		awsSecretKey := awsCredentials[secretTypes.AwsSecretAccessKey]
+
+		if len(awsSecretId) == 0 || len(awsSecretKey) == 0 {
+			log.Infoln("No AWS credentials for Route53 provided in Vault")
+			return
+		}
+
+		creds = credentials.NewStaticCredentials(awsSecretId, awsSecretKey, "")
+	}
+
 	dnsNotificationsChannel = make(chan interface{})
-	awsRoute53, err := route53.NewAwsRoute53(region, awsSecretId, awsSecretKey, dnsNotificationsChannel)
+	awsRoute53, err := route53.NewAwsRoute53(region, creds, dnsNotificationsChannel)
 	if err != nil {
 		errCreate = err
 
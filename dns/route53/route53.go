@@ -35,9 +35,11 @@ const (
 	createHostedZoneComment            = "HostedZone created by Banzaicloud Pipeline"
 	iamUserNameTemplate                = "banzaicloud.route53.%s"
 	hostedZoneAccessPolicyNameTemplate = "BanzaicloudRoute53-%s"
-	iamUserAccessKeySecretName         = "route53"
 )
 
+// IAMUserAccessKeySecretName is the name of the secret storing the access key of the Route53 IAM user
+const IAMUserAccessKeySecretName = "route53"
+
 func loggerWithFields(fields logrus.Fields) *logrus.Entry {
 	fields["tag"] = "AmazonRoute53"
 	log := logger.WithFields(fields)
@@ -126,7 +128,7 @@ type awsRoute53 struct {
 }
 
 // NewAwsRoute53 creates a new awsRoute53 using the provided region and route53 credentials
-func NewAwsRoute53(region, awsSecretId, awsSecretKey string, notifications chan interface{}) (*awsRoute53, error) {
+func NewAwsRoute53(region string, creds *credentials.Credentials, notifications chan interface{}) (*awsRoute53, error) {
 	log := loggerWithFields(logrus.Fields{"region": region})
 
 	baseDomain := viper.GetString(config.DNSBaseDomain)
@@ -135,8 +137,6 @@ func NewAwsRoute53(region, awsSecretId, awsSecretKey string, notifications chan
 		return nil, errors.New("base domain is not configured !")
 	}
 
-	creds := credentials.NewStaticCredentials(awsSecretId, awsSecretKey, "")
-
 	config := aws.NewConfig().
 		WithRegion(region).
 		WithCredentials(creds)
@@ -478,7 +478,7 @@ func (dns *awsRoute53) unregisterDomain(orgId uint, domain string) error {
 	}
 
 	for _, item := range secrets {
-		if item.Name == iamUserAccessKeySecretName {
+		if item.Name == IAMUserAccessKeySecretName {
 			if err := secret.Store.Delete(orgId, item.ID); err != nil {
 				dns.updateStateWithError(state, err)
 				return err
@@ -749,13 +749,13 @@ func (dns *awsRoute53) getRoute53Secret(orgId uint) (*secret.SecretItemResponse,
 	// route53 secret
 	var route53Secrets []*secret.SecretItemResponse
 	for _, awsAccessSecret := range awsAccessSecrets {
-		if awsAccessSecret.Name == iamUserAccessKeySecretName {
+		if awsAccessSecret.Name == IAMUserAccessKeySecretName {
 			route53Secrets = append(route53Secrets, awsAccessSecret)
 		}
 	}
 
 	if len(route53Secrets) > 1 {
-		return nil, fmt.Errorf("multiple secrets found with name '%s'", iamUserAccessKeySecretName)
+		return nil, fmt.Errorf("multiple secrets found with name '%s'", IAMUserAccessKeySecretName)
 	}
 
 	if len(route53Secrets) == 1 {
@@ -768,7 +768,7 @@ func (dns *awsRoute53) getRoute53Secret(orgId uint) (*secret.SecretItemResponse,
 // storeRoute53Secret stores the provided Amazon access key in Vault
 func (dns *awsRoute53) storeRoute53Secret(updateSecret *secret.SecretItemResponse, awsAccessKeyId, awsSecretAccessKey string, ctx *context) error {
 	req := &secret.CreateSecretRequest{
-		Name: iamUserAccessKeySecretName,
+		Name: IAMUserAccessKeySecretName,
 		Type: cluster.Amazon,
 		Tags: []string{
 			secretTypes.TagBanzaiHidden,
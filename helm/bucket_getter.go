@@ -0,0 +1,186 @@
+package helm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	azureStorage "github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2017-10-01/storage"
+	"github.com/Azure/azure-storage-blob-go/2016-05-31/azblob"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	helm2 "github.com/banzaicloud/pipeline/pkg/helm"
+	pkgSecret "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/banzaicloud/pipeline/secret"
+	"github.com/banzaicloud/pipeline/secret/verify"
+	"github.com/pkg/errors"
+	"google.golang.org/api/option"
+)
+
+// defaultBucketRegion is the region used to look up the region of S3 buckets
+const defaultBucketRegion = "us-east-1"
+
+// bucketGetter downloads the index and the charts of helm repositories hosted in object storage buckets
+// with the cloud secret of the repository
+type bucketGetter struct {
+	secret *secret.SecretItemResponse
+}
+
+// Get returns the content of the object the s3://<bucket>/<key>, gs://<bucket>/<key>
+// or azblob://<storage account>/<container>/<key> URL points to
+func (g *bucketGetter) Get(href string) (*bytes.Buffer, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid bucket url %s", href)
+	}
+
+	key := strings.TrimPrefix(u.Path, "/")
+
+	var buf *bytes.Buffer
+	switch u.Scheme {
+	case helm2.S3RepoScheme:
+		buf, err = g.getS3Object(u.Host, key)
+	case helm2.GCSRepoScheme:
+		buf, err = g.getGCSObject(u.Host, key)
+	case helm2.AzureRepoScheme:
+		parts := strings.SplitN(key, "/", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("invalid azure blob url %s", href)
+		}
+		buf, err = g.getAzureBlob(u.Host, parts[0], parts[1])
+	default:
+		return nil, errors.Errorf("unsupported bucket url scheme %q", u.Scheme)
+	}
+
+	return buf, errors.Wrapf(err, "could not get %s", href)
+}
+
+func readBucketObject(body io.ReadCloser) (*bytes.Buffer, error) {
+	defer body.Close()
+
+	buf := bytes.NewBuffer(nil)
+	_, err := io.Copy(buf, body)
+
+	return buf, err
+}
+
+func (g *bucketGetter) getS3Object(bucket string, key string) (*bytes.Buffer, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Credentials: verify.CreateAWSCredentials(g.secret.Values),
+		Region:      aws.String(defaultBucketRegion),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	region, err := s3manager.GetBucketRegion(context.Background(), sess, bucket, defaultBucketRegion)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get bucket region")
+	}
+
+	object, err := s3.New(sess, aws.NewConfig().WithRegion(region)).GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return readBucketObject(object.Body)
+}
+
+func (g *bucketGetter) getGCSObject(bucket string, key string) (*bytes.Buffer, error) {
+	ctx := context.Background()
+
+	credentials, err := verify.CreateGoogleCredentials(ctx, verify.CreateServiceAccount(g.secret.Values), storage.ScopeReadOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := storage.NewClient(ctx, option.WithCredentials(credentials))
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	reader, err := client.Bucket(bucket).Object(key).NewReader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return readBucketObject(reader)
+}
+
+func (g *bucketGetter) getAzureBlob(storageAccount string, container string, key string) (*bytes.Buffer, error) {
+	accountKey, err := g.getAzureStorageAccountKey(storageAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	blobURL, err := url.Parse(fmt.Sprintf("https://%s.blob.core.windows.net/%s/%s", storageAccount, container, key))
+	if err != nil {
+		return nil, err
+	}
+
+	p := azblob.NewPipeline(azblob.NewSharedKeyCredential(storageAccount, accountKey), azblob.PipelineOptions{})
+
+	blob, err := azblob.NewBlobURL(*blobURL, p).GetBlob(context.Background(), azblob.BlobRange{}, azblob.BlobAccessConditions{}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return readBucketObject(blob.Body())
+}
+
+// getAzureStorageAccountKey looks up the storage account in the subscription of the secret and returns its first key
+func (g *bucketGetter) getAzureStorageAccountKey(storageAccount string) (string, error) {
+	authorizer, err := auth.NewClientCredentialsConfig(
+		g.secret.GetValue(pkgSecret.AzureClientId),
+		g.secret.GetValue(pkgSecret.AzureClientSecret),
+		g.secret.GetValue(pkgSecret.AzureTenantId)).Authorizer()
+	if err != nil {
+		return "", errors.Wrap(err, "error happened during authentication")
+	}
+
+	client := azureStorage.NewAccountsClient(g.secret.GetValue(pkgSecret.AzureSubscriptionId))
+	client.Authorizer = authorizer
+
+	accounts, err := client.List(context.Background())
+	if err != nil {
+		return "", errors.Wrap(err, "could not list storage accounts")
+	}
+
+	if accounts.Value != nil {
+		for _, account := range *accounts.Value {
+			if account.Name == nil || *account.Name != storageAccount || account.ID == nil {
+				continue
+			}
+
+			// the ID of the account is /subscriptions/<subscription>/resourceGroups/<resource group>/providers/...
+			parts := strings.Split(*account.ID, "/")
+			if len(parts) < 5 {
+				break
+			}
+
+			keys, err := client.ListKeys(context.Background(), parts[4], storageAccount)
+			if err != nil {
+				return "", errors.Wrap(err, "error retrieving keys for StorageAccount")
+			}
+
+			if keys.Keys == nil || len(*keys.Keys) == 0 {
+				return "", errors.Errorf("storage account %s has no keys", storageAccount)
+			}
+
+			return *(*keys.Keys)[0].Value, nil
+		}
+	}
+
+	return "", errors.Errorf("storage account %s not found", storageAccount)
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
 	"strings"
@@ -16,6 +17,9 @@ import (
 	"time"
 
 	"github.com/Masterminds/sprig"
+	"github.com/banzaicloud/pipeline/config"
+	intHelm "github.com/banzaicloud/pipeline/internal/helm"
+	"github.com/banzaicloud/pipeline/model"
 	helm2 "github.com/banzaicloud/pipeline/pkg/helm"
 	"github.com/banzaicloud/pipeline/utils"
 	"github.com/pkg/errors"
@@ -23,7 +27,6 @@ import (
 	"k8s.io/api/extensions/v1beta1"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/helm/pkg/chartutil"
-	"k8s.io/helm/pkg/getter"
 	"k8s.io/helm/pkg/helm"
 	helm_env "k8s.io/helm/pkg/helm/environment"
 	"k8s.io/helm/pkg/proto/hapi/chart"
@@ -178,8 +181,8 @@ func getRequestedChart(releaseName, chartName, chartVersion string, chartPackage
 	return requestedChart, err
 }
 
-//UpgradeDeployment upgrades a Helm deployment
-func UpgradeDeployment(releaseName, chartName, chartVersion string, chartPackage []byte, values []byte, reuseValues bool, kubeConfig []byte, env helm_env.EnvSettings) (*rls.UpdateReleaseResponse, error) {
+//UpgradeDeployment upgrades a Helm deployment, in dry run mode the release is only rendered
+func UpgradeDeployment(releaseName, chartName, chartVersion string, chartPackage []byte, values []byte, reuseValues bool, dryRun bool, kubeConfig []byte, env helm_env.EnvSettings) (*rls.UpdateReleaseResponse, error) {
 
 	chartRequested, err := getRequestedChart(releaseName, chartName, chartVersion, chartPackage, env)
 	if err != nil {
@@ -195,7 +198,7 @@ func UpgradeDeployment(releaseName, chartName, chartVersion string, chartPackage
 		releaseName,
 		chartRequested,
 		helm.UpdateValueOverrides(values),
-		helm.UpgradeDryRun(false),
+		helm.UpgradeDryRun(dryRun),
 		//helm.ResetValues(u.resetValues),
 		helm.ReuseValues(reuseValues),
 	)
@@ -205,8 +208,8 @@ func UpgradeDeployment(releaseName, chartName, chartVersion string, chartPackage
 	return upgradeRes, nil
 }
 
-//CreateDeployment creates a Helm deployment in chosen namespace
-func CreateDeployment(chartName, chartVersion string, chartPackage []byte, namespace string, releaseName string, valueOverrides []byte, kubeConfig []byte, env helm_env.EnvSettings) (*rls.InstallReleaseResponse, error) {
+//CreateDeployment creates a Helm deployment in chosen namespace, in dry run mode the release is only rendered
+func CreateDeployment(chartName, chartVersion string, chartPackage []byte, namespace string, releaseName string, dryRun bool, valueOverrides []byte, kubeConfig []byte, env helm_env.EnvSettings) (*rls.InstallReleaseResponse, error) {
 
 	chartRequested, err := getRequestedChart(releaseName, chartName, chartVersion, chartPackage, env)
 	if err != nil {
@@ -229,7 +232,7 @@ func CreateDeployment(chartName, chartVersion string, chartPackage []byte, names
 		namespace,
 		helm.ValueOverrides(valueOverrides),
 		helm.ReleaseName(releaseName),
-		helm.InstallDryRun(false),
+		helm.InstallDryRun(dryRun),
 		helm.InstallReuseName(true),
 		helm.InstallDisableHooks(false),
 		helm.InstallTimeout(30),
@@ -371,9 +374,26 @@ func GetDeployment(releaseName string, kubeConfig []byte) (*helm2.GetDeploymentR
 		ChartName:    releaseContent.GetRelease().GetChart().GetMetadata().GetName(),
 		ChartVersion: releaseContent.GetRelease().GetChart().GetMetadata().GetVersion(),
 		Values:       values,
+		Manifest:     releaseContent.GetRelease().GetManifest(),
 	}, nil
 }
 
+// DiffDeployment compares the manifest of a helm deployment with the one an upgrade
+// to the given chart version and values would render
+func DiffDeployment(releaseName, chartName, chartVersion string, chartPackage []byte, values []byte, reuseValues bool, kubeConfig []byte, env helm_env.EnvSettings) ([]helm2.DeploymentManifestDiff, error) {
+	deployment, err := GetDeployment(releaseName, kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	upgradeRes, err := UpgradeDeployment(releaseName, chartName, chartVersion, chartPackage, values, reuseValues, true, kubeConfig, env)
+	if err != nil {
+		return nil, err
+	}
+
+	return helm2.DiffManifests(deployment.Manifest, upgradeRes.GetRelease().GetManifest())
+}
+
 // GetDeploymentStatus retrieves the status of the passed in release name.
 // returns with an error if the release is not found or another error occurs
 // in case of error the status is filled with information to classify the error cause
@@ -397,6 +417,73 @@ func GetDeploymentStatus(releaseName string, kubeConfig []byte) (int32, error) {
 
 }
 
+// maxDeploymentHistory is the maximum number of revisions returned in the history of a deployment
+const maxDeploymentHistory = 256
+
+// defaultRollbackTimeout is the timeout of rollbacks in seconds when none is given
+const defaultRollbackTimeout = 300
+
+// GetDeploymentHistory returns the revisions of a helm deployment, latest first
+func GetDeploymentHistory(releaseName string, kubeConfig []byte) ([]helm2.DeploymentHistoryItem, error) {
+	helmClient, err := GetHelmClient(kubeConfig)
+	if err != nil {
+		log.Errorf("Getting Helm client failed: %s", err.Error())
+		return nil, err
+	}
+
+	historyResponse, err := helmClient.ReleaseHistory(releaseName, helm.WithMaxHistory(maxDeploymentHistory))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, &DeploymentNotFoundError{HelmError: err}
+		}
+		return nil, err
+	}
+
+	history := make([]helm2.DeploymentHistoryItem, 0, len(historyResponse.GetReleases()))
+	for _, release := range historyResponse.GetReleases() {
+		history = append(history, helm2.DeploymentHistoryItem{
+			Revision:     release.GetVersion(),
+			Chart:        GetVersionedChartName(release.GetChart().GetMetadata().GetName(), release.GetChart().GetMetadata().GetVersion()),
+			ChartName:    release.GetChart().GetMetadata().GetName(),
+			ChartVersion: release.GetChart().GetMetadata().GetVersion(),
+			Status:       release.GetInfo().GetStatus().GetCode().String(),
+			Updated:      utils.ConvertSecondsToTime(time.Unix(release.GetInfo().GetLastDeployed().GetSeconds(), 0)),
+			Description:  release.GetInfo().GetDescription(),
+		})
+	}
+
+	return history, nil
+}
+
+// RollbackDeployment rolls back a helm deployment to the given revision,
+// if wait is set it waits until the resources of the deployment are ready or the timeout (in seconds) elapses
+func RollbackDeployment(releaseName string, revision int32, wait bool, timeout int64, kubeConfig []byte) (*rls.RollbackReleaseResponse, error) {
+	helmClient, err := GetHelmClient(kubeConfig)
+	if err != nil {
+		log.Errorf("Getting Helm client failed: %s", err.Error())
+		return nil, err
+	}
+
+	if timeout <= 0 {
+		timeout = defaultRollbackTimeout
+	}
+
+	rollbackResponse, err := helmClient.RollbackRelease(
+		releaseName,
+		helm.RollbackVersion(revision),
+		helm.RollbackWait(wait),
+		helm.RollbackTimeout(timeout),
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, &DeploymentNotFoundError{HelmError: err}
+		}
+		return nil, errors.Wrapf(err, "rollback of %s to revision %d failed", releaseName, revision)
+	}
+
+	return rollbackResponse, nil
+}
+
 func generateName(nameTemplate string) (string, error) {
 	t, err := template.New("name-template").Funcs(sprig.TxtFuncMap()).Parse(nameTemplate)
 	if err != nil {
@@ -433,7 +520,8 @@ func checkDependencies(ch *chart.Chart, reqs *chartutil.Requirements) error {
 	return nil
 }
 
-func mergeValues(dest map[string]interface{}, src map[string]interface{}) map[string]interface{} {
+// MergeValues merges the source values into the destination values recursively, source values take precedence
+func MergeValues(dest map[string]interface{}, src map[string]interface{}) map[string]interface{} {
 	for k, v := range src {
 		// If the key doesn't exist already, then just set the key to that value
 		if _, exists := dest[k]; !exists {
@@ -459,7 +547,7 @@ func mergeValues(dest map[string]interface{}, src map[string]interface{}) map[st
 			continue
 		}
 		// If we got to this point, it is a map in both, so merge them
-		dest[k] = mergeValues(destMap, nextMap)
+		dest[k] = MergeValues(destMap, nextMap)
 	}
 	return dest
 }
@@ -481,155 +569,247 @@ func ReposGet(env helm_env.EnvSettings) ([]*repo.Entry, error) {
 	return f.Repositories, nil
 }
 
-// ReposAdd adds repo(s)
-func ReposAdd(env helm_env.EnvSettings, Hrepo *repo.Entry) (bool, error) {
-	repoFile := env.Home.RepositoryFile()
-	var f *repo.RepoFile
-	if _, err := os.Stat(repoFile); err != nil {
-		log.Infof("Creating %s", repoFile)
-		f = repo.NewRepoFile()
-	} else {
-		f, err = repo.LoadRepositoriesFile(repoFile)
-		if err != nil {
-			return false, errors.Wrap(err, "Cannot create a new ChartRepo")
-		}
-		log.Debugf("Profile file %q loaded.", repoFile)
+// ReposAdd adds repo(s), the credentials of private repositories are read from the referenced secrets
+func ReposAdd(env helm_env.EnvSettings, Hrepo *repo.Entry, secrets *helm2.RepoSecrets) (bool, error) {
+	organizationID, err := homeOrganizationID(env)
+	if err != nil {
+		return false, err
 	}
 
-	for _, n := range f.Repositories {
-		log.Debugf("repo: %s", n.Name)
-		if n.Name == Hrepo.Name {
-			return false, nil
-		}
+	repositories := intHelm.NewRepositories(config.DB())
+
+	models, err := repositories.FindByOrganization(organizationID)
+	if err != nil {
+		return false, err
 	}
 
-	c := repo.Entry{
-		Name:  Hrepo.Name,
-		URL:   Hrepo.URL,
-		Cache: env.Home.CacheIndex(Hrepo.Name),
+	if findRepository(models, Hrepo.Name) != nil {
+		return false, nil
 	}
-	r, err := repo.NewChartRepository(&c, getter.All(env))
-	if err != nil {
-		return false, errors.Wrap(err, "Cannot create a new ChartRepo")
+
+	repository := &model.HelmRepositoryModel{
+		OrganizationID: organizationID,
+		Name:           Hrepo.Name,
+		URL:            Hrepo.URL,
 	}
-	log.Debugf("New repo added: %s", Hrepo.Name)
+	setRepoSecrets(repository, secrets)
 
-	errIdx := r.DownloadIndexFile("")
-	if errIdx != nil {
-		return false, errors.Wrap(errIdx, "Repo index download failed")
+	if err := downloadRepoIndex(env, append(models, repository), repository); err != nil {
+		return false, err
 	}
-	f.Add(&c)
-	if errW := f.WriteFile(repoFile, 0644); errW != nil {
-		return false, errors.Wrap(errW, "Cannot write helm repo profile file")
+
+	if err := repositories.Save(repository); err != nil {
+		return false, err
+	}
+	log.Debugf("New repo added: %s", Hrepo.Name)
+
+	if err := writeRepositoriesFile(env, append(models, repository)); err != nil {
+		return false, err
 	}
 	return true, nil
 }
 
 // ReposDelete deletes repo(s)
 func ReposDelete(env helm_env.EnvSettings, repoName string) error {
-	repoFile := env.Home.RepositoryFile()
-	log.Debugf("Repo File: %s", repoFile)
+	organizationID, err := homeOrganizationID(env)
+	if err != nil {
+		return err
+	}
 
-	r, err := repo.LoadRepositoriesFile(repoFile)
+	repositories := intHelm.NewRepositories(config.DB())
+
+	models, err := repositories.FindByOrganization(organizationID)
 	if err != nil {
 		return err
 	}
 
-	if !r.Remove(repoName) {
+	if findRepository(models, repoName) == nil {
 		return ErrRepoNotFound
 	}
-	if err := r.WriteFile(repoFile, 0644); err != nil {
+
+	if err := repositories.Delete(organizationID, repoName); err != nil {
 		return err
 	}
 
-	if _, err := os.Stat(env.Home.CacheIndex(repoName)); err == nil {
-		err = os.Remove(env.Home.CacheIndex(repoName))
-		if err != nil {
-			return err
+	if err := removeRepoIndex(env, repoName); err != nil {
+		return err
+	}
+
+	remaining := make([]*model.HelmRepositoryModel, 0, len(models))
+	for _, repository := range models {
+		if repository.Name != repoName {
+			remaining = append(remaining, repository)
 		}
 	}
-	return nil
 
+	return writeRepositoriesFile(env, remaining)
 }
 
-// ReposModify modifies repo(s)
-func ReposModify(env helm_env.EnvSettings, repoName string, newRepo *repo.Entry) error {
+// ReposModify modifies repo(s), the secrets of the repository are replaced with the passed ones
+func ReposModify(env helm_env.EnvSettings, repoName string, newRepo *repo.Entry, secrets *helm2.RepoSecrets) error {
 
 	log.Debug("ReposModify")
-	repoFile := env.Home.RepositoryFile()
-	log.Debugf("Repo File: %s", repoFile)
 	log.Debugf("New repo content: %#v", newRepo)
 
-	f, err := repo.LoadRepositoriesFile(repoFile)
+	organizationID, err := homeOrganizationID(env)
 	if err != nil {
 		return err
 	}
 
-	if !f.Has(repoName) {
+	repositories := intHelm.NewRepositories(config.DB())
+
+	models, err := repositories.FindByOrganization(organizationID)
+	if err != nil {
+		return err
+	}
+
+	repository := findRepository(models, repoName)
+	if repository == nil {
 		return ErrRepoNotFound
 	}
 
-	var formerRepo *repo.Entry
-	repos := f.Repositories
-	for _, r := range repos {
-		if r.Name == repoName {
-			formerRepo = r
-		}
+	if len(newRepo.Name) == 0 {
+		newRepo.Name = repository.Name
+		log.Infof("new repo name field is empty, replaced with: %s", repository.Name)
 	}
 
-	if formerRepo != nil {
-		if len(newRepo.Name) == 0 {
-			newRepo.Name = formerRepo.Name
-			log.Infof("new repo name field is empty, replaced with: %s", formerRepo.Name)
-		}
+	if len(newRepo.URL) == 0 {
+		newRepo.URL = repository.URL
+		log.Infof("new repo url field is empty, replaced with: %s", repository.URL)
+	}
+
+	if newRepo.Name != repoName && findRepository(models, newRepo.Name) != nil {
+		return errors.Errorf("helm repository %s already exists", newRepo.Name)
+	}
 
-		if len(newRepo.URL) == 0 {
-			newRepo.URL = formerRepo.URL
-			log.Infof("new repo url field is empty, replaced with: %s", formerRepo.URL)
+	if newRepo.Name != repoName || newRepo.URL != repository.URL {
+		// the cached index belongs to the former repository
+		if err := repositories.DeleteCaches(organizationID, repoName); err != nil {
+			return err
 		}
 
-		if len(newRepo.Cache) == 0 {
-			newRepo.Cache = formerRepo.Cache
-			log.Infof("new repo cache field is empty, replaced with: %s", formerRepo.Cache)
+		if err := removeRepoIndex(env, repoName); err != nil {
+			return err
 		}
 	}
 
-	f.Update(newRepo)
+	repository.Name = newRepo.Name
+	repository.URL = newRepo.URL
+	setRepoSecrets(repository, secrets)
 
-	if errW := f.WriteFile(repoFile, 0644); errW != nil {
-		return errors.Wrap(errW, "Cannot write helm repo profile file")
+	if err := repositories.Save(repository); err != nil {
+		return err
 	}
-	return nil
+
+	return writeRepositoriesFile(env, models)
 }
 
 // ReposUpdate updates a repo(s)
 func ReposUpdate(env helm_env.EnvSettings, repoName string) error {
 
-	repoFile := env.Home.RepositoryFile()
-	log.Debugf("Repo File: %s", repoFile)
+	models, err := findRepositories(env)
+	if err != nil {
+		return errors.Wrap(err, "Load ChartRepo")
+	}
+
+	if repository := findRepository(models, repoName); repository != nil {
+		return downloadRepoIndex(env, models, repository)
+	}
 
-	f, err := repo.LoadRepositoriesFile(repoFile)
+	return ErrRepoNotFound
+}
 
+// downloadRepoIndex downloads the index of a repository to its cache and stores it in the shared cache,
+// private repositories are accessed with their credentials
+func downloadRepoIndex(env helm_env.EnvSettings, repositories []*model.HelmRepositoryModel, repository *model.HelmRepositoryModel) error {
+	getters, cleanup, err := repoGetters(env, repositories)
 	if err != nil {
-		return errors.Wrap(err, "Load ChartRepo")
+		return err
 	}
+	defer cleanup()
 
-	for _, cfg := range f.Repositories {
-		if cfg.Name == repoName {
-			c, err := repo.NewChartRepository(cfg, getter.All(env))
-			if err != nil {
-				return errors.Wrap(err, "Cannot get ChartRepo")
-			}
-			errIdx := c.DownloadIndexFile("")
-			if errIdx != nil {
-				return errors.Wrap(errIdx, "Repo index download failed")
-			}
-			return nil
+	entry := &repo.Entry{
+		Name:  repository.Name,
+		URL:   repository.URL,
+		Cache: env.Home.CacheIndex(repository.Name),
+	}
+
+	c, err := repo.NewChartRepository(entry, getters)
+	if err != nil {
+		return errors.Wrap(err, "Cannot get ChartRepo")
+	}
+
+	if err := c.DownloadIndexFile(""); err != nil {
+		return errors.Wrap(err, "Repo index download failed")
+	}
+
+	content, err := ioutil.ReadFile(entry.Cache)
+	if err != nil {
+		return errors.Wrap(err, "could not read repository index")
+	}
+
+	return saveRepoCache(entry.Cache, repository.OrganizationID, repository.Name, repoIndexFile, content)
+}
 
+// loadRepoIndex loads the index of a repository from its cache, the index is downloaded if it is not cached yet
+func loadRepoIndex(env helm_env.EnvSettings, repositories []*model.HelmRepositoryModel, repository *model.HelmRepositoryModel) (*repo.IndexFile, error) {
+	i, err := repo.LoadIndexFile(env.Home.CacheIndex(repository.Name))
+	if os.IsNotExist(err) {
+		log.Infof("Index of repository %s is not cached, downloading it", repository.Name)
+		if err = downloadRepoIndex(env, repositories, repository); err == nil {
+			i, err = repo.LoadIndexFile(env.Home.CacheIndex(repository.Name))
 		}
 	}
 
-	return ErrRepoNotFound
+	return i, err
+}
+
+// removeRepoIndex removes the local index of a repository
+func removeRepoIndex(env helm_env.EnvSettings, repoName string) error {
+	if err := os.Remove(env.Home.CacheIndex(repoName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// downloadRepoChart downloads a chart of a repository, relative chart URLs are resolved against the repository URL
+func downloadRepoChart(env helm_env.EnvSettings, repositories []*model.HelmRepositoryModel, repository *model.HelmRepositoryModel, chartURL string) ([]byte, error) {
+	u, err := url.Parse(chartURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid chart url %s", chartURL)
+	}
+
+	if !u.IsAbs() {
+		repoURL, err := url.Parse(strings.TrimSuffix(repository.URL, "/") + "/")
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid repository url %s", repository.URL)
+		}
+		u = repoURL.ResolveReference(u)
+	}
+
+	getters, cleanup, err := repoGetters(env, repositories)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	constructor, err := getters.ByScheme(u.Scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := constructor(u.String(), "", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := g.Get(u.String())
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not download chart %s", u.String())
+	}
+
+	return buf.Bytes(), nil
 }
 
 // ChartList describe a chart list
@@ -640,20 +820,19 @@ type ChartList struct {
 
 // ChartsGet returns chart list
 func ChartsGet(env helm_env.EnvSettings, queryName, queryRepo, queryVersion, queryKeyword string) ([]ChartList, error) {
-	log.Debugf("Helm repo path %s", env.Home.RepositoryFile())
-	f, err := repo.LoadRepositoriesFile(env.Home.RepositoryFile())
+	models, err := findRepositories(env)
 	if err != nil {
 		return nil, err
 	}
-	if len(f.Repositories) == 0 {
+	if len(models) == 0 {
 		return nil, nil
 	}
 	cl := make([]ChartList, 0)
 
-	for _, r := range f.Repositories {
+	for _, r := range models {
 
 		log.Debugf("Repository: %s", r.Name)
-		i, errIndx := repo.LoadIndexFile(r.Cache)
+		i, errIndx := loadRepoIndex(env, models, r)
 		if errIndx != nil {
 			return nil, errIndx
 		}
@@ -706,24 +885,22 @@ type ChartVersion struct {
 // ChartGet returns chart details
 func ChartGet(env helm_env.EnvSettings, chartRepo, chartName, chartVersion string) (details *ChartDetails, err error) {
 
-	repoPath := env.Home.RepositoryFile()
-	log.Debugf("Helm repo path: %s", repoPath)
-	var f *repo.RepoFile
-	f, err = repo.LoadRepositoriesFile(repoPath)
+	var models []*model.HelmRepositoryModel
+	models, err = findRepositories(env)
 	if err != nil {
 		return
 	}
 
-	if len(f.Repositories) == 0 {
+	if len(models) == 0 {
 		return
 	}
 
-	for _, repository := range f.Repositories {
+	for _, repository := range models {
 
 		log.Debugf("Repository: %s", repository.Name)
 
 		var i *repo.IndexFile
-		i, err = repo.LoadIndexFile(repository.Cache)
+		i, err = loadRepoIndex(env, models, repository)
 		if err != nil {
 			return
 		}
@@ -743,7 +920,7 @@ func ChartGet(env helm_env.EnvSettings, chartRepo, chartName, chartVersion strin
 						if v.Version == chartVersion || chartVersion == "" {
 
 							var ver *ChartVersion
-							ver, err = getChartVersion(v)
+							ver, err = getChartVersion(env, models, repository, v)
 							if err != nil {
 								return
 							}
@@ -752,7 +929,7 @@ func ChartGet(env helm_env.EnvSettings, chartRepo, chartName, chartVersion strin
 							return
 						} else if chartVersion == versionAll {
 							var ver *ChartVersion
-							ver, err = getChartVersion(v)
+							ver, err = getChartVersion(env, models, repository, v)
 							if err != nil {
 								log.Warnf("error during getting chart[%s - %s]: %s", v.Name, v.Version, err.Error())
 							} else {
@@ -772,12 +949,12 @@ func ChartGet(env helm_env.EnvSettings, chartRepo, chartName, chartVersion strin
 	return
 }
 
-func getChartVersion(v *repo.ChartVersion) (*ChartVersion, error) {
+func getChartVersion(env helm_env.EnvSettings, repositories []*model.HelmRepositoryModel, repository *model.HelmRepositoryModel, v *repo.ChartVersion) (*ChartVersion, error) {
 	log.Infof("get chart[%s - %s]", v.Name, v.Version)
 
 	chartSource := v.URLs[0]
 	log.Debugf("chartSource: %s", chartSource)
-	reader, err := DownloadFile(chartSource)
+	reader, err := downloadRepoChart(env, repositories, repository, chartSource)
 	if err != nil {
 		return nil, err
 	}
@@ -2,12 +2,15 @@ package helm
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/banzaicloud/pipeline/config"
+	intHelm "github.com/banzaicloud/pipeline/internal/helm"
+	"github.com/banzaicloud/pipeline/model"
 	"github.com/banzaicloud/pipeline/pkg/helm"
 	phelm "github.com/banzaicloud/pipeline/pkg/helm"
 	"github.com/pkg/errors"
@@ -18,7 +21,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/helm/cmd/helm/installer"
 	"k8s.io/helm/pkg/downloader"
-	"k8s.io/helm/pkg/getter"
 	helm_env "k8s.io/helm/pkg/helm/environment"
 	"k8s.io/helm/pkg/helm/helmpath"
 	"k8s.io/helm/pkg/repo"
@@ -166,31 +168,74 @@ func CreateEnvSettings(helmRepoHome string) helm_env.EnvSettings {
 	return settings
 }
 
-// GenerateHelmRepoEnv Generate helm path based on orgName
-func GenerateHelmRepoEnv(orgName string) (env helm_env.EnvSettings) {
+// GenerateHelmRepoEnv Generate helm path based on orgName,
+// the local helm home is rebuilt from the repositories of the organization stored in the database
+// at most once in the sync interval
+func GenerateHelmRepoEnv(orgName string, orgID uint) (env helm_env.EnvSettings) {
 	var helmPath = config.GetHelmPath(orgName)
 	env = CreateEnvSettings(fmt.Sprintf("%s/%s", helmPath, phelm.HelmPostFix))
 
+	home := getHelmHome(env, orgID)
+
+	home.Lock()
+	defer home.Unlock()
+
 	// check local helm
 	if _, err := os.Stat(helmPath); os.IsNotExist(err) {
 		log.Infof("Helm directories [%s] not exists", helmPath)
-		InstallLocalHelm(env)
+		if err := installLocalHelm(env, home); err != nil {
+			log.Errorf("Error during installing local helm of %s: %s", orgName, err.Error())
+		}
+	}
+
+	if home.needsSync(time.Now()) {
+		if err := syncHelmHome(env, home); err != nil {
+			log.Errorf("Error during syncing helm repositories of %s: %s", orgName, err.Error())
+		}
 	}
 
 	return
 }
 
-// DownloadChartFromRepo download a given chart
+// DownloadChartFromRepo download a given chart, private repositories are accessed with the credentials of their secrets,
+// the charts of repositories are shared between Pipeline instances through the database
 func DownloadChartFromRepo(name, version string, env helm_env.EnvSettings) (string, error) {
+	repositories, err := findRepositories(env)
+	if err != nil {
+		return "", errors.Wrap(err, "Load ChartRepo")
+	}
+
+	getters, cleanup, err := repoGetters(env, repositories)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
 	dl := downloader.ChartDownloader{
 		HelmHome: env.Home,
-		Getters:  getter.All(env),
+		Getters:  getters,
 	}
 	if _, err := os.Stat(env.Home.Archive()); os.IsNotExist(err) {
 		log.Infof("Creating '%s' directory.", env.Home.Archive())
 		os.MkdirAll(env.Home.Archive(), 0744)
 	}
 
+	if parts := strings.SplitN(name, "/", 2); len(parts) == 2 {
+		if repository := findRepository(repositories, parts[0]); repository != nil {
+			index, err := loadRepoIndex(env, repositories, repository)
+			if err != nil {
+				return "", errors.Wrapf(err, "Failed to load index of repository %q", repository.Name)
+			}
+
+			chartVersion, err := index.Get(parts[1], version)
+			if err != nil {
+				return "", errors.Wrapf(err, "Failed to download chart %q, version %q", name, version)
+			}
+
+			return downloadCachedChart(dl, repository, chartVersion)
+		}
+	}
+
 	log.Infof("Downloading helm chart %q, version %q to %q", name, version, env.Home.Archive())
 	filename, _, err := dl.DownloadTo(name, version, env.Home.Archive())
 	if err == nil {
@@ -205,6 +250,65 @@ func DownloadChartFromRepo(name, version string, env helm_env.EnvSettings) (stri
 	return filename, errors.Wrapf(err, "Failed to download chart %q, version %q", name, version)
 }
 
+// downloadCachedChart returns the local archive of a chart version, the archive is taken from the shared cache
+// or downloaded from the repository and stored in the shared cache
+func downloadCachedChart(dl downloader.ChartDownloader, repository *model.HelmRepositoryModel, chartVersion *repo.ChartVersion) (string, error) {
+	name := fmt.Sprintf("%s/%s", repository.Name, chartVersion.Name)
+	file := fmt.Sprintf("%s-%s.tgz", chartVersion.Name, chartVersion.Version)
+	dir := filepath.Join(dl.HelmHome.Archive(), repository.Name)
+
+	filename, err := filepath.Abs(filepath.Join(dir, file))
+	if err != nil {
+		return "", errors.Wrapf(err, "Could not create absolute path from %s", file)
+	}
+
+	if _, err := os.Stat(filename); err == nil {
+		log.Debugf("Helm chart %q, version %q found at %q", name, chartVersion.Version, filename)
+		return filename, nil
+	}
+
+	ok, err := loadRepoCache(filename, repository.OrganizationID, repository.Name, file)
+	if err != nil {
+		log.Warnf("could not load helm chart %q, version %q from cache: %s", name, chartVersion.Version, err.Error())
+	} else if ok {
+		log.Debugf("Fetched helm chart %q, version %q from cache to %q", name, chartVersion.Version, filename)
+		return filename, nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", errors.Wrapf(err, "Could not create '%s'", dir)
+	}
+
+	log.Infof("Downloading helm chart %q, version %q to %q", name, chartVersion.Version, dir)
+	downloaded, _, err := dl.DownloadTo(name, chartVersion.Version, dir)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to download chart %q, version %q", name, chartVersion.Version)
+	}
+
+	if downloaded != filename {
+		if err := os.Rename(downloaded, filename); err != nil {
+			return "", errors.Wrapf(err, "Could not move chart to %s", filename)
+		}
+	}
+	log.Debugf("Fetched helm chart %q, version %q to %q", name, chartVersion.Version, filename)
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", errors.Wrapf(err, "Could not read %s", filename)
+	}
+
+	if err := saveRepoCache(filename, repository.OrganizationID, repository.Name, file, content); err != nil {
+		log.Warnf("could not cache helm chart %q, version %q: %s", name, chartVersion.Version, err.Error())
+	}
+
+	err = intHelm.NewRepositories(config.DB()).DeleteStaleCaches(repository.OrganizationID, repository.Name, maxCachedCharts, repoIndexFile)
+	if err != nil {
+		log.Warnf("could not evict helm charts of repository %q from cache: %s", repository.Name, err.Error())
+	}
+
+	return filename, nil
+}
+
 // InstallHelmClient Installs helm client on a given path
 func InstallHelmClient(env helm_env.EnvSettings) error {
 	if err := EnsureDirectories(env); err != nil {
@@ -256,7 +360,8 @@ func ensureDefaultRepos(env helm_env.EnvSettings) error {
 			Name:  phelm.StableRepository,
 			URL:   stableRepositoryURL,
 			Cache: env.Home.CacheIndex(phelm.StableRepository),
-		})
+		},
+		nil)
 	if err != nil {
 		return errors.Wrapf(err, "cannot init repo: %s", phelm.StableRepository)
 	}
@@ -266,24 +371,35 @@ func ensureDefaultRepos(env helm_env.EnvSettings) error {
 			Name:  phelm.BanzaiRepository,
 			URL:   banzaiRepositoryURL,
 			Cache: env.Home.CacheIndex(phelm.BanzaiRepository),
-		})
+		},
+		nil)
 	if err != nil {
 		return errors.Wrapf(err, "cannot init repo: %s", phelm.BanzaiRepository)
 	}
 	return nil
 }
 
-// InstallLocalHelm install helm into the given path
+// InstallLocalHelm install helm into the given path, the helm home has to be generated for an organization
 func InstallLocalHelm(env helm_env.EnvSettings) error {
+	home, err := findHelmHome(env)
+	if err != nil {
+		return err
+	}
+
+	home.Lock()
+	defer home.Unlock()
+
+	return installLocalHelm(env, home)
+}
+
+// installLocalHelm installs helm into the local helm home and syncs its repositories
+func installLocalHelm(env helm_env.EnvSettings, home *helmHome) error {
 	if err := InstallHelmClient(env); err != nil {
 		return err
 	}
 	log.Info("Helm client install succeeded")
 
-	if err := ensureDefaultRepos(env); err != nil {
-		return errors.Wrap(err, "Setting up default repos failed!")
-	}
-	return nil
+	return syncHelmHome(env, home)
 }
 
 // Install uses Kubernetes client to install Tiller.
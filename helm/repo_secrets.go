@@ -0,0 +1,277 @@
+package helm
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/banzaicloud/pipeline/model"
+	"github.com/banzaicloud/pipeline/pkg/cluster"
+	helm2 "github.com/banzaicloud/pipeline/pkg/helm"
+	pkgSecret "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/banzaicloud/pipeline/secret"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"k8s.io/helm/pkg/getter"
+	helm_env "k8s.io/helm/pkg/helm/environment"
+)
+
+// repoSecretsFileName is the file next to repositories.yaml the secrets of private repositories were referenced in
+// before the repositories were stored in the database, it is only read when the local repositories are imported
+const repoSecretsFileName = "secrets.yaml"
+
+// NewRepoSecrets looks up the secrets referenced by a repository request and checks their types
+func NewRepoSecrets(organizationID uint, request *helm2.RepoRequest) (*helm2.RepoSecrets, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	secrets := &helm2.RepoSecrets{OrganizationID: organizationID}
+
+	if request.PasswordSecretRef != "" {
+		item, err := getRepoSecret(organizationID, request.PasswordSecretRef, pkgSecret.PasswordSecretType)
+		if err != nil {
+			return nil, err
+		}
+
+		secrets.PasswordSecretID = item.ID
+	}
+
+	if request.TLSSecretRef != "" {
+		item, err := getRepoSecret(organizationID, request.TLSSecretRef, pkgSecret.TLSSecretType)
+		if err != nil {
+			return nil, err
+		}
+
+		secrets.TLSSecretID = item.ID
+	}
+
+	if request.CloudSecretRef != "" {
+		u, _ := url.Parse(request.URL)
+
+		item, err := getRepoSecret(organizationID, request.CloudSecretRef, helm2.BucketRepoProviders[u.Scheme])
+		if err != nil {
+			return nil, err
+		}
+
+		secrets.CloudSecretID = item.ID
+	}
+
+	return secrets, nil
+}
+
+// getRepoSecret returns the secret by name, dynamic secrets are accepted in place of the provider type they mint credentials for
+func getRepoSecret(organizationID uint, name string, secretType string) (*secret.SecretItemResponse, error) {
+	item, err := secret.Store.GetByName(organizationID, name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error during getting secret %s", name)
+	}
+
+	if err := item.ValidateSecretType(secretType); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+func repoSecretsFile(env helm_env.EnvSettings) string {
+	return filepath.Join(env.Home.Repository(), repoSecretsFileName)
+}
+
+// loadRepoSecrets returns the secret references of the local private repositories by repository name
+func loadRepoSecrets(env helm_env.EnvSettings) (map[string]helm2.RepoSecrets, error) {
+	secrets := make(map[string]helm2.RepoSecrets)
+
+	data, err := ioutil.ReadFile(repoSecretsFile(env))
+	if os.IsNotExist(err) {
+		return secrets, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not read helm repository secrets")
+	}
+
+	if err := yaml.Unmarshal(data, &secrets); err != nil {
+		return nil, errors.Wrap(err, "could not parse helm repository secrets")
+	}
+
+	return secrets, nil
+}
+
+// repoCredentials are the resolved credentials of a private repository
+type repoCredentials struct {
+	url      string
+	username string
+	password string
+	certFile string
+	keyFile  string
+	caFile   string
+	cloud    *secret.SecretItemResponse
+}
+
+// repoGetters returns the getters of the repositories of an organization, private repositories are accessed with the
+// credentials of their secrets, client certificates are written to a temporary directory removed by the returned cleanup
+func repoGetters(env helm_env.EnvSettings, repositories []*model.HelmRepositoryModel) (getter.Providers, func(), error) {
+	var tlsDir string
+	var err error
+	cleanup := func() {
+		if tlsDir != "" {
+			os.RemoveAll(tlsDir)
+		}
+	}
+
+	credentials := make([]*repoCredentials, 0)
+	for _, repository := range repositories {
+		if repoSecrets(repository).Empty() {
+			continue
+		}
+
+		if repository.TLSSecretID != "" && tlsDir == "" {
+			tlsDir, err = ioutil.TempDir("", "helm-repo-tls")
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "could not create directory for repository certificates")
+			}
+		}
+
+		creds, err := resolveRepoCredentials(repository, tlsDir)
+		if err != nil {
+			cleanup()
+			return nil, nil, errors.Wrapf(err, "could not resolve credentials of helm repository %s", repository.Name)
+		}
+
+		credentials = append(credentials, creds)
+	}
+
+	match := func(URL string) *repoCredentials {
+		for _, creds := range credentials {
+			if helm2.RepoURLMatches(creds.url, URL) {
+				return creds
+			}
+		}
+
+		return nil
+	}
+
+	providers := getter.Providers{
+		{
+			Schemes: []string{"http", "https"},
+			New: func(URL, certFile, keyFile, caFile string) (getter.Getter, error) {
+				creds := match(URL)
+				if creds != nil && creds.caFile != "" {
+					caFile = creds.caFile
+				}
+				if creds != nil && creds.certFile != "" {
+					certFile, keyFile = creds.certFile, creds.keyFile
+				}
+
+				g, err := getter.NewHTTPGetter(URL, certFile, keyFile, caFile)
+				if err != nil {
+					return nil, err
+				}
+
+				if creds != nil && creds.username != "" {
+					g.SetCredentials(creds.username, creds.password)
+				}
+
+				return g, nil
+			},
+		},
+		{
+			Schemes: []string{helm2.S3RepoScheme, helm2.GCSRepoScheme, helm2.AzureRepoScheme},
+			New: func(URL, certFile, keyFile, caFile string) (getter.Getter, error) {
+				creds := match(URL)
+				if creds == nil || creds.cloud == nil {
+					return nil, errors.Errorf("no cloud secret for bucket repository url %s", URL)
+				}
+
+				return &bucketGetter{secret: creds.cloud}, nil
+			},
+		},
+	}
+
+	// the getters are looked up by scheme in order, so the plugin getters come after the built-in ones
+	return append(providers, getter.All(env)...), cleanup, nil
+}
+
+// repoSecrets returns the secret references of a repository
+func repoSecrets(repository *model.HelmRepositoryModel) helm2.RepoSecrets {
+	return helm2.RepoSecrets{
+		OrganizationID:   repository.OrganizationID,
+		PasswordSecretID: repository.PasswordSecretID,
+		TLSSecretID:      repository.TLSSecretID,
+		CloudSecretID:    repository.CloudSecretID,
+	}
+}
+
+// setRepoSecrets sets the secret references of a repository, nil secrets make the repository public
+func setRepoSecrets(repository *model.HelmRepositoryModel, secrets *helm2.RepoSecrets) {
+	if secrets == nil {
+		secrets = &helm2.RepoSecrets{}
+	}
+
+	repository.PasswordSecretID = secrets.PasswordSecretID
+	repository.TLSSecretID = secrets.TLSSecretID
+	repository.CloudSecretID = secrets.CloudSecretID
+}
+
+// resolveRepoCredentials reads the credentials of a private repository from the secret store
+func resolveRepoCredentials(repository *model.HelmRepositoryModel, tlsDir string) (*repoCredentials, error) {
+	creds := &repoCredentials{url: repository.URL}
+
+	if repository.PasswordSecretID != "" {
+		item, err := secret.Store.Get(repository.OrganizationID, repository.PasswordSecretID)
+		if err != nil {
+			return nil, err
+		}
+
+		creds.username = item.GetValue(pkgSecret.Username)
+		creds.password = item.GetValue(pkgSecret.Password)
+	}
+
+	if repository.TLSSecretID != "" {
+		item, err := secret.Store.Get(repository.OrganizationID, repository.TLSSecretID)
+		if err != nil {
+			return nil, err
+		}
+
+		files := []struct {
+			path *string
+			key  string
+			name string
+		}{
+			{&creds.caFile, pkgSecret.CACert, "ca.crt"},
+			{&creds.certFile, pkgSecret.ClientCert, "client.crt"},
+			{&creds.keyFile, pkgSecret.ClientKey, "client.key"},
+		}
+
+		for _, file := range files {
+			value := item.GetValue(file.key)
+			if value == "" {
+				continue
+			}
+
+			*file.path = filepath.Join(tlsDir, repository.Name+"-"+file.name)
+			if err := ioutil.WriteFile(*file.path, []byte(value), 0600); err != nil {
+				return nil, errors.Wrap(err, "could not write repository certificate")
+			}
+		}
+
+		if creds.certFile == "" || creds.keyFile == "" {
+			creds.certFile, creds.keyFile = "", ""
+		}
+	}
+
+	if repository.CloudSecretID != "" {
+		item, err := secret.Store.Get(repository.OrganizationID, repository.CloudSecretID)
+		if err != nil {
+			return nil, err
+		}
+
+		if item.Type != cluster.Amazon && item.Type != cluster.Google && item.Type != cluster.Azure {
+			return nil, errors.Errorf("secret %s is not a cloud secret", item.Name)
+		}
+
+		creds.cloud = item
+	}
+
+	return creds, nil
+}
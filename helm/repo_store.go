@@ -0,0 +1,371 @@
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/banzaicloud/pipeline/config"
+	intHelm "github.com/banzaicloud/pipeline/internal/helm"
+	"github.com/banzaicloud/pipeline/model"
+	"github.com/pkg/errors"
+	helm_env "k8s.io/helm/pkg/helm/environment"
+	"k8s.io/helm/pkg/repo"
+)
+
+// repoIndexFile is the name the index of a repository is cached under in the shared cache
+const repoIndexFile = "index.yaml"
+
+// helmHomeSyncInterval is the time a local helm home is used without syncing it with the database,
+// the changes made by other Pipeline instances show up within this time
+const helmHomeSyncInterval = 10 * time.Second
+
+// maxCachedCharts is the number of chart archives of a repository kept in the shared cache,
+// the least recently used ones are evicted
+const maxCachedCharts = 20
+
+// helmHome is the local helm home of an organization, the lock serializes its rebuilds
+type helmHome struct {
+	sync.Mutex
+
+	organizationID uint
+	syncedAt       time.Time
+	setUp          bool
+}
+
+// needsSync returns true if the local helm home wasn't synced with the database for the sync interval
+func (h *helmHome) needsSync(now time.Time) bool {
+	return now.Sub(h.syncedAt) >= helmHomeSyncInterval
+}
+
+// helmHomes holds the local helm homes generated for the organizations by path
+var helmHomes = struct {
+	sync.Mutex
+	homes map[string]*helmHome
+}{homes: make(map[string]*helmHome)}
+
+// localCacheDigests holds the digests of the shared cache files written to the local helm homes by path,
+// so that a file is only written again when another Pipeline instance changes it
+var localCacheDigests = struct {
+	sync.Mutex
+	digests map[string]string
+}{digests: make(map[string]string)}
+
+// getHelmHome returns the local helm home of an organization
+func getHelmHome(env helm_env.EnvSettings, organizationID uint) *helmHome {
+	helmHomes.Lock()
+	defer helmHomes.Unlock()
+
+	home, ok := helmHomes.homes[env.Home.String()]
+	if !ok || home.organizationID != organizationID {
+		home = &helmHome{organizationID: organizationID}
+		helmHomes.homes[env.Home.String()] = home
+	}
+
+	return home
+}
+
+// findHelmHome returns a local helm home generated before
+func findHelmHome(env helm_env.EnvSettings) (*helmHome, error) {
+	helmHomes.Lock()
+	defer helmHomes.Unlock()
+
+	home, ok := helmHomes.homes[env.Home.String()]
+	if !ok {
+		return nil, errors.Errorf("helm home %s was not generated for an organization", env.Home.String())
+	}
+
+	return home, nil
+}
+
+// homeOrganizationID returns the ID of the organization a helm home was generated for
+func homeOrganizationID(env helm_env.EnvSettings) (uint, error) {
+	home, err := findHelmHome(env)
+	if err != nil {
+		return 0, err
+	}
+
+	return home.organizationID, nil
+}
+
+// findRepositories returns the repositories of the organization of a helm home
+func findRepositories(env helm_env.EnvSettings) ([]*model.HelmRepositoryModel, error) {
+	organizationID, err := homeOrganizationID(env)
+	if err != nil {
+		return nil, err
+	}
+
+	return intHelm.NewRepositories(config.DB()).FindByOrganization(organizationID)
+}
+
+// findRepository returns a repository by name or nil
+func findRepository(repositories []*model.HelmRepositoryModel, name string) *model.HelmRepositoryModel {
+	for _, repository := range repositories {
+		if repository.Name == name {
+			return repository
+		}
+	}
+
+	return nil
+}
+
+// syncHelmHome rebuilds the repositories file of a local helm home from the database and writes the repository indexes
+// changed in the shared cache, the repositories of the organization are set up on the first sync
+func syncHelmHome(env helm_env.EnvSettings, home *helmHome) error {
+	if !home.setUp {
+		if err := setupRepositories(env, home.organizationID); err != nil {
+			return err
+		}
+
+		home.setUp = true
+	}
+
+	repositories := intHelm.NewRepositories(config.DB())
+
+	models, err := repositories.FindByOrganization(home.organizationID)
+	if err != nil {
+		return err
+	}
+
+	if err := writeRepositoriesFile(env, models); err != nil {
+		return err
+	}
+
+	digests, err := repositories.FindCacheDigests(home.organizationID, repoIndexFile)
+	if err != nil {
+		return err
+	}
+
+	for _, repository := range models {
+		digest, ok := digests[repository.Name]
+		if !ok {
+			// indexes missing from the shared cache are downloaded on demand
+			continue
+		}
+
+		if err := restoreRepoCache(env.Home.CacheIndex(repository.Name), home.organizationID, repository.Name, repoIndexFile, digest); err != nil {
+			return err
+		}
+	}
+
+	home.syncedAt = time.Now()
+
+	return nil
+}
+
+// setupRepositories sets up the helm repositories of an organization once, the repositories of the local helm home
+// of earlier versions are imported, organizations without any get the default repositories.
+// Repositories deleted later are not added again.
+func setupRepositories(env helm_env.EnvSettings, organizationID uint) error {
+	repositories := intHelm.NewRepositories(config.DB())
+
+	setUp, err := repositories.IsSetUp(organizationID)
+	if err != nil || setUp {
+		return err
+	}
+
+	models, err := repositories.FindByOrganization(organizationID)
+	if err != nil {
+		return err
+	}
+
+	if len(models) == 0 {
+		imported, err := importLocalRepos(env, organizationID)
+		if err != nil {
+			return errors.Wrap(err, "could not import local helm repositories")
+		}
+
+		if len(imported) == 0 {
+			if err := ensureDefaultRepos(env); err != nil {
+				return errors.Wrap(err, "Setting up default repos failed!")
+			}
+		}
+	}
+
+	return repositories.SaveSetup(organizationID)
+}
+
+// importLocalRepos stores the repositories of a local helm home in the database with their cached indexes
+func importLocalRepos(env helm_env.EnvSettings, organizationID uint) ([]*model.HelmRepositoryModel, error) {
+	models := make([]*model.HelmRepositoryModel, 0)
+
+	f, err := repo.LoadRepositoriesFile(env.Home.RepositoryFile())
+	if os.IsNotExist(errors.Cause(err)) {
+		return models, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	secrets, err := loadRepoSecrets(env)
+	if err != nil {
+		return nil, err
+	}
+
+	repositories := intHelm.NewRepositories(config.DB())
+
+	for _, entry := range f.Repositories {
+		log.Infof("Importing local helm repository %s", entry.Name)
+
+		repository := &model.HelmRepositoryModel{
+			OrganizationID: organizationID,
+			Name:           entry.Name,
+			URL:            entry.URL,
+		}
+
+		if repoSecrets, ok := secrets[entry.Name]; ok {
+			setRepoSecrets(repository, &repoSecrets)
+		}
+
+		if err := repositories.Save(repository); err != nil {
+			return nil, err
+		}
+
+		if content, err := ioutil.ReadFile(env.Home.CacheIndex(entry.Name)); err == nil {
+			if err := saveRepoCache(env.Home.CacheIndex(entry.Name), organizationID, entry.Name, repoIndexFile, content); err != nil {
+				return nil, err
+			}
+		}
+
+		models = append(models, repository)
+	}
+
+	if err := os.Remove(repoSecretsFile(env)); err != nil && !os.IsNotExist(err) {
+		log.Warnf("could not remove local helm repository secrets: %s", err.Error())
+	}
+
+	return models, nil
+}
+
+// writeRepositoriesFile writes the repositories file of a local helm home,
+// the file is replaced at once, so that concurrent readers never see a partial file
+func writeRepositoriesFile(env helm_env.EnvSettings, repositories []*model.HelmRepositoryModel) error {
+	f := repo.NewRepoFile()
+	for _, repository := range repositories {
+		f.Add(&repo.Entry{
+			Name:  repository.Name,
+			URL:   repository.URL,
+			Cache: env.Home.CacheIndex(repository.Name),
+		})
+	}
+
+	tmpFile := env.Home.RepositoryFile() + ".tmp"
+	if err := f.WriteFile(tmpFile, 0644); err != nil {
+		return errors.Wrap(err, "Cannot write helm repo profile file")
+	}
+
+	return errors.Wrap(os.Rename(tmpFile, env.Home.RepositoryFile()), "Cannot write helm repo profile file")
+}
+
+// saveRepoCache stores a local file of a repository in the shared cache
+func saveRepoCache(path string, organizationID uint, repository string, file string, content []byte) error {
+	var compressed bytes.Buffer
+
+	w := gzip.NewWriter(&compressed)
+	if _, err := w.Write(content); err != nil {
+		return errors.Wrap(err, "could not compress helm repository cache")
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "could not compress helm repository cache")
+	}
+
+	digest := fmt.Sprintf("%x", sha256.Sum256(content))
+
+	err := intHelm.NewRepositories(config.DB()).SaveCache(&model.HelmRepositoryCacheModel{
+		OrganizationID: organizationID,
+		Repository:     repository,
+		File:           file,
+		Digest:         digest,
+		Content:        compressed.Bytes(),
+	})
+	if err != nil {
+		return err
+	}
+
+	setLocalCacheDigest(path, digest)
+
+	return nil
+}
+
+// restoreRepoCache writes a file of a repository from the shared cache to the local path,
+// unless the local file is already the version with the digest
+func restoreRepoCache(path string, organizationID uint, repository string, file string, digest string) error {
+	if getLocalCacheDigest(path) == digest {
+		if _, err := os.Stat(path); err == nil {
+			return nil
+		}
+	}
+
+	ok, err := loadRepoCache(path, organizationID, repository, file)
+	if err != nil {
+		return err
+	} else if !ok {
+		return errors.Errorf("%s of helm repository %s is not cached", file, repository)
+	}
+
+	return nil
+}
+
+// loadRepoCache writes a file of a repository from the shared cache to the local path,
+// false is returned if the file is not cached
+func loadRepoCache(path string, organizationID uint, repository string, file string) (bool, error) {
+	repositories := intHelm.NewRepositories(config.DB())
+
+	cache, err := repositories.FindCache(organizationID, repository, file)
+	if err != nil || cache == nil {
+		return false, err
+	}
+
+	// chart archives are evicted by their last use
+	if file != repoIndexFile {
+		if err := repositories.TouchCache(cache); err != nil {
+			log.Warnf("could not touch helm repository cache: %s", err.Error())
+		}
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(cache.Content))
+	if err != nil {
+		return false, errors.Wrap(err, "could not decompress helm repository cache")
+	}
+
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return false, errors.Wrap(err, "could not decompress helm repository cache")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, errors.Wrap(err, "could not create helm repository cache directory")
+	}
+
+	tmpFile := path + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, content, 0644); err != nil {
+		return false, errors.Wrap(err, "could not write helm repository cache")
+	}
+
+	if err := os.Rename(tmpFile, path); err != nil {
+		return false, errors.Wrap(err, "could not write helm repository cache")
+	}
+
+	setLocalCacheDigest(path, cache.Digest)
+
+	return true, nil
+}
+
+func getLocalCacheDigest(path string) string {
+	localCacheDigests.Lock()
+	defer localCacheDigests.Unlock()
+
+	return localCacheDigests.digests[path]
+}
+
+func setLocalCacheDigest(path string, digest string) {
+	localCacheDigests.Lock()
+	defer localCacheDigests.Unlock()
+
+	localCacheDigests.digests[path] = digest
+}
@@ -0,0 +1,58 @@
+package helm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHelmHomeNeedsSync(t *testing.T) {
+	now := time.Now()
+
+	cases := []struct {
+		name      string
+		syncedAt  time.Time
+		needsSync bool
+	}{
+		{name: "never synced", needsSync: true},
+		{name: "synced recently", syncedAt: now.Add(-time.Second), needsSync: false},
+		{name: "synced before the interval", syncedAt: now.Add(-helmHomeSyncInterval), needsSync: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			home := &helmHome{syncedAt: tc.syncedAt}
+
+			if needsSync := home.needsSync(now); needsSync != tc.needsSync {
+				t.Errorf("Expected needs sync %t, got: %t", tc.needsSync, needsSync)
+			}
+		})
+	}
+}
+
+func TestHomeOrganizationID(t *testing.T) {
+	env := CreateEnvSettings("/tmp/pipeline-test/orgs/banzaicloud/helm")
+
+	if _, err := homeOrganizationID(env); err == nil {
+		t.Fatal("Expected an error for a helm home not generated")
+	}
+
+	home := getHelmHome(env, 12)
+
+	if getHelmHome(env, 12) != home {
+		t.Error("Expected the helm home to be reused")
+	}
+
+	organizationID, err := homeOrganizationID(env)
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	if organizationID != 12 {
+		t.Errorf("Expected organization 12, got: %d", organizationID)
+	}
+
+	// the organization was deleted and created again with the same name
+	if getHelmHome(env, 13) == home {
+		t.Error("Expected a new helm home for another organization")
+	}
+}
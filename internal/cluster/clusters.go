@@ -65,6 +65,11 @@ func (c *Clusters) FindOneByName(organizationID uint, clusterName string) (*mode
 	return c.findOneBy(organizationID, "name", clusterName)
 }
 
+// FindOneByUID returns a cluster instance for an organization by cluster UID.
+func (c *Clusters) FindOneByUID(organizationID uint, clusterUID string) (*model.ClusterModel, error) {
+	return c.findOneBy(organizationID, "uid", clusterUID)
+}
+
 type clusterModelNotFoundError struct {
 	cluster        interface{}
 	organizationID uint
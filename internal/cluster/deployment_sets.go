@@ -0,0 +1,153 @@
+package cluster
+
+import (
+	"github.com/banzaicloud/pipeline/model"
+	"github.com/goph/emperror"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// DeploymentSets acts as a repository interface for deployment sets and their targets.
+type DeploymentSets struct {
+	db *gorm.DB
+}
+
+// NewDeploymentSets returns a new DeploymentSets instance.
+func NewDeploymentSets(db *gorm.DB) *DeploymentSets {
+	return &DeploymentSets{db: db}
+}
+
+type deploymentSetNotFoundError struct {
+	name           string
+	organizationID uint
+}
+
+func (e *deploymentSetNotFoundError) Error() string {
+	return "deployment set not found"
+}
+
+func (e *deploymentSetNotFoundError) Context() []interface{} {
+	return []interface{}{
+		"deploymentSet", e.name,
+		"organization", e.organizationID,
+	}
+}
+
+func (e *deploymentSetNotFoundError) NotFound() bool {
+	return true
+}
+
+// All returns all deployment sets.
+func (d *DeploymentSets) All() ([]*model.DeploymentSetModel, error) {
+	var sets []*model.DeploymentSetModel
+
+	err := d.db.Order("id asc").Find(&sets).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch deployment sets")
+	}
+
+	return sets, nil
+}
+
+// FindByOrganization returns the deployment sets of an organization ordered by name.
+func (d *DeploymentSets) FindByOrganization(organizationID uint) ([]*model.DeploymentSetModel, error) {
+	var sets []*model.DeploymentSetModel
+
+	err := d.db.Order("name asc").Find(&sets, map[string]interface{}{"organization_id": organizationID}).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch deployment sets")
+	}
+
+	return sets, nil
+}
+
+// FindOneByName returns a deployment set of an organization by name.
+func (d *DeploymentSets) FindOneByName(organizationID uint, name string) (*model.DeploymentSetModel, error) {
+	var set model.DeploymentSetModel
+
+	err := d.db.First(
+		&set,
+		map[string]interface{}{
+			"organization_id": organizationID,
+			"name":            name,
+		},
+	).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, errors.WithStack(&deploymentSetNotFoundError{
+			name:           name,
+			organizationID: organizationID,
+		})
+	} else if err != nil {
+		return nil, emperror.With(
+			errors.Wrap(err, "could not get deployment set"),
+			"deploymentSet", name,
+			"organization", organizationID,
+		)
+	}
+
+	return &set, nil
+}
+
+// Save persists a deployment set.
+func (d *DeploymentSets) Save(set *model.DeploymentSetModel) error {
+	err := d.db.Save(set).Error
+	if err != nil {
+		return errors.Wrap(err, "could not save deployment set")
+	}
+
+	return nil
+}
+
+// Delete deletes a deployment set along with its targets.
+func (d *DeploymentSets) Delete(set *model.DeploymentSetModel) error {
+	tx := d.db.Begin()
+
+	err := tx.Where(&model.DeploymentSetTargetModel{DeploymentSetID: set.ID}).Delete(model.DeploymentSetTargetModel{}).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "could not delete deployment set targets")
+	}
+
+	if err := tx.Delete(set).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "could not delete deployment set")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "could not delete deployment set")
+	}
+
+	return nil
+}
+
+// FindTargets returns the targets of a deployment set ordered by cluster ID.
+func (d *DeploymentSets) FindTargets(setID uint) ([]*model.DeploymentSetTargetModel, error) {
+	var targets []*model.DeploymentSetTargetModel
+
+	err := d.db.Order("cluster_id asc").Find(&targets, map[string]interface{}{"deployment_set_id": setID}).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch deployment set targets")
+	}
+
+	return targets, nil
+}
+
+// SaveTarget persists a deployment set target.
+func (d *DeploymentSets) SaveTarget(target *model.DeploymentSetTargetModel) error {
+	err := d.db.Save(target).Error
+	if err != nil {
+		return errors.Wrap(err, "could not save deployment set target")
+	}
+
+	return nil
+}
+
+// DeleteTarget deletes a deployment set target.
+func (d *DeploymentSets) DeleteTarget(target *model.DeploymentSetTargetModel) error {
+	err := d.db.Delete(target).Error
+	if err != nil {
+		return errors.Wrap(err, "could not delete deployment set target")
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package cluster
+
+import (
+	"github.com/banzaicloud/pipeline/model"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// DriftEvents acts as a repository interface for cluster drift events.
+type DriftEvents struct {
+	db *gorm.DB
+}
+
+// NewDriftEvents returns a new DriftEvents instance.
+func NewDriftEvents(db *gorm.DB) *DriftEvents {
+	return &DriftEvents{db: db}
+}
+
+// Save persists a cluster drift event.
+func (d *DriftEvents) Save(event *model.ClusterDriftEventModel) error {
+	err := d.db.Save(event).Error
+	if err != nil {
+		return errors.Wrap(err, "could not save cluster drift event")
+	}
+
+	return nil
+}
+
+// FindByCluster returns the latest drift events of a cluster, the most recent one first.
+func (d *DriftEvents) FindByCluster(clusterID uint, limit int) ([]*model.ClusterDriftEventModel, error) {
+	var events []*model.ClusterDriftEventModel
+
+	err := d.db.Where(&model.ClusterDriftEventModel{ClusterID: clusterID}).Order("id desc").Limit(limit).Find(&events).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch cluster drift events")
+	}
+
+	return events, nil
+}
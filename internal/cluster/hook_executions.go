@@ -0,0 +1,79 @@
+package cluster
+
+import (
+	"github.com/banzaicloud/pipeline/model"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// HookExecutions acts as a repository interface for posthook and pre-delete hook executions.
+type HookExecutions struct {
+	db *gorm.DB
+}
+
+// NewHookExecutions returns a new HookExecutions instance.
+func NewHookExecutions(db *gorm.DB) *HookExecutions {
+	return &HookExecutions{db: db}
+}
+
+// Save persists a hook execution.
+func (e *HookExecutions) Save(execution *model.HookExecutionModel) error {
+	err := e.db.Save(execution).Error
+	if err != nil {
+		return errors.Wrap(err, "could not save hook execution")
+	}
+
+	return nil
+}
+
+// FindByCluster returns the hook executions of a cluster in the given phase, the most recent one first.
+func (e *HookExecutions) FindByCluster(clusterID uint, phase string) ([]*model.HookExecutionModel, error) {
+	var executions []*model.HookExecutionModel
+
+	err := e.db.Order("id desc").Find(
+		&executions,
+		map[string]interface{}{
+			"cluster_id": clusterID,
+			"phase":      phase,
+		},
+	).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch hook executions")
+	}
+
+	return executions, nil
+}
+
+// FindLastRun returns the hook executions of the last run of a cluster in the given phase in execution order.
+func (e *HookExecutions) FindLastRun(clusterID uint, phase string) ([]*model.HookExecutionModel, error) {
+	var last model.HookExecutionModel
+
+	err := e.db.Order("id desc").First(
+		&last,
+		map[string]interface{}{
+			"cluster_id": clusterID,
+			"phase":      phase,
+		},
+	).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not fetch last hook execution")
+	}
+
+	var executions []*model.HookExecutionModel
+
+	err = e.db.Order("position asc").Find(
+		&executions,
+		map[string]interface{}{
+			"cluster_id": clusterID,
+			"phase":      phase,
+			"run_id":     last.RunID,
+		},
+	).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch hook executions")
+	}
+
+	return executions, nil
+}
@@ -0,0 +1,92 @@
+package cluster
+
+import (
+	"github.com/banzaicloud/pipeline/model"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// Labels acts as a repository interface for cluster labels.
+type Labels struct {
+	db *gorm.DB
+}
+
+// NewLabels returns a new Labels instance.
+func NewLabels(db *gorm.DB) *Labels {
+	return &Labels{db: db}
+}
+
+// FindByCluster returns the labels of a cluster.
+func (l *Labels) FindByCluster(organizationID uint, clusterID uint) (map[string]string, error) {
+	var labels []*model.ClusterLabelModel
+
+	err := l.db.Find(
+		&labels,
+		map[string]interface{}{
+			"organization_id": organizationID,
+			"cluster_id":      clusterID,
+		},
+	).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch cluster labels")
+	}
+
+	clusterLabels := make(map[string]string, len(labels))
+	for _, label := range labels {
+		clusterLabels[label.Key] = label.Value
+	}
+
+	return clusterLabels, nil
+}
+
+// FindByOrganization returns the labels of the clusters of an organization by cluster ID.
+func (l *Labels) FindByOrganization(organizationID uint) (map[uint]map[string]string, error) {
+	var labels []*model.ClusterLabelModel
+
+	err := l.db.Find(&labels, map[string]interface{}{"organization_id": organizationID}).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch cluster labels")
+	}
+
+	clusterLabels := make(map[uint]map[string]string)
+	for _, label := range labels {
+		if clusterLabels[label.ClusterID] == nil {
+			clusterLabels[label.ClusterID] = make(map[string]string)
+		}
+
+		clusterLabels[label.ClusterID][label.Key] = label.Value
+	}
+
+	return clusterLabels, nil
+}
+
+// Save replaces the labels of a cluster.
+func (l *Labels) Save(organizationID uint, clusterID uint, labels map[string]string) error {
+	tx := l.db.Begin()
+
+	err := tx.Where(&model.ClusterLabelModel{OrganizationID: organizationID, ClusterID: clusterID}).Delete(model.ClusterLabelModel{}).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "could not delete cluster labels")
+	}
+
+	for key, value := range labels {
+		label := model.ClusterLabelModel{
+			OrganizationID: organizationID,
+			ClusterID:      clusterID,
+			Key:            key,
+			Value:          value,
+		}
+
+		if err := tx.Create(&label).Error; err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "could not save cluster label")
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "could not save cluster labels")
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package cluster
+
+import (
+	"github.com/banzaicloud/pipeline/model"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// Operations acts as a repository interface for cluster operations.
+type Operations struct {
+	db *gorm.DB
+}
+
+// NewOperations returns a new Operations instance.
+func NewOperations(db *gorm.DB) *Operations {
+	return &Operations{db: db}
+}
+
+// Save persists a cluster operation.
+func (o *Operations) Save(operation *model.ClusterOperationModel) error {
+	err := o.db.Save(operation).Error
+	if err != nil {
+		return errors.Wrap(err, "could not save cluster operation")
+	}
+
+	return nil
+}
+
+// FindByCluster returns the operations of a cluster, the most recent one first.
+func (o *Operations) FindByCluster(organizationID uint, clusterID uint) ([]*model.ClusterOperationModel, error) {
+	var operations []*model.ClusterOperationModel
+
+	err := o.db.Order("id desc").Find(
+		&operations,
+		map[string]interface{}{
+			"organization_id": organizationID,
+			"cluster_id":      clusterID,
+		},
+	).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch cluster operations")
+	}
+
+	return operations, nil
+}
+
+// FindUnfinished returns all operations which are either waiting for or under execution.
+func (o *Operations) FindUnfinished() ([]*model.ClusterOperationModel, error) {
+	var operations []*model.ClusterOperationModel
+
+	err := o.db.
+		Where("status IN (?)", []string{pkgCluster.OperationPending, pkgCluster.OperationRunning}).
+		Order("id asc").
+		Find(&operations).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch unfinished cluster operations")
+	}
+
+	return operations, nil
+}
@@ -0,0 +1,61 @@
+package cluster
+
+import (
+	"github.com/banzaicloud/pipeline/model"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// SecretInstallations acts as a repository interface for the namespaces secrets were installed into.
+type SecretInstallations struct {
+	db *gorm.DB
+}
+
+// NewSecretInstallations returns a new SecretInstallations instance.
+func NewSecretInstallations(db *gorm.DB) *SecretInstallations {
+	return &SecretInstallations{db: db}
+}
+
+// Save records that a secret was installed into a namespace of a cluster.
+func (s *SecretInstallations) Save(organizationID uint, clusterID uint, secretID string, namespace string) error {
+	installation := model.ClusterSecretInstallationModel{
+		OrganizationID: organizationID,
+		ClusterID:      clusterID,
+		SecretID:       secretID,
+		Namespace:      namespace,
+	}
+
+	err := s.db.Where(&installation).FirstOrCreate(&installation).Error
+	if err != nil {
+		return errors.Wrap(err, "could not save secret installation")
+	}
+
+	return nil
+}
+
+// FindBySecret returns the installations of a secret ordered by cluster and namespace.
+func (s *SecretInstallations) FindBySecret(organizationID uint, secretID string) ([]*model.ClusterSecretInstallationModel, error) {
+	var installations []*model.ClusterSecretInstallationModel
+
+	err := s.db.Where(&model.ClusterSecretInstallationModel{
+		OrganizationID: organizationID,
+		SecretID:       secretID,
+	}).Order("cluster_id, namespace").Find(&installations).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch secret installations")
+	}
+
+	return installations, nil
+}
+
+// FindAll returns every secret installation ordered by organization, secret, cluster and namespace.
+func (s *SecretInstallations) FindAll() ([]*model.ClusterSecretInstallationModel, error) {
+	var installations []*model.ClusterSecretInstallationModel
+
+	err := s.db.Order("organization_id, secret_id, cluster_id, namespace").Find(&installations).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch secret installations")
+	}
+
+	return installations, nil
+}
@@ -0,0 +1,29 @@
+package cluster
+
+import (
+	"github.com/banzaicloud/pipeline/model"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// StatusHistory acts as a repository interface for cluster status transitions.
+type StatusHistory struct {
+	db *gorm.DB
+}
+
+// NewStatusHistory returns a new StatusHistory instance.
+func NewStatusHistory(db *gorm.DB) *StatusHistory {
+	return &StatusHistory{db: db}
+}
+
+// FindByCluster returns the status transitions of a cluster in chronological order.
+func (s *StatusHistory) FindByCluster(clusterID uint) ([]*model.ClusterStatusHistoryModel, error) {
+	var history []*model.ClusterStatusHistoryModel
+
+	err := s.db.Where(&model.ClusterStatusHistoryModel{ClusterID: clusterID}).Order("id asc").Find(&history).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch cluster status history")
+	}
+
+	return history, nil
+}
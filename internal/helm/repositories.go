@@ -0,0 +1,202 @@
+package helm
+
+import (
+	"time"
+
+	"github.com/banzaicloud/pipeline/model"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// Repositories acts as a repository interface for the helm repositories of organizations and their shared caches.
+type Repositories struct {
+	db *gorm.DB
+}
+
+// NewRepositories returns a new Repositories instance.
+func NewRepositories(db *gorm.DB) *Repositories {
+	return &Repositories{db: db}
+}
+
+// FindByOrganization returns the helm repositories of an organization ordered by name.
+func (r *Repositories) FindByOrganization(organizationID uint) ([]*model.HelmRepositoryModel, error) {
+	var repositories []*model.HelmRepositoryModel
+
+	err := r.db.Order("name asc").Find(&repositories, map[string]interface{}{"organization_id": organizationID}).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch helm repositories")
+	}
+
+	return repositories, nil
+}
+
+// Save persists a helm repository.
+func (r *Repositories) Save(repository *model.HelmRepositoryModel) error {
+	return errors.Wrap(r.db.Save(repository).Error, "could not save helm repository")
+}
+
+// Delete deletes a helm repository of an organization with its caches.
+func (r *Repositories) Delete(organizationID uint, name string) error {
+	tx := r.db.Begin()
+
+	if err := NewRepositories(tx).DeleteCaches(organizationID, name); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err := tx.Where(&model.HelmRepositoryModel{OrganizationID: organizationID, Name: name}).Delete(model.HelmRepositoryModel{}).Error
+	if err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "could not delete helm repository")
+	}
+
+	return errors.Wrap(tx.Commit().Error, "could not delete helm repository")
+}
+
+// DeleteByOrganization deletes the helm repositories of an organization with their caches.
+func (r *Repositories) DeleteByOrganization(organizationID uint) error {
+	err := r.db.Where(&model.HelmRepositoryCacheModel{OrganizationID: organizationID}).Delete(model.HelmRepositoryCacheModel{}).Error
+	if err != nil {
+		return errors.Wrap(err, "could not delete helm repository caches")
+	}
+
+	err = r.db.Where(&model.HelmRepositoryModel{OrganizationID: organizationID}).Delete(model.HelmRepositoryModel{}).Error
+	if err != nil {
+		return errors.Wrap(err, "could not delete helm repositories")
+	}
+
+	err = r.db.Where(&model.HelmRepositorySetupModel{OrganizationID: organizationID}).Delete(model.HelmRepositorySetupModel{}).Error
+
+	return errors.Wrap(err, "could not delete helm repository setup")
+}
+
+// IsSetUp returns true if the helm repositories of an organization were set up.
+func (r *Repositories) IsSetUp(organizationID uint) (bool, error) {
+	var count int
+
+	err := r.db.Model(&model.HelmRepositorySetupModel{}).Where(&model.HelmRepositorySetupModel{OrganizationID: organizationID}).Count(&count).Error
+	if err != nil {
+		return false, errors.Wrap(err, "could not fetch helm repository setup")
+	}
+
+	return count > 0, nil
+}
+
+// SaveSetup records that the helm repositories of an organization were set up.
+func (r *Repositories) SaveSetup(organizationID uint) error {
+	setup := model.HelmRepositorySetupModel{OrganizationID: organizationID}
+
+	err := r.db.Where(&setup).FirstOrCreate(&setup).Error
+
+	return errors.Wrap(err, "could not save helm repository setup")
+}
+
+// DeleteCaches deletes the cached files of a helm repository of an organization.
+func (r *Repositories) DeleteCaches(organizationID uint, repository string) error {
+	err := r.db.Where(&model.HelmRepositoryCacheModel{OrganizationID: organizationID, Repository: repository}).Delete(model.HelmRepositoryCacheModel{}).Error
+
+	return errors.Wrap(err, "could not delete helm repository caches")
+}
+
+// FindCacheDigests returns the digests of a cached file of the helm repositories of an organization
+// by repository name without the content of the file.
+func (r *Repositories) FindCacheDigests(organizationID uint, file string) (map[string]string, error) {
+	var caches []*model.HelmRepositoryCacheModel
+
+	err := r.db.Select("repository, digest").Find(
+		&caches,
+		map[string]interface{}{
+			"organization_id": organizationID,
+			"file":            file,
+		},
+	).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch helm repository caches")
+	}
+
+	digests := make(map[string]string, len(caches))
+	for _, cache := range caches {
+		digests[cache.Repository] = cache.Digest
+	}
+
+	return digests, nil
+}
+
+// FindCache returns a cached file of a helm repository, nil is returned if the file is not cached.
+func (r *Repositories) FindCache(organizationID uint, repository string, file string) (*model.HelmRepositoryCacheModel, error) {
+	var cache model.HelmRepositoryCacheModel
+
+	err := r.db.First(
+		&cache,
+		map[string]interface{}{
+			"organization_id": organizationID,
+			"repository":      repository,
+			"file":            file,
+		},
+	).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not fetch helm repository cache")
+	}
+
+	return &cache, nil
+}
+
+// SaveCache creates or replaces a cached file of a helm repository.
+func (r *Repositories) SaveCache(cache *model.HelmRepositoryCacheModel) error {
+	var current model.HelmRepositoryCacheModel
+
+	err := r.db.Select("id, created_at").First(
+		&current,
+		map[string]interface{}{
+			"organization_id": cache.OrganizationID,
+			"repository":      cache.Repository,
+			"file":            cache.File,
+		},
+	).Error
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		return errors.Wrap(err, "could not fetch helm repository cache")
+	}
+
+	cache.ID = current.ID
+	cache.CreatedAt = current.CreatedAt
+
+	return errors.Wrap(r.db.Save(cache).Error, "could not save helm repository cache")
+}
+
+// TouchCache marks a cached file of a helm repository as used.
+func (r *Repositories) TouchCache(cache *model.HelmRepositoryCacheModel) error {
+	err := r.db.Model(cache).UpdateColumn("updated_at", time.Now()).Error
+
+	return errors.Wrap(err, "could not update helm repository cache")
+}
+
+// DeleteStaleCaches deletes the cached files of a helm repository except the keep most recently used ones
+// and the file passed as except.
+func (r *Repositories) DeleteStaleCaches(organizationID uint, repository string, keep int, except string) error {
+	var caches []*model.HelmRepositoryCacheModel
+
+	err := r.db.Select("id").Where(
+		"organization_id = ? AND repository = ? AND file <> ?",
+		organizationID,
+		repository,
+		except,
+	).Order("updated_at desc").Find(&caches).Error
+	if err != nil {
+		return errors.Wrap(err, "could not fetch helm repository caches")
+	}
+
+	if len(caches) <= keep {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(caches)-keep)
+	for _, cache := range caches[keep:] {
+		ids = append(ids, cache.ID)
+	}
+
+	err = r.db.Where("id IN (?)", ids).Delete(model.HelmRepositoryCacheModel{}).Error
+
+	return errors.Wrap(err, "could not delete helm repository caches")
+}
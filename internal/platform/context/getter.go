@@ -0,0 +1,10 @@
+package context
+
+import "context"
+
+// CorrelationID returns the correlation ID of the context or an empty string if there is none.
+func CorrelationID(ctx context.Context) string {
+	cid, _ := ctx.Value(contextKeyCorrelationId).(string)
+
+	return cid
+}
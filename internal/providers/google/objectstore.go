@@ -10,7 +10,6 @@ import (
 	"github.com/banzaicloud/pipeline/auth"
 	"github.com/banzaicloud/pipeline/internal/objectstore"
 	"github.com/banzaicloud/pipeline/secret/verify"
-	"github.com/gin-gonic/gin/json"
 	"github.com/jinzhu/gorm"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -286,14 +285,9 @@ func (s *ObjectStore) ListBuckets() ([]*objectstore.BucketInfo, error) {
 }
 
 func (s *ObjectStore) newGoogleCredentials() (*google.Credentials, error) {
-	credentialsJson, err := json.Marshal(s.serviceAccount)
-	if err != nil {
-		return nil, err
-	}
-
 	ctx := context.Background()
 
-	credentials, err := google.CredentialsFromJSON(ctx, credentialsJson, apiStorage.DevstorageFullControlScope)
+	credentials, err := verify.CreateGoogleCredentials(ctx, s.serviceAccount, apiStorage.DevstorageFullControlScope)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -9,15 +10,19 @@ import (
 	"github.com/banzaicloud/pipeline/api"
 	"github.com/banzaicloud/pipeline/audit"
 	"github.com/banzaicloud/pipeline/auth"
+	"github.com/banzaicloud/pipeline/cluster"
 	"github.com/banzaicloud/pipeline/config"
 	"github.com/banzaicloud/pipeline/dns"
 	"github.com/banzaicloud/pipeline/dns/route53/model"
+	intCluster "github.com/banzaicloud/pipeline/internal/cluster"
 	"github.com/banzaicloud/pipeline/internal/platform/gin/correlationid"
 	ginlog "github.com/banzaicloud/pipeline/internal/platform/gin/log"
 	"github.com/banzaicloud/pipeline/model"
 	"github.com/banzaicloud/pipeline/model/defaults"
 	"github.com/banzaicloud/pipeline/notify"
 	"github.com/banzaicloud/pipeline/objectstore"
+	"github.com/banzaicloud/pipeline/pkg/providers"
+	"github.com/banzaicloud/pipeline/secret"
 	"github.com/banzaicloud/pipeline/spotguide"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -85,10 +90,28 @@ func main() {
 		&model.GKENodePoolModel{},
 		&model.DummyClusterModel{},
 		&model.KubernetesClusterModel{},
+		&model.ClusterOperationModel{},
+		&model.HookExecutionModel{},
+		&model.ClusterDriftEventModel{},
+		&model.ClusterStatusHistoryModel{},
+		&model.ClusterSecretInstallationModel{},
+		&model.ClusterLabelModel{},
+		&model.DeploymentSetModel{},
+		&model.DeploymentSetTargetModel{},
+		&model.HelmRepositoryModel{},
+		&model.HelmRepositoryCacheModel{},
+		&model.HelmRepositorySetupModel{},
+		&secret.SecretVersionModel{},
+		&secret.SecretACL{},
+		&secret.UsageModel{},
+		&secret.SecretTypeModel{},
+		&secret.ExternalIDModel{},
 		&auth.AuthIdentity{},
 		&auth.User{},
 		&auth.UserOrganization{},
 		&auth.Organization{},
+		&auth.APITokenModel{},
+		&auth.Invitation{},
 		&audit.AuditEvent{},
 		&defaults.EC2Profile{},
 		&defaults.EC2NodePoolProfile{},
@@ -98,6 +121,7 @@ func main() {
 		&defaults.AKSNodePoolProfile{},
 		&defaults.GKEProfile{},
 		&defaults.GKENodePoolProfile{},
+		&defaults.OrganizationProfile{},
 		&objectstore.ManagedAlibabaBucket{},
 		&route53model.Route53Domain{},
 		&spotguide.Repo{},
@@ -138,6 +162,54 @@ func main() {
 		log.Infoln("External dns service functionality is not enabled")
 	}
 
+	// Cluster operations
+	clusterOperations := cluster.NewOperationQueue(
+		intCluster.NewOperations(db),
+		viper.GetInt(config.ClusterOperationWorkers),
+		log,
+		errorHandler,
+	)
+	clusterOperations.Start()
+	api.SetClusterOperationQueue(clusterOperations)
+
+	clusterManager := cluster.NewManager(
+		intCluster.NewClusters(db),
+		providers.NewSecretValidator(secret.Store),
+		clusterOperations,
+		log,
+		errorHandler,
+	)
+	if err := clusterManager.ResumeOperations(context.Background()); err != nil {
+		errorHandler.Handle(errors.Wrap(err, "failed to resume cluster operations"))
+	}
+	if err := clusterManager.ResumeDeploymentSets(context.Background()); err != nil {
+		errorHandler.Handle(errors.Wrap(err, "failed to resume deployment set rollouts"))
+	}
+
+	auth.StartTokenPurger(viper.GetDuration(config.AuthTokenPurgeInterval))
+
+	registrySecretRefresher := cluster.NewRegistrySecretRefresher(
+		intCluster.NewClusters(db),
+		intCluster.NewSecretInstallations(db),
+		viper.GetDuration(config.SecretRegistryRefreshInterval),
+		log,
+		errorHandler,
+	)
+	registrySecretRefresher.Start()
+
+	if viper.GetBool(config.ClusterDriftEnabled) {
+		driftReconciler := cluster.NewDriftReconciler(
+			intCluster.NewClusters(db),
+			intCluster.NewDriftEvents(db),
+			clusterManager,
+			viper.GetDuration(config.ClusterDriftInterval),
+			viper.GetBool(config.ClusterDriftReapply),
+			log,
+			errorHandler,
+		)
+		driftReconciler.Start()
+	}
+
 	// Spotguides
 	go func() {
 		err := spotguide.ScrapeSpotguides()
@@ -157,7 +229,11 @@ func main() {
 	router.Use(cors.New(config.GetCORS()))
 	if viper.GetBool("audit.enabled") {
 		log.Infoln("Audit enabled, installing Gin audit middleware")
-		router.Use(audit.LogWriter(skipPaths, viper.GetStringSlice("audit.headers")))
+		auditSink, err := audit.NewSink(viper.GetString(config.AuditSinkType), viper.GetString(config.AuditSinkTarget))
+		if err != nil {
+			logger.Panic(err.Error())
+		}
+		router.Use(audit.LogWriter(skipPaths, viper.GetStringSlice("audit.headers"), auditSink))
 	}
 
 	root := router.Group("/")
@@ -191,16 +267,28 @@ func main() {
 			orgs.HEAD("/:orgid/spotguides/*name", api.GetSpotguide)
 
 			orgs.POST("/:orgid/clusters", api.CreateClusterRequest)
+			orgs.POST("/:orgid/clusterimports", api.ImportCluster)
+			orgs.POST("/:orgid/clusterdefinitions", api.CreateClusterFromDefinition)
 			//v1.GET("/status", api.Status)
 			orgs.GET("/:orgid/clusters", api.GetClusters)
 			orgs.GET("/:orgid/clusters/:id", api.GetClusterStatus)
 			orgs.GET("/:orgid/clusters/:id/details", api.GetClusterDetails)
 			orgs.GET("/:orgid/clusters/:id/pods", api.GetPodDetails)
 			orgs.PUT("/:orgid/clusters/:id", api.UpdateCluster)
+			orgs.GET("/:orgid/clusters/:id/posthooks", api.GetPostHookExecutions)
 			orgs.PUT("/:orgid/clusters/:id/posthooks", api.ReRunPostHooks)
+			orgs.GET("/:orgid/clusters/:id/operations", api.GetClusterOperations)
+			orgs.GET("/:orgid/clusters/:id/drift", api.GetClusterDrift)
+			orgs.GET("/:orgid/clusters/:id/statushistory", api.GetClusterStatusHistory)
+			orgs.GET("/:orgid/clusters/:id/definition", api.GetClusterDefinition)
+			orgs.POST("/:orgid/clusters/:id/clone", api.CloneCluster)
 			orgs.POST("/:orgid/clusters/:id/secrets", api.InstallSecretsToCluster)
+			orgs.POST("/:orgid/clusters/:id/imagepullsecrets", api.InstallImagePullSecret)
+			orgs.GET("/:orgid/clusters/:id/labels", api.GetClusterLabels)
+			orgs.PUT("/:orgid/clusters/:id/labels", api.UpdateClusterLabels)
 			orgs.Any("/:orgid/clusters/:id/proxy/*path", api.ProxyToCluster)
 			orgs.DELETE("/:orgid/clusters/:id", api.DeleteCluster)
+			orgs.GET("/:orgid/clusters/:id/predeletehooks", api.GetPreDeleteHookExecutions)
 			orgs.HEAD("/:orgid/clusters/:id", api.ClusterHEAD)
 			orgs.GET("/:orgid/clusters/:id/config", api.GetClusterConfig)
 			orgs.GET("/:orgid/clusters/:id/apiendpoint", api.GetApiEndpoint)
@@ -210,6 +298,9 @@ func main() {
 			orgs.POST("/:orgid/clusters/:id/deployments", api.CreateDeployment)
 			orgs.GET("/:orgid/clusters/:id/deployments/:name", api.GetDeployment)
 			orgs.GET("/:orgid/clusters/:id/deployments/:name/resources", api.GetDeploymentResources)
+			orgs.GET("/:orgid/clusters/:id/deployments/:name/history", api.GetDeploymentHistory)
+			orgs.POST("/:orgid/clusters/:id/deployments/:name/rollback", api.RollbackDeployment)
+			orgs.POST("/:orgid/clusters/:id/deployments/:name/diff", api.DiffDeployment)
 			orgs.GET("/:orgid/clusters/:id/hpa", api.GetHpaResource)
 			orgs.PUT("/:orgid/clusters/:id/hpa", api.PutHpaResource)
 			orgs.DELETE("/:orgid/clusters/:id/hpa", api.DeleteHpaResource)
@@ -218,6 +309,11 @@ func main() {
 			orgs.PUT("/:orgid/clusters/:id/deployments/:name", api.UpgradeDeployment)
 			orgs.HEAD("/:orgid/clusters/:id/deployments/:name", api.HelmDeploymentStatus)
 			orgs.POST("/:orgid/clusters/:id/helminit", api.InitHelmOnCluster)
+			orgs.GET("/:orgid/deploymentsets", api.ListDeploymentSets)
+			orgs.POST("/:orgid/deploymentsets", api.CreateDeploymentSet)
+			orgs.GET("/:orgid/deploymentsets/:name", api.GetDeploymentSet)
+			orgs.PUT("/:orgid/deploymentsets/:name", api.UpdateDeploymentSet)
+			orgs.DELETE("/:orgid/deploymentsets/:name", api.DeleteDeploymentSet)
 			orgs.GET("/:orgid/helm/repos", api.HelmReposGet)
 			orgs.POST("/:orgid/helm/repos", api.HelmReposAdd)
 			orgs.PUT("/:orgid/helm/repos/:name", api.HelmReposModify)
@@ -234,11 +330,30 @@ func main() {
 			orgs.POST("/:orgid/secrets", api.AddSecrets)
 			orgs.PUT("/:orgid/secrets/:id", api.UpdateSecrets)
 			orgs.DELETE("/:orgid/secrets/:id", api.DeleteSecrets)
+			orgs.POST("/:orgid/secretexports", api.ExportSecrets)
+			orgs.POST("/:orgid/secretimports", api.ImportSecrets)
 			orgs.GET("/:orgid/secrets/:id/validate", api.ValidateSecret)
+			orgs.POST("/:orgid/secrets/:id/rotate", api.RotateSecret)
+			orgs.GET("/:orgid/secrets/:id/usage", api.GetSecretUsage)
+			orgs.GET("/:orgid/secrets/:id/acl", api.GetSecretACL)
+			orgs.PUT("/:orgid/secrets/:id/acl", api.UpdateSecretACL)
+			orgs.GET("/:orgid/secrettypes", api.ListSecretTypes)
+			orgs.GET("/:orgid/secrettypes/:name", api.GetSecretType)
+			orgs.PUT("/:orgid/secrettypes/:name", api.SaveSecretType)
+			orgs.DELETE("/:orgid/secrettypes/:name", api.DeleteSecretType)
+			orgs.GET("/:orgid/allowed/secrets", api.ListAllowedSecretTypes)
+			orgs.GET("/:orgid/allowed/secrets/:type", api.ListAllowedSecretTypes)
 			orgs.GET("/:orgid/users", api.GetUsers)
 			orgs.GET("/:orgid/users/:id", api.GetUsers)
 			orgs.POST("/:orgid/users/:id", api.AddUser)
 			orgs.DELETE("/:orgid/users/:id", api.RemoveUser)
+			orgs.GET("/:orgid/audit", api.GetAuditEvents)
+			orgs.GET("/:orgid/invitations", api.GetInvitations)
+			orgs.POST("/:orgid/invitations", api.CreateInvitation)
+			orgs.DELETE("/:orgid/invitations/:id", api.RevokeInvitation)
+			orgs.GET("/:orgid/roles", api.GetOrgRoles)
+			orgs.PUT("/:orgid/roles/:name", api.SaveOrgRole)
+			orgs.DELETE("/:orgid/roles/:name", api.DeleteOrgRole)
 
 			orgs.GET("/:orgid/buckets", api.ListBuckets)
 			orgs.POST("/:orgid/buckets", api.CreateBucket)
@@ -262,6 +377,7 @@ func main() {
 		v1.GET("/tokens", auth.GetTokens)
 		v1.GET("/tokens/:id", auth.GetTokens)
 		v1.DELETE("/tokens/:id", auth.DeleteToken)
+		v1.POST("/invitations/accept", api.AcceptInvitation)
 
 		v1.GET("/allowed/secrets", api.ListAllowedSecretTypes)
 		v1.GET("/allowed/secrets/:type", api.ListAllowedSecretTypes)
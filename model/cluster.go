@@ -32,6 +32,17 @@ const (
 	TableNameGoogleNodePools      = "google_node_pools"
 	TableNameDummyProperties      = "dummy_cluster_properties"
 	TableNameKubernetesProperties = "kubernetes_cluster_properties"
+	TableNameClusterOperations    = "cluster_operations"
+	TableNameHookExecutions       = "cluster_hook_executions"
+	TableNameClusterDriftEvents   = "cluster_drift_events"
+	TableNameClusterStatusHistory = "cluster_status_history"
+	TableNameSecretInstallations  = "cluster_secret_installations"
+	TableNameClusterLabels        = "cluster_labels"
+	TableNameDeploymentSets       = "deployment_sets"
+	TableNameDeploymentSetTargets = "deployment_set_targets"
+	TableNameHelmRepositories     = "helm_repositories"
+	TableNameHelmRepositoryCaches = "helm_repository_caches"
+	TableNameHelmRepositorySetups = "helm_repository_setups"
 )
 
 //ClusterModel describes the common cluster model
@@ -54,6 +65,7 @@ type ClusterModel struct {
 	RbacEnabled    bool
 	Monitoring     bool
 	Logging        bool
+	Imported       bool   // the cluster was not created by Pipeline, so it is left in the cloud when deleted
 	StatusMessage  string `sql:"type:text;"`
 	ACSK           ACSKClusterModel
 	EC2            EC2ClusterModel
@@ -64,6 +76,10 @@ type ClusterModel struct {
 	Kubernetes     KubernetesClusterModel
 	OKE            modelOracle.Cluster
 	CreatedBy      uint
+
+	// StatusChangedBy and CorrelationID are recorded with the status transitions of the cluster
+	StatusChangedBy uint   `gorm:"-"`
+	CorrelationID   string `gorm:"-"`
 }
 
 // ACSKNodePoolModel describes Alibaba Cloud CS node groups model of a cluster
@@ -266,6 +282,11 @@ func (cs *ClusterModel) preDelete() {
 	if err := secret.Store.DeleteByClusterUID(cs.OrganizationId, cs.UID); err != nil {
 		log.Errorf("Error during deleting secret: %s", err.Error())
 	}
+
+	log.Info("Delete secret installation records")
+	if err := config.DB().Delete(ClusterSecretInstallationModel{}, "cluster_id = ?", cs.ID).Error; err != nil {
+		log.Errorf("Error during deleting secret installation records: %s", err.Error())
+	}
 }
 
 //Delete cluster from DB
@@ -453,10 +474,90 @@ func (a *EC2ClusterModel) AfterUpdate(scope *gorm.Scope) error {
 }
 
 // UpdateStatus updates the model's status and status message in database
+// and records the transition in the status history of the cluster
 func (cs *ClusterModel) UpdateStatus(status, statusMessage string) error {
+	if err := pkgCluster.ValidateStatusTransition(cs.Status, status); err != nil {
+		return err
+	}
+
+	fromStatus := cs.Status
+	fromStatusMessage := cs.StatusMessage
+
 	cs.Status = status
 	cs.StatusMessage = statusMessage
-	return cs.Save()
+	if err := cs.saveStatus(fromStatus); err != nil {
+		cs.Status = fromStatus
+		cs.StatusMessage = fromStatusMessage
+
+		return err
+	}
+
+	if fromStatus == status {
+		return nil
+	}
+
+	changedBy := cs.StatusChangedBy
+	if changedBy == 0 && fromStatus == "" {
+		changedBy = cs.CreatedBy
+	}
+
+	history := &ClusterStatusHistoryModel{
+		ClusterID:     cs.ID,
+		ClusterName:   cs.Name,
+		FromStatus:    fromStatus,
+		ToStatus:      status,
+		StatusMessage: statusMessage,
+		UserID:        changedBy,
+		CorrelationID: cs.CorrelationID,
+	}
+
+	// the status is already changed at this point, a missing history entry should not fail the caller
+	if err := config.DB().Save(history).Error; err != nil {
+		log.WithFields(logrus.Fields{"organization": cs.OrganizationId, "cluster": cs.ID}).Errorf("Error saving cluster status history: %s", err.Error())
+	}
+
+	return nil
+}
+
+// saveStatus saves the model with its new status unless the stored status changed since the transition was validated,
+// so that concurrent transitions from the same status can't both succeed
+func (cs *ClusterModel) saveStatus(fromStatus string) error {
+	// there is no stored status to compare to before the cluster is saved first
+	if cs.ID == 0 {
+		return cs.Save()
+	}
+
+	tx := config.DB().Begin()
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	result := tx.Model(&ClusterModel{}).Where("id = ? AND status = ?", cs.ID, fromStatus).UpdateColumn("status", cs.Status)
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+
+	// the row is not changed when the status stays the same, so the stored status is checked in that case
+	if result.RowsAffected == 0 {
+		var stored ClusterModel
+		if err := tx.Select("status").Where("id = ?", cs.ID).First(&stored).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if stored.Status != fromStatus {
+			tx.Rollback()
+			return &pkgCluster.StatusTransitionError{From: stored.Status, To: cs.Status}
+		}
+	}
+
+	if err := tx.Save(cs).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
 }
 
 // UpdateConfigSecret updates the model's config secret id in database
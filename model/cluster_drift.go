@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+// ClusterDriftEventModel describes a difference between the stored node pools of a cluster
+// and the state of the cluster in the cloud found by the drift reconciler.
+type ClusterDriftEventModel struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	ClusterID uint `gorm:"index:idx_cluster_drift_event_cluster"`
+	NodePool  string
+	Type      string
+	Desired   string
+	Actual    string
+	Reapplied bool
+}
+
+// TableName sets the database table name for ClusterDriftEventModel
+func (ClusterDriftEventModel) TableName() string {
+	return TableNameClusterDriftEvents
+}
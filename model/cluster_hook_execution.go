@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+)
+
+// HookExecutionModel describes a single posthook or pre-delete hook function execution of a cluster.
+// Hooks executed together share the same RunID and are ordered by Position.
+type HookExecutionModel struct {
+	ID         uint `gorm:"primary_key"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	ClusterID  uint   `gorm:"index:idx_posthook_execution_cluster"`
+	Phase      string `gorm:"index:idx_posthook_execution_cluster"`
+	RunID      string `gorm:"index:idx_posthook_execution_run"`
+	Position   int
+	Name       string
+	Params     string `sql:"type:text;"`
+	Status     string
+	Error      string `sql:"type:text;"`
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+}
+
+// TableName sets the database table name for HookExecutionModel
+func (HookExecutionModel) TableName() string {
+	return TableNameHookExecutions
+}
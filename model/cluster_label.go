@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+)
+
+// ClusterLabelModel describes a label of a cluster.
+type ClusterLabelModel struct {
+	ID             uint `gorm:"primary_key"`
+	CreatedAt      time.Time
+	OrganizationID uint   `gorm:"index:idx_cluster_label_organization"`
+	ClusterID      uint   `gorm:"unique_index:idx_cluster_label"`
+	Key            string `gorm:"unique_index:idx_cluster_label"`
+	Value          string
+}
+
+// TableName sets the database table name for ClusterLabelModel
+func (ClusterLabelModel) TableName() string {
+	return TableNameClusterLabels
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+)
+
+// ClusterOperationModel describes a long running cluster operation (eg. creation, deletion)
+// persisted so that it can be followed and resumed after a Pipeline restart.
+type ClusterOperationModel struct {
+	ID             uint `gorm:"primary_key"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	FinishedAt     *time.Time
+	OrganizationID uint `gorm:"index:idx_cluster_operation_cluster"`
+	ClusterID      uint `gorm:"index:idx_cluster_operation_cluster"`
+	UserID         uint
+	Type           string
+	Status         string `gorm:"index:idx_cluster_operation_status"`
+	Step           string
+	Attempts       int
+	LastError      string `sql:"type:text;"`
+	Force          bool
+	PostHooks      string `sql:"type:text;"`
+	PreDeleteHooks string `sql:"type:text;"`
+}
+
+// TableName sets the database table name for ClusterOperationModel
+func (ClusterOperationModel) TableName() string {
+	return TableNameClusterOperations
+}
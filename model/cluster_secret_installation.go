@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// ClusterSecretInstallationModel describes a namespace of a cluster a secret was installed into.
+type ClusterSecretInstallationModel struct {
+	ID             uint `gorm:"primary_key"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	OrganizationID uint   `gorm:"index:idx_cluster_secret_installation_secret"`
+	SecretID       string `gorm:"index:idx_cluster_secret_installation_secret;unique_index:idx_cluster_secret_installation"`
+	ClusterID      uint   `gorm:"unique_index:idx_cluster_secret_installation"`
+	Namespace      string `gorm:"unique_index:idx_cluster_secret_installation"`
+}
+
+// TableName sets the database table name for ClusterSecretInstallationModel
+func (ClusterSecretInstallationModel) TableName() string {
+	return TableNameSecretInstallations
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+)
+
+// ClusterStatusHistoryModel describes a status transition of a cluster.
+type ClusterStatusHistoryModel struct {
+	ID            uint `gorm:"primary_key"`
+	CreatedAt     time.Time
+	ClusterID     uint `gorm:"index:idx_cluster_status_history_cluster"`
+	ClusterName   string
+	FromStatus    string
+	ToStatus      string
+	StatusMessage string `sql:"type:text;"`
+	UserID        uint
+	CorrelationID string
+}
+
+// TableName sets the database table name for ClusterStatusHistoryModel
+func (ClusterStatusHistoryModel) TableName() string {
+	return TableNameClusterStatusHistory
+}
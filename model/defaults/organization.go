@@ -0,0 +1,207 @@
+package defaults
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/banzaicloud/pipeline/config"
+	"github.com/banzaicloud/pipeline/internal/platform/database"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	"github.com/pkg/errors"
+)
+
+// DefaultOrganizationProfileTableName is the table name of the organization scoped cluster profiles
+const DefaultOrganizationProfileTableName = "profiles_organizations"
+
+// OrganizationProfile describes a cluster profile owned by an organization.
+// It inherits every field of a global profile (its base) and stores only the fields it overrides.
+type OrganizationProfile struct {
+	ID             uint `gorm:"primary_key"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	OrganizationID uint   `gorm:"unique_index:idx_org_distribution_name"`
+	Distribution   string `gorm:"unique_index:idx_org_distribution_name"`
+	Name           string `gorm:"unique_index:idx_org_distribution_name"`
+	Base           string
+	Overrides      string `sql:"type:text;"`
+}
+
+// TableName overrides OrganizationProfile's table name
+func (OrganizationProfile) TableName() string {
+	return DefaultOrganizationProfileTableName
+}
+
+// SaveInstance saves the organization profile into database
+func (p *OrganizationProfile) SaveInstance() error {
+	return save(p)
+}
+
+// DeleteProfile deletes the organization profile from database
+func (p *OrganizationProfile) DeleteProfile() error {
+	return config.DB().Delete(p).Error
+}
+
+// SetOverrides merges the given fields into the overridden fields of the profile.
+// The fields follow the JSON structure of a cluster profile, null values remove an override.
+func (p *OrganizationProfile) SetOverrides(overrides map[string]interface{}) error {
+	current := make(map[string]interface{})
+	if p.Overrides != "" {
+		if err := json.Unmarshal([]byte(p.Overrides), &current); err != nil {
+			return errors.Wrap(err, "could not decode profile overrides")
+		}
+	}
+
+	// identity fields cannot be overridden
+	for _, key := range []string{"name", "cloud", "base"} {
+		delete(overrides, key)
+	}
+
+	encoded, err := json.Marshal(mergeOverrides(current, overrides))
+	if err != nil {
+		return errors.Wrap(err, "could not encode profile overrides")
+	}
+
+	p.Overrides = string(encoded)
+
+	return nil
+}
+
+// ApplyTo returns the given base profile with the overridden fields of the organization profile.
+func (p *OrganizationProfile) ApplyTo(base *pkgCluster.ClusterProfileResponse) (*pkgCluster.ClusterProfileResponse, error) {
+	encoded, err := json.Marshal(base)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encode base profile")
+	}
+
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, errors.Wrap(err, "could not decode base profile")
+	}
+
+	overrides := make(map[string]interface{})
+	if p.Overrides != "" {
+		if err := json.Unmarshal([]byte(p.Overrides), &overrides); err != nil {
+			return nil, errors.Wrap(err, "could not decode profile overrides")
+		}
+	}
+
+	encoded, err = json.Marshal(mergeOverrides(fields, overrides))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encode profile")
+	}
+
+	var profile pkgCluster.ClusterProfileResponse
+	if err := json.Unmarshal(encoded, &profile); err != nil {
+		return nil, errors.Wrap(err, "could not decode profile")
+	}
+
+	profile.Name = p.Name
+	profile.Base = p.Base
+
+	return &profile, nil
+}
+
+// GetProfile returns the organization profile resolved against its base profile
+func (p *OrganizationProfile) GetProfile() (*pkgCluster.ClusterProfileResponse, error) {
+	base, err := GetProfile(p.Distribution, p.Base)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not load base profile %q", p.Base)
+	}
+
+	return p.ApplyTo(base.GetProfile())
+}
+
+// mergeOverrides merges the overrides into the fields recursively, null overrides remove the field
+func mergeOverrides(fields map[string]interface{}, overrides map[string]interface{}) map[string]interface{} {
+	for key, override := range overrides {
+		if override == nil {
+			delete(fields, key)
+			continue
+		}
+
+		overrideMap, isMap := override.(map[string]interface{})
+		fieldMap, isFieldMap := fields[key].(map[string]interface{})
+		if isMap && isFieldMap {
+			fields[key] = mergeOverrides(fieldMap, overrideMap)
+			continue
+		}
+
+		fields[key] = override
+	}
+
+	return fields
+}
+
+// FindOrganizationProfile finds a profile of an organization by distribution and name
+func FindOrganizationProfile(organizationID uint, distribution string, name string) (*OrganizationProfile, error) {
+	var profile OrganizationProfile
+
+	err := config.DB().Where(&OrganizationProfile{
+		OrganizationID: organizationID,
+		Distribution:   distribution,
+		Name:           name,
+	}).First(&profile).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+// GetOrganizationProfile returns a cluster profile as seen by an organization:
+// its own profile with the given name or the global one as a fallback
+func GetOrganizationProfile(organizationID uint, distribution string, name string) (*pkgCluster.ClusterProfileResponse, error) {
+	profile, err := FindOrganizationProfile(organizationID, distribution, name)
+	if err == nil {
+		return profile.GetProfile()
+	} else if !database.IsRecordNotFoundError(err) {
+		return nil, err
+	}
+
+	globalProfile, err := GetProfile(distribution, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return globalProfile.GetProfile(), nil
+}
+
+// GetAllOrganizationProfiles returns the cluster profiles seen by an organization,
+// its own profiles shadow the global ones with the same name
+func GetAllOrganizationProfiles(organizationID uint, distribution string) ([]*pkgCluster.ClusterProfileResponse, error) {
+	globalProfiles, err := GetAllProfiles(distribution)
+	if err != nil {
+		return nil, err
+	}
+
+	var organizationProfiles []*OrganizationProfile
+	err = config.DB().Where(&OrganizationProfile{
+		OrganizationID: organizationID,
+		Distribution:   distribution,
+	}).Order("name").Find(&organizationProfiles).Error
+	if err != nil {
+		return nil, err
+	}
+
+	shadowed := make(map[string]bool, len(organizationProfiles))
+	profiles := make([]*pkgCluster.ClusterProfileResponse, 0, len(globalProfiles)+len(organizationProfiles))
+
+	for _, p := range organizationProfiles {
+		profile, err := p.GetProfile()
+		if err != nil {
+			return nil, err
+		}
+
+		shadowed[p.Name] = true
+		profiles = append(profiles, profile)
+	}
+
+	for _, p := range globalProfiles {
+		profile := p.GetProfile()
+		if !shadowed[profile.Name] {
+			profiles = append(profiles, profile)
+		}
+	}
+
+	return profiles, nil
+}
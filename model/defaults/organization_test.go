@@ -0,0 +1,83 @@
+package defaults_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/banzaicloud/pipeline/model/defaults"
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+	"github.com/banzaicloud/pipeline/pkg/cluster/gke"
+)
+
+func TestOrganizationProfileApplyTo(t *testing.T) {
+	base := &pkgCluster.ClusterProfileResponse{
+		Name:     "default",
+		Location: location,
+		Cloud:    pkgCluster.Google,
+		Properties: &pkgCluster.ClusterProfileProperties{
+			GKE: &gke.ClusterProfileGKE{
+				Master:      &gke.Master{Version: version},
+				NodeVersion: version,
+				NodePools: map[string]*gke.NodePool{
+					agentName: {
+						Autoscaling:      true,
+						MinCount:         minCount,
+						MaxCount:         maxCount,
+						Count:            nodeCount,
+						NodeInstanceType: nodeInstanceType,
+					},
+				},
+			},
+		},
+	}
+
+	profile := &defaults.OrganizationProfile{Name: name, Base: "default"}
+
+	err := profile.SetOverrides(map[string]interface{}{
+		"name":  "ignored",
+		"cloud": "ignored",
+		"properties": map[string]interface{}{
+			"gke": map[string]interface{}{
+				"nodePools": map[string]interface{}{
+					agentName: map[string]interface{}{
+						"instanceType": "n1-standard-2",
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	result, err := profile.ApplyTo(base)
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	expected := &pkgCluster.ClusterProfileResponse{
+		Name:     name,
+		Location: location,
+		Cloud:    pkgCluster.Google,
+		Base:     "default",
+		Properties: &pkgCluster.ClusterProfileProperties{
+			GKE: &gke.ClusterProfileGKE{
+				Master:      &gke.Master{Version: version},
+				NodeVersion: version,
+				NodePools: map[string]*gke.NodePool{
+					agentName: {
+						Autoscaling:      true,
+						MinCount:         minCount,
+						MaxCount:         maxCount,
+						Count:            nodeCount,
+						NodeInstanceType: "n1-standard-2",
+					},
+				},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(expected, result) {
+		t.Errorf("Expected result: %#v, got: %#v", expected, result)
+	}
+}
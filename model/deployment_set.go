@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+)
+
+// DeploymentSetModel describes a chart released into every cluster of an organization matching a selector.
+// The generation is increased on every change of the deployment set, targets behind it are rolled out again.
+type DeploymentSetModel struct {
+	ID             uint `gorm:"primary_key"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	OrganizationID uint   `gorm:"unique_index:idx_deployment_set_name"`
+	Name           string `gorm:"unique_index:idx_deployment_set_name"`
+	ReleaseName    string
+	Chart          string
+	ChartVersion   string
+	Namespace      string
+	Values         string `sql:"type:text;"`
+	ClusterValues  string `sql:"type:text;"`
+	Selector       string `sql:"type:text;"`
+	Concurrency    int
+	Generation     int
+	CreatedBy      uint
+}
+
+// TableName sets the database table name for DeploymentSetModel
+func (DeploymentSetModel) TableName() string {
+	return TableNameDeploymentSets
+}
+
+// DeploymentSetTargetModel describes the release of a deployment set in a cluster.
+type DeploymentSetTargetModel struct {
+	ID              uint `gorm:"primary_key"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeploymentSetID uint `gorm:"unique_index:idx_deployment_set_target"`
+	ClusterID       uint `gorm:"unique_index:idx_deployment_set_target"`
+	ClusterName     string
+	Status          string
+	StatusMessage   string `sql:"type:text;"`
+	Generation      int
+}
+
+// TableName sets the database table name for DeploymentSetTargetModel
+func (DeploymentSetTargetModel) TableName() string {
+	return TableNameDeploymentSetTargets
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+)
+
+// HelmRepositoryModel describes a helm chart repository of an organization,
+// the credentials of private repositories are referenced by secret IDs.
+type HelmRepositoryModel struct {
+	ID               uint `gorm:"primary_key"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	OrganizationID   uint   `gorm:"unique_index:idx_helm_repository_name"`
+	Name             string `gorm:"unique_index:idx_helm_repository_name"`
+	URL              string `sql:"type:text;"`
+	PasswordSecretID string
+	TLSSecretID      string
+	CloudSecretID    string
+}
+
+// TableName sets the database table name for HelmRepositoryModel
+func (HelmRepositoryModel) TableName() string {
+	return TableNameHelmRepositories
+}
+
+// HelmRepositoryCacheModel describes a cached file of a helm repository, the index or a chart archive,
+// shared by the Pipeline instances. The content is gzip compressed.
+type HelmRepositoryCacheModel struct {
+	ID             uint `gorm:"primary_key"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	OrganizationID uint   `gorm:"unique_index:idx_helm_repository_cache"`
+	Repository     string `gorm:"unique_index:idx_helm_repository_cache"`
+	File           string `gorm:"unique_index:idx_helm_repository_cache"`
+	Digest         string
+	Content        []byte `sql:"type:longblob;"`
+}
+
+// TableName sets the database table name for HelmRepositoryCacheModel
+func (HelmRepositoryCacheModel) TableName() string {
+	return TableNameHelmRepositoryCaches
+}
+
+// HelmRepositorySetupModel records that the helm repositories of an organization were set up,
+// the repositories of the local helm homes of earlier versions or the default ones are added only once.
+type HelmRepositorySetupModel struct {
+	OrganizationID uint `gorm:"primary_key;auto_increment:false"`
+	CreatedAt      time.Time
+}
+
+// TableName sets the database table name for HelmRepositorySetupModel
+func (HelmRepositorySetupModel) TableName() string {
+	return TableNameHelmRepositorySetups
+}
@@ -23,6 +23,11 @@ type CreateClusterAKS struct {
 	NodePools         map[string]*NodePoolCreate `json:"nodePools,omitempty"`
 }
 
+// ImportClusterAKS describes Azure fields of an ImportCluster request
+type ImportClusterAKS struct {
+	ResourceGroup string `json:"resourceGroup"`
+}
+
 // NodePoolCreate describes Azure's node fields of a CreateCluster request
 type NodePoolCreate struct {
 	Autoscaling      bool   `json:"autoscaling"`
@@ -96,6 +101,20 @@ func (azure *CreateClusterAKS) Validate() error {
 	return nil
 }
 
+// Validate validates aks cluster import request
+func (azure *ImportClusterAKS) Validate() error {
+
+	if azure == nil {
+		return pkgErrors.ErrorAzureFieldIsEmpty
+	}
+
+	if len(azure.ResourceGroup) == 0 {
+		return pkgErrors.ErrorResourceGroupRequired
+	}
+
+	return nil
+}
+
 func parseVersion(version string) ([]int64, error) {
 	iArray := make([]int64, 3)
 	vArray := strings.Split(version, ".")
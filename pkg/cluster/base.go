@@ -3,6 +3,7 @@ package cluster
 import (
 	"bytes"
 	"fmt"
+	"time"
 
 	"github.com/banzaicloud/pipeline/pkg/cluster/acsk"
 	"github.com/banzaicloud/pipeline/pkg/cluster/aks"
@@ -25,10 +26,56 @@ const (
 	Deleting = "DELETING"
 	Error    = "ERROR"
 
-	CreatingMessage = "Cluster is creating"
-	RunningMessage  = "Cluster is running"
-	UpdatingMessage = "Cluster is updating"
-	DeletingMessage = "Cluster is deleting"
+	CreatingMessage  = "Cluster is creating"
+	RunningMessage   = "Cluster is running"
+	UpdatingMessage  = "Cluster is updating"
+	DeletingMessage  = "Cluster is deleting"
+	ImportingMessage = "Cluster is importing"
+)
+
+// ### [ Cluster operation types, statuses and steps ] ### //
+const (
+	OperationCreate = "CREATE"
+	OperationDelete = "DELETE"
+	OperationImport = "IMPORT"
+
+	OperationPending  = "PENDING"
+	OperationRunning  = "RUNNING"
+	OperationFinished = "FINISHED"
+	OperationFailed   = "FAILED"
+
+	OperationStepCreateCluster      = "CREATE_CLUSTER"
+	OperationStepRunPostHooks       = "RUN_POSTHOOKS"
+	OperationStepRunPreDeleteHooks  = "RUN_PREDELETE_HOOKS"
+	OperationStepDeleteCluster      = "DELETE_CLUSTER"
+	OperationStepDeleteFromDatabase = "DELETE_FROM_DATABASE"
+	OperationStepCleanStateStore    = "CLEAN_STATESTORE"
+)
+
+// ### [ Hook execution phases, statuses and retry modes ] ### //
+const (
+	HookPhasePostHook  = "POSTHOOK"
+	HookPhasePreDelete = "PREDELETE"
+
+	HookPending   = "PENDING"
+	HookRunning   = "RUNNING"
+	HookSucceeded = "SUCCEEDED"
+	HookFailed    = "FAILED"
+
+	// PostHookRetryResume reruns the failed posthook and the ones after it
+	PostHookRetryResume = "resume"
+	// PostHookRetryFailed reruns the failed posthooks and the ones left pending after the first failure
+	PostHookRetryFailed = "failed"
+)
+
+// ### [ Cluster drift types ] ### //
+const (
+	// DriftMissingNodePool means a node pool of the cluster spec has no nodes in the cloud
+	DriftMissingNodePool = "MISSING_NODEPOOL"
+	// DriftUnknownNodePool means nodes in the cloud belong to a node pool missing from the cluster spec
+	DriftUnknownNodePool = "UNKNOWN_NODEPOOL"
+	// DriftNodeCount means the number of nodes in the cloud doesn't match the cluster spec
+	DriftNodeCount = "NODE_COUNT"
 )
 
 // Cloud constants
@@ -70,6 +117,15 @@ const (
 	LabelNodes                             = "LabelNodes"
 )
 
+// constants for pre-delete hooks
+const (
+	DeleteHelmDeployments     = "DeleteHelmDeployments"
+	DrainPersistentVolumes    = "DrainPersistentVolumes"
+	UnregisterDomainPreDelete = "UnregisterDomainPreDelete"
+	UpdatePrometheusPreDelete = "UpdatePrometheusPreDelete"
+	DeleteClusterSecrets      = "DeleteClusterSecrets"
+)
+
 // Provider name regexp
 const (
 	RegexpAWSName = `^[A-z0-9-_]{1,255}$`
@@ -93,6 +149,7 @@ type CreateClusterRequest struct {
 	SecretId    string                   `json:"secretId" binding:"required"`
 	ProfileName string                   `json:"profileName"`
 	PostHooks   PostHooks                `json:"postHooks"`
+	Labels      map[string]string        `json:"labels,omitempty"`
 	Properties  *CreateClusterProperties `json:"properties" binding:"required"`
 }
 
@@ -108,6 +165,54 @@ type CreateClusterProperties struct {
 	CreateClusterOKE   *oke.Cluster                 `json:"oke,omitempty"`
 }
 
+// ClusterLabels describes the labels of a cluster, deployment sets select clusters by them
+type ClusterLabels struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// CloneClusterRequest describes a clone cluster request, the clone gets the definition of the source cluster
+type CloneClusterRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Location string `json:"location,omitempty"`
+	SecretId string `json:"secretId,omitempty"`
+}
+
+// ImportClusterRequest describes an import cluster request
+type ImportClusterRequest struct {
+	// Name is the name of the existing cluster in the cloud, the imported cluster is registered under the same name
+	Name       string                   `json:"name" binding:"required"`
+	Location   string                   `json:"location" binding:"required"`
+	Cloud      string                   `json:"cloud" binding:"required"`
+	SecretId   string                   `json:"secretId" binding:"required"`
+	PostHooks  []string                 `json:"postHooks"`
+	Properties *ImportClusterProperties `json:"properties,omitempty"`
+}
+
+// ImportClusterProperties contains the cloud specific properties needed to find an existing cluster.
+type ImportClusterProperties struct {
+	ImportClusterAKS *aks.ImportClusterAKS `json:"aks,omitempty"`
+}
+
+// Validate checks the request fields
+func (r *ImportClusterRequest) Validate() error {
+	if len(r.Location) == 0 {
+		return pkgErrors.ErrorLocationEmpty
+	}
+
+	switch r.Cloud {
+	case Amazon, Google:
+		return nil
+	case Azure:
+		if r.Properties == nil {
+			return pkgErrors.ErrorAzureFieldIsEmpty
+		}
+
+		return r.Properties.ImportClusterAKS.Validate()
+	default:
+		return pkgErrors.ErrorNotSupportedCloudType
+	}
+}
+
 // PostHookParam describes posthook params in create request
 type PostHookParam interface{}
 
@@ -136,6 +241,69 @@ func (p LoggingParam) String() string {
 // PostHooks describes a {cluster_id}/posthooks API request
 type PostHooks map[string]PostHookParam
 
+// PreDeleteHookParam describes pre-delete hook params in delete request
+type PreDeleteHookParam interface{}
+
+// PreDeleteHooks describes the pre-delete hooks of a delete cluster request
+type PreDeleteHooks map[string]PreDeleteHookParam
+
+// DeleteClusterRequest describes a delete cluster request
+type DeleteClusterRequest struct {
+	PreDeleteHooks PreDeleteHooks `json:"preDeleteHooks"`
+}
+
+// DrainPersistentVolumesParam describes the DrainPersistentVolumes pre-delete hook params
+type DrainPersistentVolumesParam struct {
+	// Namespaces to delete persistent volume claims from, all namespaces if empty
+	Namespaces []string `json:"namespaces"`
+}
+
+// NodePoolDrift describes a difference between the stored spec and the live state of a node pool
+type NodePoolDrift struct {
+	NodePool string `json:"nodePool"`
+	Type     string `json:"type"`
+	Desired  string `json:"desired"`
+	Actual   string `json:"actual"`
+}
+
+// ClusterDriftEventResponse describes a drift recorded by the reconciler
+type ClusterDriftEventResponse struct {
+	NodePoolDrift
+	Reapplied  bool      `json:"reapplied"`
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// GetClusterDriftResponse describes Pipeline's GetClusterDrift API response
+type GetClusterDriftResponse struct {
+	InSync      bool                        `json:"inSync"`
+	Differences []NodePoolDrift             `json:"differences"`
+	Events      []ClusterDriftEventResponse `json:"events"`
+	CheckedAt   time.Time                   `json:"checkedAt"`
+}
+
+// StatusHistoryResponse describes a status transition in Pipeline's GetClusterStatusHistory API response
+type StatusHistoryResponse struct {
+	FromStatus    string    `json:"fromStatus,omitempty"`
+	ToStatus      string    `json:"toStatus"`
+	StatusMessage string    `json:"statusMessage,omitempty"`
+	UserID        uint      `json:"userId,omitempty"`
+	CorrelationID string    `json:"correlationId,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// HookExecutionResponse describes a posthook or pre-delete hook execution in Pipeline's GetPostHookExecutions
+// and GetPreDeleteHookExecutions API responses
+type HookExecutionResponse struct {
+	ID         uint        `json:"id"`
+	RunID      string      `json:"runId"`
+	Name       string      `json:"name"`
+	Params     interface{} `json:"params,omitempty"`
+	Status     string      `json:"status"`
+	Error      string      `json:"error,omitempty"`
+	StartedAt  *time.Time  `json:"startedAt,omitempty"`
+	FinishedAt *time.Time  `json:"finishedAt,omitempty"`
+}
+
 // GetClusterStatusResponse describes Pipeline's GetClusterStatus API response
 type GetClusterStatusResponse struct {
 	Status        string                     `json:"status"`
@@ -153,6 +321,19 @@ type GetClusterStatusResponse struct {
 	Region string `json:"region,omitempty"`
 }
 
+// OperationResponse describes a cluster operation in Pipeline's GetClusterOperations API response
+type OperationResponse struct {
+	ID         uint       `json:"id"`
+	Type       string     `json:"type"`
+	Status     string     `json:"status"`
+	Step       string     `json:"step,omitempty"`
+	Attempts   int        `json:"attempts"`
+	LastError  string     `json:"lastError,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
 // NodePoolStatus describes cluster's node status
 type NodePoolStatus struct {
 	Autoscaling  bool   `json:"autoscaling,omitempty"`
@@ -314,6 +495,27 @@ func (r *CreateClusterRequest) Validate() error {
 	}
 }
 
+// GetDistribution returns the distribution of the cluster described by the request
+func (r *CreateClusterRequest) GetDistribution() string {
+	switch r.Cloud {
+	case Alibaba:
+		return ACSK
+	case Amazon:
+		if r.Properties != nil && r.Properties.CreateClusterEC2 != nil {
+			return EC2
+		}
+		return EKS
+	case Azure:
+		return AKS
+	case Google:
+		return GKE
+	case Oracle:
+		return OKE
+	default:
+		return r.Cloud
+	}
+}
+
 // validateMainFields checks the request's main fields
 func (r *CreateClusterRequest) validateMainFields() error {
 	if r.Cloud != Kubernetes {
@@ -403,17 +605,39 @@ type ClusterProfileResponse struct {
 	Name       string                    `json:"name" binding:"required"`
 	Location   string                    `json:"location" binding:"required"`
 	Cloud      string                    `json:"cloud" binding:"required"`
+	Base       string                    `json:"base,omitempty"`
 	Properties *ClusterProfileProperties `json:"properties" binding:"required"`
 }
 
 // ClusterProfileRequest describes CreateClusterProfile request
+// Organization profiles inherit the fields missing from the request from their base profile
 type ClusterProfileRequest struct {
 	Name       string                    `json:"name" binding:"required"`
-	Location   string                    `json:"location" binding:"required"`
+	Location   string                    `json:"location"`
 	Cloud      string                    `json:"cloud" binding:"required"`
+	Base       string                    `json:"base,omitempty"`
 	Properties *ClusterProfileProperties `json:"properties" binding:"required"`
 }
 
+// GetDistribution returns the distribution of the profile described by the request
+func (r *ClusterProfileRequest) GetDistribution() string {
+	switch r.Cloud {
+	case Amazon:
+		if r.Properties != nil && r.Properties.EC2 != nil {
+			return EC2
+		}
+		return EKS
+	case Azure:
+		return AKS
+	case Google:
+		return GKE
+	case Oracle:
+		return OKE
+	default:
+		return r.Cloud
+	}
+}
+
 type ClusterProfileProperties struct {
 	ACSK *acsk.ClusterProfileACSK `json:"acsk,omitempty"`
 	EC2  *ec2.ClusterProfileEC2   `json:"ec2,omitempty"`
@@ -570,7 +794,7 @@ func (p *ClusterProfileResponse) CreateClusterRequest(createRequest *CreateClust
 			NodePools: p.Properties.ACSK.NodePools,
 		}
 	case Amazon:
-		if response.Properties.CreateClusterEC2 != nil {
+		if p.Properties.EC2 != nil {
 			response.Properties.CreateClusterEC2 = &ec2.CreateClusterEC2{
 				NodePools: p.Properties.EC2.NodePools,
 				Master: &ec2.CreateAmazonMaster{
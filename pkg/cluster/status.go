@@ -0,0 +1,52 @@
+package cluster
+
+import (
+	"fmt"
+)
+
+// statusTransitions lists the statuses a cluster can move to from a given status.
+// Staying in the same status is always allowed as it is used to report progress.
+var statusTransitions = map[string][]string{
+	Creating: {Running, Deleting, Error},
+	// posthooks can be run again on a running cluster
+	Running:  {Creating, Updating, Deleting, Error},
+	Updating: {Running, Deleting, Error},
+	Deleting: {Error},
+	Error:    {Creating, Running, Updating, Deleting},
+}
+
+// StatusTransitionError is returned when a cluster is moved to a status that is not reachable from its current one.
+type StatusTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *StatusTransitionError) Error() string {
+	return fmt.Sprintf("cluster status cannot change from %s to %s", e.From, e.To)
+}
+
+// IsInvalid tells that the transition was requested in an invalid state.
+func (*StatusTransitionError) IsInvalid() bool {
+	return true
+}
+
+// ValidateStatusTransition checks whether a cluster can move from one status to the other.
+// Clusters without a known status (not persisted yet) can start in any status.
+func ValidateStatusTransition(from, to string) error {
+	if from == to {
+		return nil
+	}
+
+	allowed, ok := statusTransitions[from]
+	if !ok {
+		return nil
+	}
+
+	for _, status := range allowed {
+		if status == to {
+			return nil
+		}
+	}
+
+	return &StatusTransitionError{From: from, To: to}
+}
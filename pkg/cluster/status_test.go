@@ -0,0 +1,40 @@
+package cluster_test
+
+import (
+	"testing"
+
+	pkgCluster "github.com/banzaicloud/pipeline/pkg/cluster"
+)
+
+func TestValidateStatusTransition(t *testing.T) {
+	cases := []struct {
+		name    string
+		from    string
+		to      string
+		isValid bool
+	}{
+		{name: "new cluster", from: "", to: pkgCluster.Creating, isValid: true},
+		{name: "created", from: pkgCluster.Creating, to: pkgCluster.Running, isValid: true},
+		{name: "progress", from: pkgCluster.Deleting, to: pkgCluster.Deleting, isValid: true},
+		{name: "update", from: pkgCluster.Running, to: pkgCluster.Updating, isValid: true},
+		{name: "failed deletion", from: pkgCluster.Deleting, to: pkgCluster.Error, isValid: true},
+		{name: "retry", from: pkgCluster.Error, to: pkgCluster.Deleting, isValid: true},
+		{name: "resurrect", from: pkgCluster.Deleting, to: pkgCluster.Running, isValid: false},
+		{name: "update while creating", from: pkgCluster.Creating, to: pkgCluster.Updating, isValid: false},
+		{name: "create while updating", from: pkgCluster.Updating, to: pkgCluster.Creating, isValid: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := pkgCluster.ValidateStatusTransition(tc.from, tc.to)
+
+			if tc.isValid && err != nil {
+				t.Errorf("expected transition from %q to %q to be valid, got: %s", tc.from, tc.to, err)
+			}
+
+			if !tc.isValid && err == nil {
+				t.Errorf("expected transition from %q to %q to be rejected", tc.from, tc.to)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package helm
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Deployment set target statuses
+const (
+	DeploymentSetTargetPending   = "PENDING"
+	DeploymentSetTargetDeploying = "DEPLOYING"
+	DeploymentSetTargetDeployed  = "DEPLOYED"
+	DeploymentSetTargetFailed    = "FAILED"
+)
+
+// DefaultDeploymentSetConcurrency is the number of clusters a deployment set is rolled out to at once by default
+const DefaultDeploymentSetConcurrency = 1
+
+// ClusterSelector selects the clusters of an organization by ID or by labels
+type ClusterSelector struct {
+	ClusterIDs []uint            `json:"clusterIds,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// Empty returns true if the selector selects no clusters
+func (s ClusterSelector) Empty() bool {
+	return len(s.ClusterIDs) == 0 && len(s.Labels) == 0
+}
+
+// Matches returns true if the cluster is listed in the selector or has every label of it
+func (s ClusterSelector) Matches(clusterID uint, labels map[string]string) bool {
+	for _, id := range s.ClusterIDs {
+		if id == clusterID {
+			return true
+		}
+	}
+
+	if len(s.Labels) == 0 {
+		return false
+	}
+
+	for key, value := range s.Labels {
+		if clusterValue, ok := labels[key]; !ok || clusterValue != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// DeploymentSetRequest describes a deployment set create or update request,
+// the chart is released into every cluster of the organization matching the selector
+type DeploymentSetRequest struct {
+	Name        string                 `json:"name"`
+	ReleaseName string                 `json:"releaseName"`
+	Chart       string                 `json:"chart" binding:"required"`
+	Version     string                 `json:"version,omitempty"`
+	Namespace   string                 `json:"namespace"`
+	Values      map[string]interface{} `json:"values,omitempty"`
+	// ClusterValues override the values in the clusters by cluster name
+	ClusterValues map[string]map[string]interface{} `json:"clusterValues,omitempty"`
+	Selector      ClusterSelector                   `json:"selector"`
+	// Concurrency limits the number of clusters the release is installed into or upgraded at once
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+// Validate validates the deployment set request
+func (r *DeploymentSetRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("name must not be empty")
+	}
+
+	if r.Selector.Empty() {
+		return errors.New("selector must contain cluster IDs or labels")
+	}
+
+	if r.Concurrency < 0 {
+		return errors.New("concurrency must not be negative")
+	}
+
+	if r.ReleaseName != "" && len(r.ReleaseName) > releaseNameMaxLen {
+		return errors.Errorf("release name must not be longer than %d characters", releaseNameMaxLen)
+	}
+
+	return nil
+}
+
+// DeploymentSetResponse describes a deployment set with its per cluster status
+type DeploymentSetResponse struct {
+	ID            uint                              `json:"id"`
+	Name          string                            `json:"name"`
+	ReleaseName   string                            `json:"releaseName"`
+	Chart         string                            `json:"chart"`
+	Version       string                            `json:"version,omitempty"`
+	Namespace     string                            `json:"namespace"`
+	Values        map[string]interface{}            `json:"values,omitempty"`
+	ClusterValues map[string]map[string]interface{} `json:"clusterValues,omitempty"`
+	Selector      ClusterSelector                   `json:"selector"`
+	Concurrency   int                               `json:"concurrency"`
+	Generation    int                               `json:"generation"`
+	CreatedAt     time.Time                         `json:"createdAt"`
+	UpdatedAt     time.Time                         `json:"updatedAt"`
+	Clusters      []DeploymentSetClusterStatus      `json:"clusters"`
+}
+
+// DeploymentSetClusterStatus describes the status of a deployment set release in a cluster
+type DeploymentSetClusterStatus struct {
+	ClusterID     uint      `json:"clusterId"`
+	ClusterName   string    `json:"clusterName"`
+	Status        string    `json:"status"`
+	StatusMessage string    `json:"statusMessage,omitempty"`
+	Generation    int       `json:"generation"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
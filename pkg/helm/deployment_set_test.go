@@ -0,0 +1,69 @@
+package helm_test
+
+import (
+	"testing"
+
+	pkgHelm "github.com/banzaicloud/pipeline/pkg/helm"
+)
+
+func TestClusterSelectorMatches(t *testing.T) {
+	cases := []struct {
+		name      string
+		selector  pkgHelm.ClusterSelector
+		clusterID uint
+		labels    map[string]string
+		matches   bool
+	}{
+		{
+			name:      "listed cluster",
+			selector:  pkgHelm.ClusterSelector{ClusterIDs: []uint{1, 2}},
+			clusterID: 2,
+			matches:   true,
+		},
+		{
+			name:      "not listed cluster",
+			selector:  pkgHelm.ClusterSelector{ClusterIDs: []uint{1, 2}},
+			clusterID: 3,
+			labels:    map[string]string{"env": "prod"},
+			matches:   false,
+		},
+		{
+			name:      "every label",
+			selector:  pkgHelm.ClusterSelector{Labels: map[string]string{"env": "prod", "region": "eu"}},
+			clusterID: 3,
+			labels:    map[string]string{"env": "prod", "region": "eu", "team": "web"},
+			matches:   true,
+		},
+		{
+			name:      "missing label",
+			selector:  pkgHelm.ClusterSelector{Labels: map[string]string{"env": "prod", "region": "eu"}},
+			clusterID: 3,
+			labels:    map[string]string{"env": "prod"},
+			matches:   false,
+		},
+		{
+			name:      "different label value",
+			selector:  pkgHelm.ClusterSelector{Labels: map[string]string{"env": "prod"}},
+			clusterID: 3,
+			labels:    map[string]string{"env": "dev"},
+			matches:   false,
+		},
+		{
+			name:      "listed or labeled",
+			selector:  pkgHelm.ClusterSelector{ClusterIDs: []uint{1}, Labels: map[string]string{"env": "prod"}},
+			clusterID: 1,
+			labels:    map[string]string{"env": "dev"},
+			matches:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			matches := tc.selector.Matches(tc.clusterID, tc.labels)
+
+			if matches != tc.matches {
+				t.Errorf("expected match: %t, got: %t", tc.matches, matches)
+			}
+		})
+	}
+}
@@ -85,17 +85,21 @@ type InstallResponse struct {
 type CreateUpdateDeploymentResponse struct {
 	ReleaseName string `json:"releaseName"`
 	Notes       string `json:"notes"`
+	// Manifests are the rendered K8s objects of a dry run
+	Manifests []DeploymentManifest `json:"manifests,omitempty"`
 }
 
 // CreateUpdateDeploymentRequest describes a Helm deployment
 type CreateUpdateDeploymentRequest struct {
-	Name        string                 `json:"name" binding:"required"`
-	Version     string                 `json:"version,omitempty"`
-	Package     []byte                 `json:"package,omitempty"`
-	ReleaseName string                 `json:"releaseName"`
-	ReUseValues bool                   `json:"reuseValues"`
-	Namespace   string                 `json:"namespace"`
-	Values      map[string]interface{} `json:"values,omitempty"`
+	Name             string                 `json:"name" binding:"required"`
+	Version          string                 `json:"version,omitempty"`
+	Package          []byte                 `json:"package,omitempty"`
+	ReleaseName      string                 `json:"releaseName"`
+	ReUseValues      bool                   `json:"reuseValues"`
+	Namespace        string                 `json:"namespace"`
+	Values           map[string]interface{} `json:"values,omitempty"`
+	ImagePullSecrets []string               `json:"imagePullSecrets,omitempty"`
+	DryRun           bool                   `json:"dryRun,omitempty"`
 }
 
 // ListDeploymentResponse describes a deployment list response
@@ -131,6 +135,34 @@ type GetDeploymentResponse struct {
 	Updated      string                 `json:"updatedAt,omitempty"`
 	Notes        string                 `json:"notes"`
 	Values       map[string]interface{} `json:"values"`
+	// Manifest is the rendered manifest of the release, it is not part of the API response
+	Manifest string `json:"-"`
+}
+
+// DeploymentHistoryItem describes a revision of a helm deployment
+type DeploymentHistoryItem struct {
+	Revision     int32  `json:"revision"`
+	Chart        string `json:"chart"`
+	ChartName    string `json:"chartName"`
+	ChartVersion string `json:"chartVersion"`
+	Status       string `json:"status"`
+	Updated      string `json:"updatedAt,omitempty"`
+	Description  string `json:"description"`
+}
+
+// RollbackDeploymentRequest describes a helm deployment rollback request
+type RollbackDeploymentRequest struct {
+	Revision int32 `json:"revision" binding:"required,min=1"`
+	Wait     bool  `json:"wait"`
+	// Timeout of the wait in seconds
+	Timeout int64 `json:"timeout"`
+}
+
+// RollbackDeploymentResponse describes a helm deployment rollback response
+type RollbackDeploymentResponse struct {
+	ReleaseName string `json:"releaseName"`
+	Revision    int32  `json:"revision"`
+	Status      string `json:"status"`
 }
 
 // GetDeploymentResourcesResponse lists the resources of a helm deployment
@@ -0,0 +1,165 @@
+package helm
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Manifest diff changes
+const (
+	ManifestAdded     = "added"
+	ManifestRemoved   = "removed"
+	ManifestChanged   = "changed"
+	ManifestUnchanged = "unchanged"
+)
+
+var manifestSeparator = regexp.MustCompile(`(?:^|\s*\n)---\s*`)
+
+// DeploymentManifest describes a rendered K8s object of a helm deployment
+type DeploymentManifest struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Manifest  string `json:"manifest"`
+}
+
+// DeploymentManifestDiff describes the change of a K8s object of a helm deployment,
+// the diff is in unified format and it is empty for unchanged objects
+type DeploymentManifestDiff struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Change    string `json:"change"`
+	Diff      string `json:"diff,omitempty"`
+}
+
+// DeploymentDiffResponse describes the changes an upgrade would make to a helm deployment
+type DeploymentDiffResponse struct {
+	ReleaseName string                   `json:"releaseName"`
+	Objects     []DeploymentManifestDiff `json:"objects"`
+}
+
+type manifestHead struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+}
+
+// SplitManifest splits the manifest of a helm release into K8s objects ordered by kind, namespace and name,
+// documents without a kind (eg. empty templates) are skipped
+func SplitManifest(manifest string) []DeploymentManifest {
+	manifests := make([]DeploymentManifest, 0)
+
+	for _, document := range manifestSeparator.Split(manifest, -1) {
+		var head manifestHead
+		if err := yaml.Unmarshal([]byte(document), &head); err != nil || head.Kind == "" {
+			continue
+		}
+
+		manifests = append(manifests, DeploymentManifest{
+			Kind:      head.Kind,
+			Name:      head.Metadata.Name,
+			Namespace: head.Metadata.Namespace,
+			Manifest:  strings.TrimSpace(document) + "\n",
+		})
+	}
+
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifestKey(manifests[i].Kind, manifests[i].Namespace, manifests[i].Name) <
+			manifestKey(manifests[j].Kind, manifests[j].Namespace, manifests[j].Name)
+	})
+
+	return manifests
+}
+
+// DiffManifests compares the K8s objects of the current and the new manifest of a helm release
+func DiffManifests(currentManifest string, newManifest string) ([]DeploymentManifestDiff, error) {
+	currentObjects := make(map[string]DeploymentManifest)
+	for _, object := range SplitManifest(currentManifest) {
+		currentObjects[manifestKey(object.Kind, object.Namespace, object.Name)] = object
+	}
+
+	diffs := make([]DeploymentManifestDiff, 0)
+
+	for _, object := range SplitManifest(newManifest) {
+		key := manifestKey(object.Kind, object.Namespace, object.Name)
+		currentObject, ok := currentObjects[key]
+		delete(currentObjects, key)
+
+		diff := DeploymentManifestDiff{
+			Kind:      object.Kind,
+			Name:      object.Name,
+			Namespace: object.Namespace,
+			Change:    ManifestAdded,
+		}
+
+		if ok {
+			diff.Change = ManifestUnchanged
+			if currentObject.Manifest != object.Manifest {
+				diff.Change = ManifestChanged
+			}
+		}
+
+		if diff.Change != ManifestUnchanged {
+			text, err := unifiedDiff(currentObject.Manifest, object.Manifest)
+			if err != nil {
+				return nil, err
+			}
+
+			diff.Diff = text
+		}
+
+		diffs = append(diffs, diff)
+	}
+
+	for _, object := range currentObjects {
+		text, err := unifiedDiff(object.Manifest, "")
+		if err != nil {
+			return nil, err
+		}
+
+		diffs = append(diffs, DeploymentManifestDiff{
+			Kind:      object.Kind,
+			Name:      object.Name,
+			Namespace: object.Namespace,
+			Change:    ManifestRemoved,
+			Diff:      text,
+		})
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return manifestKey(diffs[i].Kind, diffs[i].Namespace, diffs[i].Name) <
+			manifestKey(diffs[j].Kind, diffs[j].Namespace, diffs[j].Name)
+	})
+
+	return diffs, nil
+}
+
+func manifestKey(kind string, namespace string, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+func unifiedDiff(currentManifest string, newManifest string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(currentManifest),
+		B:        splitLines(newManifest),
+		FromFile: "current",
+		ToFile:   "new",
+		Context:  3,
+	})
+}
+
+// splitLines splits a manifest into lines, an empty manifest has no lines
+func splitLines(manifest string) []string {
+	if manifest == "" {
+		return nil
+	}
+
+	return difflib.SplitLines(manifest)
+}
@@ -0,0 +1,99 @@
+package helm_test
+
+import (
+	"reflect"
+	"testing"
+
+	pkgHelm "github.com/banzaicloud/pipeline/pkg/helm"
+)
+
+const currentManifest = `
+---
+# Source: app/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+spec:
+  ports:
+  - port: 80
+---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 1
+---
+# Source: app/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  key: value
+`
+
+const newManifest = `
+---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 2
+---
+# Source: app/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+spec:
+  ports:
+  - port: 80
+---
+# Source: app/templates/ingress.yaml
+apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: app
+---
+# Source: app/templates/empty.yaml
+`
+
+func TestDiffManifests(t *testing.T) {
+	diffs, err := pkgHelm.DiffManifests(currentManifest, newManifest)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	changes := make(map[string]string, len(diffs))
+	for _, diff := range diffs {
+		changes[diff.Kind] = diff.Change
+
+		if diff.Change == pkgHelm.ManifestUnchanged && diff.Diff != "" {
+			t.Errorf("expected no diff of unchanged %s, got: %s", diff.Kind, diff.Diff)
+		}
+
+		if diff.Change != pkgHelm.ManifestUnchanged && diff.Diff == "" {
+			t.Errorf("expected diff of %s %s", diff.Change, diff.Kind)
+		}
+	}
+
+	expected := map[string]string{
+		"ConfigMap":  pkgHelm.ManifestRemoved,
+		"Deployment": pkgHelm.ManifestChanged,
+		"Ingress":    pkgHelm.ManifestAdded,
+		"Service":    pkgHelm.ManifestUnchanged,
+	}
+
+	if !reflect.DeepEqual(expected, changes) {
+		t.Errorf("expected changes: %v, got: %v", expected, changes)
+	}
+
+	if diffs[0].Kind != "ConfigMap" || diffs[len(diffs)-1].Kind != "Service" {
+		t.Errorf("expected diffs ordered by kind, got: %v", diffs)
+	}
+}
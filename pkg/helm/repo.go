@@ -0,0 +1,91 @@
+package helm
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/banzaicloud/pipeline/pkg/cluster"
+	"github.com/pkg/errors"
+)
+
+// Schemes of helm repositories hosted in object storage buckets
+const (
+	S3RepoScheme    = "s3"
+	GCSRepoScheme   = "gs"
+	AzureRepoScheme = "azblob"
+)
+
+// BucketRepoProviders maps the schemes of bucket repositories to the provider type of the secret they are accessed with
+var BucketRepoProviders = map[string]string{
+	S3RepoScheme:    cluster.Amazon,
+	GCSRepoScheme:   cluster.Google,
+	AzureRepoScheme: cluster.Azure,
+}
+
+// RepoRequest describes a helm repository add or modify request,
+// the credentials of private repositories are referenced by the name of Pipeline secrets
+type RepoRequest struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// PasswordSecretRef references a password secret used for basic auth
+	PasswordSecretRef string `json:"passwordSecretRef,omitempty"`
+	// TLSSecretRef references a tls secret with the client certificate and/or the CA of the repository
+	TLSSecretRef string `json:"tlsSecretRef,omitempty"`
+	// CloudSecretRef references the cloud secret of repositories in s3://, gs:// or azblob://<storage account>/ buckets
+	CloudSecretRef string `json:"cloudSecretRef,omitempty"`
+}
+
+// Validate validates the repository request
+func (r *RepoRequest) Validate() error {
+	if r.URL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return errors.Wrap(err, "invalid repository url")
+	}
+
+	if _, ok := BucketRepoProviders[u.Scheme]; ok {
+		if r.CloudSecretRef == "" {
+			return errors.Errorf("cloud secret is required for %s:// repositories", u.Scheme)
+		}
+
+		if r.PasswordSecretRef != "" || r.TLSSecretRef != "" {
+			return errors.Errorf("%s:// repositories are accessed with the cloud secret only", u.Scheme)
+		}
+
+		return nil
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.Errorf("unsupported repository url scheme %q", u.Scheme)
+	}
+
+	if r.CloudSecretRef != "" {
+		return errors.New("cloud secret can only be used with bucket repositories")
+	}
+
+	return nil
+}
+
+// RepoSecrets references the secrets of a private helm repository by ID,
+// so that the repository keeps working after the secrets are renamed or rotated
+type RepoSecrets struct {
+	OrganizationID   uint   `json:"organizationId"`
+	PasswordSecretID string `json:"passwordSecretId,omitempty"`
+	TLSSecretID      string `json:"tlsSecretId,omitempty"`
+	CloudSecretID    string `json:"cloudSecretId,omitempty"`
+}
+
+// Empty returns true if the repository has no secrets
+func (s RepoSecrets) Empty() bool {
+	return s.PasswordSecretID == "" && s.TLSSecretID == "" && s.CloudSecretID == ""
+}
+
+// RepoURLMatches returns true if the URL points into the repository
+func RepoURLMatches(repoURL string, URL string) bool {
+	repoURL = strings.TrimSuffix(repoURL, "/")
+
+	return repoURL != "" && (URL == repoURL || strings.HasPrefix(URL, repoURL+"/"))
+}
@@ -0,0 +1,84 @@
+package helm_test
+
+import (
+	"testing"
+
+	pkgHelm "github.com/banzaicloud/pipeline/pkg/helm"
+)
+
+func TestRepoRequestValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		request pkgHelm.RepoRequest
+		valid   bool
+	}{
+		{
+			name:    "public",
+			request: pkgHelm.RepoRequest{Name: "stable", URL: "https://kubernetes-charts.storage.googleapis.com"},
+			valid:   true,
+		},
+		{
+			name:    "basic auth and tls",
+			request: pkgHelm.RepoRequest{Name: "private", URL: "https://charts.example.com", PasswordSecretRef: "charts", TLSSecretRef: "charts-tls"},
+			valid:   true,
+		},
+		{
+			name:    "s3 bucket",
+			request: pkgHelm.RepoRequest{Name: "internal", URL: "s3://charts/stable", CloudSecretRef: "aws"},
+			valid:   true,
+		},
+		{
+			name:    "bucket without cloud secret",
+			request: pkgHelm.RepoRequest{Name: "internal", URL: "gs://charts"},
+			valid:   false,
+		},
+		{
+			name:    "bucket with password secret",
+			request: pkgHelm.RepoRequest{Name: "internal", URL: "azblob://account/charts", CloudSecretRef: "azure", PasswordSecretRef: "charts"},
+			valid:   false,
+		},
+		{
+			name:    "cloud secret for http repository",
+			request: pkgHelm.RepoRequest{Name: "private", URL: "https://charts.example.com", CloudSecretRef: "aws"},
+			valid:   false,
+		},
+		{
+			name:    "unsupported scheme",
+			request: pkgHelm.RepoRequest{Name: "private", URL: "ftp://charts.example.com"},
+			valid:   false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.request.Validate()
+
+			if tc.valid && err != nil {
+				t.Errorf("expected valid request, got error: %s", err.Error())
+			} else if !tc.valid && err == nil {
+				t.Error("expected invalid request")
+			}
+		})
+	}
+}
+
+func TestRepoURLMatches(t *testing.T) {
+	cases := []struct {
+		repoURL  string
+		URL      string
+		expected bool
+	}{
+		{"https://charts.example.com", "https://charts.example.com/index.yaml", true},
+		{"https://charts.example.com/", "https://charts.example.com/app-0.1.0.tgz", true},
+		{"s3://charts/stable", "s3://charts/stable", true},
+		{"s3://charts/stable", "s3://charts/stable-old/index.yaml", false},
+		{"https://charts.example.com", "https://charts.example.com.evil.com/index.yaml", false},
+		{"", "https://charts.example.com/index.yaml", false},
+	}
+
+	for _, tc := range cases {
+		if actual := pkgHelm.RepoURLMatches(tc.repoURL, tc.URL); actual != tc.expected {
+			t.Errorf("expected %t for url %s of repository %s, got %t", tc.expected, tc.URL, tc.repoURL, actual)
+		}
+	}
+}
@@ -10,14 +10,35 @@ type FieldMeta struct {
 	Name        string `json:"name"`
 	Required    bool   `json:"required"`
 	Description string `json:"description,omitempty"`
+	Pattern     string `json:"pattern,omitempty"`
+	Format      string `json:"format,omitempty"`
+	Generate    string `json:"generate,omitempty"`
 }
 
 // Meta describes how a secret is built up and how it should be sourced
 type Meta struct {
-	Fields   []FieldMeta    `json:"fields"`
-	Sourcing SourcingMethod `json:"sourcing"`
+	Fields         []FieldMeta    `json:"fields"`
+	Sourcing       SourcingMethod `json:"sourcing"`
+	KubernetesType string         `json:"kubernetesType,omitempty"`
 }
 
+// Formats the values of secret fields can be validated against
+const (
+	FormatEmail    = "email"
+	FormatURL      = "url"
+	FormatHostname = "hostname"
+	FormatBase64   = "base64"
+	FormatJSON     = "json"
+)
+
+// Generators of secret field values, they generate the value if it's empty or describes the value to generate
+const (
+	// GeneratePassword generates a random string, the value may describe it as "method,length"
+	GeneratePassword = "password"
+	// GenerateTLS generates the certificates of the TLS fields for the hosts in the value
+	GenerateTLS = "tls"
+)
+
 // Alibaba keys
 const (
 	AlibabaRegion          = "ALIBABA_REGION_ID"
@@ -30,6 +51,13 @@ const (
 	AwsRegion          = "AWS_REGION"
 	AwsAccessKeyId     = "AWS_ACCESS_KEY_ID"
 	AwsSecretAccessKey = "AWS_SECRET_ACCESS_KEY"
+	AwsSessionToken    = "AWS_SESSION_TOKEN"
+)
+
+// Amazon role keys
+const (
+	AwsRoleArn    = "AWS_ROLE_ARN"
+	AwsExternalId = "AWS_EXTERNAL_ID"
 )
 
 // Azure keys
@@ -40,6 +68,11 @@ const (
 	AzureSubscriptionId = "AZURE_SUBSCRIPTION_ID"
 )
 
+// Azure client credentials keys
+const (
+	AzureApplicationObjectId = "AZURE_APPLICATION_OBJECT_ID"
+)
+
 // Google keys
 const (
 	Type          = "type"
@@ -52,6 +85,17 @@ const (
 	TokenUri      = "token_uri"
 	AuthX509Url   = "auth_provider_x509_cert_url"
 	ClientX509Url = "client_x509_cert_url"
+	AccessToken   = "access_token"
+)
+
+// Google impersonation keys
+const (
+	TargetServiceAccount = "target_service_account"
+)
+
+// Expiration of dynamic credentials
+const (
+	CredentialsExpiration = "credentials_expiration"
 )
 
 // Kubernetes keys
@@ -91,6 +135,21 @@ const (
 	Password = "password"
 )
 
+// Docker registry keys
+const (
+	DockerServer   = "server"
+	DockerUsername = "username"
+	DockerPassword = "password"
+	DockerEmail    = "email"
+	// DockerSourceSecret is the name of the cloud secret the registry credentials are minted with
+	DockerSourceSecret = "source_secret"
+	// DockerConfigJSON is the key of the docker config in Kubernetes image pull secrets
+	DockerConfigJSON = ".dockerconfigjson"
+)
+
+// KubernetesDockerConfigJSONType is the type of the Kubernetes image pull secrets
+const KubernetesDockerConfigJSONType = "kubernetes.io/dockerconfigjson"
+
 // Internal usage
 const (
 	TagKubeConfig     = "KubeConfig"
@@ -116,8 +175,39 @@ const (
 	FnSecretType = "fn"
 	// PasswordSecretType marks secrets as of type "password"
 	PasswordSecretType = "password"
+	// AmazonRoleSecretType marks secrets minting Amazon credentials by assuming a role
+	AmazonRoleSecretType = "amazon-role"
+	// AzureClientCredentialsSecretType marks secrets minting short-lived Azure client secrets for an application
+	AzureClientCredentialsSecretType = "azure-client-credentials"
+	// GoogleImpersonationSecretType marks secrets minting Google access tokens by impersonating a service account
+	GoogleImpersonationSecretType = "google-impersonation"
+	// DockerRegistrySecretType marks secrets as of type "docker-registry"
+	DockerRegistrySecretType = "docker-registry"
+	// ECRRegistrySecretType marks secrets minting Amazon ECR tokens with an Amazon secret
+	ECRRegistrySecretType = "docker-registry-ecr"
+	// GCRRegistrySecretType marks secrets minting Google Container Registry tokens with a Google secret
+	GCRRegistrySecretType = "docker-registry-gcr"
+	// ACRRegistrySecretType marks secrets minting Azure Container Registry tokens with an Azure secret
+	ACRRegistrySecretType = "docker-registry-acr"
 )
 
+// DynamicSecretTypes maps the secret types minting short-lived credentials to the provider type of the credentials
+var DynamicSecretTypes = map[string]string{
+	AmazonRoleSecretType:             cluster.Amazon,
+	AzureClientCredentialsSecretType: cluster.Azure,
+	GoogleImpersonationSecretType:    cluster.Google,
+	ECRRegistrySecretType:            DockerRegistrySecretType,
+	GCRRegistrySecretType:            DockerRegistrySecretType,
+	ACRRegistrySecretType:            DockerRegistrySecretType,
+}
+
+// RegistrySourceTypes maps the registry secret types to the type of the secret their credentials are minted with
+var RegistrySourceTypes = map[string]string{
+	ECRRegistrySecretType: cluster.Amazon,
+	GCRRegistrySecretType: cluster.Google,
+	ACRRegistrySecretType: cluster.Azure,
+}
+
 // DefaultRules key matching for types
 var DefaultRules = map[string]Meta{
 	cluster.Alibaba: {
@@ -216,6 +306,65 @@ var DefaultRules = map[string]Meta{
 		},
 		Sourcing: EnvVar,
 	},
+	AmazonRoleSecretType: {
+		Fields: []FieldMeta{
+			{Name: AwsRegion, Required: false},
+			{Name: AwsRoleArn, Required: true, Description: "The role assumed by Pipeline"},
+			{Name: AwsExternalId, Required: false, Description: "The external ID the trust policy of the role has to require, it is generated by Pipeline for the organization"},
+		},
+		Sourcing: EnvVar,
+	},
+	AzureClientCredentialsSecretType: {
+		Fields: []FieldMeta{
+			{Name: AzureClientId, Required: true},
+			{Name: AzureTenantId, Required: true},
+			{Name: AzureSubscriptionId, Required: true},
+			{Name: AzureApplicationObjectId, Required: true, Description: "The object ID of the application Pipeline adds short-lived client secrets to"},
+		},
+		Sourcing: EnvVar,
+	},
+	GoogleImpersonationSecretType: {
+		Fields: []FieldMeta{
+			{Name: ProjectId, Required: true},
+			{Name: TargetServiceAccount, Required: true, Description: "The email of the service account impersonated by Pipeline"},
+		},
+		Sourcing: EnvVar,
+	},
+	DockerRegistrySecretType: {
+		Fields: []FieldMeta{
+			{Name: DockerServer, Required: true, Description: "The address of the registry, like https://index.docker.io/v1/"},
+			{Name: DockerUsername, Required: true},
+			{Name: DockerPassword, Required: true},
+			{Name: DockerEmail, Required: false, Format: FormatEmail},
+		},
+		Sourcing:       Volume,
+		KubernetesType: KubernetesDockerConfigJSONType,
+	},
+	ECRRegistrySecretType: {
+		Fields: []FieldMeta{
+			{Name: DockerSourceSecret, Required: true, Description: "The name of the Amazon secret"},
+			{Name: AwsRegion, Required: false, Description: "The region of the registry, the region of the Amazon secret by default"},
+			{Name: DockerServer, Required: false, Description: "The address of the registry, the registry of the account by default"},
+		},
+		Sourcing:       Volume,
+		KubernetesType: KubernetesDockerConfigJSONType,
+	},
+	GCRRegistrySecretType: {
+		Fields: []FieldMeta{
+			{Name: DockerSourceSecret, Required: true, Description: "The name of the Google secret"},
+			{Name: DockerServer, Required: false, Description: "The address of the registry, https://gcr.io by default"},
+		},
+		Sourcing:       Volume,
+		KubernetesType: KubernetesDockerConfigJSONType,
+	},
+	ACRRegistrySecretType: {
+		Fields: []FieldMeta{
+			{Name: DockerSourceSecret, Required: true, Description: "The name of the Azure secret"},
+			{Name: DockerServer, Required: true, Description: "The login server of the registry, like myregistry.azurecr.io"},
+		},
+		Sourcing:       Volume,
+		KubernetesType: KubernetesDockerConfigJSONType,
+	},
 }
 
 // ListSecretsQuery represent a secret listing filter
@@ -231,6 +380,29 @@ type InstallSecretsToClusterRequest struct {
 	Query     ListSecretsQuery `json:"query" binding:"required"`
 }
 
+// InstallImagePullSecretRequest describes a docker registry secret to be installed into namespaces of a cluster
+// and referenced by the default service account of the namespaces
+type InstallImagePullSecretRequest struct {
+	SecretName string   `json:"secretName" binding:"required"`
+	Namespaces []string `json:"namespaces" binding:"required,min=1"`
+}
+
+// Propagation statuses of a rotated secret
+const (
+	PropagationSucceeded = "SUCCEEDED"
+	PropagationFailed    = "FAILED"
+	PropagationSkipped   = "SKIPPED"
+)
+
+// ClusterPropagationResult describes how a rotated secret was re-installed into a cluster
+type ClusterPropagationResult struct {
+	ClusterID   uint     `json:"clusterId"`
+	ClusterName string   `json:"clusterName"`
+	Namespaces  []string `json:"namespaces"`
+	Status      string   `json:"status"`
+	Error       string   `json:"error,omitempty"`
+}
+
 // SourcingMethod describes how an installed Secret should be sourced into a Pod in K8S
 type SourcingMethod string
 
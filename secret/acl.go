@@ -0,0 +1,186 @@
+package secret
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/banzaicloud/pipeline/config"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// SecretACL restricts who can reveal and change a secret of an organization.
+// Secrets without allowed users and roles are accessible by every member who can access secrets at all,
+// use-only secrets can be consumed by clusters and hooks, but their values are never revealed through the API,
+// only admins can read them back from the clusters they are installed into.
+type SecretACL struct {
+	ID             uint      `gorm:"primary_key" json:"-"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+	OrganizationID uint      `gorm:"unique_index:idx_secret_acl" json:"-"`
+	SecretID       string    `gorm:"unique_index:idx_secret_acl" json:"secretId"`
+	OwnerID        uint      `json:"ownerId,omitempty"`
+	Users          []uint    `gorm:"-" json:"users"`
+	Roles          []string  `gorm:"-" json:"roles"`
+	UseOnly        bool      `json:"useOnly"`
+	UsersRaw       string    `gorm:"column:users;type:text" json:"-"`
+	RolesRaw       string    `gorm:"column:roles;type:text" json:"-"`
+}
+
+// TableName overrides SecretACL's table name
+func (SecretACL) TableName() string {
+	return "secret_acls"
+}
+
+// BeforeSave serializes the allowed users and roles
+func (acl *SecretACL) BeforeSave() error {
+	users, err := json.Marshal(acl.Users)
+	if err != nil {
+		return err
+	}
+
+	roles, err := json.Marshal(acl.Roles)
+	if err != nil {
+		return err
+	}
+
+	acl.UsersRaw = string(users)
+	acl.RolesRaw = string(roles)
+
+	return nil
+}
+
+// AfterFind deserializes the allowed users and roles
+func (acl *SecretACL) AfterFind() error {
+	if acl.UsersRaw != "" {
+		if err := json.Unmarshal([]byte(acl.UsersRaw), &acl.Users); err != nil {
+			return err
+		}
+	}
+
+	if acl.RolesRaw != "" {
+		if err := json.Unmarshal([]byte(acl.RolesRaw), &acl.Roles); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SecretACLRequest describes the ACL of a secret, a zero owner leaves the owner unchanged
+type SecretACLRequest struct {
+	OwnerID uint     `json:"ownerId,omitempty"`
+	Users   []uint   `json:"users"`
+	Roles   []string `json:"roles"`
+	UseOnly bool     `json:"useOnly"`
+}
+
+// Accessor is the user accessing a secret through the API
+type Accessor struct {
+	UserID uint
+	Role   string
+	Admin  bool
+}
+
+// CanManage checks whether the accessor can change the ACL of the secret, only the owner and admins can
+func (acl *SecretACL) CanManage(accessor Accessor) bool {
+	return accessor.Admin || (acl.OwnerID != 0 && acl.OwnerID == accessor.UserID)
+}
+
+// CanAccess checks whether the accessor can update, rotate or delete the secret
+func (acl *SecretACL) CanAccess(accessor Accessor) bool {
+	if acl.CanManage(accessor) || (len(acl.Users) == 0 && len(acl.Roles) == 0) {
+		return true
+	}
+
+	for _, userID := range acl.Users {
+		if userID == accessor.UserID {
+			return true
+		}
+	}
+
+	for _, role := range acl.Roles {
+		if role == accessor.Role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanReveal checks whether the accessor can read the values of the secret
+func (acl *SecretACL) CanReveal(accessor Accessor) bool {
+	return !acl.UseOnly && acl.CanAccess(accessor)
+}
+
+// AccessDeniedError is returned when a user is not allowed to access a secret
+type AccessDeniedError struct {
+	SecretID string
+}
+
+func (e AccessDeniedError) Error() string {
+	return fmt.Sprintf("access to secret [%s] is denied by its ACL", e.SecretID)
+}
+
+// GetACL returns the ACL of a secret, secrets without a stored ACL get an unrestricted one
+func GetACL(organizationID uint, secretID string) (*SecretACL, error) {
+	acl := SecretACL{OrganizationID: organizationID, SecretID: secretID}
+
+	err := config.DB().Where(&SecretACL{OrganizationID: organizationID, SecretID: secretID}).First(&acl).Error
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		return nil, errors.Wrap(err, "could not get secret ACL")
+	}
+
+	return &acl, nil
+}
+
+// GetACLs returns the stored ACLs of an organization by secret ID
+func GetACLs(organizationID uint) (map[string]*SecretACL, error) {
+	var acls []*SecretACL
+
+	if err := config.DB().Where(&SecretACL{OrganizationID: organizationID}).Find(&acls).Error; err != nil {
+		return nil, errors.Wrap(err, "could not list secret ACLs")
+	}
+
+	aclsBySecret := make(map[string]*SecretACL, len(acls))
+	for _, acl := range acls {
+		aclsBySecret[acl.SecretID] = acl
+	}
+
+	return aclsBySecret, nil
+}
+
+// SaveACL creates or updates the ACL of a secret
+func SaveACL(acl *SecretACL) error {
+	if err := config.DB().Save(acl).Error; err != nil {
+		return errors.Wrap(err, "could not save secret ACL")
+	}
+
+	return nil
+}
+
+// HideRestrictedValues hides the values of the secrets the accessor can't reveal
+func HideRestrictedValues(organizationID uint, items []*SecretItemResponse, accessor Accessor) error {
+	acls, err := GetACLs(organizationID)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if acl, ok := acls[item.ID]; ok && !acl.CanReveal(accessor) {
+			item.hideValues()
+		}
+	}
+
+	return nil
+}
+
+func deleteACL(organizationID uint, secretID string) error {
+	err := config.DB().Where(&SecretACL{OrganizationID: organizationID, SecretID: secretID}).Delete(SecretACL{}).Error
+	if err != nil {
+		return errors.Wrap(err, "could not delete secret ACL")
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package secret_test
+
+import (
+	"testing"
+
+	"github.com/banzaicloud/pipeline/secret"
+)
+
+func TestSecretACL(t *testing.T) {
+	owner := secret.Accessor{UserID: 1, Role: "member"}
+	admin := secret.Accessor{UserID: 2, Role: "admin", Admin: true}
+	allowedUser := secret.Accessor{UserID: 3, Role: "viewer"}
+	allowedRole := secret.Accessor{UserID: 4, Role: "ops"}
+	other := secret.Accessor{UserID: 5, Role: "member"}
+
+	cases := []struct {
+		name   string
+		acl    secret.SecretACL
+		user   secret.Accessor
+		manage bool
+		access bool
+		reveal bool
+	}{
+		{name: "open secret", acl: secret.SecretACL{OwnerID: 1}, user: other, manage: false, access: true, reveal: true},
+		{name: "owner", acl: secret.SecretACL{OwnerID: 1, Users: []uint{3}}, user: owner, manage: true, access: true, reveal: true},
+		{name: "admin", acl: secret.SecretACL{OwnerID: 1, Users: []uint{3}}, user: admin, manage: true, access: true, reveal: true},
+		{name: "allowed user", acl: secret.SecretACL{OwnerID: 1, Users: []uint{3}}, user: allowedUser, manage: false, access: true, reveal: true},
+		{name: "allowed role", acl: secret.SecretACL{OwnerID: 1, Roles: []string{"ops"}}, user: allowedRole, manage: false, access: true, reveal: true},
+		{name: "not allowed", acl: secret.SecretACL{OwnerID: 1, Users: []uint{3}, Roles: []string{"ops"}}, user: other, manage: false, access: false, reveal: false},
+		{name: "use-only owner", acl: secret.SecretACL{OwnerID: 1, UseOnly: true}, user: owner, manage: true, access: true, reveal: false},
+		{name: "use-only admin", acl: secret.SecretACL{OwnerID: 1, UseOnly: true}, user: admin, manage: true, access: true, reveal: false},
+		{name: "legacy secret without owner", acl: secret.SecretACL{}, user: secret.Accessor{}, manage: false, access: true, reveal: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if manage := tc.acl.CanManage(tc.user); manage != tc.manage {
+				t.Errorf("expected manage: %t, got: %t", tc.manage, manage)
+			}
+			if access := tc.acl.CanAccess(tc.user); access != tc.access {
+				t.Errorf("expected access: %t, got: %t", tc.access, access)
+			}
+			if reveal := tc.acl.CanReveal(tc.user); reveal != tc.reveal {
+				t.Errorf("expected reveal: %t, got: %t", tc.reveal, reveal)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package secret
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	secretTypes "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+// bundleVersion is the version of the secret bundle format
+const bundleVersion = 1
+
+// scrypt parameters deriving the bundle key from the passphrase
+const (
+	bundleSaltSize = 32
+	bundleScryptN  = 32768
+	bundleScryptR  = 8
+	bundleScryptP  = 1
+)
+
+// Conflict modes of secret imports, applied when a secret with the same name already exists
+const (
+	ImportConflictSkip      = "skip"
+	ImportConflictOverwrite = "overwrite"
+	ImportConflictRename    = "rename"
+)
+
+// Statuses of imported secrets
+const (
+	ImportStatusCreated     = "CREATED"
+	ImportStatusOverwritten = "OVERWRITTEN"
+	ImportStatusRenamed     = "RENAMED"
+	ImportStatusSkipped     = "SKIPPED"
+	ImportStatusFailed      = "FAILED"
+)
+
+// ErrInvalidPassphrase is returned when a bundle can't be opened with the passphrase
+var ErrInvalidPassphrase = errors.New("invalid passphrase or corrupted bundle")
+
+// SecretBundle is a set of secrets encrypted with a key derived from a passphrase,
+// it can be moved between Pipeline installations
+type SecretBundle struct {
+	Version int    `json:"version" binding:"required"`
+	Salt    []byte `json:"salt" binding:"required"`
+	Data    []byte `json:"data" binding:"required"`
+}
+
+// BundleItem is a secret in a bundle
+type BundleItem struct {
+	Name   string            `json:"name"`
+	Type   string            `json:"type"`
+	Values map[string]string `json:"values"`
+	Tags   []string          `json:"tags"`
+}
+
+// bundlePayload is the encrypted content of a bundle
+type bundlePayload struct {
+	ExportedAt time.Time    `json:"exportedAt"`
+	Secrets    []BundleItem `json:"secrets"`
+}
+
+// ExportSecretsRequest describes the secrets to export and the passphrase encrypting them
+type ExportSecretsRequest struct {
+	Passphrase string                       `json:"passphrase" binding:"required,min=8"`
+	Query      secretTypes.ListSecretsQuery `json:"query"`
+}
+
+// ImportSecretsRequest describes a bundle to import
+type ImportSecretsRequest struct {
+	Passphrase   string       `json:"passphrase" binding:"required"`
+	Bundle       SecretBundle `json:"bundle" binding:"required"`
+	ConflictMode string       `json:"conflictMode"`
+}
+
+// ImportSecretResult describes the import of a secret of a bundle
+type ImportSecretResult struct {
+	Name   string `json:"name"`
+	ID     string `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportSecretsResponse describes the result of a secret import
+type ImportSecretsResponse struct {
+	Secrets []ImportSecretResult `json:"secrets"`
+}
+
+// IsValidConflictMode checks whether the conflict mode of an import is supported
+func IsValidConflictMode(mode string) bool {
+	return mode == ImportConflictSkip || mode == ImportConflictOverwrite || mode == ImportConflictRename
+}
+
+// SealBundle encrypts the secrets into a bundle with a key derived from the passphrase
+func SealBundle(items []*SecretItemResponse, passphrase string) (*SecretBundle, error) {
+	payload := bundlePayload{
+		ExportedAt: time.Now().UTC(),
+		Secrets:    make([]BundleItem, 0, len(items)),
+	}
+
+	for _, item := range items {
+		payload.Secrets = append(payload.Secrets, BundleItem{
+			Name:   item.Name,
+			Type:   item.Type,
+			Values: item.Values,
+			Tags:   item.Tags,
+		})
+	}
+
+	plaintext, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal secrets")
+	}
+
+	salt := make([]byte, bundleSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, errors.Wrap(err, "could not generate salt")
+	}
+
+	aead, err := newBundleAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := seal(aead, plaintext, bundleAdditionalData())
+	if err != nil {
+		return nil, err
+	}
+
+	return &SecretBundle{Version: bundleVersion, Salt: salt, Data: data}, nil
+}
+
+// OpenBundle decrypts the secrets of a bundle
+func OpenBundle(bundle *SecretBundle, passphrase string) ([]BundleItem, error) {
+	if bundle.Version != bundleVersion {
+		return nil, errors.Errorf("unsupported bundle version: %d", bundle.Version)
+	}
+
+	aead, err := newBundleAEAD(passphrase, bundle.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := open(aead, bundle.Data, bundleAdditionalData())
+	if err != nil {
+		return nil, ErrInvalidPassphrase
+	}
+
+	var payload bundlePayload
+	if err := json.Unmarshal(plaintext, &payload); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal secrets")
+	}
+
+	return payload.Secrets, nil
+}
+
+// Validate checks the secret against the rules of its type in the organization
+func (i *BundleItem) Validate(organizationID uint) error {
+	if i.Name == "" {
+		return errors.New("missing secret name")
+	}
+
+	if err := HasForbiddenTag(i.Tags); err != nil {
+		return err
+	}
+
+	request := CreateSecretRequest{Name: i.Name, Type: i.Type, Values: i.Values}
+
+	return request.Validate(organizationID, nil)
+}
+
+// newBundleAEAD derives the key of a bundle from the passphrase
+func newBundleAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, bundleScryptN, bundleScryptR, bundleScryptP, dataKeySize)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not derive bundle key")
+	}
+
+	return newAEAD(key)
+}
+
+// bundleAdditionalData binds the encrypted data to the bundle format
+func bundleAdditionalData() []byte {
+	return []byte(fmt.Sprintf("pipeline-secret-bundle/%d", bundleVersion))
+}
@@ -0,0 +1,46 @@
+package secret_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/banzaicloud/pipeline/secret"
+)
+
+func TestSecretBundle(t *testing.T) {
+	items := []*secret.SecretItemResponse{
+		{
+			Name:   "my-aws-secret",
+			Type:   "amazon",
+			Values: map[string]string{"AWS_ACCESS_KEY_ID": "id", "AWS_SECRET_ACCESS_KEY": "key"},
+			Tags:   []string{"team:ops"},
+		},
+	}
+
+	bundle, err := secret.SealBundle(items, "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := secret.OpenBundle(bundle, "wrong passphrase"); err != secret.ErrInvalidPassphrase {
+		t.Errorf("expected error: %v, got: %v", secret.ErrInvalidPassphrase, err)
+	}
+
+	opened, err := secret.OpenBundle(bundle, "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []secret.BundleItem{
+		{
+			Name:   "my-aws-secret",
+			Type:   "amazon",
+			Values: map[string]string{"AWS_ACCESS_KEY_ID": "id", "AWS_SECRET_ACCESS_KEY": "key"},
+			Tags:   []string{"team:ops"},
+		},
+	}
+
+	if !reflect.DeepEqual(expected, opened) {
+		t.Errorf("expected: %v, got: %v", expected, opened)
+	}
+}
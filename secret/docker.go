@@ -0,0 +1,59 @@
+package secret
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	secretTypes "github.com/banzaicloud/pipeline/pkg/secret"
+)
+
+// dockerConfig is the format of the docker config in Kubernetes image pull secrets
+type dockerConfig struct {
+	Auths map[string]dockerConfigEntry `json:"auths"`
+}
+
+type dockerConfigEntry struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Email    string `json:"email,omitempty"`
+	Auth     string `json:"auth"`
+}
+
+// K8SData returns the data of the Kubernetes secret the secret is installed as,
+// docker registry credentials are installed as docker config, other secrets with their values
+func (s *SecretItemResponse) K8SData() map[string]string {
+	if s.Type == secretTypes.DockerRegistrySecretType {
+		return map[string]string{
+			secretTypes.DockerConfigJSON: DockerConfigJSON(s.Values),
+		}
+	}
+
+	data := make(map[string]string, len(s.Values))
+	for k, v := range s.Values {
+		data[k] = v
+	}
+
+	return data
+}
+
+// DockerConfigJSON returns the docker config of the registry credentials of a docker registry secret
+func DockerConfigJSON(values map[string]string) string {
+	username := values[secretTypes.DockerUsername]
+	password := values[secretTypes.DockerPassword]
+
+	config := dockerConfig{
+		Auths: map[string]dockerConfigEntry{
+			values[secretTypes.DockerServer]: {
+				Username: username,
+				Password: password,
+				Email:    values[secretTypes.DockerEmail],
+				Auth:     base64.StdEncoding.EncodeToString([]byte(username + ":" + password)),
+			},
+		},
+	}
+
+	// marshaling strings can't fail
+	configJSON, _ := json.Marshal(config)
+
+	return string(configJSON)
+}
@@ -0,0 +1,51 @@
+package secret_test
+
+import (
+	"reflect"
+	"testing"
+
+	pkgSecret "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/banzaicloud/pipeline/secret"
+)
+
+func TestSecretItemK8SData(t *testing.T) {
+	cases := []struct {
+		name       string
+		secretItem secret.SecretItemResponse
+		expected   map[string]string
+	}{
+		{
+			name: "docker registry",
+			secretItem: secret.SecretItemResponse{
+				Type: pkgSecret.DockerRegistrySecretType,
+				Values: map[string]string{
+					pkgSecret.DockerServer:   "https://index.docker.io/v1/",
+					pkgSecret.DockerUsername: "banzai",
+					pkgSecret.DockerPassword: "cloud",
+					pkgSecret.DockerEmail:    "info@banzaicloud.com",
+				},
+			},
+			expected: map[string]string{
+				pkgSecret.DockerConfigJSON: `{"auths":{"https://index.docker.io/v1/":{"username":"banzai","password":"cloud","email":"info@banzaicloud.com","auth":"YmFuemFpOmNsb3Vk"}}}`,
+			},
+		},
+		{
+			name: "generic",
+			secretItem: secret.SecretItemResponse{
+				Type:   pkgSecret.GenericSecret,
+				Values: map[string]string{"key": "value"},
+			},
+			expected: map[string]string{"key": "value"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := tc.secretItem.K8SData()
+
+			if !reflect.DeepEqual(tc.expected, data) {
+				t.Errorf("expected data: %v, but got: %v", tc.expected, data)
+			}
+		})
+	}
+}
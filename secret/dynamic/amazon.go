@@ -0,0 +1,92 @@
+package dynamic
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	pkgSecret "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/pkg/errors"
+)
+
+// Limits of the duration of assumed role sessions
+const (
+	minAmazonSessionDuration = 15 * time.Minute
+	maxAmazonSessionDuration = 12 * time.Hour
+)
+
+// amazonRoleSessionName identifies the sessions of Pipeline in CloudTrail
+const amazonRoleSessionName = "banzaicloud-pipeline"
+
+// amazonDefaultRegion is used for calling STS when the secret has no region
+const amazonDefaultRegion = "us-east-1"
+
+// AmazonRoleMinter assumes the role of a secret with the credentials of Pipeline
+type AmazonRoleMinter struct{}
+
+// Mint assumes the role and returns the temporary credentials of the session
+func (AmazonRoleMinter) Mint(values map[string]string, ttl time.Duration) (*Credentials, error) {
+	sess, err := newAmazonSession(values[pkgSecret.AwsRegion])
+	if err != nil {
+		return nil, err
+	}
+
+	input := &sts.AssumeRoleInput{
+		RoleArn:         aws.String(values[pkgSecret.AwsRoleArn]),
+		RoleSessionName: aws.String(amazonRoleSessionName),
+		DurationSeconds: aws.Int64(int64(clampTTL(ttl, minAmazonSessionDuration, maxAmazonSessionDuration).Seconds())),
+	}
+
+	if externalID := values[pkgSecret.AwsExternalId]; externalID != "" {
+		input.ExternalId = aws.String(externalID)
+	}
+
+	output, err := sts.New(sess).AssumeRole(input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not assume role %s", values[pkgSecret.AwsRoleArn])
+	}
+
+	return &Credentials{
+		Values: map[string]string{
+			pkgSecret.AwsRegion:          values[pkgSecret.AwsRegion],
+			pkgSecret.AwsAccessKeyId:     aws.StringValue(output.Credentials.AccessKeyId),
+			pkgSecret.AwsSecretAccessKey: aws.StringValue(output.Credentials.SecretAccessKey),
+			pkgSecret.AwsSessionToken:    aws.StringValue(output.Credentials.SessionToken),
+		},
+		ExpiresAt: aws.TimeValue(output.Credentials.Expiration),
+	}, nil
+}
+
+// NewAmazonRoleCredentials returns the credentials of a role assumed with the credentials of Pipeline,
+// they are refreshed automatically for long running clients
+func NewAmazonRoleCredentials(region string, roleArn string, externalID string) (*credentials.Credentials, error) {
+	sess, err := newAmazonSession(region)
+	if err != nil {
+		return nil, err
+	}
+
+	return stscreds.NewCredentials(sess, roleArn, func(p *stscreds.AssumeRoleProvider) {
+		p.RoleSessionName = amazonRoleSessionName
+
+		if externalID != "" {
+			p.ExternalID = aws.String(externalID)
+		}
+	}), nil
+}
+
+// newAmazonSession returns a session with the default credential chain of Pipeline (environment, instance profile)
+func newAmazonSession(region string) (*session.Session, error) {
+	if region == "" {
+		region = amazonDefaultRegion
+	}
+
+	sess, err := session.NewSession(aws.NewConfig().WithRegion(region))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create Amazon session")
+	}
+
+	return sess, nil
+}
@@ -0,0 +1,211 @@
+package dynamic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	pkgSecret "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/pkg/errors"
+)
+
+const (
+	// azureManagedIdentityTokenURL is the endpoint of the instance metadata service issuing the tokens of the managed identity of Pipeline
+	azureManagedIdentityTokenURL = "http://169.254.169.254/metadata/identity/oauth2/token"
+	azureGraphURL                = "https://graph.microsoft.com"
+
+	// azurePasswordDisplayName marks the client secrets added by Pipeline
+	azurePasswordDisplayName = "banzaicloud-pipeline"
+)
+
+// Limits of the lifetime of the minted client secrets
+const (
+	minAzureSecretLifetime = 15 * time.Minute
+	maxAzureSecretLifetime = 24 * time.Hour
+)
+
+// AzureClientCredentialsMinter adds a client secret expiring shortly to the application of a secret,
+// using the managed identity of Pipeline, which has to be allowed to manage the credentials of the application.
+// The expired client secrets added by Pipeline are removed from the application before a new one is added.
+type AzureClientCredentialsMinter struct {
+	Client *http.Client
+
+	// GraphURL overrides the Microsoft Graph endpoint
+	GraphURL string
+	// TokenURL overrides the endpoint issuing the tokens of the managed identity
+	TokenURL string
+}
+
+type azureToken struct {
+	AccessToken string `json:"access_token"`
+}
+
+type azurePasswordCredential struct {
+	KeyID       string    `json:"keyId,omitempty"`
+	DisplayName string    `json:"displayName,omitempty"`
+	EndDateTime time.Time `json:"endDateTime"`
+	SecretText  string    `json:"secretText,omitempty"`
+}
+
+type azureApplication struct {
+	PasswordCredentials []azurePasswordCredential `json:"passwordCredentials"`
+}
+
+// Mint adds a new client secret to the application and returns the client credentials using it
+func (m AzureClientCredentialsMinter) Mint(values map[string]string, ttl time.Duration) (*Credentials, error) {
+	client := m.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	graphURL := m.GraphURL
+	if graphURL == "" {
+		graphURL = azureGraphURL
+	}
+
+	token, err := m.managedIdentityToken(client, azureGraphURL)
+	if err != nil {
+		return nil, err
+	}
+
+	applicationURL := fmt.Sprintf("%s/v1.0/applications/%s", graphURL, url.PathEscape(values[pkgSecret.AzureApplicationObjectId]))
+
+	// every mint adds a client secret, so the expired ones have to be removed not to reach the limit of the application
+	if err := m.removeExpiredPasswords(client, token, applicationURL); err != nil {
+		return nil, errors.Wrapf(err, "could not remove expired client secrets of application %s", values[pkgSecret.AzureApplicationObjectId])
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"passwordCredential": azurePasswordCredential{
+			DisplayName: azurePasswordDisplayName,
+			EndDateTime: time.Now().Add(clampTTL(ttl, minAzureSecretLifetime, maxAzureSecretLifetime)).UTC(),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := newAzureGraphRequest(http.MethodPost, applicationURL+"/addPassword", token, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var credential azurePasswordCredential
+	if err := doJSON(client, request, &credential); err != nil {
+		return nil, errors.Wrapf(err, "could not add client secret to application %s", values[pkgSecret.AzureApplicationObjectId])
+	}
+
+	return &Credentials{
+		Values: map[string]string{
+			pkgSecret.AzureClientId:       values[pkgSecret.AzureClientId],
+			pkgSecret.AzureClientSecret:   credential.SecretText,
+			pkgSecret.AzureTenantId:       values[pkgSecret.AzureTenantId],
+			pkgSecret.AzureSubscriptionId: values[pkgSecret.AzureSubscriptionId],
+		},
+		ExpiresAt: credential.EndDateTime,
+	}, nil
+}
+
+// removeExpiredPasswords removes the expired client secrets added by Pipeline from the application
+func (m AzureClientCredentialsMinter) removeExpiredPasswords(client *http.Client, token string, applicationURL string) error {
+	request, err := newAzureGraphRequest(http.MethodGet, applicationURL+"?$select=passwordCredentials", token, nil)
+	if err != nil {
+		return err
+	}
+
+	var application azureApplication
+	if err := doJSON(client, request, &application); err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	for _, credential := range application.PasswordCredentials {
+		if credential.DisplayName != azurePasswordDisplayName || credential.EndDateTime.After(now) {
+			continue
+		}
+
+		body, err := json.Marshal(map[string]string{"keyId": credential.KeyID})
+		if err != nil {
+			return err
+		}
+
+		request, err := newAzureGraphRequest(http.MethodPost, applicationURL+"/removePassword", token, body)
+		if err != nil {
+			return err
+		}
+
+		if err := doJSON(client, request, nil); err != nil {
+			return errors.Wrapf(err, "could not remove client secret %s", credential.KeyID)
+		}
+	}
+
+	return nil
+}
+
+// newAzureGraphRequest returns a request of the Microsoft Graph API authorized with the token
+func newAzureGraphRequest(method string, url string, token string, body []byte) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	request, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	return request, nil
+}
+
+// managedIdentityToken returns a token of the managed identity of Pipeline for the resource
+func (m AzureClientCredentialsMinter) managedIdentityToken(client *http.Client, resource string) (string, error) {
+	query := url.Values{}
+	query.Set("api-version", "2018-02-01")
+	query.Set("resource", resource)
+
+	tokenURL := m.TokenURL
+	if tokenURL == "" {
+		tokenURL = azureManagedIdentityTokenURL
+	}
+
+	request, err := http.NewRequest(http.MethodGet, tokenURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Metadata", "true")
+
+	var token azureToken
+	if err := doJSON(client, request, &token); err != nil {
+		return "", errors.Wrap(err, "could not get token of the managed identity")
+	}
+
+	return token.AccessToken, nil
+}
+
+// doJSON sends the request and decodes the JSON response, unless the response is nil
+func doJSON(client *http.Client, request *http.Request, response interface{}) error {
+	resp, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return errors.Errorf("unexpected response: %s", resp.Status)
+	}
+
+	if response == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(response)
+}
@@ -0,0 +1,73 @@
+package dynamic
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pkgSecret "github.com/banzaicloud/pipeline/pkg/secret"
+)
+
+func TestAzureClientCredentialsMinterRemovesExpiredPasswords(t *testing.T) {
+	var removed []string
+	var added int
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(azureToken{AccessToken: "token"})
+	})
+	mux.HandleFunc("/v1.0/applications/app", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(azureApplication{
+			PasswordCredentials: []azurePasswordCredential{
+				{KeyID: "expired", DisplayName: azurePasswordDisplayName, EndDateTime: time.Now().Add(-time.Hour)},
+				{KeyID: "valid", DisplayName: azurePasswordDisplayName, EndDateTime: time.Now().Add(time.Hour)},
+				{KeyID: "not-pipeline", DisplayName: "other", EndDateTime: time.Now().Add(-time.Hour)},
+			},
+		})
+	})
+	mux.HandleFunc("/v1.0/applications/app/removePassword", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		removed = append(removed, body["keyId"])
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/v1.0/applications/app/addPassword", func(w http.ResponseWriter, r *http.Request) {
+		added++
+		json.NewEncoder(w).Encode(azurePasswordCredential{
+			KeyID:       "new",
+			SecretText:  "secret",
+			EndDateTime: time.Now().Add(time.Hour),
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	minter := AzureClientCredentialsMinter{
+		Client:   server.Client(),
+		GraphURL: server.URL,
+		TokenURL: server.URL + "/token",
+	}
+
+	credentials, err := minter.Mint(map[string]string{
+		pkgSecret.AzureApplicationObjectId: "app",
+		pkgSecret.AzureClientId:            "client",
+	}, time.Hour)
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	if len(removed) != 1 || removed[0] != "expired" {
+		t.Errorf("Expected only the expired client secret of Pipeline to be removed, got: %v", removed)
+	}
+
+	if added != 1 {
+		t.Errorf("Expected 1 client secret to be added, got: %d", added)
+	}
+
+	if credentials.Values[pkgSecret.AzureClientSecret] != "secret" {
+		t.Errorf("Expected the added client secret, got: %s", credentials.Values[pkgSecret.AzureClientSecret])
+	}
+}
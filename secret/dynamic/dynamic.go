@@ -0,0 +1,31 @@
+// Package dynamic mints short-lived cloud credentials with the credentials of Pipeline itself,
+// so organizations don't have to store permanent keys in Pipeline.
+package dynamic
+
+import (
+	"time"
+)
+
+// Credentials are short-lived credentials in the format of the provider secret type
+type Credentials struct {
+	Values    map[string]string
+	ExpiresAt time.Time
+}
+
+// Minter mints short-lived credentials from the values of a dynamic secret
+type Minter interface {
+	Mint(values map[string]string, ttl time.Duration) (*Credentials, error)
+}
+
+// clampTTL keeps the requested lifetime within the limits of the provider
+func clampTTL(ttl time.Duration, min time.Duration, max time.Duration) time.Duration {
+	if ttl < min {
+		return min
+	}
+
+	if ttl > max {
+		return max
+	}
+
+	return ttl
+}
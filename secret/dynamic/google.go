@@ -0,0 +1,41 @@
+package dynamic
+
+import (
+	"context"
+	"time"
+
+	pkgSecret "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/banzaicloud/pipeline/secret/verify"
+)
+
+// googleCloudPlatformScope is the scope of the tokens of the Google registries
+const googleCloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// Limits of the lifetime of impersonated access tokens
+const (
+	minGoogleTokenLifetime = 15 * time.Minute
+	maxGoogleTokenLifetime = time.Hour
+)
+
+// GoogleImpersonationMinter returns access tokens of the service account of a secret, using the default credentials
+// of Pipeline, which need the Service Account Token Creator role on the service account
+type GoogleImpersonationMinter struct{}
+
+// Mint generates an access token of the impersonated service account
+func (GoogleImpersonationMinter) Mint(values map[string]string, ttl time.Duration) (*Credentials, error) {
+	serviceAccount := values[pkgSecret.TargetServiceAccount]
+
+	token, err := verify.ImpersonateServiceAccount(context.Background(), serviceAccount, clampTTL(ttl, minGoogleTokenLifetime, maxGoogleTokenLifetime))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credentials{
+		Values: map[string]string{
+			pkgSecret.ProjectId:   values[pkgSecret.ProjectId],
+			pkgSecret.ClientEmail: serviceAccount,
+			pkgSecret.AccessToken: token.AccessToken,
+		},
+		ExpiresAt: token.Expiry,
+	}, nil
+}
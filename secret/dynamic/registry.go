@@ -0,0 +1,171 @@
+package dynamic
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	pkgSecret "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/banzaicloud/pipeline/secret/verify"
+	"github.com/pkg/errors"
+)
+
+const (
+	gcrDefaultServer = "https://gcr.io"
+	// gcrAccessTokenUsername is the user name of registry logins with OAuth access tokens
+	gcrAccessTokenUsername = "oauth2accesstoken"
+
+	// acrTokenUsername is the user name of registry logins with ACR refresh tokens
+	acrTokenUsername = "00000000-0000-0000-0000-000000000000"
+	// acrRefreshTokenLifetime is the lifetime of the refresh tokens issued by ACR
+	acrRefreshTokenLifetime = 3 * time.Hour
+	azureLoginURL           = "https://login.microsoftonline.com/%s/oauth2/token"
+	azureManagementResource = "https://management.azure.com/"
+)
+
+// ECRMinter returns the credentials of an Amazon ECR registry, using the values of an Amazon secret
+type ECRMinter struct{}
+
+// Mint gets an authorization token of the registries of the account
+func (ECRMinter) Mint(values map[string]string, ttl time.Duration) (*Credentials, error) {
+	sess, err := newAmazonSession(values[pkgSecret.AwsRegion])
+	if err != nil {
+		return nil, err
+	}
+
+	client := ecr.New(sess, aws.NewConfig().WithCredentials(verify.CreateAWSCredentials(values)))
+
+	output, err := client.GetAuthorizationToken(&ecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get ECR authorization token")
+	}
+
+	if len(output.AuthorizationData) == 0 {
+		return nil, errors.New("no ECR authorization data returned")
+	}
+
+	data := output.AuthorizationData[0]
+
+	token, err := base64.StdEncoding.DecodeString(aws.StringValue(data.AuthorizationToken))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode ECR authorization token")
+	}
+
+	credentials := strings.SplitN(string(token), ":", 2)
+	if len(credentials) != 2 {
+		return nil, errors.New("invalid ECR authorization token")
+	}
+
+	server := values[pkgSecret.DockerServer]
+	if server == "" {
+		server = aws.StringValue(data.ProxyEndpoint)
+	}
+
+	return registryCredentials(server, credentials[0], credentials[1], aws.TimeValue(data.ExpiresAt)), nil
+}
+
+// GCRMinter returns the credentials of a Google Container Registry, using the values of a Google secret
+type GCRMinter struct{}
+
+// Mint gets an access token of the service account
+func (GCRMinter) Mint(values map[string]string, ttl time.Duration) (*Credentials, error) {
+	credentials, err := verify.CreateGoogleCredentials(context.Background(), verify.CreateServiceAccount(values), googleCloudPlatformScope)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create Google credentials")
+	}
+
+	token, err := credentials.TokenSource.Token()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get Google access token")
+	}
+
+	expiresAt := token.Expiry
+	if expiresAt.IsZero() {
+		// access tokens of impersonated service accounts expire together with the secret
+		if expiresAt, err = time.Parse(time.RFC3339, values[pkgSecret.CredentialsExpiration]); err != nil {
+			expiresAt = time.Now().Add(minGoogleTokenLifetime)
+		}
+	}
+
+	server := values[pkgSecret.DockerServer]
+	if server == "" {
+		server = gcrDefaultServer
+	}
+
+	return registryCredentials(server, gcrAccessTokenUsername, token.AccessToken, expiresAt), nil
+}
+
+// ACRMinter returns the credentials of an Azure Container Registry, using the values of an Azure secret
+type ACRMinter struct {
+	Client *http.Client
+}
+
+type acrRefreshToken struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Mint exchanges an Azure AD token of the service principal to a refresh token of the registry
+func (m ACRMinter) Mint(values map[string]string, ttl time.Duration) (*Credentials, error) {
+	client := m.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	tenantID := values[pkgSecret.AzureTenantId]
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", values[pkgSecret.AzureClientId])
+	form.Set("client_secret", values[pkgSecret.AzureClientSecret])
+	form.Set("resource", azureManagementResource)
+
+	var token azureToken
+	if err := postForm(client, fmt.Sprintf(azureLoginURL, url.PathEscape(tenantID)), form, &token); err != nil {
+		return nil, errors.Wrap(err, "could not get Azure AD token of the service principal")
+	}
+
+	server := strings.TrimPrefix(strings.TrimPrefix(values[pkgSecret.DockerServer], "https://"), "http://")
+	server = strings.TrimSuffix(server, "/")
+
+	form = url.Values{}
+	form.Set("grant_type", "access_token")
+	form.Set("service", server)
+	form.Set("tenant", tenantID)
+	form.Set("access_token", token.AccessToken)
+
+	var refreshToken acrRefreshToken
+	if err := postForm(client, "https://"+server+"/oauth2/exchange", form, &refreshToken); err != nil {
+		return nil, errors.Wrapf(err, "could not get refresh token of registry %s", server)
+	}
+
+	return registryCredentials(server, acrTokenUsername, refreshToken.RefreshToken, time.Now().Add(acrRefreshTokenLifetime)), nil
+}
+
+// postForm posts the form and decodes the JSON response
+func postForm(client *http.Client, address string, form url.Values, response interface{}) error {
+	request, err := http.NewRequest(http.MethodPost, address, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return doJSON(client, request, response)
+}
+
+// registryCredentials returns the credentials of a registry in the format of docker registry secrets
+func registryCredentials(server string, username string, password string, expiresAt time.Time) *Credentials {
+	return &Credentials{
+		Values: map[string]string{
+			pkgSecret.DockerServer:   server,
+			pkgSecret.DockerUsername: username,
+			pkgSecret.DockerPassword: password,
+		},
+		ExpiresAt: expiresAt,
+	}
+}
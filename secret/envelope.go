@@ -0,0 +1,104 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// dataKeySize is the size of the data keys, they are AES-256 keys
+const dataKeySize = 32
+
+// envelopeEncrypter encrypts every payload with a new data key using AES-GCM
+// and returns the data key encrypted with the master key along with the payload.
+type envelopeEncrypter struct {
+	masterKey cipher.AEAD
+}
+
+func newEnvelopeEncrypter(masterKey []byte) (*envelopeEncrypter, error) {
+	aead, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid master key")
+	}
+
+	return &envelopeEncrypter{masterKey: aead}, nil
+}
+
+// Encrypt returns the encrypted data key and the plaintext encrypted with it.
+// The additional data is authenticated but not encrypted, it has to be the same when decrypting.
+func (e *envelopeEncrypter) Encrypt(plaintext []byte, additionalData []byte) ([]byte, []byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, nil, errors.Wrap(err, "could not generate data key")
+	}
+
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ciphertext, err := seal(dataAEAD, plaintext, additionalData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryptedDataKey, err := seal(e.masterKey, dataKey, additionalData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return encryptedDataKey, ciphertext, nil
+}
+
+// Decrypt returns the plaintext encrypted by Encrypt.
+func (e *envelopeEncrypter) Decrypt(encryptedDataKey []byte, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	dataKey, err := open(e.masterKey, encryptedDataKey, additionalData)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decrypt data key")
+	}
+
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := open(dataAEAD, ciphertext, additionalData)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decrypt data")
+	}
+
+	return plaintext, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts the plaintext with a random nonce prepended to the result
+func seal(aead cipher.AEAD, plaintext []byte, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "could not generate nonce")
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts the result of seal
+func open(aead cipher.AEAD, sealed []byte, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
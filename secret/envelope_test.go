@@ -0,0 +1,47 @@
+package secret
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEnvelopeEncrypter(t *testing.T) {
+	encrypter, err := newEnvelopeEncrypter(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	plaintext := []byte(`{"name":"my-secret","values":{"password":"s3cr3t"}}`)
+	additionalData := []byte("1/my-secret/1")
+
+	dataKey, ciphertext, err := encrypter.Encrypt(plaintext, additionalData)
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	if bytes.Contains(ciphertext, []byte("s3cr3t")) {
+		t.Error("Expected the payload to be encrypted")
+	}
+
+	decrypted, err := encrypter.Decrypt(dataKey, ciphertext, additionalData)
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	if !bytes.Equal(plaintext, decrypted) {
+		t.Errorf("Expected plaintext: %s, got: %s", plaintext, decrypted)
+	}
+
+	if _, err := encrypter.Decrypt(dataKey, ciphertext, []byte("1/my-secret/2")); err == nil {
+		t.Error("Expected decryption with different additional data to fail")
+	}
+
+	otherEncrypter, err := newEnvelopeEncrypter(bytes.Repeat([]byte{2}, 32))
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	if _, err := otherEncrypter.Decrypt(dataKey, ciphertext, additionalData); err == nil {
+		t.Error("Expected decryption with a different master key to fail")
+	}
+}
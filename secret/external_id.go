@@ -0,0 +1,59 @@
+package secret
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/banzaicloud/pipeline/config"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// externalIDPrefix marks the external IDs generated by Pipeline
+const externalIDPrefix = "pipeline-"
+
+// ExternalIDModel is the external ID Pipeline assumes the Amazon roles of an organization with.
+// It is generated by Pipeline, so an organization can't make Pipeline assume the roles trusting another organization.
+type ExternalIDModel struct {
+	OrganizationID uint `gorm:"primary_key;auto_increment:false"`
+	CreatedAt      time.Time
+	ExternalID     string `gorm:"unique_index;size:64"`
+}
+
+// TableName overrides ExternalIDModel's table name
+func (ExternalIDModel) TableName() string {
+	return "secret_external_ids"
+}
+
+// GetExternalID returns the external ID of an organization, it is generated at the first use
+func GetExternalID(organizationID uint) (string, error) {
+	db := config.DB()
+
+	var model ExternalIDModel
+	err := db.Where(&ExternalIDModel{OrganizationID: organizationID}).First(&model).Error
+	if err == nil {
+		return model.ExternalID, nil
+	} else if !gorm.IsRecordNotFoundError(err) {
+		return "", errors.Wrap(err, "could not get external id")
+	}
+
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", errors.Wrap(err, "could not generate external id")
+	}
+
+	model = ExternalIDModel{
+		OrganizationID: organizationID,
+		ExternalID:     externalIDPrefix + hex.EncodeToString(random),
+	}
+
+	if err := db.Create(&model).Error; err != nil {
+		// another Pipeline instance may have generated it meanwhile
+		if err := db.Where(&ExternalIDModel{OrganizationID: organizationID}).First(&model).Error; err != nil {
+			return "", errors.Wrap(err, "could not save external id")
+		}
+	}
+
+	return model.ExternalID, nil
+}
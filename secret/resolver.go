@@ -0,0 +1,244 @@
+package secret
+
+import (
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/banzaicloud/pipeline/config"
+	secretTypes "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/banzaicloud/pipeline/secret/dynamic"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// credentialsRefreshMargin is the time before their expiration when cached credentials are minted again
+const credentialsRefreshMargin = 5 * time.Minute
+
+// Resolver mints the short-lived credentials of dynamic secrets and caches them until shortly before they expire
+type Resolver struct {
+	minters        map[string]dynamic.Minter
+	ttl            func() time.Duration
+	getByName      func(organizationID uint, name string) (*SecretItemResponse, error)
+	externalID     func(organizationID uint) (string, error)
+	allowedTargets func(organizationID uint) []string
+
+	mu    sync.Mutex
+	cache map[string]*dynamic.Credentials
+}
+
+// NewResolver returns a resolver minting credentials for the dynamic secret types,
+// registry credentials are minted with the secrets returned by getByName.
+// Amazon roles are assumed with the external ID of the organization, the Google service accounts and Azure applications
+// an organization can mint credentials for are limited to the allowed targets of the organization.
+func NewResolver(
+	ttl func() time.Duration,
+	getByName func(organizationID uint, name string) (*SecretItemResponse, error),
+	externalID func(organizationID uint) (string, error),
+	allowedTargets func(organizationID uint) []string,
+) *Resolver {
+	return &Resolver{
+		minters: map[string]dynamic.Minter{
+			secretTypes.AmazonRoleSecretType:             dynamic.AmazonRoleMinter{},
+			secretTypes.AzureClientCredentialsSecretType: dynamic.AzureClientCredentialsMinter{},
+			secretTypes.GoogleImpersonationSecretType:    dynamic.GoogleImpersonationMinter{},
+			secretTypes.ECRRegistrySecretType:            dynamic.ECRMinter{},
+			secretTypes.GCRRegistrySecretType:            dynamic.GCRMinter{},
+			secretTypes.ACRRegistrySecretType:            dynamic.ACRMinter{},
+		},
+		ttl:            ttl,
+		getByName:      getByName,
+		externalID:     externalID,
+		allowedTargets: allowedTargets,
+		cache:          make(map[string]*dynamic.Credentials),
+	}
+}
+
+// Resolve returns the secret with the credentials minted for it in the format of its provider type,
+// other secrets are returned as they are
+func (r *Resolver) Resolve(organizationID uint, item *SecretItemResponse) (*SecretItemResponse, error) {
+	providerType, ok := secretTypes.DynamicSecretTypes[item.Type]
+	if !ok {
+		return item, nil
+	}
+
+	minter, ok := r.minters[item.Type]
+	if !ok {
+		return nil, errors.Errorf("no credential minter for secret type %s", item.Type)
+	}
+
+	values, err := r.bindTarget(organizationID, item)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not mint credentials for secret %s", item.Name)
+	}
+
+	// a new version of the secret may refer to another role or account
+	key := fmt.Sprintf("%d/%s/%d", organizationID, item.ID, item.Version)
+
+	if sourceType, ok := secretTypes.RegistrySourceTypes[item.Type]; ok {
+		source, err := r.getSource(organizationID, item, sourceType)
+		if err != nil {
+			return nil, err
+		}
+
+		values = make(map[string]string, len(source.Values)+len(item.Values))
+		for k, v := range source.Values {
+			values[k] = v
+		}
+		for k, v := range item.Values {
+			if v != "" {
+				values[k] = v
+			}
+		}
+
+		key = fmt.Sprintf("%s/%s/%d", key, source.ID, source.Version)
+	}
+
+	r.mu.Lock()
+	credentials, ok := r.cache[key]
+	r.mu.Unlock()
+
+	if !ok || time.Now().Add(credentialsRefreshMargin).After(credentials.ExpiresAt) {
+		// minting happens outside of the lock, so a slow provider doesn't block the other secrets
+		credentials, err = minter.Mint(values, r.ttl())
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not mint credentials for secret %s", item.Name)
+		}
+
+		r.mu.Lock()
+		r.cache[key] = credentials
+		r.mu.Unlock()
+	}
+
+	resolved := *item
+	resolved.Type = providerType
+	resolved.Values = make(map[string]string, len(credentials.Values)+1)
+	for k, v := range credentials.Values {
+		resolved.Values[k] = v
+	}
+	resolved.Values[secretTypes.CredentialsExpiration] = credentials.ExpiresAt.UTC().Format(time.RFC3339)
+
+	return &resolved, nil
+}
+
+// bindTarget ties the credentials minted for a dynamic secret to the organization of the secret.
+// Amazon roles are assumed with the external ID of the organization, the users can't choose it.
+// Google service accounts and Azure applications have no such mechanism, so they have to be allowed
+// for the organization by the operators of Pipeline.
+func (r *Resolver) bindTarget(organizationID uint, item *SecretItemResponse) (map[string]string, error) {
+	switch item.Type {
+	case secretTypes.AmazonRoleSecretType:
+		externalID, err := r.externalID(organizationID)
+		if err != nil {
+			return nil, err
+		}
+
+		values := make(map[string]string, len(item.Values)+1)
+		for k, v := range item.Values {
+			values[k] = v
+		}
+		values[secretTypes.AwsExternalId] = externalID
+
+		return values, nil
+
+	case secretTypes.GoogleImpersonationSecretType:
+		return item.Values, r.checkTarget(organizationID, item.Values[secretTypes.TargetServiceAccount])
+
+	case secretTypes.AzureClientCredentialsSecretType:
+		return item.Values, r.checkTarget(organizationID, item.Values[secretTypes.AzureApplicationObjectId])
+	}
+
+	return item.Values, nil
+}
+
+// checkTarget checks the target of a dynamic secret against the patterns allowed for the organization
+func (r *Resolver) checkTarget(organizationID uint, target string) error {
+	for _, pattern := range r.allowedTargets(organizationID) {
+		if ok, _ := path.Match(pattern, target); ok {
+			return nil
+		}
+	}
+
+	return errors.Errorf("%s is not allowed for the organization", target)
+}
+
+// getSource returns the cloud secret the credentials of a registry secret are minted with
+func (r *Resolver) getSource(organizationID uint, item *SecretItemResponse, sourceType string) (*SecretItemResponse, error) {
+	sourceName := item.Values[secretTypes.DockerSourceSecret]
+
+	source, err := r.getByName(organizationID, sourceName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get source secret %s of secret %s", sourceName, item.Name)
+	}
+
+	if source.Type != sourceType {
+		return nil, errors.Errorf("source secret %s of secret %s must be of type %s", sourceName, item.Name, sourceType)
+	}
+
+	return source, nil
+}
+
+// resolvingSecretStore hands out the minted credentials of dynamic secrets instead of their definitions,
+// it is the store used by the consumers of the secrets, like clusters, buckets and DNS
+type resolvingSecretStore struct {
+	SecretStore
+	resolver *Resolver
+}
+
+func newResolvingSecretStore(store SecretStore) *resolvingSecretStore {
+	s := &resolvingSecretStore{SecretStore: store}
+
+	// source secrets are resolved as well, so registry credentials can be minted with dynamic cloud secrets
+	s.resolver = NewResolver(
+		func() time.Duration {
+			return viper.GetDuration(config.SecretDynamicCredentialsTTL)
+		},
+		s.GetByName,
+		GetExternalID,
+		func(organizationID uint) []string {
+			return viper.GetStringMapStringSlice(config.SecretDynamicAllowedTargets)[fmt.Sprint(organizationID)]
+		},
+	)
+
+	return s
+}
+
+func (s *resolvingSecretStore) Get(organizationID uint, secretID string) (*SecretItemResponse, error) {
+	item, err := s.SecretStore.Get(organizationID, secretID)
+	if err != nil || item == nil {
+		return item, err
+	}
+
+	return s.resolver.Resolve(organizationID, item)
+}
+
+func (s *resolvingSecretStore) GetByName(organizationID uint, name string) (*SecretItemResponse, error) {
+	item, err := s.SecretStore.GetByName(organizationID, name)
+	if err != nil || item == nil {
+		return item, err
+	}
+
+	return s.resolver.Resolve(organizationID, item)
+}
+
+func (s *resolvingSecretStore) List(organizationID uint, query *secretTypes.ListSecretsQuery) ([]*SecretItemResponse, error) {
+	items, err := s.SecretStore.List(organizationID, query)
+	if err != nil || !query.Values {
+		return items, err
+	}
+
+	// a secret the credentials of which can't be minted doesn't fail the listing of the others
+	resolved := make([]*SecretItemResponse, 0, len(items))
+	for _, item := range items {
+		resolvedItem, err := s.resolver.Resolve(organizationID, item)
+		if err != nil {
+			log.Warnf("Skipping secret %s: %s", item.ID, err.Error())
+			continue
+		}
+
+		resolved = append(resolved, resolvedItem)
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,201 @@
+package secret
+
+import (
+	"testing"
+	"time"
+
+	secretTypes "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/banzaicloud/pipeline/secret/dynamic"
+)
+
+// fakeMinter records the values it mints credentials with
+type fakeMinter struct {
+	values []map[string]string
+}
+
+func (m *fakeMinter) Mint(values map[string]string, ttl time.Duration) (*dynamic.Credentials, error) {
+	m.values = append(m.values, values)
+
+	return &dynamic.Credentials{
+		Values:    map[string]string{"token": "minted"},
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+func newTestResolver(minter dynamic.Minter, allowedTargets ...string) *Resolver {
+	return &Resolver{
+		minters: map[string]dynamic.Minter{
+			secretTypes.AmazonRoleSecretType:             minter,
+			secretTypes.AzureClientCredentialsSecretType: minter,
+			secretTypes.GoogleImpersonationSecretType:    minter,
+		},
+		ttl: func() time.Duration {
+			return time.Hour
+		},
+		externalID: func(organizationID uint) (string, error) {
+			return "pipeline-external-id", nil
+		},
+		allowedTargets: func(organizationID uint) []string {
+			return allowedTargets
+		},
+		cache: make(map[string]*dynamic.Credentials),
+	}
+}
+
+func TestResolverAmazonExternalID(t *testing.T) {
+	minter := &fakeMinter{}
+	resolver := newTestResolver(minter)
+
+	item := &SecretItemResponse{
+		ID:   "role",
+		Name: "role",
+		Type: secretTypes.AmazonRoleSecretType,
+		Values: map[string]string{
+			secretTypes.AwsRoleArn:    "arn:aws:iam::123456789012:role/other-organization",
+			secretTypes.AwsExternalId: "chosen-by-the-user",
+		},
+	}
+
+	resolved, err := resolver.Resolve(1, item)
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	if resolved.Type != secretTypes.DynamicSecretTypes[secretTypes.AmazonRoleSecretType] {
+		t.Errorf("Expected the provider type, got: %s", resolved.Type)
+	}
+
+	if len(minter.values) != 1 {
+		t.Fatalf("Expected 1 mint, got: %d", len(minter.values))
+	}
+
+	if externalID := minter.values[0][secretTypes.AwsExternalId]; externalID != "pipeline-external-id" {
+		t.Errorf("Expected the external ID of the organization, got: %s", externalID)
+	}
+
+	if item.Values[secretTypes.AwsExternalId] != "chosen-by-the-user" {
+		t.Error("Expected the values of the secret to be left intact")
+	}
+
+	if _, err := resolver.Resolve(1, item); err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	if len(minter.values) != 1 {
+		t.Errorf("Expected the credentials to be cached, got %d mints", len(minter.values))
+	}
+}
+
+func TestResolverAllowedTargets(t *testing.T) {
+	cases := []struct {
+		name    string
+		item    *SecretItemResponse
+		allowed []string
+		valid   bool
+	}{
+		{
+			name: "google not allowed",
+			item: &SecretItemResponse{
+				ID:     "google",
+				Type:   secretTypes.GoogleImpersonationSecretType,
+				Values: map[string]string{secretTypes.TargetServiceAccount: "admin@other-project.iam.gserviceaccount.com"},
+			},
+			allowed: []string{"*@my-project.iam.gserviceaccount.com"},
+			valid:   false,
+		},
+		{
+			name: "google allowed",
+			item: &SecretItemResponse{
+				ID:     "google",
+				Type:   secretTypes.GoogleImpersonationSecretType,
+				Values: map[string]string{secretTypes.TargetServiceAccount: "deployer@my-project.iam.gserviceaccount.com"},
+			},
+			allowed: []string{"*@my-project.iam.gserviceaccount.com"},
+			valid:   true,
+		},
+		{
+			name: "azure without allowed targets",
+			item: &SecretItemResponse{
+				ID:     "azure",
+				Type:   secretTypes.AzureClientCredentialsSecretType,
+				Values: map[string]string{secretTypes.AzureApplicationObjectId: "00000000-0000-0000-0000-000000000001"},
+			},
+			valid: false,
+		},
+		{
+			name: "azure allowed",
+			item: &SecretItemResponse{
+				ID:     "azure",
+				Type:   secretTypes.AzureClientCredentialsSecretType,
+				Values: map[string]string{secretTypes.AzureApplicationObjectId: "00000000-0000-0000-0000-000000000001"},
+			},
+			allowed: []string{"00000000-0000-0000-0000-000000000001"},
+			valid:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			minter := &fakeMinter{}
+
+			_, err := newTestResolver(minter, tc.allowed...).Resolve(1, tc.item)
+			if tc.valid && err != nil {
+				t.Errorf("Expected error <nil>, got: %s", err.Error())
+			} else if !tc.valid && err == nil {
+				t.Error("Expected the target to be rejected")
+			}
+
+			if !tc.valid && len(minter.values) != 0 {
+				t.Error("Expected no credentials to be minted for a rejected target")
+			}
+		})
+	}
+}
+
+// listingSecretStore returns the same secrets for every listing
+type listingSecretStore struct {
+	SecretStore
+	items []*SecretItemResponse
+}
+
+func (s *listingSecretStore) List(organizationID uint, query *secretTypes.ListSecretsQuery) ([]*SecretItemResponse, error) {
+	return s.items, nil
+}
+
+func TestResolvingSecretStoreListSkipsFailedSecrets(t *testing.T) {
+	store := &resolvingSecretStore{
+		SecretStore: &listingSecretStore{
+			items: []*SecretItemResponse{
+				{
+					ID:     "allowed",
+					Type:   secretTypes.GoogleImpersonationSecretType,
+					Values: map[string]string{secretTypes.TargetServiceAccount: "deployer@my-project.iam.gserviceaccount.com"},
+				},
+				{
+					ID:     "not-allowed",
+					Type:   secretTypes.GoogleImpersonationSecretType,
+					Values: map[string]string{secretTypes.TargetServiceAccount: "admin@other-project.iam.gserviceaccount.com"},
+				},
+				{
+					ID:     "password",
+					Type:   secretTypes.PasswordSecretType,
+					Values: map[string]string{secretTypes.Password: "secret"},
+				},
+			},
+		},
+		resolver: newTestResolver(&fakeMinter{}, "*@my-project.iam.gserviceaccount.com"),
+	}
+
+	items, err := store.List(1, &secretTypes.ListSecretsQuery{Values: true})
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 secrets, got: %d", len(items))
+	}
+
+	if items[0].ID != "allowed" || items[1].ID != "password" {
+		t.Errorf("Expected the secret failed to be minted to be skipped, got: %s, %s", items[0].ID, items[1].ID)
+	}
+}
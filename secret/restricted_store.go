@@ -7,13 +7,13 @@ import (
 )
 
 // restrictedSecretStore checks whether the user can access a certain secret.
-// For now this only means checking for forbidden tags.
+// This means checking for forbidden tags, the ACLs of the secrets are checked by the API handlers.
 type restrictedSecretStore struct {
-	*secretStore
+	SecretStore
 }
 
 func (s *restrictedSecretStore) List(orgid uint, query *secretTypes.ListSecretsQuery) ([]*SecretItemResponse, error) {
-	responseItems, err := s.secretStore.List(orgid, query)
+	responseItems, err := s.SecretStore.List(orgid, query)
 	if err != nil {
 		return nil, err
 	}
@@ -34,7 +34,15 @@ func (s *restrictedSecretStore) Update(organizationID uint, secretID string, val
 		return err
 	}
 
-	return s.secretStore.Update(organizationID, secretID, value)
+	return s.SecretStore.Update(organizationID, secretID, value)
+}
+
+func (s *restrictedSecretStore) Rotate(organizationID uint, secretID string, values map[string]string, updatedBy string) (*SecretItemResponse, error) {
+	if err := s.checkBlockingTags(organizationID, secretID); err != nil {
+		return nil, err
+	}
+
+	return s.SecretStore.Rotate(organizationID, secretID, values, updatedBy)
 }
 
 func (s *restrictedSecretStore) Delete(organizationID uint, secretID string) error {
@@ -42,12 +50,16 @@ func (s *restrictedSecretStore) Delete(organizationID uint, secretID string) err
 		return err
 	}
 
-	return s.secretStore.Delete(organizationID, secretID)
+	if err := s.SecretStore.Delete(organizationID, secretID); err != nil {
+		return err
+	}
+
+	return deleteACL(organizationID, secretID)
 }
 
 func (s *restrictedSecretStore) checkBlockingTags(organizationID uint, secretID string) error {
 
-	secretItem, err := s.secretStore.Get(organizationID, secretID)
+	secretItem, err := s.SecretStore.Get(organizationID, secretID)
 	if err != nil {
 		return err
 	}
@@ -66,7 +78,7 @@ func (s *restrictedSecretStore) checkBlockingTags(organizationID uint, secretID
 }
 
 func (s *restrictedSecretStore) checkForbiddenTags(organizationID uint, secretID string) error {
-	secretItem, err := s.secretStore.Get(organizationID, secretID)
+	secretItem, err := s.SecretStore.Get(organizationID, secretID)
 	if err != nil {
 		return err
 	}
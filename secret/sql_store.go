@@ -0,0 +1,275 @@
+package secret
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	secretTypes "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// SecretVersionTableName is the table name of the secrets stored in the database
+const SecretVersionTableName = "secret_versions"
+
+// SecretVersionModel describes a version of a secret encrypted with its own data key
+type SecretVersionModel struct {
+	ID             uint `gorm:"primary_key"`
+	CreatedAt      time.Time
+	OrganizationID uint   `gorm:"unique_index:idx_secret_version"`
+	SecretID       string `gorm:"unique_index:idx_secret_version"`
+	Version        int    `gorm:"unique_index:idx_secret_version"`
+	DataKey        []byte
+	Data           []byte
+}
+
+// TableName overrides SecretVersionModel's table name
+func (SecretVersionModel) TableName() string {
+	return SecretVersionTableName
+}
+
+// additionalData binds the encrypted data to the secret version so it cannot be moved to another one
+func (m *SecretVersionModel) additionalData() []byte {
+	return []byte(fmt.Sprintf("%d/%s/%d", m.OrganizationID, m.SecretID, m.Version))
+}
+
+// SQLSecretStore stores the versions of secrets in the database using envelope encryption
+type SQLSecretStore struct {
+	db        *gorm.DB
+	encrypter *envelopeEncrypter
+}
+
+// NewSQLSecretStore returns a secret store persisting secrets to the database,
+// the encryption key is a base64 encoded AES-256 key encrypting the data keys of the secrets
+func NewSQLSecretStore(db *gorm.DB, encryptionKey string) (*SQLSecretStore, error) {
+	key, err := base64.StdEncoding.DecodeString(encryptionKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode secret encryption key")
+	}
+
+	encrypter, err := newEnvelopeEncrypter(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLSecretStore{db: db, encrypter: encrypter}, nil
+}
+
+// DeleteByClusterUID Delete secrets by ClusterUID
+func (ss *SQLSecretStore) DeleteByClusterUID(orgID uint, clusterUID string) error {
+	return deleteByClusterUID(ss, orgID, clusterUID)
+}
+
+// Delete deletes every version of a secret
+func (ss *SQLSecretStore) Delete(organizationID uint, secretID string) error {
+
+	log.Debugf("Delete secret: %d/%s", organizationID, secretID)
+
+	err := ss.db.Where(&SecretVersionModel{OrganizationID: organizationID, SecretID: secretID}).Delete(SecretVersionModel{}).Error
+	if err != nil {
+		return errors.Wrap(err, "Error during deleting secret")
+	}
+
+	return nil
+}
+
+// Store saves the first version of a secret
+func (ss *SQLSecretStore) Store(organizationID uint, value *CreateSecretRequest) (string, error) {
+
+	secretID, err := prepareStore(organizationID, value)
+	if err != nil {
+		return "", err
+	}
+
+	if err := ss.write(organizationID, secretID, 0, value); err != nil {
+		return "", errors.Wrap(err, "Error during storing secret")
+	}
+
+	return secretID, nil
+}
+
+// Update saves a new version of a secret
+func (ss *SQLSecretStore) Update(organizationID uint, secretID string, value *CreateSecretRequest) error {
+
+	version, err := prepareUpdate(organizationID, secretID, value)
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("Update secret: %d/%s", organizationID, secretID)
+
+	if err := ss.write(organizationID, secretID, version, value); err != nil {
+		return errors.Wrap(err, "Error during updating secret")
+	}
+
+	return nil
+}
+
+// Rotate creates a new version of the secret with regenerated or the given values
+func (ss *SQLSecretStore) Rotate(organizationID uint, secretID string, values map[string]string, updatedBy string) (*SecretItemResponse, error) {
+	return rotate(ss, organizationID, secretID, values, updatedBy)
+}
+
+// GetOrCreate create new secret or get if it's exist
+func (ss *SQLSecretStore) GetOrCreate(organizationID uint, value *CreateSecretRequest) (string, error) {
+	return getOrCreate(ss, organizationID, value)
+}
+
+// CreateOrUpdate create new secret or update if it's exist
+func (ss *SQLSecretStore) CreateOrUpdate(organizationID uint, value *CreateSecretRequest) (string, error) {
+	return createOrUpdate(ss, organizationID, value)
+}
+
+// Get returns the latest version of a secret
+func (ss *SQLSecretStore) Get(organizationID uint, secretID string) (*SecretItemResponse, error) {
+
+	log.Debugf("Get secret: %d/%s", organizationID, secretID)
+
+	secretVersion, err := ss.latest(ss.db, organizationID, secretID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error during reading secret")
+	}
+
+	if secretVersion == nil {
+		return nil, ErrSecretNotExists
+	}
+
+	return ss.decrypt(secretVersion, true)
+}
+
+// GetByName returns the latest version of a secret by its name
+func (ss *SQLSecretStore) GetByName(organizationID uint, name string) (*SecretItemResponse, error) {
+	return getByName(ss, organizationID, name)
+}
+
+// List returns the latest version of the secrets of an organization matching the query
+func (ss *SQLSecretStore) List(orgid uint, query *secretTypes.ListSecretsQuery) ([]*SecretItemResponse, error) {
+
+	log.Debugf("Searching for secrets [orgid: %d, query: %#v]", orgid, query)
+
+	var secretVersions []*SecretVersionModel
+
+	err := ss.db.Where(fmt.Sprintf(
+		"organization_id = ? AND version = (SELECT MAX(v.version) FROM %[1]s v WHERE v.organization_id = %[1]s.organization_id AND v.secret_id = %[1]s.secret_id)",
+		SecretVersionTableName,
+	), orgid).Order("secret_id").Find(&secretVersions).Error
+	if err != nil {
+		log.Errorf("Error listing secrets: %s", err.Error())
+		return nil, err
+	}
+
+	responseItems := []*SecretItemResponse{}
+
+	for _, secretVersion := range secretVersions {
+		sir, err := ss.decrypt(secretVersion, query.Values)
+		if err != nil {
+			return nil, err
+		}
+
+		if sir.matches(query) {
+			responseItems = append(responseItems, sir)
+		}
+	}
+
+	return responseItems, nil
+}
+
+// write saves a new version of a secret if the check-and-set version is the current one,
+// 0 means that the secret must not exist, just like in Vault
+func (ss *SQLSecretStore) write(organizationID uint, secretID string, cas int, value *CreateSecretRequest) error {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return errors.Wrap(err, "could not encode secret")
+	}
+
+	tx := ss.db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	current, err := ss.latest(tx, organizationID, secretID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	version := 0
+	if current != nil {
+		version = current.Version
+	}
+
+	if cas != version {
+		tx.Rollback()
+		return errCASMismatch
+	}
+
+	secretVersion := SecretVersionModel{
+		OrganizationID: organizationID,
+		SecretID:       secretID,
+		Version:        version + 1,
+	}
+
+	secretVersion.DataKey, secretVersion.Data, err = ss.encrypter.Encrypt(payload, secretVersion.additionalData())
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Create(&secretVersion).Error; err != nil {
+		tx.Rollback()
+
+		// a concurrent write of the same version is rejected by the unique index
+		if latest, _ := ss.latest(ss.db, organizationID, secretID); latest != nil && latest.Version != version {
+			return errCASMismatch
+		}
+
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// latest returns the latest version of a secret or nil if the secret doesn't exist
+func (ss *SQLSecretStore) latest(db *gorm.DB, organizationID uint, secretID string) (*SecretVersionModel, error) {
+	var secretVersion SecretVersionModel
+
+	err := db.Where(&SecretVersionModel{OrganizationID: organizationID, SecretID: secretID}).Order("version desc").First(&secretVersion).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &secretVersion, nil
+}
+
+func (ss *SQLSecretStore) decrypt(secretVersion *SecretVersionModel, values bool) (*SecretItemResponse, error) {
+	payload, err := ss.encrypter.Decrypt(secretVersion.DataKey, secretVersion.Data, secretVersion.additionalData())
+	if err != nil {
+		return nil, err
+	}
+
+	var value CreateSecretRequest
+	if err := json.Unmarshal(payload, &value); err != nil {
+		return nil, errors.Wrap(err, "could not decode secret")
+	}
+
+	sir := SecretItemResponse{
+		ID:        secretVersion.SecretID,
+		Name:      value.Name,
+		Type:      value.Type,
+		Tags:      value.Tags,
+		Values:    value.Values,
+		Version:   secretVersion.Version,
+		UpdatedAt: secretVersion.CreatedAt,
+		UpdatedBy: value.UpdatedBy,
+	}
+
+	if !values {
+		sir.hideValues()
+	}
+
+	return &sir, nil
+}
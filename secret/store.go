@@ -2,7 +2,6 @@ package secret
 
 import (
 	"crypto/sha256"
-	"encoding/json"
 	"fmt"
 	"sort"
 	"strconv"
@@ -10,35 +9,49 @@ import (
 	"time"
 
 	"github.com/banzaicloud/bank-vaults/pkg/tls"
-	"github.com/banzaicloud/bank-vaults/vault"
+	"github.com/banzaicloud/pipeline/config"
 	secretTypes "github.com/banzaicloud/pipeline/pkg/secret"
 	"github.com/banzaicloud/pipeline/secret/verify"
-	vaultapi "github.com/hashicorp/vault/api"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/cast"
 	"github.com/spf13/viper"
 	"k8s.io/apimachinery/pkg/util/validation"
 )
 
-// Store object that wraps up vault logical store
-var Store *secretStore
+// SecretStore is the interface of the backends storing secrets of organizations
+type SecretStore interface {
+	Store(organizationID uint, value *CreateSecretRequest) (string, error)
+	Update(organizationID uint, secretID string, value *CreateSecretRequest) error
+	Rotate(organizationID uint, secretID string, values map[string]string, updatedBy string) (*SecretItemResponse, error)
+	Get(organizationID uint, secretID string) (*SecretItemResponse, error)
+	GetByName(organizationID uint, name string) (*SecretItemResponse, error)
+	List(organizationID uint, query *secretTypes.ListSecretsQuery) ([]*SecretItemResponse, error)
+	Delete(organizationID uint, secretID string) error
+	DeleteByClusterUID(organizationID uint, clusterUID string) error
+	GetOrCreate(organizationID uint, value *CreateSecretRequest) (string, error)
+	CreateOrUpdate(organizationID uint, value *CreateSecretRequest) (string, error)
+}
+
+// Store object that wraps up the configured secret backend, it hands out the minted credentials of dynamic secrets
+var Store SecretStore
+
+// Backend is the configured secret backend, it returns the definitions of dynamic secrets
+var Backend SecretStore
 
-// RestrictedStore object that wraps the main secret store and restricts access to certain items
+// RestrictedStore object that wraps the secret backend and restricts access to certain items
 var RestrictedStore *restrictedSecretStore
 
 // ErrSecretNotExists denotes 'Not Found' errors for secrets
 var ErrSecretNotExists = fmt.Errorf("There's no secret with this ID")
 
-func init() {
-	Store = newVaultSecretStore()
-	RestrictedStore = &restrictedSecretStore{Store}
-}
+// errCASMismatch is returned by the SQL backend with the same message as Vault does
+var errCASMismatch = errors.New("check-and-set parameter did not match the current version")
 
-type secretStore struct {
-	Client  *vault.Client
-	Logical *vaultapi.Logical
+func init() {
+	Backend = newSecretStore()
+	Store = newResolvingSecretStore(Backend)
+	RestrictedStore = &restrictedSecretStore{Backend}
 }
 
 // CreateSecretResponse API response for AddSecrets
@@ -62,6 +75,22 @@ type CreateSecretRequest struct {
 	UpdatedBy string            `json:"updatedBy"`
 }
 
+// RotateSecretRequest param for Store.Rotate
+type RotateSecretRequest struct {
+	Values map[string]string `json:"values"`
+}
+
+// RotateSecretResponse API response for RotateSecret
+type RotateSecretResponse struct {
+	ID        string                                 `json:"id"`
+	Name      string                                 `json:"name"`
+	Type      string                                 `json:"type"`
+	Version   int                                    `json:"version"`
+	UpdatedAt time.Time                              `json:"updatedAt"`
+	UpdatedBy string                                 `json:"updatedBy,omitempty"`
+	Clusters  []secretTypes.ClusterPropagationResult `json:"clusters"`
+}
+
 // SecretItemResponse for GetSecret
 type SecretItemResponse struct {
 	ID        string            `json:"id"`
@@ -82,11 +111,70 @@ func (s *SecretItemResponse) K8SSourceMeta() secretTypes.K8SSourceMeta {
 	}
 }
 
+// RotateRequest returns the request creating the next version of the secret.
+// TLS certificates and passwords are regenerated unless new values are given,
+// values of other secret types must be given and replace the current ones.
+func (s *SecretItemResponse) RotateRequest(values map[string]string) (*CreateSecretRequest, error) {
+	request := &CreateSecretRequest{
+		Name:    s.Name,
+		Type:    s.Type,
+		Tags:    s.Tags,
+		Version: &s.Version,
+		Values:  make(map[string]string),
+	}
+
+	switch s.Type {
+	case secretTypes.TLSSecretType:
+		// keeping only the hosts and the validity makes the certificates generated again
+		for _, key := range []string{secretTypes.TLSHosts, secretTypes.TLSValidity} {
+			if value, ok := s.Values[key]; ok {
+				request.Values[key] = value
+			}
+		}
+
+	case secretTypes.PasswordSecretType:
+		for k, v := range s.Values {
+			request.Values[k] = v
+		}
+
+		// an empty password is generated using the default format
+		request.Values[secretTypes.Password] = ""
+
+	default:
+		if len(values) == 0 {
+			return nil, errors.Errorf("new values are required to rotate a %s secret", s.Type)
+		}
+
+		for k, v := range s.Values {
+			request.Values[k] = v
+		}
+	}
+
+	for k, v := range values {
+		request.Values[k] = v
+	}
+
+	return request, nil
+}
+
 // GetValue returns the value under key
 func (s *SecretItemResponse) GetValue(key string) string {
 	return s.Values[key]
 }
 
+// hideValues clears the values of the secret
+func (s *SecretItemResponse) hideValues() {
+	for k := range s.Values {
+		s.Values[k] = "<hidden>"
+	}
+}
+
+// matches checks whether the secret is selected by the query
+func (s *SecretItemResponse) matches(query *secretTypes.ListSecretsQuery) bool {
+	return (query.Type == secretTypes.AllSecrets || s.Type == query.Type) &&
+		(query.Tag == "" || hasTag(s.Tags, query.Tag))
+}
+
 // ValidateSecretType validates the secret type
 func (s *SecretItemResponse) ValidateSecretType(validType string) error {
 	if string(s.Type) != validType {
@@ -107,14 +195,23 @@ type AllowedFilteredSecretTypesResponse struct {
 // AllowedSecretTypesResponse for API response for AllowedSecretTypes
 type AllowedSecretTypesResponse map[string]secretTypes.Meta
 
-func newVaultSecretStore() *secretStore {
-	role := "pipeline"
-	client, err := vault.NewClient(role)
-	if err != nil {
-		panic(err)
+func newSecretStore() SecretStore {
+	switch backend := viper.GetString(config.SecretBackend); backend {
+	case SQLBackend:
+		store, err := NewSQLSecretStore(config.DB(), viper.GetString(config.SecretSQLEncryptionKey))
+		if err != nil {
+			panic(err)
+		}
+		return store
+	case VaultBackend:
+		store, err := NewVaultSecretStore("pipeline")
+		if err != nil {
+			panic(err)
+		}
+		return store
+	default:
+		panic(fmt.Sprintf("unknown secret backend: %s", backend))
 	}
-	logical := client.Vault().Logical()
-	return &secretStore{Client: client, Logical: logical}
 }
 
 // GenerateSecretIDFromName generates a "unique by name per organization" id for Secrets
@@ -127,18 +224,32 @@ func GenerateSecretID(request *CreateSecretRequest) string {
 	return GenerateSecretIDFromName(request.Name)
 }
 
-// Validate SecretRequest
-func (r *CreateSecretRequest) Validate(verifier verify.Verifier) error {
-	fields, ok := secretTypes.DefaultRules[r.Type]
-
-	if !ok {
+// Validate SecretRequest against the built-in secret types and the custom secret types of the organization
+func (r *CreateSecretRequest) Validate(organizationID uint, verifier verify.Verifier) error {
+	fields, err := GetSecretTypeMeta(organizationID, r.Type)
+	if err == ErrSecretTypeNotExists {
 		return errors.Errorf("wrong secret type: %s", r.Type)
+	} else if err != nil {
+		return err
 	}
 
 	for _, field := range fields.Fields {
-		if _, ok := r.Values[field.Name]; field.Required && !ok {
+		value, ok := r.Values[field.Name]
+
+		// generated values are checked by the generator, values given by the user are validated
+		if field.Generate != "" {
+			if isGeneratorInput(field, r.Values) {
+				continue
+			}
+		} else if field.Required && !ok {
 			return errors.Errorf("missing key: %s", field.Name)
 		}
+
+		if ok {
+			if err := validateField(field, value); err != nil {
+				return err
+			}
+		}
 	}
 
 	if verifier != nil {
@@ -148,8 +259,14 @@ func (r *CreateSecretRequest) Validate(verifier verify.Verifier) error {
 	return nil
 }
 
-// DeleteByClusterUID Delete secrets by ClusterUID
-func (ss *secretStore) DeleteByClusterUID(orgID uint, clusterUID string) error {
+// Secret backends
+const (
+	VaultBackend = "vault"
+	SQLBackend   = "sql"
+)
+
+// deleteByClusterUID deletes the secrets of a cluster from the given store
+func deleteByClusterUID(store SecretStore, orgID uint, clusterUID string) error {
 	if clusterUID == "" {
 		return errors.New("ClusterUID is empty.")
 	}
@@ -157,7 +274,7 @@ func (ss *secretStore) DeleteByClusterUID(orgID uint, clusterUID string) error {
 	log := log.WithFields(logrus.Fields{"organization": orgID, "clusterUID": clusterUID})
 
 	clusterIdTag := fmt.Sprintf("clusterUID:%s", clusterUID)
-	secrets, err := Store.List(orgID,
+	secrets, err := store.List(orgID,
 		&secretTypes.ListSecretsQuery{
 			Tag: clusterIdTag,
 		})
@@ -169,7 +286,7 @@ func (ss *secretStore) DeleteByClusterUID(orgID uint, clusterUID string) error {
 
 	for _, s := range secrets {
 		log := log.WithFields(logrus.Fields{"secret": s.ID, "secretName": s.Name})
-		err := Store.Delete(orgID, s.ID)
+		err := store.Delete(orgID, s.ID)
 		if err != nil {
 			log.Errorf("Error during delete secret: %s", err.Error())
 		}
@@ -179,32 +296,15 @@ func (ss *secretStore) DeleteByClusterUID(orgID uint, clusterUID string) error {
 	return nil
 }
 
-// Delete secret secret/orgs/:orgid:/:id: scope
-func (ss *secretStore) Delete(organizationID uint, secretID string) error {
-
-	path := secretMetadataPath(organizationID, secretID)
-
-	log.Debugln("Delete secret:", path)
-
-	if _, err := ss.Logical.Delete(path); err != nil {
-		return errors.Wrap(err, "Error during deleting secret")
-	}
-
-	return nil
-}
-
-// Save secret secret/orgs/:orgid:/:id: scope
-func (ss *secretStore) Store(organizationID uint, value *CreateSecretRequest) (string, error) {
+// prepareStore validates and completes a new secret, it returns the ID of the secret
+func prepareStore(organizationID uint, value *CreateSecretRequest) (string, error) {
 
 	// We allow only Kubernetes compatible Secret names
 	if errorList := validation.IsDNS1123Subdomain(value.Name); errorList != nil {
 		return "", errors.New(errorList[0])
 	}
 
-	secretID := GenerateSecretID(value)
-	path := secretDataPath(organizationID, secretID)
-
-	if err := generateValuesIfNeeded(value); err != nil {
+	if err := generateValuesIfNeeded(organizationID, value); err != nil {
 		return "", err
 	}
 
@@ -212,25 +312,21 @@ func (ss *secretStore) Store(organizationID uint, value *CreateSecretRequest) (s
 
 	value.Version = nil
 
-	data := vault.NewData(0, map[string]interface{}{"value": value})
-
-	if _, err := ss.Logical.Write(path, data); err != nil {
-		return "", errors.Wrap(err, "Error during storing secret")
-	}
-
-	return secretID, nil
+	return GenerateSecretID(value), nil
 }
 
-// Update secret secret/orgs/:orgid:/:id: scope
-func (ss *secretStore) Update(organizationID uint, secretID string, value *CreateSecretRequest) error {
+// prepareUpdate validates a new version of a secret, it returns the version expected to be replaced
+func prepareUpdate(organizationID uint, secretID string, value *CreateSecretRequest) (int, error) {
 
 	if GenerateSecretID(value) != secretID {
-		return errors.New("Secret name cannot be changed")
+		return 0, errors.New("Secret name cannot be changed")
 	}
 
-	path := secretDataPath(organizationID, secretID)
-
-	log.Debugln("Update secret:", path)
+	if value.Type == secretTypes.AmazonRoleSecretType {
+		if err := setExternalID(organizationID, value); err != nil {
+			return 0, err
+		}
+	}
 
 	sort.Strings(value.Tags)
 
@@ -241,27 +337,54 @@ func (ss *secretStore) Update(organizationID uint, secretID string, value *Creat
 		value.Version = nil
 	}
 
-	data := vault.NewData(version, map[string]interface{}{"value": value})
+	return version, nil
+}
+
+// rotate creates a new version of a secret in the given store
+func rotate(store SecretStore, organizationID uint, secretID string, values map[string]string, updatedBy string) (*SecretItemResponse, error) {
 
-	if _, err := ss.Logical.Write(path, data); err != nil {
-		return errors.Wrap(err, "Error during updating secret")
+	current, err := store.Get(organizationID, secretID)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	value, err := current.RotateRequest(values)
+	if err != nil {
+		return nil, err
+	}
+
+	value.UpdatedBy = updatedBy
+
+	// generated values are validated by the generator
+	if err := value.Validate(organizationID, nil); err != nil {
+		return nil, err
+	}
+
+	if err := generateValuesIfNeeded(organizationID, value); err != nil {
+		return nil, err
+	}
+
+	log.Debugf("Rotate secret: %d/%s", organizationID, secretID)
+
+	if err := store.Update(organizationID, secretID, value); err != nil {
+		return nil, err
+	}
+
+	return store.Get(organizationID, secretID)
 }
 
-// GetOrCreate create new secret or get if it's exist. secret/orgs/:orgid:/:id: scope
-func (ss *secretStore) GetOrCreate(organizationID uint, value *CreateSecretRequest) (string, error) {
+// getOrCreate gets a secret from the given store or creates it if it doesn't exist
+func getOrCreate(store SecretStore, organizationID uint, value *CreateSecretRequest) (string, error) {
 	secretID := GenerateSecretID(value)
 
 	// Try to get the secret version first
-	if secret, err := Store.Get(organizationID, secretID); err != nil && err != ErrSecretNotExists {
+	if secret, err := store.Get(organizationID, secretID); err != nil && err != ErrSecretNotExists {
 		log.Errorf("Error during checking secret: %s", err.Error())
 		return "", err
 	} else if secret != nil {
 		return secret.ID, nil
 	} else {
-		secretID, err = Store.Store(organizationID, value)
+		secretID, err = store.Store(organizationID, value)
 		if err != nil {
 			log.Errorf("Error during storing secret: %s", err.Error())
 			return "", err
@@ -270,24 +393,24 @@ func (ss *secretStore) GetOrCreate(organizationID uint, value *CreateSecretReque
 	return secretID, nil
 }
 
-// CreateOrUpdate create new secret or update if it's exist. secret/orgs/:orgid:/:id: scope
-func (ss *secretStore) CreateOrUpdate(organizationID uint, value *CreateSecretRequest) (string, error) {
+// createOrUpdate creates a secret in the given store or updates it if it exists
+func createOrUpdate(store SecretStore, organizationID uint, value *CreateSecretRequest) (string, error) {
 
 	secretID := GenerateSecretID(value)
 
 	// Try to get the secret version first
-	if secret, err := Store.Get(organizationID, secretID); err != nil && err != ErrSecretNotExists {
+	if secret, err := store.Get(organizationID, secretID); err != nil && err != ErrSecretNotExists {
 		log.Errorf("Error during checking secret: %s", err.Error())
 		return "", err
 	} else if secret != nil {
 		value.Version = &(secret.Version)
-		err := Store.Update(organizationID, secretID, value)
+		err := store.Update(organizationID, secretID, value)
 		if err != nil {
 			log.Errorf("Error during updating secret: %s", err.Error())
 			return "", err
 		}
 	} else {
-		secretID, err = Store.Store(organizationID, value)
+		secretID, err = store.Store(organizationID, value)
 		if err != nil {
 			log.Errorf("Error during storing secret: %s", err.Error())
 			return "", err
@@ -296,74 +419,11 @@ func (ss *secretStore) CreateOrUpdate(organizationID uint, value *CreateSecretRe
 	return secretID, nil
 }
 
-func parseSecret(secretID string, secret *vaultapi.Secret, values bool) (*SecretItemResponse, error) {
-
-	data := cast.ToStringMap(secret.Data["data"])
-	metadata := cast.ToStringMap(secret.Data["metadata"])
-
-	value := data["value"].(map[string]interface{})
-	sname := value["name"].(string)
-	stype := value["type"].(string)
-	stags := cast.ToStringSlice(value["tags"])
-	version, _ := metadata["version"].(json.Number).Int64()
-
-	updatedAt, err := time.Parse(time.RFC3339, metadata["created_time"].(string))
-	if err != nil {
-		return nil, err
-	}
-
-	updatedBy := ""
-	updatedByRaw, ok := value["updatedBy"]
-	if ok {
-		updatedBy = updatedByRaw.(string)
-	}
-
-	sir := SecretItemResponse{
-		ID:        secretID,
-		Name:      sname,
-		Type:      stype,
-		Tags:      stags,
-		Values:    cast.ToStringMapString(value["values"]),
-		Version:   int(version),
-		UpdatedAt: updatedAt,
-		UpdatedBy: updatedBy,
-	}
-
-	if !values {
-		// Clear the values otherwise
-		for k := range sir.Values {
-			sir.Values[k] = "<hidden>"
-		}
-	}
-
-	return &sir, nil
-}
-
-// Retrieve secret secret/orgs/:orgid:/:id: scope
-func (ss *secretStore) Get(organizationID uint, secretID string) (*SecretItemResponse, error) {
-
-	path := secretDataPath(organizationID, secretID)
-
-	log.Debugln("Get secret:", path)
-
-	secret, err := ss.Logical.Read(path)
-
-	if err != nil {
-		return nil, errors.Wrap(err, "Error during reading secret")
-	}
-
-	if secret == nil {
-		return nil, ErrSecretNotExists
-	}
-
-	return parseSecret(secretID, secret, true)
-}
-
-// Retrieve secret by secret Name secret/orgs/:orgid:/:id: scope
-func (ss *secretStore) GetByName(organizationID uint, name string) (*SecretItemResponse, error) {
+// getByName gets a secret from the given store by its name
+func getByName(store SecretStore, organizationID uint, name string) (*SecretItemResponse, error) {
 
 	secretID := GenerateSecretIDFromName(name)
-	secret, err := Store.Get(organizationID, secretID)
+	secret, err := store.Get(organizationID, secretID)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error during reading secret")
 	}
@@ -374,60 +434,6 @@ func (ss *secretStore) GetByName(organizationID uint, name string) (*SecretItemR
 
 	return secret, nil
 }
-
-// List secret secret/orgs/:orgid:/ scope
-func (ss *secretStore) List(orgid uint, query *secretTypes.ListSecretsQuery) ([]*SecretItemResponse, error) {
-
-	log.Debugf("Searching for secrets [orgid: %d, query: %#v]", orgid, query)
-
-	listPath := fmt.Sprintf("secret/metadata/orgs/%d", orgid)
-
-	responseItems := []*SecretItemResponse{}
-
-	list, err := ss.Logical.List(listPath)
-	if err != nil {
-		log.Errorf("Error listing secrets: %s", err.Error())
-		return nil, err
-	}
-
-	if list != nil {
-
-		keys := cast.ToStringSlice(list.Data["keys"])
-
-		for _, secretID := range keys {
-
-			if secret, err := ss.Logical.Read(secretDataPath(orgid, secretID)); err != nil {
-
-				log.Errorf("Error listing secrets: %s", err.Error())
-				return nil, err
-
-			} else if secret != nil {
-
-				sir, err := parseSecret(secretID, secret, query.Values)
-				if err != nil {
-					return nil, err
-				}
-
-				if (query.Type == secretTypes.AllSecrets || sir.Type == query.Type) &&
-					(query.Tag == "" || hasTag(sir.Tags, query.Tag)) {
-
-					responseItems = append(responseItems, sir)
-				}
-			}
-		}
-	}
-
-	return responseItems, nil
-}
-
-func secretDataPath(organizationID uint, secretID string) string {
-	return fmt.Sprintf("secret/data/orgs/%d/%s", organizationID, secretID)
-}
-
-func secretMetadataPath(organizationID uint, secretID string) string {
-	return fmt.Sprintf("secret/metadata/orgs/%d/%s", organizationID, secretID)
-}
-
 func hasTag(tags []string, tag string) bool {
 	index := sort.SearchStrings(tags, tag)
 	return index < len(tags) && tags[index] == tag
@@ -452,38 +458,97 @@ func IsCASError(err error) bool {
 	return strings.HasSuffix(err.Error(), "check-and-set parameter did not match the current version")
 }
 
-func generateValuesIfNeeded(value *CreateSecretRequest) error {
+func generateValuesIfNeeded(organizationID uint, value *CreateSecretRequest) error {
 	// If we are not storing a full TLS secret instead of it's a request to generate one
 	if value.Type == secretTypes.TLSSecretType && len(value.Values) <= 2 {
-		validity := value.Values[secretTypes.TLSValidity]
-		if validity == "" {
-			validity = viper.GetString("tls.validity")
+		return generateTLS(value, secretTypes.TLSHosts, value.Values[secretTypes.TLSValidity])
+		// Generate a password if needed (if password is in method,length)
+	} else if value.Type == secretTypes.PasswordSecretType {
+		return generatePassword(value, secretTypes.Password)
+	} else if value.Type == secretTypes.AmazonRoleSecretType {
+		return setExternalID(organizationID, value)
+	} else if _, ok := secretTypes.DefaultRules[value.Type]; ok || organizationID == 0 {
+		return nil
+	}
+
+	secretType, err := GetSecretType(organizationID, value.Type)
+	if err == ErrSecretTypeNotExists {
+		// validation reports the unknown type
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, field := range secretType.Meta.Fields {
+		switch field.Generate {
+		case secretTypes.GeneratePassword:
+			err = generatePassword(value, field.Name)
+		case secretTypes.GenerateTLS:
+			// certificates given already are kept
+			if value.Values[secretTypes.CACert] == "" {
+				err = generateTLS(value, field.Name, value.Values[secretTypes.TLSValidity])
+			}
 		}
-		cc, err := tls.GenerateTLS(value.Values[secretTypes.TLSHosts], validity)
+
 		if err != nil {
-			return errors.Wrap(err, "Error during generating TLS secret")
+			return err
 		}
-		err = mapstructure.Decode(cc, &value.Values)
+	}
+
+	return nil
+}
+
+// setExternalID sets the external ID of the organization, so that it can be required by the trust policy of the role,
+// the external ID given by the user is overwritten
+func setExternalID(organizationID uint, value *CreateSecretRequest) error {
+	externalID, err := GetExternalID(organizationID)
+	if err != nil {
+		return err
+	}
+
+	if value.Values == nil {
+		value.Values = make(map[string]string)
+	}
+	value.Values[secretTypes.AwsExternalId] = externalID
+
+	return nil
+}
+
+// generateTLS generates the TLS certificates for the hosts under the key
+func generateTLS(value *CreateSecretRequest, hostsKey string, validity string) error {
+	if validity == "" {
+		validity = viper.GetString("tls.validity")
+	}
+	cc, err := tls.GenerateTLS(value.Values[hostsKey], validity)
+	if err != nil {
+		return errors.Wrap(err, "Error during generating TLS secret")
+	}
+	err = mapstructure.Decode(cc, &value.Values)
+	if err != nil {
+		return errors.Wrap(err, "Error during decoding TLS secret")
+	}
+	return nil
+}
+
+// generatePassword generates the password under the key if it's empty or in the "method,length" format
+func generatePassword(value *CreateSecretRequest, key string) error {
+	if value.Values == nil {
+		value.Values = make(map[string]string)
+	}
+	if value.Values[key] == "" {
+		value.Values[key] = DefaultPasswordFormat
+	}
+	methodAndLength := strings.Split(value.Values[key], ",")
+	if len(methodAndLength) == 2 {
+		length, err := strconv.Atoi(methodAndLength[1])
 		if err != nil {
-			return errors.Wrap(err, "Error during decoding TLS secret")
-		}
-		// Generate a password if needed (if password is in method,length)
-	} else if value.Type == secretTypes.PasswordSecretType {
-		if value.Values[secretTypes.Password] == "" {
-			value.Values[secretTypes.Password] = DefaultPasswordFormat
+			return err
 		}
-		methodAndLength := strings.Split(value.Values[secretTypes.Password], ",")
-		if len(methodAndLength) == 2 {
-			length, err := strconv.Atoi(methodAndLength[1])
-			if err != nil {
-				return err
-			}
-			password, err := RandomString(methodAndLength[0], length)
-			if err != nil {
-				return err
-			}
-			value.Values[secretTypes.Password] = password
+		password, err := RandomString(methodAndLength[0], length)
+		if err != nil {
+			return err
 		}
+		value.Values[key] = password
 	}
 	return nil
 }
@@ -57,7 +57,7 @@ func TestCreateSecretValidate(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := tc.request.Validate(tc.verifier)
+			err := tc.request.Validate(0, tc.verifier)
 
 			if err != nil {
 				if !tc.isError {
@@ -72,6 +72,67 @@ func TestCreateSecretValidate(t *testing.T) {
 
 }
 
+func TestSecretItemRotateRequest(t *testing.T) {
+
+	cases := []struct {
+		name       string
+		secretItem secret.SecretItemResponse
+		values     map[string]string
+		expected   map[string]string
+		isError    bool
+	}{
+		{
+			name: "tls",
+			secretItem: secret.SecretItemResponse{Name: secretDesc, Type: pkgSecret.TLSSecretType, Values: map[string]string{
+				pkgSecret.TLSHosts:   "localhost",
+				pkgSecret.ServerCert: "cert",
+				pkgSecret.ServerKey:  "key",
+			}},
+			expected: map[string]string{pkgSecret.TLSHosts: "localhost"},
+		},
+		{
+			name: "password",
+			secretItem: secret.SecretItemResponse{Name: secretDesc, Type: pkgSecret.PasswordSecretType, Values: map[string]string{
+				pkgSecret.Username: "admin",
+				pkgSecret.Password: "secret",
+			}},
+			expected: map[string]string{pkgSecret.Username: "admin", pkgSecret.Password: ""},
+		},
+		{
+			name:       "gke with new values",
+			secretItem: secretItem1,
+			values:     map[string]string{pkgSecret.ProjectId: "new-project"},
+			expected:   map[string]string{pkgSecret.ProjectId: "new-project"},
+		},
+		{
+			name:       "gke without new values",
+			secretItem: secretItem1,
+			isError:    true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := tc.secretItem.RotateRequest(tc.values)
+
+			if tc.isError {
+				if err == nil {
+					t.Errorf("Expected error, got <nil>")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected error <nil>, got: %s", err.Error())
+			}
+
+			if !reflect.DeepEqual(tc.expected, request.Values) {
+				t.Errorf("Expected values: %v, but got: %v", tc.expected, request.Values)
+			}
+		})
+	}
+}
+
 const (
 	secretId         = "secretId"
 	secretDesc       = "secretDesc"
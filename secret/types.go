@@ -0,0 +1,305 @@
+package secret
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/banzaicloud/pipeline/config"
+	secretTypes "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ErrSecretTypeNotExists is returned when neither a built-in nor a custom secret type exists with the name
+var ErrSecretTypeNotExists = errors.New("secret type not found")
+
+// kubernetesSecretTypes are the Kubernetes secret types custom secret types can be installed as, with the keys they require
+var kubernetesSecretTypes = map[string][]string{
+	"":                                    nil,
+	string(v1.SecretTypeOpaque):           nil,
+	string(v1.SecretTypeDockercfg):        {v1.DockerConfigKey},
+	string(v1.SecretTypeDockerConfigJson): {v1.DockerConfigJsonKey},
+	string(v1.SecretTypeBasicAuth):        nil,
+	string(v1.SecretTypeSSHAuth):          {v1.SSHAuthPrivateKey},
+	string(v1.SecretTypeTLS):              {v1.TLSCertKey, v1.TLSPrivateKeyKey},
+}
+
+// SecretTypeModel is a custom secret type registered by an organization
+type SecretTypeModel struct {
+	ID             uint             `gorm:"primary_key" json:"-"`
+	CreatedAt      time.Time        `json:"createdAt"`
+	UpdatedAt      time.Time        `json:"updatedAt"`
+	OrganizationID uint             `gorm:"unique_index:idx_secret_type_org_name" json:"-"`
+	Name           string           `gorm:"unique_index:idx_secret_type_org_name" json:"name"`
+	Meta           secretTypes.Meta `gorm:"-" json:"meta"`
+	MetaRaw        string           `gorm:"column:meta;type:text" json:"-"`
+}
+
+// TableName overrides SecretTypeModel's table name
+func (SecretTypeModel) TableName() string {
+	return "secret_types"
+}
+
+// BeforeSave serializes the fields of the secret type
+func (t *SecretTypeModel) BeforeSave() error {
+	meta, err := json.Marshal(t.Meta)
+	if err != nil {
+		return err
+	}
+
+	t.MetaRaw = string(meta)
+
+	return nil
+}
+
+// AfterFind deserializes the fields of the secret type
+func (t *SecretTypeModel) AfterFind() error {
+	return json.Unmarshal([]byte(t.MetaRaw), &t.Meta)
+}
+
+// SecretTypeRequest describes a custom secret type of an organization
+type SecretTypeRequest struct {
+	Fields         []secretTypes.FieldMeta    `json:"fields" binding:"required,min=1"`
+	Sourcing       secretTypes.SourcingMethod `json:"sourcing"`
+	KubernetesType string                     `json:"kubernetesType"`
+}
+
+// Validate checks the name of the type and the rules of its fields
+func (r *SecretTypeRequest) Validate(name string) error {
+	if errorList := validation.IsDNS1123Label(name); errorList != nil {
+		return errors.Errorf("invalid secret type name %q: %s", name, errorList[0])
+	}
+
+	if _, ok := secretTypes.DefaultRules[name]; ok {
+		return errors.Errorf("%s is a built-in secret type", name)
+	}
+
+	switch r.Sourcing {
+	case "", secretTypes.EnvVar, secretTypes.Volume:
+	default:
+		return errors.Errorf("unknown sourcing method: %s", r.Sourcing)
+	}
+
+	requiredKeys, ok := kubernetesSecretTypes[r.KubernetesType]
+	if !ok {
+		return errors.Errorf("unsupported Kubernetes secret type: %s", r.KubernetesType)
+	}
+
+	fieldNames := make(map[string]bool, len(r.Fields))
+
+	for _, field := range r.Fields {
+		if errorList := validation.IsConfigMapKey(field.Name); errorList != nil {
+			return errors.Errorf("invalid field name %q: %s", field.Name, errorList[0])
+		}
+
+		if fieldNames[field.Name] {
+			return errors.Errorf("duplicated field: %s", field.Name)
+		}
+		fieldNames[field.Name] = true
+
+		if _, err := regexp.Compile(field.Pattern); err != nil {
+			return errors.Wrapf(err, "invalid pattern of field %s", field.Name)
+		}
+
+		switch field.Format {
+		case "", secretTypes.FormatEmail, secretTypes.FormatURL, secretTypes.FormatHostname, secretTypes.FormatBase64, secretTypes.FormatJSON:
+		default:
+			return errors.Errorf("unknown format of field %s: %s", field.Name, field.Format)
+		}
+
+		switch field.Generate {
+		case "", secretTypes.GeneratePassword, secretTypes.GenerateTLS:
+		default:
+			return errors.Errorf("unknown generator of field %s: %s", field.Name, field.Generate)
+		}
+	}
+
+	for _, key := range requiredKeys {
+		if !fieldNames[key] {
+			return errors.Errorf("field %s is required by Kubernetes secret type %s", key, r.KubernetesType)
+		}
+	}
+
+	return nil
+}
+
+// Meta returns the description of the secret type
+func (r *SecretTypeRequest) Meta() secretTypes.Meta {
+	meta := secretTypes.Meta{
+		Fields:         r.Fields,
+		Sourcing:       r.Sourcing,
+		KubernetesType: r.KubernetesType,
+	}
+
+	if meta.Sourcing == "" {
+		meta.Sourcing = secretTypes.EnvVar
+	}
+
+	return meta
+}
+
+// GetSecretTypeMeta returns the built-in secret type or the custom secret type of the organization with the name
+func GetSecretTypeMeta(organizationID uint, name string) (secretTypes.Meta, error) {
+	if meta, ok := secretTypes.DefaultRules[name]; ok {
+		return meta, nil
+	}
+
+	if organizationID == 0 {
+		return secretTypes.Meta{}, ErrSecretTypeNotExists
+	}
+
+	secretType, err := GetSecretType(organizationID, name)
+	if err != nil {
+		return secretTypes.Meta{}, err
+	}
+
+	return secretType.Meta, nil
+}
+
+// GetSecretType returns the custom secret type of the organization
+func GetSecretType(organizationID uint, name string) (*SecretTypeModel, error) {
+	var secretType SecretTypeModel
+
+	err := config.DB().Where(&SecretTypeModel{OrganizationID: organizationID, Name: name}).First(&secretType).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, ErrSecretTypeNotExists
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "could not get secret type %s", name)
+	}
+
+	return &secretType, nil
+}
+
+// ListSecretTypes returns the custom secret types of the organization
+func ListSecretTypes(organizationID uint) ([]SecretTypeModel, error) {
+	var types []SecretTypeModel
+
+	err := config.DB().Where(&SecretTypeModel{OrganizationID: organizationID}).Order("name").Find(&types).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list secret types")
+	}
+
+	return types, nil
+}
+
+// GetAllowedSecretTypes returns the built-in secret types and the custom secret types of the organization
+func GetAllowedSecretTypes(organizationID uint) (map[string]secretTypes.Meta, error) {
+	allowed := make(map[string]secretTypes.Meta, len(secretTypes.DefaultRules))
+	for name, meta := range secretTypes.DefaultRules {
+		allowed[name] = meta
+	}
+
+	if organizationID == 0 {
+		return allowed, nil
+	}
+
+	customTypes, err := ListSecretTypes(organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, secretType := range customTypes {
+		// custom types registered before a built-in type with the same name was added are shadowed by it
+		if _, ok := secretTypes.DefaultRules[secretType.Name]; ok {
+			log.Warnf("custom secret type %s of organization %d is shadowed by the built-in type", secretType.Name, organizationID)
+			continue
+		}
+
+		allowed[secretType.Name] = secretType.Meta
+	}
+
+	return allowed, nil
+}
+
+// SaveSecretType creates or replaces the custom secret type of the organization
+func SaveSecretType(organizationID uint, name string, request *SecretTypeRequest) (*SecretTypeModel, error) {
+	if err := request.Validate(name); err != nil {
+		return nil, err
+	}
+
+	secretType, err := GetSecretType(organizationID, name)
+	if err == ErrSecretTypeNotExists {
+		secretType = &SecretTypeModel{OrganizationID: organizationID, Name: name}
+	} else if err != nil {
+		return nil, err
+	}
+
+	secretType.Meta = request.Meta()
+
+	if err := config.DB().Save(secretType).Error; err != nil {
+		return nil, errors.Wrapf(err, "could not save secret type %s", name)
+	}
+
+	return secretType, nil
+}
+
+// DeleteSecretType deletes the custom secret type of the organization
+func DeleteSecretType(organizationID uint, name string) error {
+	err := config.DB().Where(&SecretTypeModel{OrganizationID: organizationID, Name: name}).Delete(&SecretTypeModel{}).Error
+
+	return errors.Wrapf(err, "could not delete secret type %s", name)
+}
+
+// isGeneratorInput tells whether the value of a generated field is the input of the generator instead of the value itself
+func isGeneratorInput(field secretTypes.FieldMeta, values map[string]string) bool {
+	switch field.Generate {
+	case secretTypes.GeneratePassword:
+		// an empty value or the "method,length" format
+		value := values[field.Name]
+		return value == "" || len(strings.Split(value, ",")) == 2
+	case secretTypes.GenerateTLS:
+		// the hosts of the certificates, unless the certificates are given already
+		return values[secretTypes.CACert] == ""
+	}
+
+	return false
+}
+
+// validateField checks the value of a secret field against the pattern and the format of the field
+func validateField(field secretTypes.FieldMeta, value string) error {
+	if field.Pattern != "" {
+		pattern, err := regexp.Compile(field.Pattern)
+		if err != nil {
+			return errors.Wrapf(err, "invalid pattern of field %s", field.Name)
+		}
+
+		if !pattern.MatchString(value) {
+			return errors.Errorf("value of %s doesn't match pattern %s", field.Name, field.Pattern)
+		}
+	}
+
+	var err error
+
+	switch field.Format {
+	case secretTypes.FormatEmail:
+		_, err = mail.ParseAddress(value)
+	case secretTypes.FormatURL:
+		var u *url.URL
+		if u, err = url.Parse(value); err == nil && (u.Scheme == "" || u.Host == "") {
+			err = errors.New("absolute URL expected")
+		}
+	case secretTypes.FormatHostname:
+		if errorList := validation.IsDNS1123Subdomain(value); errorList != nil {
+			err = errors.New(errorList[0])
+		}
+	case secretTypes.FormatBase64:
+		_, err = base64.StdEncoding.DecodeString(value)
+	case secretTypes.FormatJSON:
+		if !json.Valid([]byte(value)) {
+			err = errors.New("invalid JSON")
+		}
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "value of %s is not a valid %s", field.Name, field.Format)
+	}
+
+	return nil
+}
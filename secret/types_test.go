@@ -0,0 +1,120 @@
+package secret_test
+
+import (
+	"testing"
+
+	pkgSecret "github.com/banzaicloud/pipeline/pkg/secret"
+	"github.com/banzaicloud/pipeline/secret"
+)
+
+func TestSecretTypeRequestValidate(t *testing.T) {
+	cases := []struct {
+		name     string
+		typeName string
+		request  secret.SecretTypeRequest
+		isError  bool
+	}{
+		{
+			name:     "valid",
+			typeName: "datadog",
+			request: secret.SecretTypeRequest{
+				Fields: []pkgSecret.FieldMeta{
+					{Name: "api_key", Required: true, Pattern: "^[0-9a-f]{32}$"},
+					{Name: "site", Format: pkgSecret.FormatHostname},
+				},
+				Sourcing: pkgSecret.EnvVar,
+			},
+		},
+		{
+			name:     "docker config",
+			typeName: "registry",
+			request: secret.SecretTypeRequest{
+				Fields:         []pkgSecret.FieldMeta{{Name: ".dockerconfigjson", Required: true, Format: pkgSecret.FormatJSON}},
+				Sourcing:       pkgSecret.Volume,
+				KubernetesType: "kubernetes.io/dockerconfigjson",
+			},
+		},
+		{
+			name:     "unknown kubernetes type",
+			typeName: "registry",
+			request: secret.SecretTypeRequest{
+				Fields:         []pkgSecret.FieldMeta{{Name: "config"}},
+				KubernetesType: "kubernetes.io/dockerconfig",
+			},
+			isError: true,
+		},
+		{
+			name:     "missing kubernetes key",
+			typeName: "certificate",
+			request: secret.SecretTypeRequest{
+				Fields:         []pkgSecret.FieldMeta{{Name: "tls.crt", Required: true}},
+				Sourcing:       pkgSecret.Volume,
+				KubernetesType: "kubernetes.io/tls",
+			},
+			isError: true,
+		},
+		{
+			name:     "built-in type",
+			typeName: pkgSecret.PasswordSecretType,
+			request:  secret.SecretTypeRequest{Fields: []pkgSecret.FieldMeta{{Name: "password"}}},
+			isError:  true,
+		},
+		{
+			name:     "built-in registry type",
+			typeName: pkgSecret.DockerRegistrySecretType,
+			request: secret.SecretTypeRequest{
+				Fields:         []pkgSecret.FieldMeta{{Name: ".dockerconfigjson", Required: true}},
+				KubernetesType: "kubernetes.io/dockerconfigjson",
+			},
+			isError: true,
+		},
+		{
+			name:     "invalid name",
+			typeName: "Docker_Registry",
+			request:  secret.SecretTypeRequest{Fields: []pkgSecret.FieldMeta{{Name: "password"}}},
+			isError:  true,
+		},
+		{
+			name:     "duplicated field",
+			typeName: "custom",
+			request:  secret.SecretTypeRequest{Fields: []pkgSecret.FieldMeta{{Name: "key"}, {Name: "key"}}},
+			isError:  true,
+		},
+		{
+			name:     "invalid pattern",
+			typeName: "custom",
+			request:  secret.SecretTypeRequest{Fields: []pkgSecret.FieldMeta{{Name: "key", Pattern: "[a-"}}},
+			isError:  true,
+		},
+		{
+			name:     "unknown format",
+			typeName: "custom",
+			request:  secret.SecretTypeRequest{Fields: []pkgSecret.FieldMeta{{Name: "key", Format: "uuid"}}},
+			isError:  true,
+		},
+		{
+			name:     "unknown generator",
+			typeName: "custom",
+			request:  secret.SecretTypeRequest{Fields: []pkgSecret.FieldMeta{{Name: "key", Generate: "uuid"}}},
+			isError:  true,
+		},
+		{
+			name:     "unknown sourcing",
+			typeName: "custom",
+			request:  secret.SecretTypeRequest{Fields: []pkgSecret.FieldMeta{{Name: "key"}}, Sourcing: "file"},
+			isError:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.request.Validate(tc.typeName)
+
+			if err != nil && !tc.isError {
+				t.Errorf("unexpected error: %s", err.Error())
+			} else if err == nil && tc.isError {
+				t.Error("expected error")
+			}
+		})
+	}
+}
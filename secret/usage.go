@@ -0,0 +1,80 @@
+package secret
+
+import (
+	"time"
+
+	"github.com/banzaicloud/pipeline/config"
+	"github.com/pkg/errors"
+)
+
+// Kinds of resources referencing secrets
+const (
+	UsageKindCluster   = "cluster"
+	UsageKindBucket    = "bucket"
+	UsageKindDNS       = "dns"
+	UsageKindSpotguide = "spotguide"
+)
+
+// Usage describes a resource referencing a secret
+type Usage struct {
+	Kind   string `json:"kind"`
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// UsageResponse lists the resources referencing a secret
+type UsageResponse struct {
+	SecretID string  `json:"secretId"`
+	InUse    bool    `json:"inUse"`
+	Usages   []Usage `json:"usages"`
+}
+
+// UsageModel records a resource referencing a secret which can't be looked up by the secret otherwise, like buckets
+type UsageModel struct {
+	ID             uint `gorm:"primary_key"`
+	CreatedAt      time.Time
+	OrganizationID uint   `gorm:"index:idx_secret_usage_secret;unique_index:idx_secret_usage"`
+	SecretID       string `gorm:"index:idx_secret_usage_secret"`
+	Kind           string `gorm:"unique_index:idx_secret_usage"`
+	Name           string `gorm:"unique_index:idx_secret_usage"`
+}
+
+// TableName overrides UsageModel's table name
+func (UsageModel) TableName() string {
+	return "secret_usages"
+}
+
+// RecordUsage records that a resource of an organization references a secret
+func RecordUsage(organizationID uint, secretID string, kind string, name string) error {
+	usage := UsageModel{OrganizationID: organizationID, Kind: kind, Name: name}
+
+	err := config.DB().Where(&usage).Assign(UsageModel{SecretID: secretID}).FirstOrCreate(&usage).Error
+	if err != nil {
+		return errors.Wrap(err, "could not record secret usage")
+	}
+
+	return nil
+}
+
+// ReleaseUsage removes the usage record of a resource of an organization
+func ReleaseUsage(organizationID uint, kind string, name string) error {
+	err := config.DB().Where(&UsageModel{OrganizationID: organizationID, Kind: kind, Name: name}).Delete(UsageModel{}).Error
+	if err != nil {
+		return errors.Wrap(err, "could not release secret usage")
+	}
+
+	return nil
+}
+
+// FindUsages returns the recorded usages of a secret
+func FindUsages(organizationID uint, secretID string) ([]*UsageModel, error) {
+	var usages []*UsageModel
+
+	err := config.DB().Where(&UsageModel{OrganizationID: organizationID, SecretID: secretID}).Order("kind, name").Find(&usages).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "could not fetch secret usages")
+	}
+
+	return usages, nil
+}
@@ -0,0 +1,219 @@
+package secret
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/banzaicloud/bank-vaults/vault"
+	secretTypes "github.com/banzaicloud/pipeline/pkg/secret"
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+// VaultSecretStore stores the secrets in the KV (version 2) secret engine of Vault
+type VaultSecretStore struct {
+	Client  *vault.Client
+	Logical *vaultapi.Logical
+}
+
+// NewVaultSecretStore returns a secret store authenticated to Vault with the given role
+func NewVaultSecretStore(role string) (*VaultSecretStore, error) {
+	client, err := vault.NewClient(role)
+	if err != nil {
+		return nil, err
+	}
+	logical := client.Vault().Logical()
+	return &VaultSecretStore{Client: client, Logical: logical}, nil
+}
+
+// DeleteByClusterUID Delete secrets by ClusterUID
+func (ss *VaultSecretStore) DeleteByClusterUID(orgID uint, clusterUID string) error {
+	return deleteByClusterUID(ss, orgID, clusterUID)
+}
+
+// Delete secret secret/orgs/:orgid:/:id: scope
+func (ss *VaultSecretStore) Delete(organizationID uint, secretID string) error {
+
+	path := secretMetadataPath(organizationID, secretID)
+
+	log.Debugln("Delete secret:", path)
+
+	if _, err := ss.Logical.Delete(path); err != nil {
+		return errors.Wrap(err, "Error during deleting secret")
+	}
+
+	return nil
+}
+
+// Save secret secret/orgs/:orgid:/:id: scope
+func (ss *VaultSecretStore) Store(organizationID uint, value *CreateSecretRequest) (string, error) {
+
+	secretID, err := prepareStore(organizationID, value)
+	if err != nil {
+		return "", err
+	}
+
+	path := secretDataPath(organizationID, secretID)
+
+	data := vault.NewData(0, map[string]interface{}{"value": value})
+
+	if _, err := ss.Logical.Write(path, data); err != nil {
+		return "", errors.Wrap(err, "Error during storing secret")
+	}
+
+	return secretID, nil
+}
+
+// Update secret secret/orgs/:orgid:/:id: scope
+func (ss *VaultSecretStore) Update(organizationID uint, secretID string, value *CreateSecretRequest) error {
+
+	version, err := prepareUpdate(organizationID, secretID, value)
+	if err != nil {
+		return err
+	}
+
+	path := secretDataPath(organizationID, secretID)
+
+	log.Debugln("Update secret:", path)
+
+	data := vault.NewData(version, map[string]interface{}{"value": value})
+
+	if _, err := ss.Logical.Write(path, data); err != nil {
+		return errors.Wrap(err, "Error during updating secret")
+	}
+
+	return nil
+}
+
+// Rotate creates a new version of the secret with regenerated or the given values secret/orgs/:orgid:/:id: scope
+func (ss *VaultSecretStore) Rotate(organizationID uint, secretID string, values map[string]string, updatedBy string) (*SecretItemResponse, error) {
+	return rotate(ss, organizationID, secretID, values, updatedBy)
+}
+
+// GetOrCreate create new secret or get if it's exist. secret/orgs/:orgid:/:id: scope
+func (ss *VaultSecretStore) GetOrCreate(organizationID uint, value *CreateSecretRequest) (string, error) {
+	return getOrCreate(ss, organizationID, value)
+}
+
+// CreateOrUpdate create new secret or update if it's exist. secret/orgs/:orgid:/:id: scope
+func (ss *VaultSecretStore) CreateOrUpdate(organizationID uint, value *CreateSecretRequest) (string, error) {
+	return createOrUpdate(ss, organizationID, value)
+}
+
+func parseSecret(secretID string, secret *vaultapi.Secret, values bool) (*SecretItemResponse, error) {
+
+	data := cast.ToStringMap(secret.Data["data"])
+	metadata := cast.ToStringMap(secret.Data["metadata"])
+
+	value := data["value"].(map[string]interface{})
+	sname := value["name"].(string)
+	stype := value["type"].(string)
+	stags := cast.ToStringSlice(value["tags"])
+	version, _ := metadata["version"].(json.Number).Int64()
+
+	updatedAt, err := time.Parse(time.RFC3339, metadata["created_time"].(string))
+	if err != nil {
+		return nil, err
+	}
+
+	updatedBy := ""
+	updatedByRaw, ok := value["updatedBy"]
+	if ok {
+		updatedBy = updatedByRaw.(string)
+	}
+
+	sir := SecretItemResponse{
+		ID:        secretID,
+		Name:      sname,
+		Type:      stype,
+		Tags:      stags,
+		Values:    cast.ToStringMapString(value["values"]),
+		Version:   int(version),
+		UpdatedAt: updatedAt,
+		UpdatedBy: updatedBy,
+	}
+
+	if !values {
+		sir.hideValues()
+	}
+
+	return &sir, nil
+}
+
+// Retrieve secret secret/orgs/:orgid:/:id: scope
+func (ss *VaultSecretStore) Get(organizationID uint, secretID string) (*SecretItemResponse, error) {
+
+	path := secretDataPath(organizationID, secretID)
+
+	log.Debugln("Get secret:", path)
+
+	secret, err := ss.Logical.Read(path)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "Error during reading secret")
+	}
+
+	if secret == nil {
+		return nil, ErrSecretNotExists
+	}
+
+	return parseSecret(secretID, secret, true)
+}
+
+// Retrieve secret by secret Name secret/orgs/:orgid:/:id: scope
+func (ss *VaultSecretStore) GetByName(organizationID uint, name string) (*SecretItemResponse, error) {
+	return getByName(ss, organizationID, name)
+}
+
+// List secret secret/orgs/:orgid:/ scope
+func (ss *VaultSecretStore) List(orgid uint, query *secretTypes.ListSecretsQuery) ([]*SecretItemResponse, error) {
+
+	log.Debugf("Searching for secrets [orgid: %d, query: %#v]", orgid, query)
+
+	listPath := fmt.Sprintf("secret/metadata/orgs/%d", orgid)
+
+	responseItems := []*SecretItemResponse{}
+
+	list, err := ss.Logical.List(listPath)
+	if err != nil {
+		log.Errorf("Error listing secrets: %s", err.Error())
+		return nil, err
+	}
+
+	if list != nil {
+
+		keys := cast.ToStringSlice(list.Data["keys"])
+
+		for _, secretID := range keys {
+
+			if secret, err := ss.Logical.Read(secretDataPath(orgid, secretID)); err != nil {
+
+				log.Errorf("Error listing secrets: %s", err.Error())
+				return nil, err
+
+			} else if secret != nil {
+
+				sir, err := parseSecret(secretID, secret, query.Values)
+				if err != nil {
+					return nil, err
+				}
+
+				if sir.matches(query) {
+					responseItems = append(responseItems, sir)
+				}
+			}
+		}
+	}
+
+	return responseItems, nil
+}
+
+func secretDataPath(organizationID uint, secretID string) string {
+	return fmt.Sprintf("secret/data/orgs/%d/%s", organizationID, secretID)
+}
+
+func secretMetadataPath(organizationID uint, secretID string) string {
+	return fmt.Sprintf("secret/metadata/orgs/%d/%s", organizationID, secretID)
+}
@@ -64,6 +64,6 @@ func CreateAWSCredentials(values map[string]string) *credentials.Credentials {
 	return credentials.NewStaticCredentials(
 		values[pkgSecret.AwsAccessKeyId],
 		values[pkgSecret.AwsSecretAccessKey],
-		"",
+		values[pkgSecret.AwsSessionToken],
 	)
 }
@@ -3,9 +3,11 @@ package verify
 import (
 	"context"
 	"net/http"
+	"time"
 
 	pkgSecret "github.com/banzaicloud/pipeline/pkg/secret"
 	"github.com/gin-gonic/gin/json"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"golang.org/x/oauth2/jwt"
 	gkeCompute "google.golang.org/api/compute/v1"
@@ -27,6 +29,15 @@ func CreateGKESecret(values map[string]string) *gkeVerify {
 // VerifySecret validates GKE credentials
 func (g *gkeVerify) VerifySecret() error {
 
+	if g.svc.AccessToken != "" {
+		client, err := CreateOath2Client(g.svc)
+		if err != nil {
+			return err
+		}
+
+		return checkProject(client, g.svc.ProjectId)
+	}
+
 	config, err := createJWTConfig(g.svc)
 	if err != nil {
 		return err
@@ -77,10 +88,18 @@ type ServiceAccount struct {
 	TokenUri               string `json:"token_uri"`
 	AuthProviderX50CertUrl string `json:"auth_provider_x509_cert_url"`
 	ClientX509CertUrl      string `json:"client_x509_cert_url"`
+
+	// AccessToken is the short-lived token of an impersonated service account, used instead of the key
+	AccessToken string `json:"-"`
+	// AccessTokenExpiry is the time the access token expires at
+	AccessTokenExpiry time.Time `json:"-"`
 }
 
 // CreateServiceAccount creates a new 'ServiceAccount' instance
 func CreateServiceAccount(values map[string]string) *ServiceAccount {
+	// the expiration is only set for minted credentials
+	expiry, _ := time.Parse(time.RFC3339, values[pkgSecret.CredentialsExpiration])
+
 	return &ServiceAccount{
 		Type:                   values[pkgSecret.Type],
 		ProjectId:              values[pkgSecret.ProjectId],
@@ -92,6 +111,8 @@ func CreateServiceAccount(values map[string]string) *ServiceAccount {
 		TokenUri:               values[pkgSecret.TokenUri],
 		AuthProviderX50CertUrl: values[pkgSecret.AuthX509Url],
 		ClientX509CertUrl:      values[pkgSecret.ClientX509Url],
+		AccessToken:            values[pkgSecret.AccessToken],
+		AccessTokenExpiry:      expiry,
 	}
 }
 
@@ -109,6 +130,10 @@ func createJWTConfig(credentials *ServiceAccount) (*jwt.Config, error) {
 // CreateOath2Client creates a new OAuth2 client with credentials
 func CreateOath2Client(credentials *ServiceAccount) (*http.Client, error) {
 
+	if credentials.AccessToken != "" {
+		return oauth2.NewClient(context.TODO(), accessTokenSource(credentials)), nil
+	}
+
 	config, err := createJWTConfig(credentials)
 	if err != nil {
 		return nil, err
@@ -117,3 +142,33 @@ func CreateOath2Client(credentials *ServiceAccount) (*http.Client, error) {
 	// Create oauth2 client with credential
 	return config.Client(context.TODO()), nil
 }
+
+// CreateGoogleCredentials creates Google credentials from the service account key or access token
+func CreateGoogleCredentials(ctx context.Context, credentials *ServiceAccount, scopes ...string) (*google.Credentials, error) {
+
+	if credentials.AccessToken != "" {
+		return &google.Credentials{
+			ProjectID:   credentials.ProjectId,
+			TokenSource: accessTokenSource(credentials),
+		}, nil
+	}
+
+	jsonConfig, err := json.Marshal(credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	return google.CredentialsFromJSON(ctx, jsonConfig, scopes...)
+}
+
+// accessTokenSource returns the access token of the impersonated service account,
+// a new one is generated when it expires, so long-running clients keep working
+func accessTokenSource(credentials *ServiceAccount) oauth2.TokenSource {
+	token := &oauth2.Token{
+		AccessToken: credentials.AccessToken,
+		TokenType:   "Bearer",
+		Expiry:      credentials.AccessTokenExpiry,
+	}
+
+	return oauth2.ReuseTokenSource(token, impersonatedTokenSource{serviceAccount: credentials.ClientEmail})
+}
@@ -0,0 +1,32 @@
+package verify
+
+import (
+	"testing"
+	"time"
+
+	pkgSecret "github.com/banzaicloud/pipeline/pkg/secret"
+)
+
+func TestAccessTokenSource(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	credentials := CreateServiceAccount(map[string]string{
+		pkgSecret.ClientEmail:           "deployer@my-project.iam.gserviceaccount.com",
+		pkgSecret.AccessToken:           "token",
+		pkgSecret.CredentialsExpiration: expiry.Format(time.RFC3339),
+	})
+
+	if !credentials.AccessTokenExpiry.Equal(expiry) {
+		t.Fatalf("Expected expiry %s, got: %s", expiry, credentials.AccessTokenExpiry)
+	}
+
+	// the token is valid, so it is returned without impersonating the service account
+	token, err := accessTokenSource(credentials).Token()
+	if err != nil {
+		t.Fatalf("Expected error <nil>, got: %s", err.Error())
+	}
+
+	if token.AccessToken != "token" || !token.Expiry.Equal(expiry) {
+		t.Errorf("Expected the access token of the secret, got: %s expiring at %s", token.AccessToken, token.Expiry)
+	}
+}
@@ -0,0 +1,79 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	gke "google.golang.org/api/container/v1"
+)
+
+const googleGenerateAccessTokenURL = "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/%s:generateAccessToken"
+
+// impersonatedTokenLifetime is the lifetime of the access tokens generated when the token of a secret expires
+const impersonatedTokenLifetime = time.Hour
+
+type googleAccessToken struct {
+	AccessToken string    `json:"accessToken"`
+	ExpireTime  time.Time `json:"expireTime"`
+}
+
+// ImpersonateServiceAccount generates an access token of the service account with the default credentials of Pipeline,
+// which need the Service Account Token Creator role on the service account
+func ImpersonateServiceAccount(ctx context.Context, serviceAccount string, lifetime time.Duration) (*oauth2.Token, error) {
+	client, err := google.DefaultClient(ctx, gke.CloudPlatformScope)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get the default Google credentials")
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"scope":    []string{gke.CloudPlatformScope},
+		"lifetime": fmt.Sprintf("%ds", int64(lifetime.Seconds())),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, fmt.Sprintf(googleGenerateAccessTokenURL, url.PathEscape(serviceAccount)), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(request.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not impersonate service account %s", serviceAccount)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, errors.Errorf("could not impersonate service account %s: unexpected response: %s", serviceAccount, resp.Status)
+	}
+
+	var token googleAccessToken
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, errors.Wrapf(err, "could not impersonate service account %s", serviceAccount)
+	}
+
+	return &oauth2.Token{
+		AccessToken: token.AccessToken,
+		TokenType:   "Bearer",
+		Expiry:      token.ExpireTime,
+	}, nil
+}
+
+// impersonatedTokenSource generates new access tokens of an impersonated service account
+type impersonatedTokenSource struct {
+	serviceAccount string
+}
+
+func (s impersonatedTokenSource) Token() (*oauth2.Token, error) {
+	return ImpersonateServiceAccount(context.Background(), s.serviceAccount, impersonatedTokenLifetime)
+}